@@ -10,6 +10,8 @@ import (
 
 type Config struct {
 	// Database
+	DBDriver   string // postgres or sqlite
+	DBPath     string // SQLite database file, or :memory:
 	DBHost     string
 	DBPort     string
 	DBUser     string
@@ -17,13 +19,22 @@ type Config struct {
 	DBName     string
 	DBSSLMode  string
 
+	// Apply pending migrations at startup instead of refusing to start
+	DBMigrateOnStart bool
+
 	// Server
 	Port    string
 	GinMode string
 
 	// JWT
-	JWTSecret      string
-	JWTExpiryHours int
+	JWTSecret              string
+	JWTAccessExpiryMinutes int
+	RefreshTokenExpiryDays int
+
+	// Brute-force protection
+	RateLimitEnabled    bool
+	LoginMaxFailures    int
+	LoginLockoutMinutes int
 
 	// CORS
 	CORSAllowedOrigins []string
@@ -50,10 +61,37 @@ type Config struct {
 	SMTPFromEmail string
 	SMTPFromName  string
 
+	// Email Notifications
+	EmailNotificationsEnabled bool
+	EmailTemplateDir          string
+	EmailTransport            string
+	EmailFilePath             string
+
 	// Timezone Configuration
 	AppTimeZone string
 	UseUTC      bool
 
+	// Working calendar
+	WeekendDays           string // comma-separated weekday names, e.g. "saturday,sunday"
+	WeekendDaysByLocation string // per work location overrides, e.g. "dubai=friday,saturday;pune=sunday"
+	OptionalHolidaysOff   bool   // count optional holidays as days off for everyone
+	OptionalHolidayQuota  int    // optional holidays each employee may pick in a year the admins set no quota for
+
+	// Leave policies
+	LeavePolicyJobEnabled bool // run accrual and year-end rollover in the background
+
+	// Leave approvals
+	LeaveEscalationJobEnabled bool // escalate approval steps past their SLA in the background
+
+	// Timesheets and comp-off
+	TimesheetMaxDailyHours        float64 // most hours that can be logged on one day
+	CompOffOvertimeThresholdHours float64 // hours on a working day past which overtime earns comp-off
+	CompOffHoursPerDay            float64 // eligible hours that earn one day of comp-off; half of them a half day
+	CompOffExpiryDays             int     // days after the work date a comp-off credit lapses
+	CompOffJobEnabled             bool    // lapse expired comp-off credits in the background
+
+	CalendarFeedPastDays int // how far back iCalendar feeds go; they run to the end of next year
+
 	// AWS SDK Configuration
 	AWSRegion                    string
 	AWSAccessKeyID               string
@@ -61,7 +99,12 @@ type Config struct {
 	AWSS3BucketName              string
 	AWSPresignedURLExpiryMinutes int
 
-	// For Passwords storing
+	// Password hashing
+	PasswordHashScheme string
+	PasswordBcryptCost int
+
+	// Deprecated: passwords are always hashed now. Legacy plaintext values
+	// still log in and are re-hashed; see "migrate-passwords".
 	UserPlainPasswords bool
 }
 
@@ -71,6 +114,8 @@ func Load() *Config {
 
 	cfg := &Config{
 		// Database defaults
+		DBDriver:   getEnv("DB_DRIVER", "postgres"),
+		DBPath:     getEnv("DB_PATH", "./data/employee_dashboard.db"),
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "5432"),
 		DBUser:     getEnv("DB_USER", "postgres"),
@@ -78,13 +123,21 @@ func Load() *Config {
 		DBName:     getEnv("DB_NAME", "employee_dashboard"),
 		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
 
+		DBMigrateOnStart: getEnvAsBool("DB_MIGRATE_ON_START", false),
+
 		// Server defaults
 		Port:    getEnv("PORT", "8082"),
 		GinMode: getEnv("GIN_MODE", "debug"),
 
 		// JWT defaults
-		JWTSecret:      getEnv("JWT_SECRET", "your-super-secret-jwt-key"),
-		JWTExpiryHours: getEnvAsInt("JWT_EXPIRY_HOURS", 24),
+		JWTSecret:              getEnv("JWT_SECRET", "your-super-secret-jwt-key"),
+		JWTAccessExpiryMinutes: getEnvAsInt("JWT_ACCESS_EXPIRY_MINUTES", 15),
+		RefreshTokenExpiryDays: getEnvAsInt("REFRESH_TOKEN_EXPIRY_DAYS", 30),
+
+		// Brute-force protection defaults
+		RateLimitEnabled:    getEnvAsBool("RATE_LIMIT_ENABLED", true),
+		LoginMaxFailures:    getEnvAsInt("LOGIN_MAX_FAILURES", 5),
+		LoginLockoutMinutes: getEnvAsInt("LOGIN_LOCKOUT_MINUTES", 15),
 
 		// CORS defaults
 		CORSAllowedOrigins: strings.Split(getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:3000,http://localhost:5173"), ","),
@@ -111,10 +164,37 @@ func Load() *Config {
 		SMTPFromEmail: getEnv("SMTP_FROM_EMAIL", "noreply@teamda.com"),
 		SMTPFromName:  getEnv("SMTP_FROM_NAME", "teamDa"),
 
+		// Email Notifications
+		EmailNotificationsEnabled: getEnvAsBool("EMAIL_NOTIFICATIONS_ENABLED", true),
+		EmailTemplateDir:          getEnv("EMAIL_TEMPLATE_DIR", ""),  // empty uses the built-in templates
+		EmailTransport:            getEnv("EMAIL_TRANSPORT", "smtp"), // smtp, file or memory
+		EmailFilePath:             getEnv("EMAIL_FILE_PATH", "./mail/outbox.mbox"),
+
 		// Timezone Configuration
 		AppTimeZone: getEnv("APP_TIMEZONE", "Asia/Kolkata"),
 		UseUTC:      getEnvAsBool("USE_UTC", false),
 
+		// Working calendar
+		WeekendDays:           getEnv("WEEKEND_DAYS", "saturday,sunday"),
+		WeekendDaysByLocation: getEnv("WEEKEND_DAYS_BY_LOCATION", ""),
+		OptionalHolidaysOff:   getEnvAsBool("OPTIONAL_HOLIDAYS_OFF", false),
+		OptionalHolidayQuota:  getEnvAsInt("OPTIONAL_HOLIDAY_QUOTA", 2),
+
+		// Leave policies
+		LeavePolicyJobEnabled: getEnvAsBool("LEAVE_POLICY_JOB_ENABLED", true),
+
+		// Leave approvals
+		LeaveEscalationJobEnabled: getEnvAsBool("LEAVE_ESCALATION_JOB_ENABLED", true),
+
+		// Timesheets and comp-off
+		TimesheetMaxDailyHours:        getEnvAsFloat("TIMESHEET_MAX_DAILY_HOURS", 8),
+		CompOffOvertimeThresholdHours: getEnvAsFloat("COMP_OFF_OVERTIME_THRESHOLD_HOURS", 8),
+		CompOffHoursPerDay:            getEnvAsFloat("COMP_OFF_HOURS_PER_DAY", 8),
+		CompOffExpiryDays:             getEnvAsInt("COMP_OFF_EXPIRY_DAYS", 90),
+		CompOffJobEnabled:             getEnvAsBool("COMP_OFF_JOB_ENABLED", true),
+
+		CalendarFeedPastDays: getEnvAsInt("CALENDAR_FEED_PAST_DAYS", 365),
+
 		// AWS Configuration
 		AWSRegion:                    getEnv("AWS_REGION", "us-east-1"),
 		AWSAccessKeyID:               getEnv("AWS_ACCESS_KEY_ID", ""),
@@ -122,8 +202,10 @@ func Load() *Config {
 		AWSS3BucketName:              getEnv("AWS_S3_BUCKET_NAME", ""),
 		AWSPresignedURLExpiryMinutes: getEnvAsInt("AWS_PRESIGNED_URL_EXPIRY_MINUTES", 15),
 
-		//Load Password Config
-		UserPlainPasswords: getEnvAsBool("USER_PLAIN_PASSWORDS", false), //Default its false (means storing the passwords in hash format)
+		// Password hashing defaults
+		PasswordHashScheme: getEnv("PASSWORD_HASH_SCHEME", "bcrypt"),
+		PasswordBcryptCost: getEnvAsInt("PASSWORD_BCRYPT_COST", 10),
+		UserPlainPasswords: getEnvAsBool("USER_PLAIN_PASSWORDS", false),
 	}
 
 	return cfg
@@ -154,6 +236,15 @@ func getEnvAsInt64(key string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
@@ -2,52 +2,72 @@ package database
 
 import (
 	"employee-dashboard-api/internal/config"
-	"employee-dashboard-api/internal/models"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
 func Initialize(cfg *config.Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
-		cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort, cfg.DBSSLMode)
+	dialector, err := openDialector(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Auto-migrate all models
-	// Migrate models in order to avoid foreign key constraint issues
-	err = db.AutoMigrate(
-		&models.Department{},
-		&models.User{},
-		&models.LeaveType{},
-		&models.LeaveApplication{},
-		&models.LeaveBalance{},
-		&models.Project{},
-		&models.TimesheetEntry{},
-		&models.Event{},
-		&models.Document{},
-		&models.Asset{},
-		&models.News{},
-		&models.LearningSession{},
-		&models.LearningEnrollment{},
-		&models.SportsEvent{},
-		&models.SportsFacility{},
-		&models.Policy{},
-		&models.Notification{},
-		&models.RSSFeed{},
-		&models.RSSNewsItem{},
-		&models.GalleryImage{}, // Add this line
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	if IsSQLite(db) && isSQLiteMemory(cfg.DBPath) {
+		// Every connection to ":memory:" is a separate database
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
 	}
 
+	// Schema changes are applied by versioned migrations, see migrate.go
 	return db, nil
 }
+
+// openDialector picks the GORM dialector for cfg.DBDriver.
+func openDialector(cfg *config.Config) (gorm.Dialector, error) {
+	switch strings.ToLower(cfg.DBDriver) {
+	case "", DriverPostgres:
+		dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+			cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort, cfg.DBSSLMode)
+		return postgres.Open(dsn), nil
+	case DriverSQLite:
+		if !isSQLiteMemory(cfg.DBPath) {
+			if err := os.MkdirAll(filepath.Dir(cfg.DBPath), 0o755); err != nil {
+				return nil, fmt.Errorf("failed to create database directory: %w", err)
+			}
+		}
+		return sqlite.Open(SQLiteDSN(cfg.DBPath)), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.DBDriver)
+	}
+}
+
+// SQLiteDSN returns the DSN for a SQLite database at path, with foreign keys
+// enforced and a busy timeout so concurrent writers wait instead of failing.
+func SQLiteDSN(path string) string {
+	pragmas := "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	if !isSQLiteMemory(path) {
+		pragmas += "&_pragma=journal_mode(WAL)"
+	}
+	return "file:" + path + "?" + pragmas
+}
+
+func isSQLiteMemory(path string) bool {
+	return path == ":memory:"
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Supported values of DB_DRIVER
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// IsSQLite reports whether db is backed by SQLite.
+func IsSQLite(db *gorm.DB) bool {
+	return db.Dialector.Name() == DriverSQLite
+}
+
+// YearOf returns an SQL expression for the calendar year of a date column.
+func YearOf(db *gorm.DB, column string) string {
+	if IsSQLite(db) {
+		return fmt.Sprintf("CAST(strftime('%%Y', %s) AS INTEGER)", column)
+	}
+	return fmt.Sprintf("EXTRACT(YEAR FROM %s)", column)
+}
+
+// MonthOf returns an SQL expression for the month (1-12) of a date column.
+func MonthOf(db *gorm.DB, column string) string {
+	if IsSQLite(db) {
+		return fmt.Sprintf("CAST(strftime('%%m', %s) AS INTEGER)", column)
+	}
+	return fmt.Sprintf("EXTRACT(MONTH FROM %s)", column)
+}
+
+// DaysBetween returns an SQL expression for the whole days from one date
+// column to another.
+func DaysBetween(db *gorm.DB, from, to string) string {
+	if IsSQLite(db) {
+		return fmt.Sprintf("CAST(julianday(%s) - julianday(%s) AS INTEGER)", to, from)
+	}
+	return fmt.Sprintf("EXTRACT(DAY FROM (%s - %s))", to, from)
+}
+
+// ForUpdate makes the rows db selects locked until the end of the
+// transaction. SQLite has no row locks and needs none, as it runs one writing
+// transaction at a time.
+func ForUpdate(db *gorm.DB) *gorm.DB {
+	if IsSQLite(db) {
+		return db
+	}
+	return db.Clauses(clause.Locking{Strength: "UPDATE"})
+}
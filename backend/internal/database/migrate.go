@@ -0,0 +1,324 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationLockKey is the pg_advisory_lock key held while migrating, so
+// replicas starting together apply each migration exactly once.
+const migrationLockKey int64 = 7_241_986_530_112
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one numbered schema change with its up and down SQL.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a known migration has been applied.
+type MigrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// migrationDialect holds the bookkeeping SQL that differs between drivers.
+// Migrations themselves live in a directory per driver.
+type migrationDialect struct {
+	lock        string // empty when the driver needs no cross-process lock
+	unlock      string
+	createTable string
+	insert      string
+	delete      string
+}
+
+var migrationDialects = map[string]migrationDialect{
+	DriverPostgres: {
+		lock:   "SELECT pg_advisory_lock($1)",
+		unlock: "SELECT pg_advisory_unlock($1)",
+		createTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version bigint PRIMARY KEY,
+			name text NOT NULL,
+			applied_at timestamptz NOT NULL
+		)`,
+		insert: "INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)",
+		delete: "DELETE FROM schema_migrations WHERE version = $1",
+	},
+	// SQLite serialises writers on the database file, so the migration
+	// transactions need no extra lock.
+	DriverSQLite: {
+		createTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version integer PRIMARY KEY,
+			name text NOT NULL,
+			applied_at datetime NOT NULL
+		)`,
+		insert: "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+		delete: "DELETE FROM schema_migrations WHERE version = ?",
+	},
+}
+
+// Migrator applies the embedded SQL migrations and records them in
+// schema_migrations.
+type Migrator struct {
+	db         *sql.DB
+	logger     *logrus.Logger
+	dialect    migrationDialect
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB, logger *logrus.Logger) (*Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	driver := db.Dialector.Name()
+	dialect, ok := migrationDialects[driver]
+	if !ok {
+		return nil, fmt.Errorf("no migrations for database driver %q", driver)
+	}
+
+	migrations, err := loadMigrations(migrationFiles, "migrations/"+driver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         sqlDB,
+		logger:     logger,
+		dialect:    dialect,
+		migrations: migrations,
+	}, nil
+}
+
+// loadMigrations reads NNNN_name.up.sql / NNNN_name.down.sql pairs from dir,
+// sorted by version.
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		body, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies every pending migration in order and returns how many ran.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			m.logger.Infof("Applying migration %d_%s", migration.Version, migration.Name)
+			if err := m.apply(ctx, conn, migration, migration.Up, true); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the latest steps applied migrations and returns how many
+// were rolled back.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	rolledBack := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && rolledBack < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+			m.logger.Infof("Rolling back migration %d_%s", migration.Version, migration.Name)
+			if err := m.apply(ctx, conn, migration, migration.Down, false); err != nil {
+				return err
+			}
+			rolledBack++
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Status lists every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	done, err := m.appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := done[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations not yet applied.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for i, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, m.migrations[i])
+		}
+	}
+	return pending, nil
+}
+
+// withLock runs fn on a single connection holding the migration advisory
+// lock. Other migrators block until it is released.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.dialect.lock == "" {
+		return fn(conn)
+	}
+
+	if _, err := conn.ExecContext(ctx, m.dialect.lock, migrationLockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), m.dialect.unlock, migrationLockKey); err != nil {
+			m.logger.Errorf("Failed to release migration lock: %v", err)
+		}
+	}()
+
+	return fn(conn)
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, m.dialect.createTable)
+	return err
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		done[version] = appliedAt
+	}
+	return done, rows.Err()
+}
+
+// apply runs one migration's SQL and its schema_migrations bookkeeping in a
+// single transaction.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, script string, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, m.dialect.insert, migration.Version, migration.Name, time.Now().UTC())
+	} else {
+		_, err = tx.ExecContext(ctx, m.dialect.delete, migration.Version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
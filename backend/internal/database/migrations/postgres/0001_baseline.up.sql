@@ -0,0 +1,325 @@
+-- Baseline schema, equivalent to what AutoMigrate created before versioned
+-- migrations. Everything is IF NOT EXISTS so databases previously managed by
+-- AutoMigrate are adopted as-is.
+
+CREATE TABLE IF NOT EXISTS users (
+    id uuid DEFAULT gen_random_uuid(),
+    employee_id text NOT NULL,
+    email text NOT NULL,
+    password_hash text NOT NULL,
+    first_name text NOT NULL,
+    last_name text NOT NULL,
+    phone text,
+    department text,
+    position text,
+    manager_id uuid,
+    hire_date timestamptz,
+    employment_type text DEFAULT 'full-time',
+    status text DEFAULT 'active',
+    profile_image_url text,
+    bio text,
+    skills text,
+    languages text,
+    role varchar(20) NOT NULL DEFAULT 'employee',
+    approval_status varchar(20) NOT NULL DEFAULT 'pending',
+    approved_by uuid,
+    approved_at timestamptz,
+    rejection_reason text,
+    is_anonymous boolean DEFAULT false,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_users_manager FOREIGN KEY (manager_id) REFERENCES users (id) ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT fk_users_approver FOREIGN KEY (approved_by) REFERENCES users (id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_employee_id ON users (employee_id);
+
+CREATE TABLE IF NOT EXISTS departments (
+    id uuid DEFAULT gen_random_uuid(),
+    name text NOT NULL,
+    description text,
+    manager_id uuid,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_departments_manager FOREIGN KEY (manager_id) REFERENCES users (id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS leave_types (
+    id uuid DEFAULT gen_random_uuid(),
+    name text NOT NULL,
+    description text,
+    max_days_per_year bigint,
+    is_active boolean DEFAULT true,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS leave_applications (
+    id uuid DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL,
+    leave_type_id uuid NOT NULL,
+    start_date timestamptz NOT NULL,
+    end_date timestamptz NOT NULL,
+    is_half_day boolean DEFAULT false,
+    is_lop boolean DEFAULT false,
+    lop_days decimal DEFAULT 0,
+    paid_days decimal DEFAULT 0,
+    reason text,
+    description text,
+    status text DEFAULT 'pending',
+    approved_by uuid,
+    approved_at timestamptz,
+    rejection_reason text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_leave_applications_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_leave_applications_leave_type FOREIGN KEY (leave_type_id) REFERENCES leave_types (id),
+    CONSTRAINT fk_leave_applications_approver FOREIGN KEY (approved_by) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS leave_balances (
+    id uuid DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL,
+    leave_type_id uuid NOT NULL,
+    year bigint NOT NULL,
+    allocated_days bigint NOT NULL,
+    used_days decimal DEFAULT 0,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_leave_balances_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_leave_balances_leave_type FOREIGN KEY (leave_type_id) REFERENCES leave_types (id)
+);
+
+CREATE TABLE IF NOT EXISTS projects (
+    id uuid DEFAULT gen_random_uuid(),
+    name text NOT NULL,
+    description text,
+    client_name text,
+    status text DEFAULT 'active',
+    start_date timestamptz,
+    end_date timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS timesheet_entries (
+    id uuid DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL,
+    project_id uuid NOT NULL,
+    task_description text NOT NULL,
+    entry_date timestamptz NOT NULL,
+    start_time timestamptz,
+    end_time timestamptz,
+    duration_hours decimal,
+    break_time_minutes bigint DEFAULT 0,
+    status text DEFAULT 'draft',
+    submitted_at timestamptz,
+    approved_by uuid,
+    approved_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_timesheet_entries_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_timesheet_entries_project FOREIGN KEY (project_id) REFERENCES projects (id),
+    CONSTRAINT fk_timesheet_entries_approver FOREIGN KEY (approved_by) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS events (
+    id uuid DEFAULT gen_random_uuid(),
+    title text NOT NULL,
+    description text,
+    event_type text NOT NULL,
+    event_date timestamptz NOT NULL,
+    user_id uuid,
+    is_company_wide boolean DEFAULT false,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_events_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS documents (
+    id uuid DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL,
+    filename text NOT NULL,
+    original_filename text NOT NULL,
+    file_path text NOT NULL,
+    file_size bigint,
+    mime_type text,
+    category text,
+    description text,
+    uploaded_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_documents_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS assets (
+    id uuid DEFAULT gen_random_uuid(),
+    asset_id text NOT NULL,
+    name text NOT NULL,
+    type text,
+    brand text,
+    model text,
+    serial_number text,
+    status text DEFAULT 'available',
+    assigned_to uuid,
+    assigned_date timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_assets_user FOREIGN KEY (assigned_to) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_assets_asset_id ON assets (asset_id);
+
+CREATE TABLE IF NOT EXISTS news (
+    id uuid DEFAULT gen_random_uuid(),
+    title text NOT NULL,
+    content text NOT NULL,
+    summary text,
+    category text,
+    type text DEFAULT 'news',
+    author_id uuid,
+    is_featured boolean DEFAULT false,
+    is_published boolean DEFAULT true,
+    published_at timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_news_author FOREIGN KEY (author_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS learning_sessions (
+    id uuid DEFAULT gen_random_uuid(),
+    title text NOT NULL,
+    description text,
+    topic text,
+    instructor text,
+    session_date timestamptz NOT NULL,
+    duration_minutes bigint,
+    max_participants bigint,
+    location text,
+    is_virtual boolean DEFAULT false,
+    meeting_link text,
+    status text DEFAULT 'scheduled',
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS learning_enrollments (
+    id uuid DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL,
+    session_id uuid NOT NULL,
+    enrolled_at timestamptz,
+    attendance_status text,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_learning_enrollments_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_learning_enrollments_session FOREIGN KEY (session_id) REFERENCES learning_sessions (id)
+);
+
+CREATE TABLE IF NOT EXISTS sports_events (
+    id uuid DEFAULT gen_random_uuid(),
+    title text NOT NULL,
+    description text,
+    sport_type text,
+    event_date timestamptz NOT NULL,
+    location text,
+    max_participants bigint,
+    registration_deadline timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS sports_facilities (
+    id uuid DEFAULT gen_random_uuid(),
+    name text NOT NULL,
+    type text,
+    description text,
+    capacity bigint,
+    is_available boolean DEFAULT true,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS policies (
+    id uuid DEFAULT gen_random_uuid(),
+    title text NOT NULL,
+    description text,
+    content text NOT NULL,
+    category text,
+    version text DEFAULT '1.0',
+    effective_date timestamptz,
+    is_active boolean DEFAULT true,
+    created_by uuid,
+    s3_key text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_policies_creator FOREIGN KEY (created_by) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id uuid DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL,
+    title text NOT NULL,
+    message text NOT NULL,
+    type text,
+    is_read boolean DEFAULT false,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_notifications_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS rss_feeds (
+    id uuid DEFAULT gen_random_uuid(),
+    name text NOT NULL,
+    url text NOT NULL,
+    category text DEFAULT 'general',
+    is_active boolean DEFAULT true,
+    last_fetched timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS rss_news_items (
+    id uuid DEFAULT gen_random_uuid(),
+    feed_id uuid NOT NULL,
+    title text NOT NULL,
+    description text,
+    content text,
+    link text NOT NULL,
+    author text,
+    category text,
+    image_url text,
+    published_at timestamptz,
+    guid text,
+    is_read boolean DEFAULT false,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_rss_news_items_feed FOREIGN KEY (feed_id) REFERENCES rss_feeds (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rss_news_items_guid ON rss_news_items (guid);
+
+CREATE TABLE IF NOT EXISTS gallery_images (
+    id uuid DEFAULT gen_random_uuid(),
+    s3_key text NOT NULL,
+    title text NOT NULL,
+    description text,
+    uploaded_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_gallery_images_s3_key ON gallery_images (s3_key);
+
+CREATE TABLE IF NOT EXISTS otp_records (
+    id bigserial,
+    email text NOT NULL,
+    otp text NOT NULL,
+    purpose text NOT NULL,
+    user_data text,
+    expires_at timestamptz NOT NULL,
+    used boolean DEFAULT false,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_otp_records_email ON otp_records (email);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_events_type_date;
+
+ALTER TABLE events DROP COLUMN IF EXISTS location;
+ALTER TABLE events DROP COLUMN IF EXISTS is_optional;
+
+ALTER TABLE users DROP COLUMN IF EXISTS work_location;
@@ -0,0 +1,12 @@
+-- Working calendar: per-location weekends and optional holidays.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS work_location text;
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS is_optional boolean NOT NULL DEFAULT false;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS location text;
+
+-- The CSV loader used to record the optional flag only in the description
+UPDATE events SET is_optional = true
+WHERE event_type = 'holiday' AND description LIKE '% - Optional)';
+
+CREATE INDEX IF NOT EXISTS idx_events_type_date ON events (event_type, event_date);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS leave_status_changes;
+
+ALTER TABLE leave_applications DROP COLUMN IF EXISTS cancel_reason;
+ALTER TABLE leave_applications DROP COLUMN IF EXISTS cancel_from;
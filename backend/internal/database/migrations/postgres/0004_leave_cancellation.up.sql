@@ -0,0 +1,26 @@
+-- Leave cancellation requests and the status history of leave applications.
+
+ALTER TABLE leave_applications ADD COLUMN IF NOT EXISTS cancel_from timestamptz;
+ALTER TABLE leave_applications ADD COLUMN IF NOT EXISTS cancel_reason text;
+
+CREATE TABLE IF NOT EXISTS leave_status_changes (
+    id uuid DEFAULT gen_random_uuid(),
+    leave_application_id uuid NOT NULL,
+    from_status text,
+    to_status text NOT NULL,
+    changed_by uuid,
+    note text,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_leave_applications_status_history FOREIGN KEY (leave_application_id) REFERENCES leave_applications (id) ON DELETE CASCADE,
+    CONSTRAINT fk_leave_status_changes_changed_by FOREIGN KEY (changed_by) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_leave_status_changes_leave_application_id ON leave_status_changes (leave_application_id);
+
+-- Start the history of existing applications from what they record
+INSERT INTO leave_status_changes (leave_application_id, from_status, to_status, changed_by, created_at)
+SELECT id, '', 'pending', user_id, created_at FROM leave_applications;
+
+INSERT INTO leave_status_changes (leave_application_id, from_status, to_status, changed_by, note, created_at)
+SELECT id, 'pending', status, approved_by, rejection_reason, COALESCE(approved_at, updated_at)
+FROM leave_applications WHERE status <> 'pending';
@@ -0,0 +1,18 @@
+-- Half-day sessions of leave applications. Days without a row are taken in
+-- full.
+
+CREATE TABLE IF NOT EXISTS leave_sessions (
+    id uuid DEFAULT gen_random_uuid(),
+    leave_application_id uuid NOT NULL,
+    date text NOT NULL,
+    session text NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_leave_applications_sessions FOREIGN KEY (leave_application_id) REFERENCES leave_applications (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_leave_sessions_leave_date ON leave_sessions (leave_application_id, date);
+
+-- Existing half-day applications took the first half of their start date
+INSERT INTO leave_sessions (leave_application_id, date, session)
+SELECT id, to_char(start_date, 'YYYY-MM-DD'), 'first_half'
+FROM leave_applications WHERE is_half_day = true
+ON CONFLICT DO NOTHING;
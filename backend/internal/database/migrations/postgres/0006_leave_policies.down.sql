@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS leave_policy_runs;
+DROP TABLE IF EXISTS leave_policies;
+
+ALTER TABLE leave_balances DROP COLUMN IF EXISTS closed_at;
+ALTER TABLE leave_balances DROP COLUMN IF EXISTS accrued_months;
+ALTER TABLE leave_balances DROP COLUMN IF EXISTS encashed_days;
+ALTER TABLE leave_balances DROP COLUMN IF EXISTS expired_days;
+ALTER TABLE leave_balances DROP COLUMN IF EXISTS carry_expires_at;
+ALTER TABLE leave_balances DROP COLUMN IF EXISTS carried_days;
+ALTER TABLE leave_balances ALTER COLUMN allocated_days TYPE bigint USING round(allocated_days);
@@ -0,0 +1,51 @@
+-- Leave policies: accrual, carry-forward, expiry and encashment of leave
+-- balances, and the log of the job that applies them. Allocations become
+-- fractional as accrual credits half days.
+
+ALTER TABLE leave_balances ALTER COLUMN allocated_days TYPE decimal;
+ALTER TABLE leave_balances ADD COLUMN IF NOT EXISTS carried_days decimal NOT NULL DEFAULT 0;
+ALTER TABLE leave_balances ADD COLUMN IF NOT EXISTS carry_expires_at timestamptz;
+ALTER TABLE leave_balances ADD COLUMN IF NOT EXISTS expired_days decimal NOT NULL DEFAULT 0;
+ALTER TABLE leave_balances ADD COLUMN IF NOT EXISTS encashed_days decimal NOT NULL DEFAULT 0;
+ALTER TABLE leave_balances ADD COLUMN IF NOT EXISTS accrued_months integer NOT NULL DEFAULT 0;
+ALTER TABLE leave_balances ADD COLUMN IF NOT EXISTS closed_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS leave_policies (
+    id uuid DEFAULT gen_random_uuid(),
+    leave_type_id uuid NOT NULL,
+    annual_days decimal NOT NULL,
+    accrual_frequency text NOT NULL DEFAULT 'monthly',
+    prorate_by_hire_date boolean NOT NULL DEFAULT true,
+    max_balance decimal,
+    carry_forward_limit decimal NOT NULL DEFAULT 0,
+    carry_forward_expiry_months integer NOT NULL DEFAULT 0,
+    encashment_enabled boolean NOT NULL DEFAULT false,
+    max_encash_days decimal NOT NULL DEFAULT 0,
+    is_active boolean NOT NULL DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_leave_policies_leave_type FOREIGN KEY (leave_type_id) REFERENCES leave_types (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_leave_policies_leave_type_id ON leave_policies (leave_type_id);
+
+CREATE TABLE IF NOT EXISTS leave_policy_runs (
+    id uuid DEFAULT gen_random_uuid(),
+    as_of timestamptz NOT NULL,
+    triggered_by uuid,
+    status text NOT NULL,
+    balances_accrued integer NOT NULL DEFAULT 0,
+    days_accrued decimal NOT NULL DEFAULT 0,
+    balances_rolled integer NOT NULL DEFAULT 0,
+    days_carried decimal NOT NULL DEFAULT 0,
+    days_encashed decimal NOT NULL DEFAULT 0,
+    days_lapsed decimal NOT NULL DEFAULT 0,
+    balances_expired integer NOT NULL DEFAULT 0,
+    days_expired decimal NOT NULL DEFAULT 0,
+    error text,
+    started_at timestamptz NOT NULL,
+    finished_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_leave_policy_runs_triggered_by FOREIGN KEY (triggered_by) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_leave_policy_runs_started_at ON leave_policy_runs (started_at);
@@ -0,0 +1,47 @@
+-- Append-only ledger of leave balance changes. Balances rolled over before
+-- the ledger get the days they carried out and the days that lapsed, which
+-- used to be left in their remaining days. Every existing balance then opens
+-- its ledger with an entry for its available days.
+
+ALTER TABLE leave_balances ADD COLUMN IF NOT EXISTS carried_out_days decimal NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS leave_ledger_entries (
+    id uuid DEFAULT gen_random_uuid(),
+    leave_balance_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    leave_type_id uuid NOT NULL,
+    year bigint NOT NULL,
+    kind text NOT NULL,
+    days decimal NOT NULL,
+    leave_application_id uuid,
+    actor_id uuid,
+    policy_run_id uuid,
+    note text,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_leave_ledger_entries_balance FOREIGN KEY (leave_balance_id) REFERENCES leave_balances (id),
+    CONSTRAINT fk_leave_ledger_entries_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_leave_ledger_entries_leave_type FOREIGN KEY (leave_type_id) REFERENCES leave_types (id),
+    CONSTRAINT fk_leave_ledger_entries_leave FOREIGN KEY (leave_application_id) REFERENCES leave_applications (id),
+    CONSTRAINT fk_leave_ledger_entries_actor FOREIGN KEY (actor_id) REFERENCES users (id),
+    CONSTRAINT fk_leave_ledger_entries_policy_run FOREIGN KEY (policy_run_id) REFERENCES leave_policy_runs (id)
+);
+CREATE INDEX IF NOT EXISTS idx_leave_ledger_entries_leave_balance_id ON leave_ledger_entries (leave_balance_id);
+CREATE INDEX IF NOT EXISTS idx_leave_ledger_entries_user_year ON leave_ledger_entries (user_id, year);
+
+UPDATE leave_balances lb SET carried_out_days = nb.carried_days
+FROM leave_balances nb
+WHERE lb.closed_at IS NOT NULL AND lb.carried_out_days = 0
+  AND nb.user_id = lb.user_id AND nb.leave_type_id = lb.leave_type_id AND nb.year = lb.year + 1;
+
+UPDATE leave_balances
+SET expired_days = expired_days + (allocated_days + carried_days - carried_out_days - expired_days - encashed_days - used_days)
+WHERE closed_at IS NOT NULL
+  AND allocated_days + carried_days - carried_out_days - expired_days - encashed_days - used_days > 0;
+
+INSERT INTO leave_ledger_entries (leave_balance_id, user_id, leave_type_id, year, kind, days, note, created_at)
+SELECT id, user_id, leave_type_id, year, 'opening',
+       allocated_days + carried_days - carried_out_days - expired_days - encashed_days - used_days,
+       'Balance when the ledger was introduced', now()
+FROM leave_balances
+WHERE NOT EXISTS (SELECT 1 FROM leave_ledger_entries e WHERE e.leave_balance_id = leave_balances.id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS approval_delegations;
+DROP TABLE IF EXISTS leave_approvals;
+DROP TABLE IF EXISTS leave_approval_steps;
+DROP TABLE IF EXISTS leave_approval_chains;
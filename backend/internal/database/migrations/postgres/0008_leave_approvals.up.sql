@@ -0,0 +1,71 @@
+-- Multi-step leave approval chains, the approval steps of each leave and
+-- out-of-office delegations of approvers.
+
+CREATE TABLE IF NOT EXISTS leave_approval_chains (
+    id uuid DEFAULT gen_random_uuid(),
+    name text NOT NULL,
+    leave_type_id uuid,
+    department text,
+    min_days decimal NOT NULL DEFAULT 0,
+    is_active boolean NOT NULL DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_leave_approval_chains_leave_type FOREIGN KEY (leave_type_id) REFERENCES leave_types (id)
+);
+
+CREATE TABLE IF NOT EXISTS leave_approval_steps (
+    id uuid DEFAULT gen_random_uuid(),
+    chain_id uuid NOT NULL,
+    position bigint NOT NULL,
+    approver text NOT NULL,
+    approver_role varchar(20),
+    approver_user_id uuid,
+    sla_hours bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_leave_approval_chains_steps FOREIGN KEY (chain_id) REFERENCES leave_approval_chains (id) ON DELETE CASCADE,
+    CONSTRAINT fk_leave_approval_steps_approver_user FOREIGN KEY (approver_user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_leave_approval_steps_chain_id ON leave_approval_steps (chain_id);
+
+CREATE TABLE IF NOT EXISTS leave_approvals (
+    id uuid DEFAULT gen_random_uuid(),
+    leave_application_id uuid NOT NULL,
+    position bigint NOT NULL,
+    approver_role varchar(20),
+    assigned_to uuid,
+    status text NOT NULL,
+    sla_hours bigint NOT NULL DEFAULT 0,
+    due_at timestamptz,
+    escalated_at timestamptz,
+    escalated_to uuid,
+    decided_by uuid,
+    on_behalf_of uuid,
+    decided_at timestamptz,
+    note text,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_leave_applications_approvals FOREIGN KEY (leave_application_id) REFERENCES leave_applications (id) ON DELETE CASCADE,
+    CONSTRAINT fk_leave_approvals_assigned_to FOREIGN KEY (assigned_to) REFERENCES users (id),
+    CONSTRAINT fk_leave_approvals_escalated_to FOREIGN KEY (escalated_to) REFERENCES users (id),
+    CONSTRAINT fk_leave_approvals_decided_by FOREIGN KEY (decided_by) REFERENCES users (id),
+    CONSTRAINT fk_leave_approvals_on_behalf_of FOREIGN KEY (on_behalf_of) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_leave_approvals_leave_application_id ON leave_approvals (leave_application_id);
+CREATE INDEX IF NOT EXISTS idx_leave_approvals_status_due_at ON leave_approvals (status, due_at);
+
+CREATE TABLE IF NOT EXISTS approval_delegations (
+    id uuid DEFAULT gen_random_uuid(),
+    delegator_id uuid NOT NULL,
+    delegate_id uuid NOT NULL,
+    start_date timestamptz NOT NULL,
+    end_date timestamptz NOT NULL,
+    reason text,
+    revoked_at timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_approval_delegations_delegator FOREIGN KEY (delegator_id) REFERENCES users (id),
+    CONSTRAINT fk_approval_delegations_delegate FOREIGN KEY (delegate_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_approval_delegations_delegator_id ON approval_delegations (delegator_id);
+CREATE INDEX IF NOT EXISTS idx_approval_delegations_delegate_id ON approval_delegations (delegate_id);
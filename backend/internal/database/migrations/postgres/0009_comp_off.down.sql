@@ -0,0 +1,3 @@
+ALTER TABLE leave_ledger_entries DROP COLUMN IF EXISTS comp_off_claim_id;
+
+DROP TABLE IF EXISTS comp_off_claims;
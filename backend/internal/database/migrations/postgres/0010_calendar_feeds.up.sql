@@ -0,0 +1,17 @@
+-- Tokenised iCalendar subscription URLs. Only the hash of each URL's token
+-- is stored.
+
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    id uuid DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL,
+    scope text NOT NULL,
+    token_hash text NOT NULL,
+    last_accessed_at timestamptz,
+    revoked_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_calendar_feeds_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_calendar_feeds_token_hash ON calendar_feeds (token_hash);
+CREATE INDEX IF NOT EXISTS idx_calendar_feeds_user_id ON calendar_feeds (user_id);
@@ -0,0 +1,2 @@
+ALTER TABLE events DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE events DROP COLUMN IF EXISTS holiday_type;
@@ -0,0 +1,15 @@
+-- Holidays as first-class records: a type of their own and an update time
+-- so re-imports and calendar feeds can tell what changed.
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS holiday_type text;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+
+-- The CSV loader used to append " (<type>)" or " (<type> - Optional)" to the
+-- description; move the type into its own column
+UPDATE events SET
+    holiday_type = regexp_replace(substring(description from ' \(([^()]*)\)$'), ' - Optional$', ''),
+    description = NULLIF(regexp_replace(description, ' \([^()]*\)$', ''), '')
+WHERE event_type = 'holiday' AND description ~ ' \([^()]*\)$';
+
+UPDATE events SET holiday_type = 'public' WHERE event_type = 'holiday' AND holiday_type IS NULL;
+UPDATE events SET updated_at = created_at WHERE updated_at IS NULL;
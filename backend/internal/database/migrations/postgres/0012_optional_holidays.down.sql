@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS optional_holiday_selections;
+DROP TABLE IF EXISTS optional_holiday_quotas;
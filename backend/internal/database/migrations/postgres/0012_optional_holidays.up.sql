@@ -0,0 +1,34 @@
+-- Yearly quotas of optional holidays and the optional holidays employees
+-- pick within them. Approved picks are days off for their employee.
+
+CREATE TABLE IF NOT EXISTS optional_holiday_quotas (
+    year integer NOT NULL,
+    quota integer NOT NULL,
+    updated_by uuid,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (year),
+    CONSTRAINT fk_optional_holiday_quotas_updater FOREIGN KEY (updated_by) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS optional_holiday_selections (
+    id uuid DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL,
+    holiday_id uuid NOT NULL,
+    note text,
+    status text NOT NULL DEFAULT 'pending',
+    reviewed_by uuid,
+    reviewed_at timestamptz,
+    rejection_reason text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_optional_holiday_selections_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_optional_holiday_selections_holiday FOREIGN KEY (holiday_id) REFERENCES events (id) ON DELETE CASCADE,
+    CONSTRAINT fk_optional_holiday_selections_reviewer FOREIGN KEY (reviewed_by) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_optional_holiday_selections_user_id ON optional_holiday_selections (user_id, status);
+CREATE INDEX IF NOT EXISTS idx_optional_holiday_selections_holiday_id ON optional_holiday_selections (holiday_id);
+-- An employee picks a holiday at most once until the pick is rejected or cancelled
+CREATE UNIQUE INDEX IF NOT EXISTS idx_optional_holiday_selections_live
+    ON optional_holiday_selections (user_id, holiday_id) WHERE status IN ('pending', 'approved');
@@ -0,0 +1,19 @@
+DROP TABLE IF EXISTS email_delivery_attempts;
+DROP TABLE IF EXISTS email_outbox;
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS user_sessions;
+
+DROP INDEX IF EXISTS idx_otp_records_request_ip;
+ALTER TABLE otp_records DROP COLUMN IF EXISTS attempts;
+ALTER TABLE otp_records DROP COLUMN IF EXISTS request_ip;
+
+DROP INDEX IF EXISTS idx_notifications_user_read;
+ALTER TABLE notifications DROP COLUMN IF EXISTS read_at;
+ALTER TABLE notifications DROP COLUMN IF EXISTS entity_id;
+
+ALTER TABLE timesheet_entries DROP COLUMN IF EXISTS rejection_reason;
+
+ALTER TABLE users DROP COLUMN IF EXISTS must_reset_password;
+ALTER TABLE users DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE users DROP COLUMN IF EXISTS failed_logins;
+ALTER TABLE users DROP COLUMN IF EXISTS role_version;
@@ -0,0 +1,82 @@
+-- Columns and tables that AutoMigrate added in the releases just before
+-- versioned migrations: sessions and token revocation, role versions, login
+-- lockout, the email outbox, OTP throttling, notification read state and
+-- timesheet review. Databases created earlier lack them; those created by
+-- a later AutoMigrate already have them, hence IF NOT EXISTS throughout.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role_version bigint NOT NULL DEFAULT 1;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS failed_logins bigint NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until timestamptz;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS must_reset_password boolean NOT NULL DEFAULT false;
+
+ALTER TABLE timesheet_entries ADD COLUMN IF NOT EXISTS rejection_reason text;
+
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS entity_id uuid;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS read_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_notifications_user_read ON notifications (user_id, is_read);
+
+ALTER TABLE otp_records ADD COLUMN IF NOT EXISTS request_ip text;
+ALTER TABLE otp_records ADD COLUMN IF NOT EXISTS attempts bigint NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_otp_records_request_ip ON otp_records (request_ip);
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id uuid DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL,
+    refresh_token_hash text NOT NULL,
+    previous_token_hash text,
+    user_agent text,
+    ip_address text,
+    expires_at timestamptz NOT NULL,
+    last_used_at timestamptz,
+    revoked_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_user_sessions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_sessions_refresh_token_hash ON user_sessions (refresh_token_hash);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_previous_token_hash ON user_sessions (previous_token_hash);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions (user_id);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti text,
+    user_id uuid NOT NULL,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (jti)
+);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_user_id ON revoked_tokens (user_id);
+
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id uuid DEFAULT gen_random_uuid(),
+    to_address text NOT NULL,
+    subject text NOT NULL,
+    text_body text,
+    html_body text,
+    status text NOT NULL DEFAULT 'pending',
+    attempts bigint NOT NULL DEFAULT 0,
+    max_attempts bigint NOT NULL DEFAULT 5,
+    next_attempt_at timestamptz NOT NULL,
+    last_error text,
+    sent_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_email_outbox_to_address ON email_outbox (to_address);
+CREATE INDEX IF NOT EXISTS idx_email_outbox_due ON email_outbox (status, next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS email_delivery_attempts (
+    id uuid DEFAULT gen_random_uuid(),
+    outbox_id uuid NOT NULL,
+    attempt bigint NOT NULL,
+    transport text NOT NULL,
+    success boolean,
+    error text,
+    duration_ms bigint,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_email_outbox_delivery_log FOREIGN KEY (outbox_id) REFERENCES email_outbox (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_email_delivery_attempts_outbox_id ON email_delivery_attempts (outbox_id);
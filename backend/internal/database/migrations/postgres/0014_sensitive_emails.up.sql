@@ -0,0 +1,10 @@
+-- OTP emails are sensitive: their bodies are hidden from admins and cleared
+-- once sent or given up on.
+
+ALTER TABLE email_outbox ADD COLUMN IF NOT EXISTS sensitive boolean NOT NULL DEFAULT false;
+
+-- OTP emails queued before this migration
+UPDATE email_outbox SET sensitive = true
+WHERE subject IN ('Verify Your teamDa Account', 'Reset Your teamDa Password');
+UPDATE email_outbox SET text_body = '', html_body = ''
+WHERE sensitive = true AND status IN ('sent', 'failed');
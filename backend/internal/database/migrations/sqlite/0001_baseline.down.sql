@@ -0,0 +1,21 @@
+DROP TABLE IF EXISTS otp_records;
+DROP TABLE IF EXISTS gallery_images;
+DROP TABLE IF EXISTS rss_news_items;
+DROP TABLE IF EXISTS rss_feeds;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS policies;
+DROP TABLE IF EXISTS sports_facilities;
+DROP TABLE IF EXISTS sports_events;
+DROP TABLE IF EXISTS learning_enrollments;
+DROP TABLE IF EXISTS learning_sessions;
+DROP TABLE IF EXISTS news;
+DROP TABLE IF EXISTS assets;
+DROP TABLE IF EXISTS documents;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS timesheet_entries;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS leave_balances;
+DROP TABLE IF EXISTS leave_applications;
+DROP TABLE IF EXISTS leave_types;
+DROP TABLE IF EXISTS departments;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,323 @@
+-- Baseline schema, the SQLite counterpart of postgres/0001_baseline.up.sql.
+-- UUIDs are stored as text and generated by the models' BeforeCreate hooks.
+
+CREATE TABLE IF NOT EXISTS users (
+    id text,
+    employee_id text NOT NULL,
+    email text NOT NULL,
+    password_hash text NOT NULL,
+    first_name text NOT NULL,
+    last_name text NOT NULL,
+    phone text,
+    department text,
+    position text,
+    manager_id text,
+    hire_date datetime,
+    employment_type text DEFAULT 'full-time',
+    status text DEFAULT 'active',
+    profile_image_url text,
+    bio text,
+    skills text,
+    languages text,
+    role varchar(20) NOT NULL DEFAULT 'employee',
+    approval_status varchar(20) NOT NULL DEFAULT 'pending',
+    approved_by text,
+    approved_at datetime,
+    rejection_reason text,
+    is_anonymous boolean DEFAULT false,
+    created_at datetime,
+    updated_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_users_manager FOREIGN KEY (manager_id) REFERENCES users (id) ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT fk_users_approver FOREIGN KEY (approved_by) REFERENCES users (id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_employee_id ON users (employee_id);
+
+CREATE TABLE IF NOT EXISTS departments (
+    id text,
+    name text NOT NULL,
+    description text,
+    manager_id text,
+    created_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_departments_manager FOREIGN KEY (manager_id) REFERENCES users (id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS leave_types (
+    id text,
+    name text NOT NULL,
+    description text,
+    max_days_per_year integer,
+    is_active boolean DEFAULT true,
+    created_at datetime,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS leave_applications (
+    id text,
+    user_id text NOT NULL,
+    leave_type_id text NOT NULL,
+    start_date datetime NOT NULL,
+    end_date datetime NOT NULL,
+    is_half_day boolean DEFAULT false,
+    is_lop boolean DEFAULT false,
+    lop_days numeric DEFAULT 0,
+    paid_days numeric DEFAULT 0,
+    reason text,
+    description text,
+    status text DEFAULT 'pending',
+    approved_by text,
+    approved_at datetime,
+    rejection_reason text,
+    created_at datetime,
+    updated_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_leave_applications_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_leave_applications_leave_type FOREIGN KEY (leave_type_id) REFERENCES leave_types (id),
+    CONSTRAINT fk_leave_applications_approver FOREIGN KEY (approved_by) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS leave_balances (
+    id text,
+    user_id text NOT NULL,
+    leave_type_id text NOT NULL,
+    year integer NOT NULL,
+    allocated_days integer NOT NULL,
+    used_days numeric DEFAULT 0,
+    created_at datetime,
+    updated_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_leave_balances_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_leave_balances_leave_type FOREIGN KEY (leave_type_id) REFERENCES leave_types (id)
+);
+
+CREATE TABLE IF NOT EXISTS projects (
+    id text,
+    name text NOT NULL,
+    description text,
+    client_name text,
+    status text DEFAULT 'active',
+    start_date datetime,
+    end_date datetime,
+    created_at datetime,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS timesheet_entries (
+    id text,
+    user_id text NOT NULL,
+    project_id text NOT NULL,
+    task_description text NOT NULL,
+    entry_date datetime NOT NULL,
+    start_time datetime,
+    end_time datetime,
+    duration_hours numeric,
+    break_time_minutes integer DEFAULT 0,
+    status text DEFAULT 'draft',
+    submitted_at datetime,
+    approved_by text,
+    approved_at datetime,
+    created_at datetime,
+    updated_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_timesheet_entries_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_timesheet_entries_project FOREIGN KEY (project_id) REFERENCES projects (id),
+    CONSTRAINT fk_timesheet_entries_approver FOREIGN KEY (approved_by) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS events (
+    id text,
+    title text NOT NULL,
+    description text,
+    event_type text NOT NULL,
+    event_date datetime NOT NULL,
+    user_id text,
+    is_company_wide boolean DEFAULT false,
+    created_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_events_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS documents (
+    id text,
+    user_id text NOT NULL,
+    filename text NOT NULL,
+    original_filename text NOT NULL,
+    file_path text NOT NULL,
+    file_size integer,
+    mime_type text,
+    category text,
+    description text,
+    uploaded_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_documents_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS assets (
+    id text,
+    asset_id text NOT NULL,
+    name text NOT NULL,
+    type text,
+    brand text,
+    model text,
+    serial_number text,
+    status text DEFAULT 'available',
+    assigned_to text,
+    assigned_date datetime,
+    created_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_assets_user FOREIGN KEY (assigned_to) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_assets_asset_id ON assets (asset_id);
+
+CREATE TABLE IF NOT EXISTS news (
+    id text,
+    title text NOT NULL,
+    content text NOT NULL,
+    summary text,
+    category text,
+    type text DEFAULT 'news',
+    author_id text,
+    is_featured boolean DEFAULT false,
+    is_published boolean DEFAULT true,
+    published_at datetime,
+    created_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_news_author FOREIGN KEY (author_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS learning_sessions (
+    id text,
+    title text NOT NULL,
+    description text,
+    topic text,
+    instructor text,
+    session_date datetime NOT NULL,
+    duration_minutes integer,
+    max_participants integer,
+    location text,
+    is_virtual boolean DEFAULT false,
+    meeting_link text,
+    status text DEFAULT 'scheduled',
+    created_at datetime,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS learning_enrollments (
+    id text,
+    user_id text NOT NULL,
+    session_id text NOT NULL,
+    enrolled_at datetime,
+    attendance_status text,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_learning_enrollments_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_learning_enrollments_session FOREIGN KEY (session_id) REFERENCES learning_sessions (id)
+);
+
+CREATE TABLE IF NOT EXISTS sports_events (
+    id text,
+    title text NOT NULL,
+    description text,
+    sport_type text,
+    event_date datetime NOT NULL,
+    location text,
+    max_participants integer,
+    registration_deadline datetime,
+    created_at datetime,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS sports_facilities (
+    id text,
+    name text NOT NULL,
+    type text,
+    description text,
+    capacity integer,
+    is_available boolean DEFAULT true,
+    created_at datetime,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS policies (
+    id text,
+    title text NOT NULL,
+    description text,
+    content text NOT NULL,
+    category text,
+    version text DEFAULT '1.0',
+    effective_date datetime,
+    is_active boolean DEFAULT true,
+    created_by text,
+    s3_key text,
+    created_at datetime,
+    updated_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_policies_creator FOREIGN KEY (created_by) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id text,
+    user_id text NOT NULL,
+    title text NOT NULL,
+    message text NOT NULL,
+    type text,
+    is_read boolean DEFAULT false,
+    created_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_notifications_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS rss_feeds (
+    id text,
+    name text NOT NULL,
+    url text NOT NULL,
+    category text DEFAULT 'general',
+    is_active boolean DEFAULT true,
+    last_fetched datetime,
+    created_at datetime,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS rss_news_items (
+    id text,
+    feed_id text NOT NULL,
+    title text NOT NULL,
+    description text,
+    content text,
+    link text NOT NULL,
+    author text,
+    category text,
+    image_url text,
+    published_at datetime,
+    guid text,
+    is_read boolean DEFAULT false,
+    created_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_rss_news_items_feed FOREIGN KEY (feed_id) REFERENCES rss_feeds (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rss_news_items_guid ON rss_news_items (guid);
+
+CREATE TABLE IF NOT EXISTS gallery_images (
+    id text,
+    s3_key text NOT NULL,
+    title text NOT NULL,
+    description text,
+    uploaded_at datetime,
+    created_at datetime,
+    updated_at datetime,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_gallery_images_s3_key ON gallery_images (s3_key);
+
+CREATE TABLE IF NOT EXISTS otp_records (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    email text NOT NULL,
+    otp text NOT NULL,
+    purpose text NOT NULL,
+    user_data text,
+    expires_at datetime NOT NULL,
+    used boolean DEFAULT false,
+    created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_otp_records_email ON otp_records (email);
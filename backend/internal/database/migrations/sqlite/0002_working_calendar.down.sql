@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_events_type_date;
+
+ALTER TABLE events DROP COLUMN location;
+ALTER TABLE events DROP COLUMN is_optional;
+
+ALTER TABLE users DROP COLUMN work_location;
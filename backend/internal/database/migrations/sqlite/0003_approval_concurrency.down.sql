@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS idempotency_keys;
+
+ALTER TABLE leave_balances DROP COLUMN version;
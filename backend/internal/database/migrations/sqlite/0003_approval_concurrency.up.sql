@@ -0,0 +1,19 @@
+-- Concurrency-safe leave approval: optimistic versioning on balances and
+-- idempotency keys for retried mutating requests.
+
+ALTER TABLE leave_balances ADD COLUMN version integer NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id text,
+    user_id text NOT NULL,
+    key text NOT NULL,
+    request_hash text NOT NULL,
+    status_code integer NOT NULL DEFAULT 0,
+    response_body text,
+    expires_at datetime NOT NULL,
+    created_at datetime,
+    updated_at datetime,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_keys_user_key ON idempotency_keys (user_id, key);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,32 @@
+-- Leave cancellation requests and the status history of leave applications.
+
+ALTER TABLE leave_applications ADD COLUMN cancel_from datetime;
+ALTER TABLE leave_applications ADD COLUMN cancel_reason text;
+
+CREATE TABLE IF NOT EXISTS leave_status_changes (
+    id text,
+    leave_application_id text NOT NULL,
+    from_status text,
+    to_status text NOT NULL,
+    changed_by text,
+    note text,
+    created_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_leave_applications_status_history FOREIGN KEY (leave_application_id) REFERENCES leave_applications (id) ON DELETE CASCADE,
+    CONSTRAINT fk_leave_status_changes_changed_by FOREIGN KEY (changed_by) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_leave_status_changes_leave_application_id ON leave_status_changes (leave_application_id);
+
+-- Start the history of existing applications from what they record. SQLite
+-- has no UUID function, so random version 4 UUIDs are built by hand.
+INSERT INTO leave_status_changes (id, leave_application_id, from_status, to_status, changed_by, created_at)
+SELECT lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+             substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))),
+       id, '', 'pending', user_id, created_at
+FROM leave_applications;
+
+INSERT INTO leave_status_changes (id, leave_application_id, from_status, to_status, changed_by, note, created_at)
+SELECT lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+             substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))),
+       id, 'pending', status, approved_by, rejection_reason, COALESCE(approved_at, updated_at)
+FROM leave_applications WHERE status <> 'pending';
@@ -0,0 +1,19 @@
+-- Half-day sessions of leave applications. Days without a row are taken in
+-- full.
+
+CREATE TABLE IF NOT EXISTS leave_sessions (
+    id text,
+    leave_application_id text NOT NULL,
+    date text NOT NULL,
+    session text NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_leave_applications_sessions FOREIGN KEY (leave_application_id) REFERENCES leave_applications (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_leave_sessions_leave_date ON leave_sessions (leave_application_id, date);
+
+-- Existing half-day applications took the first half of their start date
+INSERT OR IGNORE INTO leave_sessions (id, leave_application_id, date, session)
+SELECT lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+             substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))),
+       id, substr(start_date, 1, 10), 'first_half'
+FROM leave_applications WHERE is_half_day = 1;
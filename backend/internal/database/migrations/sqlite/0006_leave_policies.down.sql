@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS leave_policy_runs;
+DROP TABLE IF EXISTS leave_policies;
+
+ALTER TABLE leave_balances DROP COLUMN closed_at;
+ALTER TABLE leave_balances DROP COLUMN accrued_months;
+ALTER TABLE leave_balances DROP COLUMN encashed_days;
+ALTER TABLE leave_balances DROP COLUMN expired_days;
+ALTER TABLE leave_balances DROP COLUMN carry_expires_at;
+ALTER TABLE leave_balances DROP COLUMN carried_days;
@@ -0,0 +1,50 @@
+-- Leave policies: accrual, carry-forward, expiry and encashment of leave
+-- balances, and the log of the job that applies them. SQLite already stores
+-- fractional allocations in the integer column.
+
+ALTER TABLE leave_balances ADD COLUMN carried_days numeric NOT NULL DEFAULT 0;
+ALTER TABLE leave_balances ADD COLUMN carry_expires_at datetime;
+ALTER TABLE leave_balances ADD COLUMN expired_days numeric NOT NULL DEFAULT 0;
+ALTER TABLE leave_balances ADD COLUMN encashed_days numeric NOT NULL DEFAULT 0;
+ALTER TABLE leave_balances ADD COLUMN accrued_months integer NOT NULL DEFAULT 0;
+ALTER TABLE leave_balances ADD COLUMN closed_at datetime;
+
+CREATE TABLE IF NOT EXISTS leave_policies (
+    id text,
+    leave_type_id text NOT NULL,
+    annual_days numeric NOT NULL,
+    accrual_frequency text NOT NULL DEFAULT 'monthly',
+    prorate_by_hire_date boolean NOT NULL DEFAULT true,
+    max_balance numeric,
+    carry_forward_limit numeric NOT NULL DEFAULT 0,
+    carry_forward_expiry_months integer NOT NULL DEFAULT 0,
+    encashment_enabled boolean NOT NULL DEFAULT false,
+    max_encash_days numeric NOT NULL DEFAULT 0,
+    is_active boolean NOT NULL DEFAULT true,
+    created_at datetime,
+    updated_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_leave_policies_leave_type FOREIGN KEY (leave_type_id) REFERENCES leave_types (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_leave_policies_leave_type_id ON leave_policies (leave_type_id);
+
+CREATE TABLE IF NOT EXISTS leave_policy_runs (
+    id text,
+    as_of datetime NOT NULL,
+    triggered_by text,
+    status text NOT NULL,
+    balances_accrued integer NOT NULL DEFAULT 0,
+    days_accrued numeric NOT NULL DEFAULT 0,
+    balances_rolled integer NOT NULL DEFAULT 0,
+    days_carried numeric NOT NULL DEFAULT 0,
+    days_encashed numeric NOT NULL DEFAULT 0,
+    days_lapsed numeric NOT NULL DEFAULT 0,
+    balances_expired integer NOT NULL DEFAULT 0,
+    days_expired numeric NOT NULL DEFAULT 0,
+    error text,
+    started_at datetime NOT NULL,
+    finished_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_leave_policy_runs_triggered_by FOREIGN KEY (triggered_by) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_leave_policy_runs_started_at ON leave_policy_runs (started_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS leave_ledger_entries;
+
+ALTER TABLE leave_balances DROP COLUMN carried_out_days;
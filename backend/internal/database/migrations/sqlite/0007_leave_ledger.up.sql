@@ -0,0 +1,50 @@
+-- Append-only ledger of leave balance changes. Balances rolled over before
+-- the ledger get the days they carried out and the days that lapsed, which
+-- used to be left in their remaining days. Every existing balance then opens
+-- its ledger with an entry for its available days.
+
+ALTER TABLE leave_balances ADD COLUMN carried_out_days numeric NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS leave_ledger_entries (
+    id text,
+    leave_balance_id text NOT NULL,
+    user_id text NOT NULL,
+    leave_type_id text NOT NULL,
+    year integer NOT NULL,
+    kind text NOT NULL,
+    days numeric NOT NULL,
+    leave_application_id text,
+    actor_id text,
+    policy_run_id text,
+    note text,
+    created_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_leave_ledger_entries_balance FOREIGN KEY (leave_balance_id) REFERENCES leave_balances (id),
+    CONSTRAINT fk_leave_ledger_entries_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_leave_ledger_entries_leave_type FOREIGN KEY (leave_type_id) REFERENCES leave_types (id),
+    CONSTRAINT fk_leave_ledger_entries_leave FOREIGN KEY (leave_application_id) REFERENCES leave_applications (id),
+    CONSTRAINT fk_leave_ledger_entries_actor FOREIGN KEY (actor_id) REFERENCES users (id),
+    CONSTRAINT fk_leave_ledger_entries_policy_run FOREIGN KEY (policy_run_id) REFERENCES leave_policy_runs (id)
+);
+CREATE INDEX IF NOT EXISTS idx_leave_ledger_entries_leave_balance_id ON leave_ledger_entries (leave_balance_id);
+CREATE INDEX IF NOT EXISTS idx_leave_ledger_entries_user_year ON leave_ledger_entries (user_id, year);
+
+UPDATE leave_balances SET carried_out_days = COALESCE((
+    SELECT nb.carried_days FROM leave_balances nb
+    WHERE nb.user_id = leave_balances.user_id AND nb.leave_type_id = leave_balances.leave_type_id
+      AND nb.year = leave_balances.year + 1), 0)
+WHERE closed_at IS NOT NULL AND carried_out_days = 0;
+
+UPDATE leave_balances
+SET expired_days = expired_days + (allocated_days + carried_days - carried_out_days - expired_days - encashed_days - used_days)
+WHERE closed_at IS NOT NULL
+  AND allocated_days + carried_days - carried_out_days - expired_days - encashed_days - used_days > 0;
+
+INSERT INTO leave_ledger_entries (id, leave_balance_id, user_id, leave_type_id, year, kind, days, note, created_at)
+SELECT lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+             substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))),
+       id, user_id, leave_type_id, year, 'opening',
+       allocated_days + carried_days - carried_out_days - expired_days - encashed_days - used_days,
+       'Balance when the ledger was introduced', datetime('now')
+FROM leave_balances
+WHERE NOT EXISTS (SELECT 1 FROM leave_ledger_entries e WHERE e.leave_balance_id = leave_balances.id);
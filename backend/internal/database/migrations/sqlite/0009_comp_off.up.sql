@@ -0,0 +1,31 @@
+-- Comp-off claims for extra hours logged on weekends, holidays or past the
+-- daily threshold. Approved claims are credited to the Comp Off balance
+-- through the leave ledger.
+
+CREATE TABLE IF NOT EXISTS comp_off_claims (
+    id text,
+    user_id text NOT NULL,
+    work_date datetime NOT NULL,
+    reason text NOT NULL,
+    holiday text,
+    hours numeric NOT NULL,
+    days numeric NOT NULL,
+    note text,
+    status text NOT NULL DEFAULT 'pending',
+    reviewed_by text,
+    reviewed_at datetime,
+    rejection_reason text,
+    leave_balance_id text,
+    expires_at datetime NOT NULL,
+    lapsed_days numeric NOT NULL DEFAULT 0,
+    created_at datetime,
+    updated_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_comp_off_claims_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_comp_off_claims_reviewer FOREIGN KEY (reviewed_by) REFERENCES users (id),
+    CONSTRAINT fk_comp_off_claims_balance FOREIGN KEY (leave_balance_id) REFERENCES leave_balances (id)
+);
+CREATE INDEX IF NOT EXISTS idx_comp_off_claims_user_id ON comp_off_claims (user_id, work_date);
+CREATE INDEX IF NOT EXISTS idx_comp_off_claims_status_expires_at ON comp_off_claims (status, expires_at);
+
+ALTER TABLE leave_ledger_entries ADD COLUMN comp_off_claim_id text REFERENCES comp_off_claims (id);
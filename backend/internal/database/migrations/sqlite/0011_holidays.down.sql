@@ -0,0 +1,2 @@
+ALTER TABLE events DROP COLUMN updated_at;
+ALTER TABLE events DROP COLUMN holiday_type;
@@ -0,0 +1,16 @@
+-- Holidays as first-class records: a type of their own and an update time
+-- so re-imports and calendar feeds can tell what changed.
+
+ALTER TABLE events ADD COLUMN holiday_type text;
+ALTER TABLE events ADD COLUMN updated_at datetime;
+
+-- The CSV loader used to append " (<type>)" or " (<type> - Optional)" to the
+-- description; move the type into its own column. rtrim(d, replace(d, '(',
+-- '')) keeps d up to its last "(".
+UPDATE events SET
+    holiday_type = replace(replace(substr(description, length(rtrim(description, replace(description, '(', ''))) + 1), ' - Optional)', ''), ')', ''),
+    description = NULLIF(substr(rtrim(description, replace(description, '(', '')), 1, length(rtrim(description, replace(description, '(', ''))) - 2), '')
+WHERE event_type = 'holiday' AND description LIKE '% (%)';
+
+UPDATE events SET holiday_type = 'public' WHERE event_type = 'holiday' AND holiday_type IS NULL;
+UPDATE events SET updated_at = created_at WHERE updated_at IS NULL;
@@ -0,0 +1,19 @@
+DROP TABLE IF EXISTS email_delivery_attempts;
+DROP TABLE IF EXISTS email_outbox;
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS user_sessions;
+
+DROP INDEX IF EXISTS idx_otp_records_request_ip;
+ALTER TABLE otp_records DROP COLUMN attempts;
+ALTER TABLE otp_records DROP COLUMN request_ip;
+
+DROP INDEX IF EXISTS idx_notifications_user_read;
+ALTER TABLE notifications DROP COLUMN read_at;
+ALTER TABLE notifications DROP COLUMN entity_id;
+
+ALTER TABLE timesheet_entries DROP COLUMN rejection_reason;
+
+ALTER TABLE users DROP COLUMN must_reset_password;
+ALTER TABLE users DROP COLUMN locked_until;
+ALTER TABLE users DROP COLUMN failed_logins;
+ALTER TABLE users DROP COLUMN role_version;
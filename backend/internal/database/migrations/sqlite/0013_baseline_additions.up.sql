@@ -0,0 +1,80 @@
+-- The SQLite counterpart of postgres/0013_baseline_additions.up.sql. SQLite
+-- support arrived after versioned migrations, so its databases never went
+-- through AutoMigrate and the columns are added unconditionally.
+
+ALTER TABLE users ADD COLUMN role_version integer NOT NULL DEFAULT 1;
+ALTER TABLE users ADD COLUMN failed_logins integer NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN locked_until datetime;
+ALTER TABLE users ADD COLUMN must_reset_password boolean NOT NULL DEFAULT false;
+
+ALTER TABLE timesheet_entries ADD COLUMN rejection_reason text;
+
+ALTER TABLE notifications ADD COLUMN entity_id text;
+ALTER TABLE notifications ADD COLUMN read_at datetime;
+CREATE INDEX IF NOT EXISTS idx_notifications_user_read ON notifications (user_id, is_read);
+
+ALTER TABLE otp_records ADD COLUMN request_ip text;
+ALTER TABLE otp_records ADD COLUMN attempts integer NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_otp_records_request_ip ON otp_records (request_ip);
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id text,
+    user_id text NOT NULL,
+    refresh_token_hash text NOT NULL,
+    previous_token_hash text,
+    user_agent text,
+    ip_address text,
+    expires_at datetime NOT NULL,
+    last_used_at datetime,
+    revoked_at datetime,
+    created_at datetime,
+    updated_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_user_sessions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_sessions_refresh_token_hash ON user_sessions (refresh_token_hash);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_previous_token_hash ON user_sessions (previous_token_hash);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions (user_id);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti text,
+    user_id text NOT NULL,
+    expires_at datetime NOT NULL,
+    created_at datetime,
+    PRIMARY KEY (jti)
+);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_user_id ON revoked_tokens (user_id);
+
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id text,
+    to_address text NOT NULL,
+    subject text NOT NULL,
+    text_body text,
+    html_body text,
+    status text NOT NULL DEFAULT 'pending',
+    attempts integer NOT NULL DEFAULT 0,
+    max_attempts integer NOT NULL DEFAULT 5,
+    next_attempt_at datetime NOT NULL,
+    last_error text,
+    sent_at datetime,
+    created_at datetime,
+    updated_at datetime,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_email_outbox_to_address ON email_outbox (to_address);
+CREATE INDEX IF NOT EXISTS idx_email_outbox_due ON email_outbox (status, next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS email_delivery_attempts (
+    id text,
+    outbox_id text NOT NULL,
+    attempt integer NOT NULL,
+    transport text NOT NULL,
+    success boolean,
+    error text,
+    duration_ms integer,
+    created_at datetime,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_email_outbox_delivery_log FOREIGN KEY (outbox_id) REFERENCES email_outbox (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_email_delivery_attempts_outbox_id ON email_delivery_attempts (outbox_id);
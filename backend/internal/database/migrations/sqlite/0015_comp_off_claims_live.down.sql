@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_comp_off_claims_live;
@@ -0,0 +1,3 @@
+-- A work date is claimed at most once until the claim is rejected
+CREATE UNIQUE INDEX IF NOT EXISTS idx_comp_off_claims_live
+    ON comp_off_claims (user_id, work_date) WHERE status <> 'rejected';
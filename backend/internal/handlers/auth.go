@@ -5,7 +5,9 @@ import (
 	"employee-dashboard-api/internal/models"
 	"employee-dashboard-api/internal/services"
 	"employee-dashboard-api/internal/utils"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -15,28 +17,22 @@ import (
 )
 
 type AuthHandler struct {
-	db           *gorm.DB
-	config       *config.Config
-	logger       *logrus.Logger
-	emailService *services.EmailService
+	db            *gorm.DB
+	config        *config.Config
+	logger        *logrus.Logger
+	emailService  *services.EmailService
+	tokenService  *services.TokenService
+	notifications *services.NotificationService
 }
 
-func NewAuthHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger) *AuthHandler {
-	emailConfig := services.EmailConfig{
-		SMTPHost:     cfg.SMTPHost,
-		SMTPPort:     cfg.SMTPPortStr,
-		SMTPUsername: cfg.SMTPUsername,
-		SMTPPassword: cfg.SMTPPassword,
-		FromEmail:    cfg.SMTPFromEmail,
-		FromName:     cfg.SMTPFromName,
-	}
-	emailService := services.NewEmailService(db, logger, emailConfig)
-
+func NewAuthHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger, tokenService *services.TokenService, emailService *services.EmailService, notifications *services.NotificationService) *AuthHandler {
 	return &AuthHandler{
-		db:           db,
-		config:       cfg,
-		logger:       logger,
-		emailService: emailService,
+		db:            db,
+		config:        cfg,
+		logger:        logger,
+		emailService:  emailService,
+		tokenService:  tokenService,
+		notifications: notifications,
 	}
 }
 
@@ -73,9 +69,25 @@ type CompleteRegistrationRequest struct {
 	Phone      string `json:"phone"`
 }
 
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Email       string `json:"email" binding:"required,email"`
+	OTP         string `json:"otp" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 type AuthResponse struct {
-	Token string      `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
-	User  models.User `json:"user"`
+	Token        string      `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string      `json:"refresh_token" example:"3q2-7wEAAAB0aGlzIGlzIGEgcmVmcmVzaCB0b2tlbg"`
+	ExpiresAt    time.Time   `json:"expires_at" example:"2025-01-01T10:15:00Z"`
+	User         models.User `json:"user"`
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -112,7 +124,7 @@ func (h *AuthHandler) CompleteRegistration(c *gin.Context) {
 	}
 
 	// Verify OTP
-	if err := h.emailService.VerifyOTP(req.Email, req.OTP, "signup"); err != nil {
+	if err := h.emailService.VerifyOTP(req.Email, req.OTP, services.OTPPurposeSignup); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid or expired OTP", err.Error())
 		return
 	}
@@ -125,22 +137,10 @@ func (h *AuthHandler) CompleteRegistration(c *gin.Context) {
 	}
 
 	// Hash password
-	// hashedPassword, err := utils.HashPassword(req.Password)
-	// if err != nil {
-	// 	utils.InternalErrorResponse(c, err)
-	// 	return
-	// }
-
-	var passwordToStore string
-	if h.config.UserPlainPasswords {
-		passwordToStore = req.Password // Store plain password
-	} else {
-		hashedPassword, err := utils.HashPassword(req.Password)
-		if err != nil {
-			utils.InternalErrorResponse(c, err)
-			return
-		}
-		passwordToStore = hashedPassword
+	passwordToStore, err := utils.HashPassword(req.Password)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
 	}
 
 	// Create user with pending status - admin approval required
@@ -168,7 +168,7 @@ func (h *AuthHandler) CompleteRegistration(c *gin.Context) {
 }
 
 // @Summary User login
-// @Description Authenticate user with employee ID and password, returning a JWT token and user details.
+// @Description Authenticate user with employee ID and password, returning a short-lived JWT access token, a refresh token and user details.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -195,6 +195,14 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// Locked accounts are refused before the password is even checked, with
+	// the answer given for unknown accounts so that lockouts do not reveal
+	// which accounts exist
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid credentials", "")
+		return
+	}
+
 	// Check if user is approved
 	if user.ApprovalStatus != models.StatusApproved {
 		switch user.ApprovalStatus {
@@ -208,21 +216,46 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Check password
-	var passwordMatch bool
-	if h.config.UserPlainPasswords {
-		passwordMatch = (req.Password == user.PasswordHash) // Compare plain password
-	} else {
-		passwordMatch = utils.CheckPasswordHash(req.Password, user.PasswordHash)
+	// Accounts without a usable password (e.g. seeded from CSV) must set one first
+	if user.MustResetPassword {
+		utils.ErrorResponse(c, http.StatusForbidden, "Password reset required", "Use forgot password to set a new password")
+		return
 	}
 
+	// Check password
+	passwordMatch, needsRehash := utils.VerifyPassword(req.Password, user.PasswordHash)
 	if !passwordMatch {
+		if err := h.recordFailedLogin(&user); err != nil {
+			h.logger.Errorf("Failed to record failed login for user %s: %v", user.ID, err)
+		}
 		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid credentials", "")
 		return
 	}
 
-	// Generate token
-	token, err := utils.GenerateToken(user.ID, user.Email, h.config.JWTSecret, h.config.JWTExpiryHours)
+	updates := map[string]interface{}{}
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		updates["failed_logins"] = 0
+		updates["locked_until"] = nil
+	}
+	// Upgrade legacy plaintext values and outdated hashes while we have the password
+	if needsRehash {
+		if hashedPassword, err := utils.HashPassword(req.Password); err != nil {
+			h.logger.Errorf("Failed to rehash password for user %s: %v", user.ID, err)
+		} else {
+			updates["password_hash"] = hashedPassword
+		}
+	}
+	if len(updates) > 0 {
+		if err := h.db.Model(&user).Updates(updates).Error; err != nil {
+			h.logger.Errorf("Failed to update login state for user %s: %v", user.ID, err)
+		}
+	}
+
+	// Start a session and issue the token pair
+	tokens, err := h.tokenService.CreateSession(&user, services.SessionInfo{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	})
 	if err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
@@ -232,13 +265,35 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	user.PasswordHash = ""
 
 	response := AuthResponse{
-		Token: token,
-		User:  user,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    tokens.ExpiresAt,
+		User:         user,
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, "Login successful", response)
 }
 
+// recordFailedLogin counts a wrong password and locks the account for
+// LoginLockoutMinutes once LoginMaxFailures is reached.
+func (h *AuthHandler) recordFailedLogin(user *models.User) error {
+	if h.config.LoginMaxFailures <= 0 {
+		return nil
+	}
+
+	if user.FailedLogins+1 < h.config.LoginMaxFailures {
+		return h.db.Model(&models.User{}).Where("id = ?", user.ID).
+			Update("failed_logins", gorm.Expr("failed_logins + 1")).Error
+	}
+
+	lockedUntil := time.Now().Add(time.Duration(h.config.LoginLockoutMinutes) * time.Minute)
+	h.logger.Warnf("Locking user %s until %s after %d failed logins", user.ID, lockedUntil.Format(time.RFC3339), user.FailedLogins+1)
+	return h.db.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"failed_logins": 0,
+		"locked_until":  lockedUntil,
+	}).Error
+}
+
 func (h *AuthHandler) SendSignupOTP(c *gin.Context) {
 	var req SendOTPRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -263,7 +318,7 @@ func (h *AuthHandler) VerifySignupOTP(c *gin.Context) {
 	}
 
 	// Verify OTP
-	if err := h.emailService.VerifyOTP(req.Email, req.OTP, "signup"); err != nil {
+	if err := h.emailService.VerifyOTP(req.Email, req.OTP, services.OTPPurposeSignup); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid or expired OTP", err.Error())
 		return
 	}
@@ -273,12 +328,208 @@ func (h *AuthHandler) VerifySignupOTP(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "OTP verified successfully", nil)
 }
 
+// @Summary Request a password reset
+// @Description Email a password reset OTP. The response is the same whether or not an account exists for the email.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body ForgotPasswordRequest true "Account email"
+// @Success 200 {object} utils.APIResponse "Reset code sent if the account exists"
+// @Failure 429 {object} utils.APIResponse "Too many reset requests from this client"
+// @Router /auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	// Only approved accounts get the email, but an OTP is recorded either way so
+	// the response and rate limiting do not reveal whether the account exists
+	var user models.User
+	err := h.db.Select("id").Where("LOWER(email) = ? AND approval_status = ?", email, models.StatusApproved).First(&user).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	if err := h.emailService.SendPasswordResetOTP(email, c.ClientIP(), err == nil); err != nil {
+		var rateLimited *services.RateLimitError
+		if errors.As(err, &rateLimited) {
+			utils.TooManyRequestsResponse(c, rateLimited.RetryAfter)
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "If an account exists for this email, a password reset code has been sent", nil)
+}
+
+// @Summary Reset password
+// @Description Set a new password using the OTP from /auth/forgot-password. All existing sessions are revoked.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body ResetPasswordRequest true "Email, OTP and new password"
+// @Success 200 {object} utils.APIResponse "Password reset successfully"
+// @Failure 400 {object} utils.APIResponse "Invalid or expired OTP"
+// @Router /auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	if err := h.emailService.VerifyOTP(email, req.OTP, services.OTPPurposePasswordReset); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid or expired OTP", "")
+		return
+	}
+
+	var user models.User
+	if err := h.db.Where("LOWER(email) = ? AND approval_status = ?", email, models.StatusApproved).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid or expired OTP", "")
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	passwordToStore, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	// A successful reset also lifts any login lockout and pending forced reset
+	if err := h.db.Model(&user).Updates(map[string]interface{}{
+		"password_hash":       passwordToStore,
+		"failed_logins":       0,
+		"locked_until":        nil,
+		"must_reset_password": false,
+	}).Error; err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	// Whoever knew the old password must not stay logged in
+	revoked, err := h.tokenService.RevokeAllSessions(user.ID)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	h.logger.Infof("Password reset for user %s, %d sessions revoked", user.ID, revoked)
+
+	utils.SuccessResponse(c, http.StatusOK, "Password reset successfully. Please log in with your new password.", nil)
+}
+
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token. The refresh token is rotated; the old one stops working.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} services.TokenPair "Token refreshed"
+// @Failure 401 {object} utils.APIResponse "Invalid, expired or reused refresh token"
+// @Router /auth/refresh [post]
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	tokens, err := h.tokenService.Refresh(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReuse) {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid refresh token", err.Error())
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Token refreshed successfully", tokens)
+}
+
+// Logout ends the current session: the access token is revoked and the
+// session's refresh token stops working.
 func (h *AuthHandler) Logout(c *gin.Context) {
-	// In a stateless JWT system, logout is handled client-side
-	// Here we could implement token blacklisting if needed
+	claims, ok := currentClaims(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	if err := h.tokenService.RevokeAccessToken(claims); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	if err := h.tokenService.RevokeSession(claims.SessionID); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
 	utils.SuccessResponse(c, http.StatusOK, "Logout successful", nil)
 }
 
+// LogoutAll ends every session of the current user, on all devices.
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	if err := h.tokenService.RevokeAccessToken(claims); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	revoked, err := h.tokenService.RevokeAllSessions(claims.UserID)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Logged out of all devices", gin.H{
+		"revoked_sessions": revoked,
+	})
+}
+
+// RevokeUserSessions - Admin endpoint to kill every session of a user
+func (h *AuthHandler) RevokeUserSessions(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	var user models.User
+	if err := h.db.Select("id").First(&user, "id = ?", userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "User")
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	revoked, err := h.tokenService.RevokeAllSessions(user.ID)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "User sessions revoked successfully", gin.H{
+		"user_id":          user.ID,
+		"revoked_sessions": revoked,
+	})
+}
+
 // @Summary Get current user details
 // @Description Retrieve the profile information of the currently authenticated user.
 // @Tags Auth
@@ -359,15 +610,7 @@ func (h *AuthHandler) ApproveUser(c *gin.Context) {
 	}
 
 	// Validate role
-	validRoles := []string{"admin", "hr", "manager", "team-lead", "employee"}
-	isValidRole := false
-	for _, role := range validRoles {
-		if req.Role == role {
-			isValidRole = true
-			break
-		}
-	}
-	if !isValidRole {
+	if !models.UserRole(req.Role).IsValid() {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid role", "")
 		return
 	}
@@ -378,18 +621,70 @@ func (h *AuthHandler) ApproveUser(c *gin.Context) {
 	updates := map[string]interface{}{
 		"approval_status": models.StatusApproved,
 		"role":            models.UserRole(req.Role),
+		"role_version":    gorm.Expr("role_version + 1"),
 		"approved_by":     adminUUID,
 		"approved_at":     &now,
 	}
 
-	if err := h.db.Model(&models.User{}).Where("id = ? AND approval_status = ?", userID, models.StatusPending).Updates(updates).Error; err != nil {
-		utils.InternalErrorResponse(c, err)
+	result := h.db.Model(&models.User{}).Where("id = ? AND approval_status = ?", userID, models.StatusPending).Updates(updates)
+	if result.Error != nil {
+		utils.InternalErrorResponse(c, result.Error)
 		return
 	}
+	if parsedID, err := uuid.Parse(userID); err == nil {
+		h.tokenService.InvalidateRoleVersion(parsedID)
+		if result.RowsAffected > 0 {
+			h.notifications.NotifyUserReviewed(parsedID, true, "")
+		}
+	}
 
 	utils.SuccessResponse(c, http.StatusOK, "User approved successfully", nil)
 }
 
+// ChangeUserRole - Admin endpoint to change the role of an approved user. The
+// role version is bumped so tokens carrying the old role stop working; the
+// client picks up the new role on its next refresh.
+func (h *AuthHandler) ChangeUserRole(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	var req ApproveUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	role := models.UserRole(req.Role)
+	if !role.IsValid() {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid role", "")
+		return
+	}
+
+	result := h.db.Model(&models.User{}).
+		Where("id = ? AND approval_status = ?", userID, models.StatusApproved).
+		Updates(map[string]interface{}{
+			"role":         role,
+			"role_version": gorm.Expr("role_version + 1"),
+		})
+	if result.Error != nil {
+		utils.InternalErrorResponse(c, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.NotFoundResponse(c, "Approved user")
+		return
+	}
+	h.tokenService.InvalidateRoleVersion(userID)
+
+	utils.SuccessResponse(c, http.StatusOK, "User role updated successfully", gin.H{
+		"user_id": userID,
+		"role":    role,
+	})
+}
+
 func (h *AuthHandler) RejectUser(c *gin.Context) {
 	userID := c.Param("id")
 	adminID, exists := c.Get("user_id")
@@ -414,10 +709,17 @@ func (h *AuthHandler) RejectUser(c *gin.Context) {
 		"rejection_reason": req.Reason,
 	}
 
-	if err := h.db.Model(&models.User{}).Where("id = ? AND approval_status = ?", userID, models.StatusPending).Updates(updates).Error; err != nil {
-		utils.InternalErrorResponse(c, err)
+	result := h.db.Model(&models.User{}).Where("id = ? AND approval_status = ?", userID, models.StatusPending).Updates(updates)
+	if result.Error != nil {
+		utils.InternalErrorResponse(c, result.Error)
 		return
 	}
+	if parsedID, err := uuid.Parse(userID); err == nil {
+		h.tokenService.InvalidateRoleVersion(parsedID)
+		if result.RowsAffected > 0 {
+			h.notifications.NotifyUserReviewed(parsedID, false, req.Reason)
+		}
+	}
 
 	utils.SuccessResponse(c, http.StatusOK, "User rejected successfully", nil)
 }
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
+	"employee-dashboard-api/internal/services"
+	"employee-dashboard-api/internal/utils"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// calendarFeedPath is where feeds are served; the token and ".ics" follow.
+const calendarFeedPath = "/api/v1/calendar/feeds/"
+
+type CalendarFeedHandler struct {
+	db          *gorm.DB
+	config      *config.Config
+	logger      *logrus.Logger
+	feeds       repository.CalendarFeedRepository
+	feedService *services.CalendarFeedService
+}
+
+func NewCalendarFeedHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger, repos *repository.Repositories, feedService *services.CalendarFeedService) *CalendarFeedHandler {
+	return &CalendarFeedHandler{
+		db:          db,
+		config:      cfg,
+		logger:      logger,
+		feeds:       repos.Feeds,
+		feedService: feedService,
+	}
+}
+
+// CreateCalendarFeedRequest picks what a new feed covers.
+type CreateCalendarFeedRequest struct {
+	Scope string `json:"scope" binding:"required,oneof=personal team"`
+}
+
+// GetFeeds lists the current user's live calendar feeds. Their URLs are only
+// shown when a feed is created or rotated.
+func (h *CalendarFeedHandler) GetFeeds(c *gin.Context) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	feeds, err := h.feeds.ListForUser(userID)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Calendar feeds retrieved successfully", feeds)
+}
+
+// CreateFeed issues a personal or team feed URL for the current user.
+func (h *CalendarFeedHandler) CreateFeed(c *gin.Context) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	var req CreateCalendarFeedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	feed, token, err := h.feedService.Create(userID, req.Scope)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidFeedScope) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid calendar feed scope", err.Error())
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusCreated, "Calendar feed created successfully", gin.H{
+		"feed": feed,
+		"url":  feedURL(c, token),
+	})
+}
+
+// RotateFeed gives one of the current user's feeds a new URL; the old one
+// stops working.
+func (h *CalendarFeedHandler) RotateFeed(c *gin.Context) {
+	feed, ok := h.ownFeed(c)
+	if !ok {
+		return
+	}
+
+	token, err := h.feedService.Rotate(feed)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Calendar feed rotated successfully", gin.H{
+		"feed": feed,
+		"url":  feedURL(c, token),
+	})
+}
+
+// RevokeFeed disables one of the current user's feeds.
+func (h *CalendarFeedHandler) RevokeFeed(c *gin.Context) {
+	feed, ok := h.ownFeed(c)
+	if !ok {
+		return
+	}
+
+	if err := h.feedService.Revoke(feed); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Calendar feed revoked successfully", nil)
+}
+
+// ownFeed loads the live feed in the id parameter if the current user owns
+// it. On false an error response has already been written.
+func (h *CalendarFeedHandler) ownFeed(c *gin.Context) (*models.CalendarFeed, bool) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return nil, false
+	}
+
+	feedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid calendar feed ID", err.Error())
+		return nil, false
+	}
+
+	feed, err := h.feeds.FindForUser(feedID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.NotFoundResponse(c, "Calendar feed")
+			return nil, false
+		}
+		utils.InternalErrorResponse(c, err)
+		return nil, false
+	}
+	return feed, true
+}
+
+// ServeFeed serves a feed as text/calendar. It is public: the token in the
+// URL is the credential, so calendar apps can subscribe without a bearer
+// header.
+func (h *CalendarFeedHandler) ServeFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	feed, owner, err := h.feedService.Resolve(token)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.NotFoundResponse(c, "Calendar feed")
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	body, err := h.feedService.Render(feed, owner, time.Now())
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=900")
+	c.Header("Content-Disposition", `inline; filename="`+feed.Scope+`.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", body)
+}
+
+// feedURL is the subscription URL of a feed token, on the host the request
+// came in on.
+func feedURL(c *gin.Context, token string) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + calendarFeedPath + token + ".ics"
+}
@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
+	"employee-dashboard-api/internal/services"
+	"employee-dashboard-api/internal/utils"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type CompOffHandler struct {
+	db             *gorm.DB
+	config         *config.Config
+	logger         *logrus.Logger
+	location       *time.Location
+	claims         repository.CompOffRepository
+	teamScope      *services.TeamScopeService
+	compOffService *services.CompOffService
+	notifications  *services.NotificationService
+}
+
+func NewCompOffHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger, location *time.Location, repos *repository.Repositories, compOffService *services.CompOffService, notifications *services.NotificationService) *CompOffHandler {
+	return &CompOffHandler{
+		db:             db,
+		config:         cfg,
+		logger:         logger,
+		location:       location,
+		claims:         repos.CompOffs,
+		teamScope:      services.NewTeamScopeService(db, logger),
+		compOffService: compOffService,
+		notifications:  notifications,
+	}
+}
+
+// CompOffClaimRequest claims the comp-off earned on WorkDate (YYYY-MM-DD).
+type CompOffClaimRequest struct {
+	WorkDate string `json:"work_date" binding:"required"`
+	Note     string `json:"note" binding:"max=500"`
+}
+
+// ReviewCompOffRequest carries the reason a claim is rejected.
+type ReviewCompOffRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// RunCompOffExpiryRequest expires the comp-off credits due by a time (RFC
+// 3339, default now).
+type RunCompOffExpiryRequest struct {
+	AsOf string `json:"as_of"`
+}
+
+// GetEligibleDays lists the days the current user earned comp-off on, from
+// from to to (YYYY-MM-DD, default the days whose comp-off has not expired
+// yet), with any claim already made for them.
+func (h *CompOffHandler) GetEligibleDays(c *gin.Context) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	today := time.Now().In(h.location)
+	to, ok := h.queryDate(c, "to", today)
+	if !ok {
+		return
+	}
+	from, ok := h.queryDate(c, "from", today.AddDate(0, 0, 1-h.config.CompOffExpiryDays))
+	if !ok {
+		return
+	}
+	if to.Before(from) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "from must not be after to", "")
+		return
+	}
+
+	days, err := h.compOffService.Eligible(userID, from, to)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Comp-off eligible days retrieved successfully", days)
+}
+
+// queryDate parses the YYYY-MM-DD query parameter name, falling back to def.
+// On false an error response has already been written.
+func (h *CompOffHandler) queryDate(c *gin.Context, name string, def time.Time) (time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return def, true
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, h.location)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid "+name+" date format", err.Error())
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// GetClaims lists the current user's comp-off claims (?status= to filter).
+func (h *CompOffHandler) GetClaims(c *gin.Context) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+	h.listClaims(c, repository.CompOffFilter{UserID: userID, Status: c.Query("status")})
+}
+
+// GetTeamClaims lists the comp-off claims in the reviewer's team scope
+// (?status=pending for the ones waiting for review).
+func (h *CompOffHandler) GetTeamClaims(c *gin.Context) {
+	scope, ok := requestTeamScope(c, h.teamScope)
+	if !ok {
+		return
+	}
+	h.listClaims(c, repository.CompOffFilter{Scope: scope, Status: c.Query("status")})
+}
+
+func (h *CompOffHandler) listClaims(c *gin.Context, filter repository.CompOffFilter) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	claims, total, err := h.claims.List(filter, repository.Page{Offset: offset, Limit: limit})
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Comp-off claims retrieved successfully", gin.H{
+		"claims": claims,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// CreateClaim claims the comp-off the current user earned on a day. The
+// days are worked out from the submitted timesheets of the day; the claim
+// then waits for the manager's approval.
+func (h *CompOffHandler) CreateClaim(c *gin.Context) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	var req CompOffClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	workDate, err := time.ParseInLocation("2006-01-02", req.WorkDate, h.location)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid work date format", err.Error())
+		return
+	}
+
+	claim, err := h.compOffService.Claim(userID, workDate, strings.TrimSpace(req.Note))
+	if err != nil {
+		compOffErrorResponse(c, err)
+		return
+	}
+
+	if claim, err = h.claims.FindByID(claim.ID); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	h.notifications.NotifyCompOffClaimed(claim)
+
+	utils.SuccessResponse(c, http.StatusCreated, "Comp-off claimed successfully", claim)
+}
+
+// ApproveClaim approves a pending claim in the reviewer's team scope and
+// credits its days to the claimant's Comp Off balance.
+func (h *CompOffHandler) ApproveClaim(c *gin.Context) {
+	h.reviewClaim(c, true)
+}
+
+// RejectClaim turns down a pending claim in the reviewer's team scope with
+// a reason.
+func (h *CompOffHandler) RejectClaim(c *gin.Context) {
+	h.reviewClaim(c, false)
+}
+
+func (h *CompOffHandler) reviewClaim(c *gin.Context, approve bool) {
+	reviewerID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	claimID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid comp-off claim ID", err.Error())
+		return
+	}
+
+	var req ReviewCompOffRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if !approve && reason == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Reason is required", "")
+		return
+	}
+
+	claim, err := h.claims.FindByID(claimID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.NotFoundResponse(c, "Comp-off claim")
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	// Nobody reviews their own claims, and reviewers only their team's
+	scope, ok := requestTeamScope(c, h.teamScope)
+	if !ok {
+		return
+	}
+	if claim.UserID == reviewerID || !scope.Contains(claim.UserID) {
+		utils.ForbiddenResponse(c)
+		return
+	}
+
+	if approve {
+		err = h.compOffService.Approve(claim, reviewerID)
+	} else {
+		err = h.compOffService.Reject(claim, reviewerID, reason)
+	}
+	if err != nil {
+		compOffErrorResponse(c, err)
+		return
+	}
+
+	if claim, err = h.claims.FindByID(claim.ID); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	h.notifications.NotifyCompOffReviewed(claim)
+
+	message := "Comp-off claim rejected successfully"
+	if approve {
+		message = "Comp-off claim approved successfully"
+	}
+	utils.SuccessResponse(c, http.StatusOK, message, claim)
+}
+
+// RunExpiry expires the comp-off credits due now instead of waiting for the
+// scheduled job.
+func (h *CompOffHandler) RunExpiry(c *gin.Context) {
+	var req RunCompOffExpiryRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	asOf := time.Now()
+	if req.AsOf != "" {
+		parsed, err := time.Parse(time.RFC3339, req.AsOf)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid as_of time format", err.Error())
+			return
+		}
+		asOf = parsed
+	}
+
+	expired, err := h.compOffService.Expire(asOf)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Comp-off expiry completed", gin.H{"expired": expired})
+}
+
+func compOffErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrNoCompOffEarned):
+		utils.ErrorResponse(c, http.StatusBadRequest, "No comp-off earned on this day", "Only submitted hours on weekends, holidays or past the daily overtime threshold earn comp-off")
+	case errors.Is(err, services.ErrCompOffExpired):
+		utils.ErrorResponse(c, http.StatusBadRequest, "Comp-off for this day has expired", "")
+	case errors.Is(err, services.ErrCompOffClaimed):
+		utils.ErrorResponse(c, http.StatusConflict, "Comp-off for this day has already been claimed", "")
+	case errors.Is(err, services.ErrCompOffNotPending):
+		utils.ErrorResponse(c, http.StatusConflict, "Comp-off claim is not in pending status", "")
+	case errors.Is(err, services.ErrCompOffUnavailable):
+		utils.ErrorResponse(c, http.StatusConflict, "The "+models.CompOffLeaveTypeName+" leave type is not available", "")
+	default:
+		utils.InternalErrorResponse(c, err)
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/services"
+	"employee-dashboard-api/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// currentUserUUID returns the authenticated (non-anonymous) user ID from the context.
+func currentUserUUID(c *gin.Context) (uuid.UUID, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, false
+	}
+	id, ok := userID.(uuid.UUID)
+	if !ok || id == uuid.Nil {
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// currentClaims returns the access token claims stored by AuthMiddleware.
+func currentClaims(c *gin.Context) (*utils.Claims, bool) {
+	value, exists := c.Get("claims")
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*utils.Claims)
+	return claims, ok && claims != nil
+}
+
+// requestTeamScope resolves the team scope of the authenticated user. Holders
+// of team:view_all see everybody; everyone else sees their reporting tree.
+// On failure an error response has already been written.
+func requestTeamScope(c *gin.Context, teamScope *services.TeamScopeService) (*services.TeamScope, bool) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return nil, false
+	}
+
+	scope, err := teamScope.ResolveScope(claims.UserID, claims.HasPermission(models.PermTeamViewAll))
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return nil, false
+	}
+	return scope, true
+}
@@ -3,7 +3,9 @@ package handlers
 import (
 	"employee-dashboard-api/internal/config"
 	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
 	"employee-dashboard-api/internal/utils"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -19,16 +21,18 @@ import (
 )
 
 type DocumentHandler struct {
-	db     *gorm.DB
-	config *config.Config
-	logger *logrus.Logger
+	db        *gorm.DB
+	config    *config.Config
+	logger    *logrus.Logger
+	documents repository.DocumentRepository
 }
 
-func NewDocumentHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger) *DocumentHandler {
+func NewDocumentHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger, repos *repository.Repositories) *DocumentHandler {
 	return &DocumentHandler{
-		db:     db,
-		config: cfg,
-		logger: logger,
+		db:        db,
+		config:    cfg,
+		logger:    logger,
+		documents: repos.Documents,
 	}
 }
 
@@ -46,20 +50,11 @@ func (h *DocumentHandler) GetDocuments(c *gin.Context) {
 
 	offset := (page - 1) * limit
 
-	query := h.db.Where("user_id = ?", userID)
-
-	if category != "" {
-		query = query.Where("category = ?", category)
-	}
-
-	var documents []models.Document
-	var total int64
-
-	// Get total count
-	query.Model(&models.Document{}).Count(&total)
-
-	// Get paginated results
-	if err := query.Order("uploaded_at DESC").Offset(offset).Limit(limit).Find(&documents).Error; err != nil {
+	documents, total, err := h.documents.List(repository.DocumentFilter{
+		UserID:   userID.(uuid.UUID),
+		Category: category,
+	}, repository.Page{Offset: offset, Limit: limit})
+	if err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
@@ -144,7 +139,7 @@ func (h *DocumentHandler) UploadDocument(c *gin.Context) {
 		UploadedAt:       time.Now(),
 	}
 
-	if err := h.db.Create(&document).Error; err != nil {
+	if err := h.documents.Create(&document); err != nil {
 		// Clean up file if database save fails
 		os.Remove(filePath)
 		utils.InternalErrorResponse(c, err)
@@ -168,9 +163,9 @@ func (h *DocumentHandler) DeleteDocument(c *gin.Context) {
 	}
 
 	// Find document
-	var document models.Document
-	if err := h.db.Where("id = ? AND user_id = ?", documentID, userID).First(&document).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	document, err := h.documents.FindForUser(documentID, userID.(uuid.UUID))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			utils.NotFoundResponse(c, "Document")
 			return
 		}
@@ -184,7 +179,7 @@ func (h *DocumentHandler) DeleteDocument(c *gin.Context) {
 	}
 
 	// Delete document record from database
-	if err := h.db.Delete(&document).Error; err != nil {
+	if err := h.documents.Delete(document); err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
@@ -206,9 +201,9 @@ func (h *DocumentHandler) DownloadDocument(c *gin.Context) {
 	}
 
 	// Find document
-	var document models.Document
-	if err := h.db.Where("id = ? AND user_id = ?", documentID, userID).First(&document).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	document, err := h.documents.FindForUser(documentID, userID.(uuid.UUID))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			utils.NotFoundResponse(c, "Document")
 			return
 		}
@@ -242,11 +237,8 @@ func (h *DocumentHandler) GetCategories(c *gin.Context) {
 	}
 
 	// Get distinct categories for the user
-	var categories []string
-	if err := h.db.Model(&models.Document{}).
-		Where("user_id = ? AND category IS NOT NULL", userID).
-		Distinct("category").
-		Pluck("category", &categories).Error; err != nil {
+	categories, err := h.documents.Categories(userID.(uuid.UUID))
+	if err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
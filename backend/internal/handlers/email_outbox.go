@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/services"
+	"employee-dashboard-api/internal/utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type EmailOutboxHandler struct {
+	db     *gorm.DB
+	config *config.Config
+	logger *logrus.Logger
+	outbox *services.EmailOutboxService
+}
+
+func NewEmailOutboxHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger, outbox *services.EmailOutboxService) *EmailOutboxHandler {
+	return &EmailOutboxHandler{
+		db:     db,
+		config: cfg,
+		logger: logger,
+		outbox: outbox,
+	}
+}
+
+// @Summary List outbound emails
+// @Description Admin: list email outbox entries, newest first. Bodies are omitted.
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param status query string false "Filter by status (pending, sending, sent, failed)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(20)
+// @Success 200 {object} object{emails=[]models.EmailOutbox,pagination=object{page=int,limit=int,total=int}} "Emails retrieved successfully"
+// @Router /admin/emails [get]
+func (h *EmailOutboxHandler) GetEmails(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.EmailStatusPending, models.EmailStatusSending, models.EmailStatusSent, models.EmailStatusFailed:
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid status", "")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	emails, total, err := h.outbox.ListEmails(status, page, limit)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Emails retrieved successfully", gin.H{
+		"emails": emails,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// @Summary Get an outbound email
+// @Description Admin: get an email outbox entry with its delivery attempts. Bodies of sensitive emails (OTPs) are redacted.
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "Email ID"
+// @Success 200 {object} models.EmailOutbox "Email retrieved successfully"
+// @Failure 404 {object} utils.APIResponse "Email not found"
+// @Router /admin/emails/{id} [get]
+func (h *EmailOutboxHandler) GetEmail(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid email ID", err.Error())
+		return
+	}
+
+	email, err := h.outbox.GetEmail(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Email")
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Email retrieved successfully", email)
+}
+
+// @Summary Resend an outbound email
+// @Description Admin: queue a failed or sent email for delivery again. Sensitive emails (OTPs) cannot be resent.
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "Email ID"
+// @Success 200 {object} models.EmailOutbox "Email queued for resending"
+// @Failure 404 {object} utils.APIResponse "No failed or sent email with this ID"
+// @Failure 409 {object} utils.APIResponse "Sensitive emails cannot be resent"
+// @Router /admin/emails/{id}/resend [post]
+func (h *EmailOutboxHandler) ResendEmail(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid email ID", err.Error())
+		return
+	}
+
+	email, err := h.outbox.Resend(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Failed or sent email")
+			return
+		}
+		if errors.Is(err, services.ErrSensitiveEmail) {
+			utils.ErrorResponse(c, http.StatusConflict, "Emails carrying a one-time code cannot be resent", "")
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Email queued for resending", email)
+}
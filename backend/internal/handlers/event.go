@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/database"
 	"employee-dashboard-api/internal/models"
 	"employee-dashboard-api/internal/utils"
 	"net/http"
@@ -84,7 +85,7 @@ func (h *EventHandler) GetBirthdays(c *gin.Context) {
 
 	var events []models.Event
 	if err := h.db.Preload("User").
-		Where("event_type = ? AND EXTRACT(MONTH FROM event_date) = ? AND EXTRACT(YEAR FROM event_date) = ?",
+		Where("event_type = ? AND "+database.MonthOf(h.db, "event_date")+" = ? AND "+database.YearOf(h.db, "event_date")+" = ?",
 			"birthday", month, year).
 		Order("event_date ASC").
 		Find(&events).Error; err != nil {
@@ -102,7 +103,7 @@ func (h *EventHandler) GetAnniversaries(c *gin.Context) {
 
 	var events []models.Event
 	if err := h.db.Preload("User").
-		Where("event_type = ? AND EXTRACT(MONTH FROM event_date) = ? AND EXTRACT(YEAR FROM event_date) = ?",
+		Where("event_type = ? AND "+database.MonthOf(h.db, "event_date")+" = ? AND "+database.YearOf(h.db, "event_date")+" = ?",
 			"anniversary", month, year).
 		Order("event_date ASC").
 		Find(&events).Error; err != nil {
@@ -121,10 +122,10 @@ func (h *EventHandler) GetHolidays(c *gin.Context) {
 	query := h.db.Where("event_type = ?", "holiday")
 
 	if month != "" {
-		query = query.Where("EXTRACT(MONTH FROM event_date) = ?", month)
+		query = query.Where(database.MonthOf(h.db, "event_date")+" = ?", month)
 	}
 
-	query = query.Where("EXTRACT(YEAR FROM event_date) = ?", year)
+	query = query.Where(database.YearOf(h.db, "event_date")+" = ?", year)
 
 	var events []models.Event
 	if err := query.Order("event_date ASC").Find(&events).Error; err != nil {
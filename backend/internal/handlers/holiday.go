@@ -2,13 +2,19 @@ package handlers
 
 import (
 	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
 	"employee-dashboard-api/internal/services"
 	"employee-dashboard-api/internal/utils"
+	"errors"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
@@ -17,28 +23,54 @@ type HolidayHandler struct {
 	db             *gorm.DB
 	config         *config.Config
 	logger         *logrus.Logger
+	holidays       repository.HolidayRepository
 	holidayService *services.HolidayService
 	location       *time.Location
 }
 
-func NewHolidayHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger, location *time.Location) *HolidayHandler {
-	holidayService := services.NewHolidayService(db, logger)
+func NewHolidayHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger, location *time.Location, repos *repository.Repositories) *HolidayHandler {
+	holidayService := services.NewHolidayService(repos, logger)
 	return &HolidayHandler{
 		db:             db,
 		config:         cfg,
 		logger:         logger,
+		holidays:       repos.Holidays,
 		holidayService: holidayService,
 		location:       location,
 	}
 }
 
+// HolidayRequest creates a holiday. Date is YYYY-MM-DD; an empty Location
+// applies the holiday at every work location.
+type HolidayRequest struct {
+	Title       string `json:"title" binding:"required,max=255"`
+	Date        string `json:"date" binding:"required"`
+	HolidayType string `json:"holiday_type" binding:"max=50"`
+	Description string `json:"description" binding:"max=1000"`
+	IsOptional  bool   `json:"is_optional"`
+	Location    string `json:"location" binding:"max=100"`
+}
+
+// UpdateHolidayRequest changes the fields of a holiday that are set.
+type UpdateHolidayRequest struct {
+	Title       *string `json:"title" binding:"omitempty,min=1,max=255"`
+	Date        *string `json:"date"`
+	HolidayType *string `json:"holiday_type" binding:"omitempty,max=50"`
+	Description *string `json:"description" binding:"omitempty,max=1000"`
+	IsOptional  *bool   `json:"is_optional"`
+	Location    *string `json:"location" binding:"omitempty,max=100"`
+}
+
+// LoadHolidayData imports holidays.csv from the working directory. Holidays
+// already there are updated rather than duplicated.
 func (h *HolidayHandler) LoadHolidayData(c *gin.Context) {
-	if err := h.holidayService.LoadHolidaysFromCSV("holidays.csv"); err != nil {
-		utils.InternalErrorResponse(c, err)
+	result, err := h.holidayService.LoadHolidaysFromCSV("holidays.csv")
+	if err != nil {
+		holidayErrorResponse(c, err)
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Holiday data loaded successfully", nil)
+	utils.SuccessResponse(c, http.StatusOK, "Holiday data loaded successfully", result)
 }
 
 func (h *HolidayHandler) InitializeHolidayData(c *gin.Context) {
@@ -82,3 +114,199 @@ func (h *HolidayHandler) GetUpcomingHolidays(c *gin.Context) {
 
 	utils.SuccessResponse(c, http.StatusOK, "Upcoming holidays retrieved successfully", holidays)
 }
+
+// CreateHoliday adds a holiday to the calendar.
+func (h *HolidayHandler) CreateHoliday(c *gin.Context) {
+	var req HolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err.Error())
+		return
+	}
+
+	holiday, err := h.holidayService.Create(services.HolidayInput{
+		Title:       req.Title,
+		Date:        date,
+		HolidayType: req.HolidayType,
+		Description: req.Description,
+		IsOptional:  req.IsOptional,
+		Location:    req.Location,
+	})
+	if err != nil {
+		holidayErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusCreated, "Holiday created successfully", holiday)
+}
+
+// UpdateHoliday changes the fields of a holiday given in the request.
+func (h *HolidayHandler) UpdateHoliday(c *gin.Context) {
+	holiday, ok := h.findHoliday(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateHolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	in := holidayInput(holiday)
+	if req.Title != nil {
+		in.Title = *req.Title
+	}
+	if req.Date != nil {
+		date, err := time.Parse("2006-01-02", *req.Date)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err.Error())
+			return
+		}
+		in.Date = date
+	}
+	if req.HolidayType != nil {
+		in.HolidayType = *req.HolidayType
+	}
+	if req.Description != nil {
+		in.Description = *req.Description
+	}
+	if req.IsOptional != nil {
+		in.IsOptional = *req.IsOptional
+	}
+	if req.Location != nil {
+		in.Location = *req.Location
+	}
+
+	if err := h.holidayService.Update(holiday, in); err != nil {
+		holidayErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Holiday updated successfully", holiday)
+}
+
+// DeleteHoliday removes a holiday from the calendar.
+func (h *HolidayHandler) DeleteHoliday(c *gin.Context) {
+	holiday, ok := h.findHoliday(c)
+	if !ok {
+		return
+	}
+
+	if err := h.holidayService.Delete(holiday); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Holiday deleted successfully", nil)
+}
+
+// ImportHolidays imports the holidays of an uploaded .csv or .ics file.
+// Re-importing a file changes nothing. ?dry_run=true only reports the
+// changes, ?prune=true also deletes the holidays of the file's years and
+// locations that are not in it, and ?location= applies the rows without a
+// location of their own at that work location only.
+func (h *HolidayHandler) ImportHolidays(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "No file provided", err.Error())
+		return
+	}
+	defer file.Close()
+
+	// Validate file size
+	if header.Size > h.config.MaxUploadSize {
+		utils.ErrorResponse(c, http.StatusBadRequest, "File too large", "")
+		return
+	}
+
+	var inputs []services.HolidayInput
+	switch strings.ToLower(filepath.Ext(header.Filename)) {
+	case ".csv":
+		inputs, err = services.ParseHolidaysCSV(file)
+	case ".ics", ".ical":
+		inputs, err = services.ParseHolidaysICS(file)
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "Unsupported file type", "Upload a .csv or .ics file")
+		return
+	}
+	if err != nil {
+		holidayErrorResponse(c, err)
+		return
+	}
+
+	if location := strings.TrimSpace(c.Query("location")); location != "" {
+		for i := range inputs {
+			if strings.TrimSpace(inputs[i].Location) == "" {
+				inputs[i].Location = location
+			}
+		}
+	}
+
+	dryRun := c.Query("dry_run") == "true"
+	result, err := h.holidayService.Import(inputs, c.Query("prune") == "true", dryRun)
+	if err != nil {
+		holidayErrorResponse(c, err)
+		return
+	}
+
+	message := "Holidays imported successfully"
+	if dryRun {
+		message = "Holiday import previewed successfully"
+	}
+	utils.SuccessResponse(c, http.StatusOK, message, result)
+}
+
+// findHoliday loads the holiday in the id parameter. On false an error
+// response has already been written.
+func (h *HolidayHandler) findHoliday(c *gin.Context) (*models.Event, bool) {
+	holidayID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid holiday ID", err.Error())
+		return nil, false
+	}
+
+	holiday, err := h.holidays.FindByID(holidayID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.NotFoundResponse(c, "Holiday")
+			return nil, false
+		}
+		utils.InternalErrorResponse(c, err)
+		return nil, false
+	}
+	return holiday, true
+}
+
+// holidayInput is the current state of holiday as an update's starting
+// point.
+func holidayInput(holiday *models.Event) services.HolidayInput {
+	in := services.HolidayInput{
+		Title:      holiday.Title,
+		Date:       holiday.EventDate,
+		IsOptional: holiday.IsOptional,
+	}
+	if holiday.HolidayType != nil {
+		in.HolidayType = *holiday.HolidayType
+	}
+	if holiday.Description != nil {
+		in.Description = *holiday.Description
+	}
+	if holiday.Location != nil {
+		in.Location = *holiday.Location
+	}
+	return in
+}
+
+func holidayErrorResponse(c *gin.Context, err error) {
+	var importErr *services.HolidayImportError
+	switch {
+	case errors.As(err, &importErr):
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid holiday file", strings.Join(importErr.Problems, "; "))
+	case errors.Is(err, services.ErrHolidayExists):
+		utils.ErrorResponse(c, http.StatusConflict, "A holiday with this name already exists on this date and location", "")
+	default:
+		utils.InternalErrorResponse(c, err)
+	}
+}
@@ -3,10 +3,15 @@ package handlers
 import (
 	"employee-dashboard-api/internal/config"
 	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
 	"employee-dashboard-api/internal/services"
 	"employee-dashboard-api/internal/utils"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -16,37 +21,80 @@ import (
 )
 
 type LeaveHandler struct {
-	db           *gorm.DB
-	config       *config.Config
-	logger       *logrus.Logger
-	leaveService *services.LeaveService
+	db            *gorm.DB
+	config        *config.Config
+	logger        *logrus.Logger
+	leaves        repository.LeaveRepository
+	balances      repository.LeaveBalanceRepository
+	leaveService  *services.LeaveService
+	teamScope     *services.TeamScopeService
+	notifications *services.NotificationService
+	approvals     *services.LeaveApprovalService
 }
 
-func NewLeaveHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger) *LeaveHandler {
-	leaveService := services.NewLeaveService(db, logger)
+func NewLeaveHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger, repos *repository.Repositories, notifications *services.NotificationService, approvals *services.LeaveApprovalService) *LeaveHandler {
+	leaveService := services.NewLeaveService(repos, services.NewWorkingCalendarService(db, cfg, logger), logger)
 	return &LeaveHandler{
-		db:           db,
-		config:       cfg,
-		logger:       logger,
-		leaveService: leaveService,
+		db:            db,
+		config:        cfg,
+		logger:        logger,
+		leaves:        repos.Leaves,
+		balances:      repos.Balances,
+		leaveService:  leaveService,
+		teamScope:     services.NewTeamScopeService(db, logger),
+		notifications: notifications,
+		approvals:     approvals,
 	}
 }
 
+// CreateLeaveRequest applies for leave. Sessions lists the days taken as a
+// first or second half; all other days are taken in full. IsHalfDay without
+// Sessions takes the first half of the start date.
 type CreateLeaveRequest struct {
-	LeaveTypeID uuid.UUID `json:"leave_type_id" binding:"required"`
-	StartDate   string    `json:"start_date" binding:"required"`
-	EndDate     string    `json:"end_date" binding:"required"`
-	IsHalfDay   bool      `json:"is_half_day"`
-	Reason      string    `json:"reason"`
-	Description string    `json:"description"`
+	LeaveTypeID uuid.UUID             `json:"leave_type_id" binding:"required"`
+	StartDate   string                `json:"start_date" binding:"required"`
+	EndDate     string                `json:"end_date" binding:"required"`
+	IsHalfDay   bool                  `json:"is_half_day"`
+	Sessions    []LeaveSessionRequest `json:"sessions" binding:"dive"`
+	Reason      string                `json:"reason"`
+	Description string                `json:"description"`
 }
 
+// UpdateLeaveRequest changes a pending leave application. The sessions given
+// replace the previous ones, as in CreateLeaveRequest.
 type UpdateLeaveRequest struct {
-	StartDate   string `json:"start_date"`
-	EndDate     string `json:"end_date"`
-	IsHalfDay   bool   `json:"is_half_day"`
-	Reason      string `json:"reason"`
-	Description string `json:"description"`
+	StartDate   string                `json:"start_date"`
+	EndDate     string                `json:"end_date"`
+	IsHalfDay   bool                  `json:"is_half_day"`
+	Sessions    []LeaveSessionRequest `json:"sessions" binding:"dive"`
+	Reason      string                `json:"reason"`
+	Description string                `json:"description"`
+}
+
+// LeaveSessionRequest takes one day (YYYY-MM-DD) of a leave as full,
+// first_half or second_half.
+type LeaveSessionRequest struct {
+	Date    string `json:"date" binding:"required"`
+	Session string `json:"session" binding:"required,oneof=full first_half second_half"`
+}
+
+// CancelLeaveRequest cancels a leave from CancelFrom (YYYY-MM-DD) to its end;
+// an empty CancelFrom cancels the whole leave.
+type CancelLeaveRequest struct {
+	CancelFrom string `json:"cancel_from"`
+	Reason     string `json:"reason"`
+}
+
+// ApproveLeaveRequest carries an optional note for a step of an approval
+// chain.
+type ApproveLeaveRequest struct {
+	Note string `json:"note"`
+}
+
+// ReviewCancellationRequest carries the reason for turning down a
+// cancellation request.
+type ReviewCancellationRequest struct {
+	Reason string `json:"reason"`
 }
 
 func (h *LeaveHandler) GetLeaves(c *gin.Context) {
@@ -63,20 +111,12 @@ func (h *LeaveHandler) GetLeaves(c *gin.Context) {
 
 	offset := (page - 1) * limit
 
-	query := h.db.Preload("LeaveType").Preload("Approver").Where("user_id = ?", userID)
-
-	if status != "" {
-		query = query.Where("status = ?", status)
-	}
-
-	var leaves []models.LeaveApplication
-	var total int64
-
-	// Get total count
-	query.Model(&models.LeaveApplication{}).Count(&total)
-
-	// Get paginated results with proper preloading to trigger AfterFind hook
-	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&leaves).Error; err != nil {
+	// Paginated results with proper preloading to trigger AfterFind hook
+	leaves, total, err := h.leaves.List(repository.LeaveFilter{
+		UserID: userID.(uuid.UUID),
+		Status: status,
+	}, repository.Page{Offset: offset, Limit: limit})
+	if err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
@@ -100,8 +140,13 @@ func (h *LeaveHandler) GetLeaves(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Leaves retrieved successfully", response)
 }
 
-// GetAllLeaves - Admin endpoint to fetch all leave applications
+// GetAllLeaves - Admin endpoint to fetch all leave applications in the caller's team scope
 func (h *LeaveHandler) GetAllLeaves(c *gin.Context) {
+	scope, ok := requestTeamScope(c, h.teamScope)
+	if !ok {
+		return
+	}
+
 	// Parse query parameters
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
@@ -110,28 +155,17 @@ func (h *LeaveHandler) GetAllLeaves(c *gin.Context) {
 
 	offset := (page - 1) * limit
 
-	query := h.db.Preload("LeaveType").Preload("Approver").Preload("User")
-
-	// Filter by status if provided
-	if status != "" {
-		query = query.Where("status = ?", status)
-	}
+	filter := repository.LeaveFilter{Status: status, Scope: scope}
 
 	// Filter by specific user if provided
 	if userID != "" {
 		if parsedUserID, err := uuid.Parse(userID); err == nil {
-			query = query.Where("user_id = ?", parsedUserID)
+			filter.UserID = parsedUserID
 		}
 	}
 
-	var leaves []models.LeaveApplication
-	var total int64
-
-	// Get total count
-	query.Model(&models.LeaveApplication{}).Count(&total)
-
-	// Get paginated results
-	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&leaves).Error; err != nil {
+	leaves, total, err := h.leaves.List(filter, repository.Page{Offset: offset, Limit: limit})
+	if err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
@@ -171,9 +205,9 @@ func (h *LeaveHandler) ApproveLeave(c *gin.Context) {
 	}
 
 	// Find the leave application
-	var leave models.LeaveApplication
-	if err := h.db.Preload("LeaveType").Preload("User").First(&leave, parsedLeaveID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	leave, err := h.leaves.FindByID(parsedLeaveID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			utils.ErrorResponse(c, http.StatusNotFound, "Leave application not found", "")
 			return
 		}
@@ -187,44 +221,41 @@ func (h *LeaveHandler) ApproveLeave(c *gin.Context) {
 		return
 	}
 
-	// Update the leave status
-	now := time.Now()
-	if err := h.db.Model(&leave).Updates(map[string]interface{}{
-		"status":      "approved",
-		"approved_by": adminUserID,
-		"approved_at": &now,
-	}).Error; err != nil {
-		utils.InternalErrorResponse(c, err)
+	// Leaves with an approval chain are decided step by step by its approvers
+	if len(leave.Approvals) > 0 {
+		var req ApproveLeaveRequest
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			utils.ValidationErrorResponse(c, err)
+			return
+		}
+		h.decideApprovalStep(c, leave, true, req.Note)
 		return
 	}
 
-	// Calculate days to deduct from balance
-	var daysUsed float64
-	if leave.IsHalfDay {
-		daysUsed = 0.5
-	} else {
-		days := int(leave.EndDate.Sub(leave.StartDate).Hours()/24) + 1
-		daysUsed = float64(days)
-	}
-
-	// Now deduct the balance since leave is approved
-	if err := h.leaveService.UpdateLeaveBalance(leave.UserID, leave.LeaveTypeID, leave.StartDate.Year(), daysUsed); err != nil {
-		// If balance update fails, rollback the approval
-		h.db.Model(&leave).Updates(map[string]interface{}{
-			"status":      "pending",
-			"approved_by": nil,
-			"approved_at": nil,
-		})
-		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to update leave balance", err.Error())
+	// Nobody decides their own leave, and approvers only their team's
+	scope, ok := requestTeamScope(c, h.teamScope)
+	if !ok {
+		return
+	}
+	if leave.UserID == adminUserID.(uuid.UUID) || !scope.Contains(leave.UserID) {
+		utils.ForbiddenResponse(c)
+		return
+	}
+
+	// Approve and charge the balance in one transaction
+	if err := h.leaveService.ApproveLeave(leave, adminUserID.(uuid.UUID)); err != nil {
+		leaveStatusErrorResponse(c, err)
 		return
 	}
 
 	// Reload the leave with updated data
-	if err := h.db.Preload("LeaveType").Preload("User").Preload("Approver").First(&leave, parsedLeaveID).Error; err != nil {
+	if leave, err = h.leaves.FindByID(parsedLeaveID); err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
 
+	h.notifications.NotifyLeaveReviewed(leave)
+
 	utils.SuccessResponse(c, http.StatusOK, "Leave application approved successfully", leave)
 }
 
@@ -260,9 +291,9 @@ func (h *LeaveHandler) RejectLeave(c *gin.Context) {
 	}
 
 	// Find the leave application
-	var leave models.LeaveApplication
-	if err := h.db.Preload("LeaveType").Preload("User").First(&leave, parsedLeaveID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	leave, err := h.leaves.FindByID(parsedLeaveID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			utils.ErrorResponse(c, http.StatusNotFound, "Leave application not found", "")
 			return
 		}
@@ -276,29 +307,124 @@ func (h *LeaveHandler) RejectLeave(c *gin.Context) {
 		return
 	}
 
+	// Any approver of the open step of a chain can reject the leave
+	if len(leave.Approvals) > 0 {
+		h.decideApprovalStep(c, leave, false, requestBody.RejectionReason)
+		return
+	}
+
+	// Nobody decides their own leave, and approvers only their team's
+	scope, ok := requestTeamScope(c, h.teamScope)
+	if !ok {
+		return
+	}
+	if leave.UserID == adminUserID.(uuid.UUID) || !scope.Contains(leave.UserID) {
+		utils.ForbiddenResponse(c)
+		return
+	}
+
 	// Update the leave status
-	now := time.Now()
-	if err := h.db.Model(&leave).Updates(map[string]interface{}{
-		"status":           "rejected",
-		"approved_by":      adminUserID,
-		"approved_at":      &now,
-		"rejection_reason": requestBody.RejectionReason,
-	}).Error; err != nil {
-		utils.InternalErrorResponse(c, err)
+	if err := h.leaveService.RejectLeave(leave, adminUserID.(uuid.UUID), requestBody.RejectionReason); err != nil {
+		leaveStatusErrorResponse(c, err)
 		return
 	}
 
 	// Reload the leave with updated data
-	if err := h.db.Preload("LeaveType").Preload("User").Preload("Approver").First(&leave, parsedLeaveID).Error; err != nil {
+	if leave, err = h.leaves.FindByID(parsedLeaveID); err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
 
+	h.notifications.NotifyLeaveReviewed(leave)
+
 	utils.SuccessResponse(c, http.StatusOK, "Leave application rejected successfully", leave)
 }
 
-// GetDashboardStats - Admin endpoint to get dashboard statistics
+// decideApprovalStep approves or rejects the open step of leave's approval
+// chain as the current user, who must be its approver, a delegate of its
+// approver or an admin. Approving the last step approves the leave.
+func (h *LeaveHandler) decideApprovalStep(c *gin.Context, leave *models.LeaveApplication, approve bool, note string) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	decided := true
+	var err error
+	if approve {
+		decided, err = h.approvals.Approve(leave, claims.UserID, claims.Role, note)
+	} else {
+		err = h.approvals.Reject(leave, claims.UserID, claims.Role, note)
+	}
+	if err != nil {
+		leaveStatusErrorResponse(c, err)
+		return
+	}
+
+	if leave, err = h.leaves.FindByID(leave.ID); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	if !decided {
+		utils.SuccessResponse(c, http.StatusOK, "Leave approval recorded, awaiting the next approver", leave)
+		return
+	}
+
+	h.notifications.NotifyLeaveReviewed(leave)
+
+	message := "Leave application approved successfully"
+	if !approve {
+		message = "Leave application rejected successfully"
+	}
+	utils.SuccessResponse(c, http.StatusOK, message, leave)
+}
+
+// GetApprovalQueue lists the pending leaves waiting for the current user:
+// those whose open approval step they may decide, themselves or as a
+// delegate, and those without an approval chain in their team scope.
+func (h *LeaveHandler) GetApprovalQueue(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	queue, err := h.approvals.Queue(claims.UserID, claims.Role)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	scope, ok := requestTeamScope(c, h.teamScope)
+	if !ok {
+		return
+	}
+	pending, _, err := h.leaves.List(repository.LeaveFilter{Status: models.LeaveStatusPending, Scope: scope}, repository.Page{})
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	for _, leave := range pending {
+		if len(leave.Approvals) == 0 && leave.UserID != claims.UserID {
+			queue = append(queue, leave)
+		}
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Approval queue retrieved successfully", queue)
+}
+
+// GetDashboardStats - Admin endpoint to get dashboard statistics for the caller's team scope
 func (h *LeaveHandler) GetDashboardStats(c *gin.Context) {
+	scope, ok := requestTeamScope(c, h.teamScope)
+	if !ok {
+		return
+	}
+	leaves := func() *gorm.DB {
+		return scope.Apply(h.db.Model(&models.LeaveApplication{}), "user_id")
+	}
+
 	// Get date parameter (default to today)
 	dateStr := c.DefaultQuery("date", time.Now().Format("2006-01-02"))
 
@@ -316,40 +442,40 @@ func (h *LeaveHandler) GetDashboardStats(c *gin.Context) {
 
 	// Count pending leaves (all pending, not date-specific)
 	var pendingCount int64
-	h.db.Model(&models.LeaveApplication{}).Where("status = ?", "pending").Count(&pendingCount)
+	leaves().Where("status = ?", "pending").Count(&pendingCount)
 
 	// Count approved leaves for the year
 	var approvedCount int64
-	h.db.Model(&models.LeaveApplication{}).Where("status = ? AND created_at >= ? AND created_at < ?",
-		"approved", startOfYear, endOfYear).Count(&approvedCount)
+	leaves().Where("status IN ? AND created_at >= ? AND created_at < ?",
+		models.LeaveTakenStatuses, startOfYear, endOfYear).Count(&approvedCount)
 
 	// Count employees out on the selected date (approved leaves that include the selected date)
 	var employeesOutToday int64
-	h.db.Model(&models.LeaveApplication{}).Where(
-		"status = ? AND start_date <= ? AND end_date >= ?",
-		"approved", startOfDay, startOfDay).Count(&employeesOutToday)
+	leaves().Where(
+		"status IN ? AND start_date <= ? AND end_date >= ?",
+		models.LeaveTakenStatuses, startOfDay, startOfDay).Count(&employeesOutToday)
 
 	// Calculate average days per employee for the year
-	var totalDays int64
+	var totalDays float64
 	var totalEmployees int64
 
-	// Get total approved leave days for the year (accounting for half-day leaves)
-	h.db.Model(&models.LeaveApplication{}).
-		// Select("COALESCE(SUM(EXTRACT(DAY FROM (end_date - start_date)) + 1), 0)").
-		Select("COALESCE(SUM(CASE WHEN is_half_day = true THEN 0.5 ELSE EXTRACT(DAY FROM (end_date - start_date)) + 1 END), 0)").
-		Where("status = ? AND created_at >= ? AND created_at < ?", "approved", startOfYear, endOfYear).
+	// Get total approved leave days for the year, as charged (half days,
+	// weekends and holidays accounted for)
+	leaves().
+		Select("COALESCE(SUM(paid_days + lop_days), 0)").
+		Where("status IN ? AND created_at >= ? AND created_at < ?", models.LeaveTakenStatuses, startOfYear, endOfYear).
 		Scan(&totalDays)
 
 	// Get total number of employees who took leave this year
-	h.db.Model(&models.LeaveApplication{}).
+	leaves().
 		Select("COUNT(DISTINCT user_id)").
-		Where("status = ? AND created_at >= ? AND created_at < ?", "approved", startOfYear, endOfYear).
+		Where("status IN ? AND created_at >= ? AND created_at < ?", models.LeaveTakenStatuses, startOfYear, endOfYear).
 		Scan(&totalEmployees)
 
 	// Calculate average (avoid division by zero)
 	var avgDaysPerEmployee float64
 	if totalEmployees > 0 {
-		avgDaysPerEmployee = float64(totalDays) / float64(totalEmployees)
+		avgDaysPerEmployee = totalDays / float64(totalEmployees)
 	}
 
 	// Prepare response
@@ -366,8 +492,13 @@ func (h *LeaveHandler) GetDashboardStats(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Dashboard stats retrieved successfully", stats)
 }
 
-// GetTeamLeaveBalances - Admin endpoint to get team leave balances
+// GetTeamLeaveBalances - Admin endpoint to get leave balances of the caller's team scope
 func (h *LeaveHandler) GetTeamLeaveBalances(c *gin.Context) {
+	scope, ok := requestTeamScope(c, h.teamScope)
+	if !ok {
+		return
+	}
+
 	// Get year parameter (default to current year)
 	year := time.Now().Year()
 	if yearParam := c.Query("year"); yearParam != "" {
@@ -384,13 +515,14 @@ func (h *LeaveHandler) GetTeamLeaveBalances(c *gin.Context) {
 		EmployeeID    string    `json:"employee_id"`
 		Position      string    `json:"position"`
 		LeaveTypeName string    `json:"leave_type_name"`
-		AllocatedDays int       `json:"allocated_days"`
+		AllocatedDays float64   `json:"allocated_days"`
+		CarriedDays   float64   `json:"carried_days"`
 		UsedDays      float64   `json:"used_days"`
 		RemainingDays float64   `json:"remaining_days"`
 	}
 
 	// Join leave balances with users and leave types
-	if err := h.db.Table("leave_balances lb").
+	if err := scope.Apply(h.db.Table("leave_balances lb"), "lb.user_id").
 		Select(`
             u.id as user_id,
             u.first_name,
@@ -399,12 +531,13 @@ func (h *LeaveHandler) GetTeamLeaveBalances(c *gin.Context) {
             u.position,
             lt.name as leave_type_name,
             lb.allocated_days,
+            lb.carried_days,
             lb.used_days,
-            (lb.allocated_days - lb.used_days) as remaining_days
+            (lb.allocated_days + lb.carried_days - lb.carried_out_days - lb.expired_days - lb.encashed_days - lb.used_days) as remaining_days
         `).
 		Joins("JOIN users u ON lb.user_id = u.id").
 		Joins("JOIN leave_types lt ON lb.leave_type_id = lt.id").
-		Where("lb.year = ? AND u.role = ?", year, "employee").
+		Where("lb.year = ? AND u.approval_status = ?", year, models.StatusApproved).
 		Scan(&teamBalances).Error; err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
@@ -422,7 +555,7 @@ func (h *LeaveHandler) GetTeamLeaveBalances(c *gin.Context) {
 				"employee_id":     balance.EmployeeID,
 				"position":        balance.Position,
 				"leave_types":     make(map[string]map[string]interface{}),
-				"total_allocated": 0,
+				"total_allocated": 0.0,
 				"total_used":      0.0,
 				"total_remaining": 0.0,
 			}
@@ -433,12 +566,13 @@ func (h *LeaveHandler) GetTeamLeaveBalances(c *gin.Context) {
 
 		leaveTypes[balance.LeaveTypeName] = map[string]interface{}{
 			"allocated": balance.AllocatedDays,
+			"carried":   balance.CarriedDays,
 			"used":      balance.UsedDays,
 			"remaining": balance.RemainingDays,
 		}
 
 		// Update totals
-		employee["total_allocated"] = employee["total_allocated"].(int) + balance.AllocatedDays
+		employee["total_allocated"] = employee["total_allocated"].(float64) + balance.AllocatedDays
 		employee["total_used"] = employee["total_used"].(float64) + balance.UsedDays
 		employee["total_remaining"] = employee["total_remaining"].(float64) + balance.RemainingDays
 	}
@@ -469,6 +603,15 @@ func (h *LeaveHandler) GetEmployeeLeaves(c *gin.Context) {
 		return
 	}
 
+	scope, ok := requestTeamScope(c, h.teamScope)
+	if !ok {
+		return
+	}
+	if !scope.Contains(parsedUserID) {
+		utils.ForbiddenResponse(c)
+		return
+	}
+
 	// Get query parameters
 	status := c.Query("status")
 	year := time.Now().Year()
@@ -478,22 +621,13 @@ func (h *LeaveHandler) GetEmployeeLeaves(c *gin.Context) {
 		}
 	}
 
-	// Build query
-	query := h.db.Preload("User").Preload("LeaveType").Where("user_id = ?", parsedUserID)
-
-	// Add status filter if provided
-	if status != "" {
-		query = query.Where("status = ?", status)
-	}
-
-	// Add year filter
-	query = query.Where("EXTRACT(YEAR FROM start_date) = ?", year)
-
-	// Order by created_at descending (newest first)
-	query = query.Order("created_at DESC")
-
-	var leaves []models.LeaveApplication
-	if err := query.Find(&leaves).Error; err != nil {
+	// Newest first, unpaginated
+	leaves, _, err := h.leaves.List(repository.LeaveFilter{
+		UserID: parsedUserID,
+		Status: status,
+		Year:   year,
+	}, repository.Page{})
+	if err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
@@ -535,9 +669,8 @@ func (h *LeaveHandler) CreateLeave(c *gin.Context) {
 	}
 
 	// Check if leave type exists
-	var leaveType models.LeaveType
-	if err := h.db.Where("id = ? AND is_active = true", req.LeaveTypeID).First(&leaveType).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	if _, err := h.leaves.FindActiveType(req.LeaveTypeID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid leave type", "")
 			return
 		}
@@ -545,73 +678,129 @@ func (h *LeaveHandler) CreateLeave(c *gin.Context) {
 		return
 	}
 
+	sessions, err := leaveSessions(startDate, endDate, req.IsHalfDay, req.Sessions)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid leave sessions", err.Error())
+		return
+	}
+
+	breakdown, ok := h.chargeLeave(c, userID.(uuid.UUID), req.LeaveTypeID, uuid.Nil, startDate, endDate, sessions)
+	if !ok {
+		return
+	}
+
 	// Create leave application (status: pending, no balance deduction yet)
 	leave := models.LeaveApplication{
 		UserID:      userID.(uuid.UUID),
 		LeaveTypeID: req.LeaveTypeID,
 		StartDate:   startDate,
 		EndDate:     endDate,
-		IsHalfDay:   req.IsHalfDay,
+		IsHalfDay:   len(sessions) > 0,
+		Sessions:    sessions,
+		IsLOP:       breakdown.IsLOP,
+		LOPDays:     breakdown.LOPDays,
+		PaidDays:    breakdown.PaidDays,
 		Reason:      &req.Reason,
 		Description: &req.Description,
 		Status:      "pending", // Leave is pending, balance not deducted yet
 	}
 
-	if err := h.db.Create(&leave).Error; err != nil {
+	if leave.Approvals, err = h.approvals.Plan(&leave); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	if err := h.leaveService.CreateLeave(&leave, breakdown.Days); err != nil {
+		leaveOverlapErrorResponse(c, err)
+		return
+	}
+
+	// Validate leave balance (this will always pass now since we allow LOP)
+	if err := h.leaveService.ValidateLeaveBalance(userID.(uuid.UUID), req.LeaveTypeID, startDate.Year(), breakdown.TotalDays); err != nil {
+		// If validation fails, rollback the leave application
+		h.leaves.Delete(&leave)
+		// utils.ErrorResponse(c, http.StatusBadRequest, "Insufficient leave balance", err.Error())
+		utils.ErrorResponse(c, http.StatusBadRequest, "Leave validation failed", err.Error())
+		return
+	}
+
+	// Load relationships
+	created, err := h.leaves.FindByID(leave.ID)
+	if err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
 
-	// Calculate days for LOP breakdown
-	var daysRequested float64
-	if req.IsHalfDay {
-		daysRequested = 0.5
+	created.Days = breakdown.Days
+
+	if len(created.Approvals) > 0 {
+		h.approvals.NotifyApprovers(created)
 	} else {
-		days := int(endDate.Sub(startDate).Hours()/24) + 1
-		daysRequested = float64(days)
+		h.notifications.NotifyLeaveRequested(created)
 	}
 
-	// Calculate LOP breakdown
-	paidDays, lopDays, isLOP, err := h.leaveService.CalculateLOPBreakdown(userID.(uuid.UUID), req.LeaveTypeID, startDate.Year(), daysRequested)
+	utils.SuccessResponse(c, http.StatusCreated, "Leave application created successfully", created)
+}
+
+// PreviewLeave - Shows how a leave would be charged without applying for it:
+// the per-day breakdown with weekends and holidays, and the paid/LOP split
+func (h *LeaveHandler) PreviewLeave(c *gin.Context) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	leaveTypeID, err := uuid.Parse(c.Query("leave_type_id"))
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to calculate leave breakdown", err.Error())
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid leave type ID", err.Error())
 		return
 	}
 
-	// Update leave application with LOP information
-	leave.UserID = userID.(uuid.UUID)
-	leave.LeaveTypeID = req.LeaveTypeID
-	leave.StartDate = startDate
-	leave.EndDate = endDate
-	leave.IsHalfDay = req.IsHalfDay
-	leave.IsLOP = isLOP
-	leave.LOPDays = lopDays
-	leave.PaidDays = paidDays
-	leave.Reason = &req.Reason
-	leave.Description = &req.Description
-	leave.Status = "pending"
+	startDate, err := time.Parse("2006-01-02", c.Query("start_date"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid start date format", err.Error())
+		return
+	}
 
-	if err := h.db.Create(&leave).Error; err != nil {
-		utils.InternalErrorResponse(c, err)
+	endDate, err := time.Parse("2006-01-02", c.Query("end_date"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid end date format", err.Error())
 		return
 	}
 
-	// Validate leave balance (this will always pass now since we allow LOP)
-	if err := h.leaveService.ValidateLeaveBalance(userID.(uuid.UUID), req.LeaveTypeID, startDate.Year(), daysRequested); err != nil {
-		// If validation fails, rollback the leave application
-		h.db.Delete(&leave)
-		// utils.ErrorResponse(c, http.StatusBadRequest, "Insufficient leave balance", err.Error())
-		utils.ErrorResponse(c, http.StatusBadRequest, "Leave validation failed", err.Error())
+	if endDate.Before(startDate) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "End date cannot be before start date", "")
 		return
 	}
 
-	// Load relationships
-	if err := h.db.Preload("LeaveType").Preload("User").First(&leave, leave.ID).Error; err != nil {
-		utils.InternalErrorResponse(c, err)
+	// sessions=2025-03-05:first_half,2025-03-07:second_half
+	isHalfDay, _ := strconv.ParseBool(c.DefaultQuery("is_half_day", "false"))
+	var requested []LeaveSessionRequest
+	for _, entry := range strings.Split(c.Query("sessions"), ",") {
+		if date, session, ok := strings.Cut(strings.TrimSpace(entry), ":"); ok {
+			requested = append(requested, LeaveSessionRequest{Date: date, Session: session})
+		}
+	}
+	sessions, err := leaveSessions(startDate, endDate, isHalfDay, requested)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid leave sessions", err.Error())
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusCreated, "Leave application created successfully", leave)
+	breakdown, err := h.leaveService.CalculateLOPBreakdown(userID, leaveTypeID, startDate, endDate, sessionMap(sessions))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to calculate leave breakdown", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Leave breakdown calculated successfully", gin.H{
+		"days":       breakdown.Days,
+		"total_days": breakdown.TotalDays,
+		"paid_days":  breakdown.PaidDays,
+		"lop_days":   breakdown.LOPDays,
+		"is_lop":     breakdown.IsLOP,
+	})
 }
 
 func (h *LeaveHandler) UpdateLeave(c *gin.Context) {
@@ -634,9 +823,9 @@ func (h *LeaveHandler) UpdateLeave(c *gin.Context) {
 	}
 
 	// Find leave application
-	var leave models.LeaveApplication
-	if err := h.db.Where("id = ? AND user_id = ?", leaveID, userID).First(&leave).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	leave, err := h.leaves.FindForUser(leaveID, userID.(uuid.UUID))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			utils.NotFoundResponse(c, "Leave application")
 			return
 		}
@@ -653,9 +842,9 @@ func (h *LeaveHandler) UpdateLeave(c *gin.Context) {
 	// Update fields
 	updates := make(map[string]interface{})
 
+	startDate, endDate := leave.StartDate, leave.EndDate
 	if req.StartDate != "" {
-		startDate, err := time.Parse("2006-01-02", req.StartDate)
-		if err != nil {
+		if startDate, err = time.Parse("2006-01-02", req.StartDate); err != nil {
 			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid start date format", err.Error())
 			return
 		}
@@ -663,15 +852,34 @@ func (h *LeaveHandler) UpdateLeave(c *gin.Context) {
 	}
 
 	if req.EndDate != "" {
-		endDate, err := time.Parse("2006-01-02", req.EndDate)
-		if err != nil {
+		if endDate, err = time.Parse("2006-01-02", req.EndDate); err != nil {
 			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid end date format", err.Error())
 			return
 		}
 		updates["end_date"] = endDate
 	}
 
-	updates["is_half_day"] = req.IsHalfDay
+	if endDate.Before(startDate) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "End date cannot be before start date", "")
+		return
+	}
+
+	sessions, err := leaveSessions(startDate, endDate, req.IsHalfDay, req.Sessions)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid leave sessions", err.Error())
+		return
+	}
+
+	// The days charged follow the new dates and sessions
+	breakdown, ok := h.chargeLeave(c, leave.UserID, leave.LeaveTypeID, leave.ID, startDate, endDate, sessions)
+	if !ok {
+		return
+	}
+	updates["paid_days"] = breakdown.PaidDays
+	updates["lop_days"] = breakdown.LOPDays
+	updates["is_lop"] = breakdown.IsLOP
+
+	updates["is_half_day"] = len(sessions) > 0
 	if req.Reason != "" {
 		updates["reason"] = req.Reason
 	}
@@ -679,23 +887,138 @@ func (h *LeaveHandler) UpdateLeave(c *gin.Context) {
 		updates["description"] = req.Description
 	}
 
-	if err := h.db.Model(&leave).Updates(updates).Error; err != nil {
+	// The approval chain starts over for the changed leave
+	approvals, err := h.approvals.Plan(&models.LeaveApplication{
+		UserID:      leave.UserID,
+		LeaveTypeID: leave.LeaveTypeID,
+		PaidDays:    breakdown.PaidDays,
+		LOPDays:     breakdown.LOPDays,
+	})
+	if err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
 
+	// Only while it is still pending; it may have been decided meanwhile
+	if err := h.leaveService.UpdatePendingLeave(leave, updates, breakdown.Days, sessions, approvals); err != nil {
+		if errors.Is(err, services.ErrLeaveNotPending) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Cannot update non-pending leave application", "")
+			return
+		}
+		leaveOverlapErrorResponse(c, err)
+		return
+	}
+
 	// Load updated leave with relationships
-	if err := h.db.Preload("LeaveType").Preload("User").First(&leave, leave.ID).Error; err != nil {
+	if leave, err = h.leaves.FindByID(leave.ID); err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
 
+	if len(leave.Approvals) > 0 {
+		h.approvals.NotifyApprovers(leave)
+	}
+
 	utils.SuccessResponse(c, http.StatusOK, "Leave application updated successfully", leave)
 }
 
+// chargeLeave works out the days a leave of userID from start to end with
+// sessions charges, and checks that it charges at least one working day,
+// that its half days are working days and that it does not clash with
+// another leave than excludeID. It responds and returns false otherwise.
+func (h *LeaveHandler) chargeLeave(c *gin.Context, userID, leaveTypeID, excludeID uuid.UUID, start, end time.Time, sessions []models.LeaveSession) (*services.LeaveBreakdown, bool) {
+	// Working days of the leave and their LOP breakdown; weekends and
+	// holidays are not charged
+	breakdown, err := h.leaveService.CalculateLOPBreakdown(userID, leaveTypeID, start, end, sessionMap(sessions))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to calculate leave breakdown", err.Error())
+		return nil, false
+	}
+	if breakdown.TotalDays == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Leave contains no working days", "")
+		return nil, false
+	}
+
+	halfDays := sessionMap(sessions)
+	for _, day := range breakdown.Days {
+		if _, ok := halfDays[day.Date]; ok && day.Kind != models.DayKindWorking {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid leave sessions", "half day "+day.Date+" is not a working day")
+			return nil, false
+		}
+	}
+
+	if err := h.leaveService.CheckOverlap(userID, excludeID, breakdown.Days); err != nil {
+		leaveOverlapErrorResponse(c, err)
+		return nil, false
+	}
+	return breakdown, true
+}
+
+// leaveOverlapErrorResponse answers 409 for a *services.LeaveOverlapError and
+// 500 for any other error.
+func leaveOverlapErrorResponse(c *gin.Context, err error) {
+	var overlap *services.LeaveOverlapError
+	if errors.As(err, &overlap) {
+		utils.ErrorResponse(c, http.StatusConflict, "Leave overlaps an existing leave application", err.Error())
+		return
+	}
+	utils.InternalErrorResponse(c, err)
+}
+
+// leaveSessions validates the requested sessions of a leave from start to end
+// and returns its half days. A request marked is_half_day without sessions
+// takes the first half of the start date.
+func leaveSessions(start, end time.Time, isHalfDay bool, requested []LeaveSessionRequest) ([]models.LeaveSession, error) {
+	if len(requested) == 0 && isHalfDay {
+		return []models.LeaveSession{{Date: start.Format("2006-01-02"), Session: models.SessionFirstHalf}}, nil
+	}
+
+	var sessions []models.LeaveSession
+	seen := make(map[string]bool, len(requested))
+	for _, r := range requested {
+		date, err := time.Parse("2006-01-02", r.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid session date %q", r.Date)
+		}
+		if date.Before(start) || date.After(end) {
+			return nil, fmt.Errorf("session date %s is outside the leave", r.Date)
+		}
+		if seen[r.Date] {
+			return nil, fmt.Errorf("more than one session for %s", r.Date)
+		}
+		seen[r.Date] = true
+
+		switch r.Session {
+		case models.SessionFull:
+		case models.SessionFirstHalf, models.SessionSecondHalf:
+			sessions = append(sessions, models.LeaveSession{Date: r.Date, Session: r.Session})
+		default:
+			return nil, fmt.Errorf("invalid session %q for %s, expected full, first_half or second_half", r.Session, r.Date)
+		}
+	}
+	return sessions, nil
+}
+
+func sessionMap(sessions []models.LeaveSession) map[string]string {
+	leave := models.LeaveApplication{Sessions: sessions}
+	return leave.SessionsByDate()
+}
+
+// DeleteLeave cancels a whole leave application; see CancelLeave.
 func (h *LeaveHandler) DeleteLeave(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists || userID == uuid.Nil {
+	h.cancelLeave(c, false)
+}
+
+// CancelLeave cancels the caller's leave, wholly or from cancel_from to its
+// end. Pending leaves are withdrawn or shortened at once; approved leaves get
+// a cancellation request for their approver and stay approved until then.
+func (h *LeaveHandler) CancelLeave(c *gin.Context) {
+	h.cancelLeave(c, true)
+}
+
+func (h *LeaveHandler) cancelLeave(c *gin.Context, withBody bool) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
 		utils.UnauthorizedResponse(c)
 		return
 	}
@@ -706,10 +1029,18 @@ func (h *LeaveHandler) DeleteLeave(c *gin.Context) {
 		return
 	}
 
+	var req CancelLeaveRequest
+	if withBody {
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			utils.ValidationErrorResponse(c, err)
+			return
+		}
+	}
+
 	// Find leave application
-	var leave models.LeaveApplication
-	if err := h.db.Where("id = ? AND user_id = ?", leaveID, userID).First(&leave).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	leave, err := h.leaves.FindForUser(leaveID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			utils.NotFoundResponse(c, "Leave application")
 			return
 		}
@@ -717,18 +1048,211 @@ func (h *LeaveHandler) DeleteLeave(c *gin.Context) {
 		return
 	}
 
-	// Check if leave can be deleted (only pending leaves)
-	if leave.Status != "pending" {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Cannot delete non-pending leave application", "")
+	cancelFrom := leave.StartDate
+	if req.CancelFrom != "" {
+		if cancelFrom, err = time.Parse("2006-01-02", req.CancelFrom); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid cancel from date format", err.Error())
+			return
+		}
+	}
+
+	if err := h.leaveService.CancelLeave(leave, userID, cancelFrom, req.Reason); err != nil {
+		leaveStatusErrorResponse(c, err)
+		return
+	}
+
+	if leave, err = h.leaves.FindByID(leave.ID); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	message := "Leave application cancelled successfully"
+	switch leave.Status {
+	case models.LeaveStatusPending:
+		message = "Leave application shortened successfully"
+	case models.LeaveStatusCancellationRequested:
+		message = "Leave cancellation requested, awaiting approval"
+		h.notifications.NotifyLeaveCancellationRequested(leave)
+	}
+	utils.SuccessResponse(c, http.StatusOK, message, leave)
+}
+
+// GetLeaveHistory returns the status history of one of the caller's leaves.
+func (h *LeaveHandler) GetLeaveHistory(c *gin.Context) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	leaveID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid leave ID", err.Error())
+		return
+	}
+
+	if _, err := h.leaves.FindForUser(leaveID, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.NotFoundResponse(c, "Leave application")
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	history, err := h.leaves.ListStatusChanges(leaveID)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Leave history retrieved successfully", history)
+}
+
+// ApproveCancellation - Admin endpoint to grant a cancellation request; the
+// paid days cancelled go back to the leave balance
+func (h *LeaveHandler) ApproveCancellation(c *gin.Context) {
+	approverID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	leave, ok := h.reviewableLeave(c)
+	if !ok {
+		return
+	}
+
+	if err := h.leaveService.ApproveCancellation(leave, approverID); err != nil {
+		leaveStatusErrorResponse(c, err)
 		return
 	}
 
-	if err := h.db.Delete(&leave).Error; err != nil {
+	leave, err := h.leaves.FindByID(leave.ID)
+	if err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Leave application deleted successfully", nil)
+	h.notifications.NotifyLeaveCancellationReviewed(leave, true, "")
+
+	utils.SuccessResponse(c, http.StatusOK, "Leave cancellation approved successfully", leave)
+}
+
+// RejectCancellation - Admin endpoint to turn down a cancellation request;
+// the leave stays approved
+func (h *LeaveHandler) RejectCancellation(c *gin.Context) {
+	approverID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	var req ReviewCancellationRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	leave, ok := h.reviewableLeave(c)
+	if !ok {
+		return
+	}
+
+	if err := h.leaveService.RejectCancellation(leave, approverID, req.Reason); err != nil {
+		leaveStatusErrorResponse(c, err)
+		return
+	}
+
+	leave, err := h.leaves.FindByID(leave.ID)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	h.notifications.NotifyLeaveCancellationReviewed(leave, false, req.Reason)
+
+	utils.SuccessResponse(c, http.StatusOK, "Leave cancellation rejected successfully", leave)
+}
+
+// GetLeaveConflicts - Admin endpoint listing who else in the caller's team is
+// off on the working days of a leave
+func (h *LeaveHandler) GetLeaveConflicts(c *gin.Context) {
+	leave, ok := h.reviewableLeave(c)
+	if !ok {
+		return
+	}
+	scope, ok := requestTeamScope(c, h.teamScope)
+	if !ok {
+		return
+	}
+
+	conflicts, err := h.leaveService.TeamConflicts(leave, scope)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Team conflicts retrieved successfully", conflicts)
+}
+
+// reviewableLeave loads the leave named by the id parameter, responding and
+// returning false if it does not exist or its applicant is the caller or
+// outside the caller's team scope.
+func (h *LeaveHandler) reviewableLeave(c *gin.Context) (*models.LeaveApplication, bool) {
+	leaveID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid leave ID format", err.Error())
+		return nil, false
+	}
+
+	leave, err := h.leaves.FindByID(leaveID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Leave application not found", "")
+			return nil, false
+		}
+		utils.InternalErrorResponse(c, err)
+		return nil, false
+	}
+
+	// Nobody decides their own cancellation, and reviewers only their team's
+	reviewerID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return nil, false
+	}
+	scope, ok := requestTeamScope(c, h.teamScope)
+	if !ok {
+		return nil, false
+	}
+	if leave.UserID == reviewerID || !scope.Contains(leave.UserID) {
+		utils.ForbiddenResponse(c)
+		return nil, false
+	}
+	return leave, true
+}
+
+// leaveStatusErrorResponse responds to an error from a leave status change.
+func leaveStatusErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrLeaveNotPending):
+		utils.ErrorResponse(c, http.StatusBadRequest, "Leave application is not in pending status", "")
+	case errors.Is(err, services.ErrNotApprover):
+		utils.ErrorResponse(c, http.StatusForbidden, "You are not an approver of this leave's current step", "")
+	case errors.Is(err, services.ErrLeaveNotCancellable):
+		utils.ErrorResponse(c, http.StatusBadRequest, "Only pending or approved leave applications can be cancelled", "")
+	case errors.Is(err, services.ErrNoCancellationRequest):
+		utils.ErrorResponse(c, http.StatusBadRequest, "Leave application has no pending cancellation request", "")
+	case errors.Is(err, services.ErrInvalidCancelFrom):
+		utils.ErrorResponse(c, http.StatusBadRequest, "Cancellation must start on a day of the leave", "")
+	case errors.Is(err, repository.ErrConflict):
+		utils.ErrorResponse(c, http.StatusConflict, "Leave balance is being updated, please retry", "")
+	case errors.Is(err, repository.ErrNotFound):
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to update leave balance", err.Error())
+	default:
+		utils.InternalErrorResponse(c, err)
+	}
 }
 
 func (h *LeaveHandler) GetLeaveBalance(c *gin.Context) {
@@ -740,8 +1264,8 @@ func (h *LeaveHandler) GetLeaveBalance(c *gin.Context) {
 
 	year, _ := strconv.Atoi(c.DefaultQuery("year", strconv.Itoa(time.Now().Year())))
 
-	var balances []models.LeaveBalance
-	if err := h.db.Preload("LeaveType").Where("user_id = ? AND year = ?", userID, year).Find(&balances).Error; err != nil {
+	balances, err := h.balances.ListForUser(userID.(uuid.UUID), year)
+	if err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
@@ -749,9 +1273,29 @@ func (h *LeaveHandler) GetLeaveBalance(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Leave balance retrieved successfully", balances)
 }
 
+// GetBalanceStatement returns the current user's balances for a year with
+// the ledger entries that make them up and the balance after each.
+func (h *LeaveHandler) GetBalanceStatement(c *gin.Context) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	year, _ := strconv.Atoi(c.DefaultQuery("year", strconv.Itoa(time.Now().Year())))
+
+	statements, err := h.leaveService.BalanceStatement(userID, year)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Leave balance statement retrieved successfully", statements)
+}
+
 func (h *LeaveHandler) GetLeaveTypes(c *gin.Context) {
-	var leaveTypes []models.LeaveType
-	if err := h.db.Where("is_active = true").Find(&leaveTypes).Error; err != nil {
+	leaveTypes, err := h.leaves.ListActiveTypes()
+	if err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
@@ -2,11 +2,17 @@ package handlers
 
 import (
 	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/repository"
 	"employee-dashboard-api/internal/services"
 	"employee-dashboard-api/internal/utils"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
@@ -15,19 +21,34 @@ type LeaveAllocationHandler struct {
 	db           *gorm.DB
 	config       *config.Config
 	logger       *logrus.Logger
+	leaves       repository.LeaveRepository
+	users        repository.UserRepository
+	policies     repository.LeavePolicyRepository
 	leaveService *services.LeaveService
 }
 
-func NewLeaveAllocationHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger) *LeaveAllocationHandler {
-	leaveService := services.NewLeaveService(db, logger)
+func NewLeaveAllocationHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger, repos *repository.Repositories) *LeaveAllocationHandler {
+	leaveService := services.NewLeaveService(repos, services.NewWorkingCalendarService(db, cfg, logger), logger)
 	return &LeaveAllocationHandler{
 		db:           db,
 		config:       cfg,
 		logger:       logger,
+		leaves:       repos.Leaves,
+		users:        repos.Users,
+		policies:     repos.Policies,
 		leaveService: leaveService,
 	}
 }
 
+// BalanceAdjustmentRequest corrects a balance by Days, which may be negative.
+type BalanceAdjustmentRequest struct {
+	UserID      uuid.UUID `json:"user_id" binding:"required"`
+	LeaveTypeID uuid.UUID `json:"leave_type_id" binding:"required"`
+	Year        int       `json:"year" binding:"required,gte=2000,lte=2100"`
+	Days        float64   `json:"days" binding:"required,gte=-366,lte=366"`
+	Reason      string    `json:"reason" binding:"required,max=500"`
+}
+
 func (h *LeaveAllocationHandler) LoadLeaveAllocations(c *gin.Context) {
 	if err := h.leaveService.LoadLeaveAllocationsFromCSV("leave_allocations.csv"); err != nil {
 		utils.InternalErrorResponse(c, err)
@@ -46,15 +67,94 @@ func (h *LeaveAllocationHandler) InitializeLeaveAllocations(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Leave allocations initialized successfully", nil)
 }
 
+// GetMonthlyAllocation describes how the active leave policies credit
+// balances over the year.
 func (h *LeaveAllocationHandler) GetMonthlyAllocation(c *gin.Context) {
-	monthlyAllocation := h.leaveService.GetMonthlyLeaveAllocation()
-	annualAllocation := h.leaveService.CalculateAnnualAllocation()
+	policies, err := h.policies.ListActive()
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	allocations := make([]gin.H, 0, len(policies))
+	for _, policy := range policies {
+		allocations = append(allocations, gin.H{
+			"leave_type_id":      policy.LeaveTypeID,
+			"leave_type_name":    policy.LeaveType.Name,
+			"accrual_frequency":  policy.AccrualFrequency,
+			"days_per_period":    policy.AnnualDays * float64(policy.PeriodMonths()) / 12,
+			"monthly_allocation": policy.AnnualDays / 12,
+			"annual_allocation":  policy.AnnualDays,
+		})
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Leave allocation info retrieved", allocations)
+}
+
+// AdjustBalance applies a manual correction to an employee's leave balance.
+// The change is recorded in the ledger with the admin and the reason.
+func (h *LeaveAllocationHandler) AdjustBalance(c *gin.Context) {
+	actorID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	var req BalanceAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "A reason is required", "")
+		return
+	}
+
+	if _, err := h.users.FindByID(req.UserID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user", "")
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	if _, err := h.leaves.FindActiveType(req.LeaveTypeID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid leave type", "")
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
 
-	response := gin.H{
-		"monthly_allocation": monthlyAllocation,
-		"annual_allocation":  annualAllocation,
-		"description":        "Each employee gets 2 leaves per month (24 per year)",
+	balance, err := h.leaveService.AdjustBalance(req.UserID, req.LeaveTypeID, req.Year, req.Days, actorID, reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNegativeBalance):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Adjustment would leave a negative balance", "")
+		case errors.Is(err, repository.ErrConflict):
+			utils.ErrorResponse(c, http.StatusConflict, "Leave balance changed concurrently, please retry", "")
+		default:
+			utils.InternalErrorResponse(c, err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Leave balance adjusted successfully", balance)
+}
+
+// ReconcileBalances lists the balances of a year whose available days do not
+// match the sum of their ledger entries. An empty list means the ledger and
+// the balances agree.
+func (h *LeaveAllocationHandler) ReconcileBalances(c *gin.Context) {
+	year, _ := strconv.Atoi(c.DefaultQuery("year", strconv.Itoa(time.Now().Year())))
+
+	mismatches, err := h.leaveService.Reconcile(year)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Leave allocation info retrieved", response)
+	utils.SuccessResponse(c, http.StatusOK, "Leave balances reconciled", mismatches)
 }
@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
+	"employee-dashboard-api/internal/services"
+	"employee-dashboard-api/internal/utils"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type LeaveApprovalHandler struct {
+	db              *gorm.DB
+	config          *config.Config
+	logger          *logrus.Logger
+	leaves          repository.LeaveRepository
+	users           repository.UserRepository
+	approvals       repository.LeaveApprovalRepository
+	approvalService *services.LeaveApprovalService
+}
+
+func NewLeaveApprovalHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger, repos *repository.Repositories, approvalService *services.LeaveApprovalService) *LeaveApprovalHandler {
+	return &LeaveApprovalHandler{
+		db:              db,
+		config:          cfg,
+		logger:          logger,
+		leaves:          repos.Leaves,
+		users:           repos.Users,
+		approvals:       repos.Approvals,
+		approvalService: approvalService,
+	}
+}
+
+// LeaveApprovalChainRequest creates or replaces an approval chain; see
+// models.LeaveApprovalChain. Steps are decided in the order given.
+type LeaveApprovalChainRequest struct {
+	Name        string                     `json:"name" binding:"required,max=100"`
+	LeaveTypeID *uuid.UUID                 `json:"leave_type_id"`
+	Department  *string                    `json:"department"`
+	MinDays     float64                    `json:"min_days" binding:"gte=0"`
+	IsActive    *bool                      `json:"is_active"` // defaults to true
+	Steps       []LeaveApprovalStepRequest `json:"steps" binding:"required,min=1,max=10,dive"`
+}
+
+// LeaveApprovalStepRequest is one step of a chain. Role steps need
+// approver_role and user steps approver_user_id.
+type LeaveApprovalStepRequest struct {
+	Approver       string     `json:"approver" binding:"required,oneof=manager skip_manager role user"`
+	ApproverRole   string     `json:"approver_role"`
+	ApproverUserID *uuid.UUID `json:"approver_user_id"`
+	SLAHours       int        `json:"sla_hours" binding:"gte=0,lte=720"`
+}
+
+// RunEscalationRequest escalates the approval steps past their SLA as of a
+// time (RFC 3339, default now).
+type RunEscalationRequest struct {
+	AsOf string `json:"as_of"`
+}
+
+// ApprovalDelegationRequest hands the current user's leave approvals to
+// DelegateID from StartDate to EndDate (YYYY-MM-DD, inclusive).
+type ApprovalDelegationRequest struct {
+	DelegateID uuid.UUID `json:"delegate_id" binding:"required"`
+	StartDate  string    `json:"start_date" binding:"required"`
+	EndDate    string    `json:"end_date" binding:"required"`
+	Reason     string    `json:"reason" binding:"max=500"`
+}
+
+// GetChains lists every approval chain with its steps.
+func (h *LeaveApprovalHandler) GetChains(c *gin.Context) {
+	chains, err := h.approvals.ListChains()
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Leave approval chains retrieved successfully", chains)
+}
+
+// CreateChain adds an approval chain. It applies to leaves applied for from
+// now on.
+func (h *LeaveApprovalHandler) CreateChain(c *gin.Context) {
+	chain := &models.LeaveApprovalChain{}
+	if !h.bindChain(c, chain) {
+		return
+	}
+
+	if err := h.approvals.SaveChain(chain); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	h.respondChain(c, http.StatusCreated, "Leave approval chain created successfully", chain.ID)
+}
+
+// UpdateChain replaces an approval chain. Leaves already applied for keep
+// the steps they were given.
+func (h *LeaveApprovalHandler) UpdateChain(c *gin.Context) {
+	chain, ok := h.findChain(c)
+	if !ok {
+		return
+	}
+	if !h.bindChain(c, chain) {
+		return
+	}
+
+	if err := h.approvals.SaveChain(chain); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	h.respondChain(c, http.StatusOK, "Leave approval chain updated successfully", chain.ID)
+}
+
+// DeleteChain removes an approval chain. Leaves already applied for keep
+// the steps they were given.
+func (h *LeaveApprovalHandler) DeleteChain(c *gin.Context) {
+	chain, ok := h.findChain(c)
+	if !ok {
+		return
+	}
+
+	if err := h.approvals.DeleteChain(chain); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Leave approval chain deleted successfully", nil)
+}
+
+// RunEscalation escalates the approval steps past their SLA now instead of
+// waiting for the scheduled job.
+func (h *LeaveApprovalHandler) RunEscalation(c *gin.Context) {
+	var req RunEscalationRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	asOf := time.Now()
+	if req.AsOf != "" {
+		parsed, err := time.Parse(time.RFC3339, req.AsOf)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid as_of time format", err.Error())
+			return
+		}
+		asOf = parsed
+	}
+
+	escalated, err := h.approvalService.Escalate(asOf)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Leave approval escalation completed", gin.H{"escalated": escalated})
+}
+
+// GetDelegations lists the delegations the current user gave or received.
+func (h *LeaveApprovalHandler) GetDelegations(c *gin.Context) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	delegations, err := h.approvals.ListDelegations(userID)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Approval delegations retrieved successfully", delegations)
+}
+
+// CreateDelegation hands the current user's leave approvals to another
+// approver for a date range, e.g. while they are out of office. They can
+// still decide the approvals themselves.
+func (h *LeaveApprovalHandler) CreateDelegation(c *gin.Context) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	var req ApprovalDelegationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid start date format", err.Error())
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid end date format", err.Error())
+		return
+	}
+	if endDate.Before(startDate) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "End date cannot be before start date", "")
+		return
+	}
+	if req.DelegateID == userID {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Approvals cannot be delegated to yourself", "")
+		return
+	}
+
+	delegate, err := h.users.FindByID(req.DelegateID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid delegate", "")
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	if delegate.Status != "active" || !delegate.Role.HasPermission(models.PermLeaveApprove) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "The delegate must be an active user who can approve leaves", "")
+		return
+	}
+
+	delegation := models.ApprovalDelegation{
+		DelegatorID: userID,
+		DelegateID:  req.DelegateID,
+		StartDate:   startDate,
+		EndDate:     endDate,
+	}
+	if reason := strings.TrimSpace(req.Reason); reason != "" {
+		delegation.Reason = &reason
+	}
+	if err := h.approvals.CreateDelegation(&delegation); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Approval delegation created successfully", delegation)
+}
+
+// RevokeDelegation ends a delegation early. Only the delegator or an admin
+// can revoke it.
+func (h *LeaveApprovalHandler) RevokeDelegation(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	delegationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid delegation ID", err.Error())
+		return
+	}
+
+	delegation, err := h.approvals.FindDelegation(delegationID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.NotFoundResponse(c, "Approval delegation")
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	if delegation.DelegatorID != claims.UserID && claims.Role != models.RoleAdmin {
+		utils.ForbiddenResponse(c)
+		return
+	}
+	if delegation.RevokedAt != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Approval delegation is already revoked", "")
+		return
+	}
+
+	if err := h.approvals.RevokeDelegation(delegation); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Approval delegation revoked successfully", delegation)
+}
+
+// findChain loads the chain named by the id parameter, responding and
+// returning false if there is none.
+func (h *LeaveApprovalHandler) findChain(c *gin.Context) (*models.LeaveApprovalChain, bool) {
+	chainID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid approval chain ID", err.Error())
+		return nil, false
+	}
+
+	chain, err := h.approvals.FindChain(chainID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.NotFoundResponse(c, "Leave approval chain")
+			return nil, false
+		}
+		utils.InternalErrorResponse(c, err)
+		return nil, false
+	}
+	return chain, true
+}
+
+// bindChain validates the request body and copies it onto chain, responding
+// and returning false if it is invalid.
+func (h *LeaveApprovalHandler) bindChain(c *gin.Context, chain *models.LeaveApprovalChain) bool {
+	var req LeaveApprovalChainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return false
+	}
+
+	if req.LeaveTypeID != nil {
+		if _, err := h.leaves.FindActiveType(*req.LeaveTypeID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				utils.ErrorResponse(c, http.StatusBadRequest, "Invalid leave type", "")
+				return false
+			}
+			utils.InternalErrorResponse(c, err)
+			return false
+		}
+	}
+
+	steps := make([]models.LeaveApprovalStep, 0, len(req.Steps))
+	for i, stepReq := range req.Steps {
+		step := models.LeaveApprovalStep{Position: i + 1, Approver: stepReq.Approver, SLAHours: stepReq.SLAHours}
+		switch stepReq.Approver {
+		case models.ApproverRole:
+			role := models.UserRole(stepReq.ApproverRole)
+			if !role.IsValid() || !role.HasPermission(models.PermLeaveApprove) {
+				utils.ErrorResponse(c, http.StatusBadRequest, "Role steps need an approver_role that can approve leaves", "")
+				return false
+			}
+			step.ApproverRole = &role
+		case models.ApproverUser:
+			if stepReq.ApproverUserID == nil {
+				utils.ErrorResponse(c, http.StatusBadRequest, "User steps need an approver_user_id", "")
+				return false
+			}
+			approver, err := h.users.FindByID(*stepReq.ApproverUserID)
+			if err != nil {
+				if errors.Is(err, repository.ErrNotFound) {
+					utils.ErrorResponse(c, http.StatusBadRequest, "Invalid approver user", "")
+					return false
+				}
+				utils.InternalErrorResponse(c, err)
+				return false
+			}
+			if !approver.Role.HasPermission(models.PermLeaveApprove) {
+				utils.ErrorResponse(c, http.StatusBadRequest, "The approver user cannot approve leaves", "")
+				return false
+			}
+			step.ApproverUserID = stepReq.ApproverUserID
+		}
+		steps = append(steps, step)
+	}
+
+	chain.Name = strings.TrimSpace(req.Name)
+	chain.LeaveTypeID = req.LeaveTypeID
+	chain.Department = nil
+	if req.Department != nil && strings.TrimSpace(*req.Department) != "" {
+		department := strings.TrimSpace(*req.Department)
+		chain.Department = &department
+	}
+	chain.MinDays = req.MinDays
+	chain.IsActive = req.IsActive == nil || *req.IsActive
+	chain.Steps = steps
+	chain.LeaveType = nil
+	return true
+}
+
+// respondChain reloads the chain with its steps and sends it.
+func (h *LeaveApprovalHandler) respondChain(c *gin.Context, status int, message string, chainID uuid.UUID) {
+	chain, err := h.approvals.FindChain(chainID)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, status, message, chain)
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
+	"employee-dashboard-api/internal/services"
+	"employee-dashboard-api/internal/utils"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type LeavePolicyHandler struct {
+	db            *gorm.DB
+	config        *config.Config
+	logger        *logrus.Logger
+	leaves        repository.LeaveRepository
+	policies      repository.LeavePolicyRepository
+	policyService *services.LeavePolicyService
+}
+
+func NewLeavePolicyHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger, repos *repository.Repositories, policyService *services.LeavePolicyService) *LeavePolicyHandler {
+	return &LeavePolicyHandler{
+		db:            db,
+		config:        cfg,
+		logger:        logger,
+		leaves:        repos.Leaves,
+		policies:      repos.Policies,
+		policyService: policyService,
+	}
+}
+
+// LeavePolicyRequest sets the policy of a leave type; see models.LeavePolicy.
+type LeavePolicyRequest struct {
+	AnnualDays               float64  `json:"annual_days" binding:"gte=0,lte=366"`
+	AccrualFrequency         string   `json:"accrual_frequency" binding:"required,oneof=yearly quarterly monthly"`
+	ProrateByHireDate        *bool    `json:"prorate_by_hire_date"` // defaults to true
+	MaxBalance               *float64 `json:"max_balance" binding:"omitempty,gte=0"`
+	CarryForwardLimit        float64  `json:"carry_forward_limit" binding:"gte=0"`
+	CarryForwardExpiryMonths int      `json:"carry_forward_expiry_months" binding:"gte=0,lte=12"`
+	EncashmentEnabled        bool     `json:"encashment_enabled"`
+	MaxEncashDays            float64  `json:"max_encash_days" binding:"gte=0"`
+	IsActive                 *bool    `json:"is_active"` // defaults to true
+}
+
+// RunLeavePoliciesRequest runs the leave policy job as of a date (YYYY-MM-DD,
+// default today).
+type RunLeavePoliciesRequest struct {
+	AsOf string `json:"as_of"`
+}
+
+// GetPolicies lists the policies of every leave type that has one.
+func (h *LeavePolicyHandler) GetPolicies(c *gin.Context) {
+	policies, err := h.policies.List()
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Leave policies retrieved successfully", policies)
+}
+
+// SavePolicy creates or replaces the policy of a leave type. The change
+// applies from the next run of the policy job.
+func (h *LeavePolicyHandler) SavePolicy(c *gin.Context) {
+	leaveTypeID, err := uuid.Parse(c.Param("leaveTypeId"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid leave type ID", err.Error())
+		return
+	}
+
+	var req LeavePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	if req.EncashmentEnabled && req.MaxEncashDays == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "max_encash_days is required when encashment is enabled", "")
+		return
+	}
+
+	if _, err := h.leaves.FindActiveType(leaveTypeID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid leave type", "")
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	policy, err := h.policies.FindByLeaveType(leaveTypeID)
+	if errors.Is(err, repository.ErrNotFound) {
+		policy = &models.LeavePolicy{LeaveTypeID: leaveTypeID}
+	} else if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	policy.AnnualDays = req.AnnualDays
+	policy.AccrualFrequency = req.AccrualFrequency
+	policy.ProrateByHireDate = req.ProrateByHireDate == nil || *req.ProrateByHireDate
+	policy.MaxBalance = req.MaxBalance
+	policy.CarryForwardLimit = req.CarryForwardLimit
+	policy.CarryForwardExpiryMonths = req.CarryForwardExpiryMonths
+	policy.EncashmentEnabled = req.EncashmentEnabled
+	policy.MaxEncashDays = req.MaxEncashDays
+	policy.IsActive = req.IsActive == nil || *req.IsActive
+
+	if err := h.policies.Save(policy); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	if policy, err = h.policies.FindByLeaveType(leaveTypeID); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Leave policy saved successfully", policy)
+}
+
+// RunPolicies runs the leave policy job now. It is safe to repeat: periods
+// already accrued and years already rolled over are skipped.
+func (h *LeavePolicyHandler) RunPolicies(c *gin.Context) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	var req RunLeavePoliciesRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	asOf := time.Now()
+	if req.AsOf != "" {
+		date, err := time.Parse("2006-01-02", req.AsOf)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid as_of date format", err.Error())
+			return
+		}
+		asOf = date
+	}
+
+	run, err := h.policyService.Run(asOf, &userID)
+	if err != nil {
+		if run == nil {
+			utils.InternalErrorResponse(c, err)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Leave policy run failed", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Leave policy run completed", run)
+}
+
+// GetPolicyRuns lists runs of the leave policy job, newest first.
+func (h *LeavePolicyHandler) GetPolicyRuns(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	runs, total, err := h.policies.ListRuns(repository.Page{Offset: (page - 1) * limit, Limit: limit})
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Leave policy runs retrieved successfully", gin.H{
+		"runs": runs,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/services"
+	"employee-dashboard-api/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type NotificationHandler struct {
+	db            *gorm.DB
+	config        *config.Config
+	logger        *logrus.Logger
+	notifications *services.NotificationService
+}
+
+func NewNotificationHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger, notifications *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		db:            db,
+		config:        cfg,
+		logger:        logger,
+		notifications: notifications,
+	}
+}
+
+// @Summary List notifications
+// @Description Retrieve the authenticated user's notifications, newest first.
+// @Tags Notifications
+// @Security ApiKeyAuth
+// @Produce json
+// @Param unread query bool false "Only unread notifications"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(20)
+// @Success 200 {object} object{notifications=[]models.Notification,pagination=object{page=int,limit=int,total=int}} "Notifications retrieved successfully"
+// @Router /notifications [get]
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	unreadOnly, _ := strconv.ParseBool(c.DefaultQuery("unread", "false"))
+
+	notifications, total, err := h.notifications.ListNotifications(userID, unreadOnly, page, limit)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Notifications retrieved successfully", gin.H{
+		"notifications": notifications,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// @Summary Get unread notification count
+// @Tags Notifications
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} object{unread_count=int} "Unread count retrieved successfully"
+// @Router /notifications/unread-count [get]
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	count, err := h.notifications.UnreadCount(userID)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Unread count retrieved successfully", gin.H{
+		"unread_count": count,
+	})
+}
+
+// @Summary Mark a notification as read
+// @Tags Notifications
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "Notification ID"
+// @Success 200 {object} models.Notification "Notification marked as read"
+// @Failure 404 {object} utils.APIResponse "Notification not found"
+// @Router /notifications/{id}/read [put]
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	notificationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid notification ID", err.Error())
+		return
+	}
+
+	notification, err := h.notifications.MarkRead(userID, notificationID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Notification")
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Notification marked as read", notification)
+}
+
+// @Summary Mark all notifications as read
+// @Tags Notifications
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} object{updated=int} "All notifications marked as read"
+// @Router /notifications/read-all [put]
+func (h *NotificationHandler) MarkAllNotificationsRead(c *gin.Context) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	updated, err := h.notifications.MarkAllRead(userID)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "All notifications marked as read", gin.H{
+		"updated": updated,
+	})
+}
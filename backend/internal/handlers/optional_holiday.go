@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
+	"employee-dashboard-api/internal/services"
+	"employee-dashboard-api/internal/utils"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type OptionalHolidayHandler struct {
+	db                     *gorm.DB
+	config                 *config.Config
+	logger                 *logrus.Logger
+	location               *time.Location
+	users                  repository.UserRepository
+	selections             repository.OptionalHolidayRepository
+	teamScope              *services.TeamScopeService
+	optionalHolidayService *services.OptionalHolidayService
+	notifications          *services.NotificationService
+}
+
+func NewOptionalHolidayHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger, location *time.Location, repos *repository.Repositories, optionalHolidayService *services.OptionalHolidayService, notifications *services.NotificationService) *OptionalHolidayHandler {
+	return &OptionalHolidayHandler{
+		db:                     db,
+		config:                 cfg,
+		logger:                 logger,
+		location:               location,
+		users:                  repos.Users,
+		selections:             repos.OptionalHolidays,
+		teamScope:              services.NewTeamScopeService(db, logger),
+		optionalHolidayService: optionalHolidayService,
+		notifications:          notifications,
+	}
+}
+
+// SelectOptionalHolidayRequest picks an optional holiday.
+type SelectOptionalHolidayRequest struct {
+	HolidayID string `json:"holiday_id" binding:"required,uuid"`
+	Note      string `json:"note" binding:"max=500"`
+}
+
+// ReviewOptionalHolidayRequest carries the reason a selection is rejected.
+type ReviewOptionalHolidayRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// OptionalHolidayQuotaRequest sets how many optional holidays each employee
+// may pick in a year.
+type OptionalHolidayQuotaRequest struct {
+	Quota *int `json:"quota" binding:"required,min=0,max=366"`
+}
+
+// GetOptionalHolidays lists the optional holidays of ?year= (default this
+// year) at the current user's work location, with their selections and what
+// is left of the quota.
+func (h *OptionalHolidayHandler) GetOptionalHolidays(c *gin.Context) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+	year, ok := h.queryYear(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.users.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.NotFoundResponse(c, "User")
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	summary, err := h.optionalHolidayService.Summary(user, year)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Optional holidays retrieved successfully", summary)
+}
+
+// queryYear parses the ?year= query parameter, defaulting to this year. On
+// false an error response has already been written.
+func (h *OptionalHolidayHandler) queryYear(c *gin.Context) (int, bool) {
+	value := c.Query("year")
+	if value == "" {
+		return time.Now().In(h.location).Year(), true
+	}
+	year, err := strconv.Atoi(value)
+	if err != nil || year < 1 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid year", value)
+		return 0, false
+	}
+	return year, true
+}
+
+// SelectOptionalHoliday picks an optional holiday for the current user
+// within the quota of its year; the selection then waits for the manager's
+// approval.
+func (h *OptionalHolidayHandler) SelectOptionalHoliday(c *gin.Context) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	var req SelectOptionalHolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	selection, err := h.optionalHolidayService.Select(userID, uuid.MustParse(req.HolidayID), strings.TrimSpace(req.Note))
+	if err != nil {
+		optionalHolidayErrorResponse(c, err)
+		return
+	}
+
+	if selection, err = h.selections.FindByID(selection.ID); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	h.notifications.NotifyOptionalHolidaySelected(selection)
+
+	utils.SuccessResponse(c, http.StatusCreated, "Optional holiday selected successfully", selection)
+}
+
+// CancelSelection withdraws one of the current user's pending or approved
+// selections, freeing its place in the quota.
+func (h *OptionalHolidayHandler) CancelSelection(c *gin.Context) {
+	userID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	selection, ok := h.findSelection(c)
+	if !ok {
+		return
+	}
+	if selection.UserID != userID {
+		utils.NotFoundResponse(c, "Optional holiday selection")
+		return
+	}
+
+	if err := h.optionalHolidayService.Cancel(selection); err != nil {
+		optionalHolidayErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Optional holiday selection cancelled successfully", nil)
+}
+
+// GetTeamSelections lists the optional holiday selections in the reviewer's
+// team scope (?status=pending for the ones waiting for review, ?year= for
+// the holidays of a year).
+func (h *OptionalHolidayHandler) GetTeamSelections(c *gin.Context) {
+	scope, ok := requestTeamScope(c, h.teamScope)
+	if !ok {
+		return
+	}
+	filter := repository.OptionalHolidayFilter{Scope: scope, Status: c.Query("status")}
+	if c.Query("year") != "" {
+		if filter.Year, ok = h.queryYear(c); !ok {
+			return
+		}
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	selections, total, err := h.selections.List(filter, repository.Page{Offset: offset, Limit: limit})
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Optional holiday selections retrieved successfully", gin.H{
+		"selections": selections,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// ApproveSelection approves a pending selection in the reviewer's team
+// scope; the holiday becomes a day off for the employee.
+func (h *OptionalHolidayHandler) ApproveSelection(c *gin.Context) {
+	h.reviewSelection(c, true)
+}
+
+// RejectSelection turns down a pending selection in the reviewer's team
+// scope with a reason.
+func (h *OptionalHolidayHandler) RejectSelection(c *gin.Context) {
+	h.reviewSelection(c, false)
+}
+
+func (h *OptionalHolidayHandler) reviewSelection(c *gin.Context, approve bool) {
+	reviewerID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	var req ReviewOptionalHolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if !approve && reason == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Reason is required", "")
+		return
+	}
+
+	selection, ok := h.findSelection(c)
+	if !ok {
+		return
+	}
+
+	// Nobody reviews their own selections, and reviewers only their team's
+	scope, ok := requestTeamScope(c, h.teamScope)
+	if !ok {
+		return
+	}
+	if selection.UserID == reviewerID || !scope.Contains(selection.UserID) {
+		utils.ForbiddenResponse(c)
+		return
+	}
+
+	var err error
+	if approve {
+		err = h.optionalHolidayService.Approve(selection, reviewerID)
+	} else {
+		err = h.optionalHolidayService.Reject(selection, reviewerID, reason)
+	}
+	if err != nil {
+		optionalHolidayErrorResponse(c, err)
+		return
+	}
+
+	if selection, err = h.selections.FindByID(selection.ID); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	h.notifications.NotifyOptionalHolidayReviewed(selection)
+
+	message := "Optional holiday selection rejected successfully"
+	if approve {
+		message = "Optional holiday selection approved successfully"
+	}
+	utils.SuccessResponse(c, http.StatusOK, message, selection)
+}
+
+// findSelection loads the selection named by the :id path parameter. On
+// false an error response has already been written.
+func (h *OptionalHolidayHandler) findSelection(c *gin.Context) (*models.OptionalHolidaySelection, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid optional holiday selection ID", err.Error())
+		return nil, false
+	}
+
+	selection, err := h.selections.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.NotFoundResponse(c, "Optional holiday selection")
+			return nil, false
+		}
+		utils.InternalErrorResponse(c, err)
+		return nil, false
+	}
+	return selection, true
+}
+
+// GetQuotas lists the optional holiday quotas set per year, with the quota
+// of the years that have none.
+func (h *OptionalHolidayHandler) GetQuotas(c *gin.Context) {
+	quotas, err := h.selections.ListQuotas()
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Optional holiday quotas retrieved successfully", gin.H{
+		"default_quota": h.optionalHolidayService.DefaultQuota(),
+		"quotas":        quotas,
+	})
+}
+
+// SetQuota sets how many optional holidays each employee may pick in the
+// :year. Selections already made are kept.
+func (h *OptionalHolidayHandler) SetQuota(c *gin.Context) {
+	actorID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	year, err := strconv.Atoi(c.Param("year"))
+	if err != nil || year < 1 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid year", c.Param("year"))
+		return
+	}
+
+	var req OptionalHolidayQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	quota, err := h.optionalHolidayService.SetQuota(year, *req.Quota, actorID)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Optional holiday quota saved successfully", quota)
+}
+
+func optionalHolidayErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrNotOptionalHoliday):
+		utils.ErrorResponse(c, http.StatusBadRequest, "Not an optional holiday at your work location", "")
+	case errors.Is(err, services.ErrOptionalHolidayPassed):
+		utils.ErrorResponse(c, http.StatusBadRequest, "The optional holiday has already passed", "")
+	case errors.Is(err, services.ErrOptionalHolidaysOff):
+		utils.ErrorResponse(c, http.StatusConflict, "Optional holidays are already days off for everyone", "")
+	case errors.Is(err, services.ErrOptionalHolidaySelected):
+		utils.ErrorResponse(c, http.StatusConflict, "The optional holiday has already been selected", "")
+	case errors.Is(err, services.ErrOptionalHolidayQuotaReached):
+		utils.ErrorResponse(c, http.StatusConflict, "The optional holiday quota for the year has been used up", "Cancel another selection first")
+	case errors.Is(err, services.ErrOptionalHolidayOnLeave):
+		utils.ErrorResponse(c, http.StatusConflict, "There is leave on the optional holiday", "Cancel the leave for the day first")
+	case errors.Is(err, services.ErrOptionalHolidayNotPending):
+		utils.ErrorResponse(c, http.StatusConflict, "Optional holiday selection is not in pending status", "")
+	case errors.Is(err, services.ErrOptionalHolidayClosed):
+		utils.ErrorResponse(c, http.StatusConflict, "Optional holiday selection has already been rejected or cancelled", "")
+	default:
+		utils.InternalErrorResponse(c, err)
+	}
+}
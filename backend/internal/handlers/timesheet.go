@@ -3,6 +3,8 @@ package handlers
 import (
 	"employee-dashboard-api/internal/config"
 	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
+	"employee-dashboard-api/internal/services"
 	"employee-dashboard-api/internal/utils"
 	"errors"
 	"fmt"
@@ -19,21 +21,47 @@ import (
 )
 
 type TimesheetHandler struct {
-	db       *gorm.DB
-	config   *config.Config
-	logger   *logrus.Logger
-	location *time.Location
+	db            *gorm.DB
+	config        *config.Config
+	logger        *logrus.Logger
+	location      *time.Location
+	timesheets    repository.TimesheetRepository
+	teamScope     *services.TeamScopeService
+	leaveService  *services.LeaveService
+	notifications *services.NotificationService
 }
 
-func NewTimesheetHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger, location *time.Location) *TimesheetHandler {
+func NewTimesheetHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger, location *time.Location, repos *repository.Repositories, notifications *services.NotificationService) *TimesheetHandler {
 	return &TimesheetHandler{
-		db:       db,
-		config:   cfg,
-		logger:   logger,
-		location: location,
+		db:            db,
+		config:        cfg,
+		logger:        logger,
+		location:      location,
+		timesheets:    repos.Timesheets,
+		teamScope:     services.NewTeamScopeService(db, logger),
+		leaveService:  services.NewLeaveService(repos, services.NewWorkingCalendarService(db, cfg, logger), logger),
+		notifications: notifications,
 	}
 }
 
+// canViewUser reports whether the authenticated user may see targetUserID's
+// timesheets: their own, or anyone in their team scope. On false an error
+// response has already been written.
+func (h *TimesheetHandler) canViewUser(c *gin.Context, currentUserID, targetUserID uuid.UUID) bool {
+	if currentUserID == targetUserID {
+		return true
+	}
+	scope, ok := requestTeamScope(c, h.teamScope)
+	if !ok {
+		return false
+	}
+	if !scope.Contains(targetUserID) {
+		utils.ForbiddenResponse(c)
+		return false
+	}
+	return true
+}
+
 // ▶️ NEW: parseAndValidateTimes combines entryDate + time strings into full Time and ensures end > start.
 func (h *TimesheetHandler) parseAndValidateTimes(entryDate time.Time, startStr, endStr string) (time.Time, time.Time, error) {
 	layout := "15:04"
@@ -63,12 +91,8 @@ func (h *TimesheetHandler) ensureNoOverlap(
 	newStart, newEnd time.Time,
 	excludeID uuid.UUID,
 ) error {
-	var entries []models.TimesheetEntry
-	q := h.db.Where("user_id = ? AND entry_date = ?", userID, date)
-	if excludeID != uuid.Nil {
-		q = q.Where("id <> ?", excludeID)
-	}
-	if err := q.Find(&entries).Error; err != nil {
+	entries, err := h.timesheets.ListForDay(userID, date, excludeID)
+	if err != nil {
 		return err
 	}
 	for _, ex := range entries {
@@ -116,6 +140,7 @@ type UpdateTimesheetRequest struct {
 // @Failure 400 {object} utils.APIResponse "Invalid request payload or validation error (e.g., time overlap, daily limit exceeded)"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 404 {object} utils.APIResponse "Project not found or inactive"
+// @Failure 409 {object} utils.APIResponse "Time overlap, or the entry date falls on approved leave"
 // @Router /timesheets [post]
 
 func (h *TimesheetHandler) CreateTimesheet(c *gin.Context) {
@@ -144,10 +169,8 @@ func (h *TimesheetHandler) CreateTimesheet(c *gin.Context) {
 	}
 
 	// Check if project exists
-	var project models.Project
-	if err := h.db.Where("id = ? AND status = 'active'", req.ProjectID).
-		First(&project).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	if _, err := h.timesheets.FindActiveProject(req.ProjectID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid or inactive project", "")
 			return
 		}
@@ -155,20 +178,35 @@ func (h *TimesheetHandler) CreateTimesheet(c *gin.Context) {
 		return
 	}
 
-	// Check daily hour limit (8 hours max per day)
-	var existingHours float64
-	if err := h.db.Model(&models.TimesheetEntry{}).
-		Select("COALESCE(SUM(duration_hours), 0)").
-		Where("user_id = ? AND entry_date = ?", userIDUUID, entryDate).
-		Scan(&existingHours).Error; err != nil {
+	// No time can be logged on a day of approved leave, and only half a day
+	// on a half day of leave
+	maxHours := h.config.TimesheetMaxDailyHours
+	leave, session, err := h.leaveService.TakenLeaveOn(userIDUUID, entryDate)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	if leave != nil {
+		if session == models.SessionFull {
+			utils.ErrorResponse(c, http.StatusConflict, "Entry date falls on approved leave",
+				fmt.Sprintf("%s leave from %s to %s", leave.LeaveType.Name,
+					leave.StartDate.Format("2006-01-02"), leave.EndDate.Format("2006-01-02")))
+			return
+		}
+		maxHours /= 2
+	}
+
+	// Check daily hour limit (TIMESHEET_MAX_DAILY_HOURS, 8 by default)
+	existingHours, err := h.timesheets.HoursOnDay(userIDUUID, entryDate)
+	if err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
 	totalHours := existingHours + req.DurationHours
-	if totalHours > 8.0 {
+	if totalHours > maxHours {
 		utils.ErrorResponse(c, http.StatusBadRequest,
-			fmt.Sprintf("Cannot exceed 8 hours per day. Current: %.1f hours, Trying to add: %.1f hours",
-				existingHours, req.DurationHours), "")
+			fmt.Sprintf("Cannot exceed %g hours per day. Current: %.1f hours, Trying to add: %.1f hours",
+				maxHours, existingHours, req.DurationHours), "")
 		return
 	}
 
@@ -197,18 +235,19 @@ func (h *TimesheetHandler) CreateTimesheet(c *gin.Context) {
 	}
 
 	// Save
-	if err := h.db.Create(&ts).Error; err != nil {
+	if err := h.timesheets.Create(&ts); err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
 
 	// Load relationships
-	if err := h.db.Preload("Project").First(&ts, ts.ID).Error; err != nil {
+	created, err := h.timesheets.FindByID(ts.ID)
+	if err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusCreated, "Timesheet entry created successfully", ts)
+	utils.SuccessResponse(c, http.StatusCreated, "Timesheet entry created successfully", created)
 }
 
 // /////////////////////////// UpdateTimesheet Summary Handler /////////////////////////////
@@ -237,9 +276,9 @@ func (h *TimesheetHandler) UpdateTimesheet(c *gin.Context) {
 	}
 
 	// Find existing entry
-	var timesheet models.TimesheetEntry
-	if err := h.db.Where("id = ? AND user_id = ?", timesheetID, userIDUUID).First(&timesheet).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	timesheet, err := h.timesheets.FindForUser(timesheetID, userIDUUID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			utils.NotFoundResponse(c, "Timesheet entry")
 			return
 		}
@@ -247,9 +286,9 @@ func (h *TimesheetHandler) UpdateTimesheet(c *gin.Context) {
 		return
 	}
 
-	// Only draft can be updated
-	if timesheet.Status != "draft" {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Cannot update submitted timesheet entry", "")
+	// Submitted and approved entries are locked
+	if timesheet.IsLocked() {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Cannot update submitted or approved timesheet entry", "")
 		return
 	}
 
@@ -281,14 +320,14 @@ func (h *TimesheetHandler) UpdateTimesheet(c *gin.Context) {
 	updates["break_time_minutes"] = req.BreakTimeMinutes
 
 	if len(updates) > 0 {
-		if err := h.db.Model(&timesheet).Updates(updates).Error; err != nil {
+		if err := h.timesheets.Update(timesheet, updates); err != nil {
 			utils.InternalErrorResponse(c, err)
 			return
 		}
 	}
 
 	// Load updated entry
-	if err := h.db.Preload("Project").First(&timesheet, timesheet.ID).Error; err != nil {
+	if timesheet, err = h.timesheets.FindByID(timesheet.ID); err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
@@ -316,9 +355,9 @@ func (h *TimesheetHandler) DeleteTimesheet(c *gin.Context) {
 	}
 
 	// Find timesheet entry
-	var timesheet models.TimesheetEntry
-	if err := h.db.Where("id = ? AND user_id = ?", timesheetID, userIDUUID).First(&timesheet).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	timesheet, err := h.timesheets.FindForUser(timesheetID, userIDUUID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			utils.NotFoundResponse(c, "Timesheet entry")
 			return
 		}
@@ -326,13 +365,13 @@ func (h *TimesheetHandler) DeleteTimesheet(c *gin.Context) {
 		return
 	}
 
-	// Check if timesheet can be deleted (only draft entries)
-	if timesheet.Status != "draft" {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Cannot delete submitted timesheet entry", "")
+	// Submitted and approved entries are locked
+	if timesheet.IsLocked() {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Cannot delete submitted or approved timesheet entry", "")
 		return
 	}
 
-	if err := h.db.Delete(&timesheet).Error; err != nil {
+	if err := h.timesheets.Delete(timesheet); err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
@@ -374,21 +413,30 @@ func (h *TimesheetHandler) SubmitTimesheet(c *gin.Context) {
 		return
 	}
 
-	// Update all draft timesheets in the date range to submitted
-	result := h.db.Model(&models.TimesheetEntry{}).
-		Where("user_id = ? AND status = 'draft' AND entry_date BETWEEN ? AND ?", userIDUUID, startDate, endDate).
-		Updates(map[string]interface{}{
-			"status":       "submitted",
-			"submitted_at": time.Now().In(h.location), // timezone-consistent timestamp
+	// Update all editable timesheets in the date range to submitted. Returned and
+	// rejected entries are resubmitted together with new drafts.
+	submitted, err := h.timesheets.UpdateInRange(userIDUUID,
+		[]string{models.TimesheetStatusDraft, models.TimesheetStatusReturned, models.TimesheetStatusRejected},
+		startDate, endDate,
+		map[string]interface{}{
+			"status":           models.TimesheetStatusSubmitted,
+			"submitted_at":     time.Now().In(h.location), // timezone-consistent timestamp
+			"approved_by":      nil,
+			"approved_at":      nil,
+			"rejection_reason": nil,
 		})
 
-	if result.Error != nil {
-		utils.InternalErrorResponse(c, result.Error)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
 		return
 	}
 
+	if submitted > 0 {
+		h.notifications.NotifyTimesheetSubmitted(userIDUUID, submitted, startDate, endDate)
+	}
+
 	utils.SuccessResponse(c, http.StatusOK, "Timesheet submitted successfully", gin.H{
-		"submitted_entries": result.RowsAffected,
+		"submitted_entries": submitted,
 	})
 }
 
@@ -560,8 +608,14 @@ func (h *TimesheetHandler) GetTimesheetSummary(c *gin.Context) {
 			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID format in query", err.Error())
 			return
 		}
-		// Add role-based access control here if needed, similar to GetTimesheets
-		// For now, assuming middleware handles general access to this endpoint
+		currentUserID, ok := currentUserUUID(c)
+		if !ok {
+			utils.UnauthorizedResponse(c)
+			return
+		}
+		if !h.canViewUser(c, currentUserID, targetUserID) {
+			return
+		}
 	} else {
 		// If no user_id is specified in query, default to the authenticated user's ID
 		authUserID, exists := c.Get("user_id")
@@ -660,25 +714,10 @@ func (h *TimesheetHandler) GetTimesheets(c *gin.Context) {
 		}
 		targetUserID = parsedUserID
 
-		// If the requested user ID is different from the current user's ID,
-		// then perform role-based access control.
-		if targetUserID != currentUserIDUUID {
-			// Fetch user's role from DB if not already set by a middleware
-			var currentUser models.User
-			if err := h.db.Select("role").Where("id = ?", currentUserIDUUID).First(&currentUser).Error; err != nil {
-				// If the current user cannot be found, or there's a DB error,
-				// it's an internal issue or an invalid token.
-				utils.InternalErrorResponse(c, err)
-				return
-			}
-
-			// Check if current user has sufficient role to view other users' timesheets
-			if currentUser.Role != models.RoleAdmin && currentUser.Role != models.RoleHR && currentUser.Role != models.RoleManager {
-				utils.ForbiddenResponse(c)
-				return
-			}
+		// Other users' timesheets are only visible within the caller's team scope
+		if !h.canViewUser(c, currentUserIDUUID, targetUserID) {
+			return
 		}
-		// If targetUserID == currentUserIDUUID, it's a request for their own data, which is allowed.
 	} else {
 		// If no user_id is requested, default to the current user's ID
 		targetUserID = currentUserIDUUID
@@ -697,31 +736,13 @@ func (h *TimesheetHandler) GetTimesheets(c *gin.Context) {
 		offset = 0
 	}
 
-	query := h.db.Preload("Project").Preload("User").Where("user_id = ?", targetUserID)
-
-	if status != "" {
-		query = query.Where("status = ?", status)
-	}
-
-	if startDate != "" {
-		query = query.Where("entry_date >= ?", startDate)
-	}
-
-	if endDate != "" {
-		query = query.Where("entry_date <= ?", endDate)
-	}
-
-	var timesheets []models.TimesheetEntry
-	var total int64
-
-	// Get total count
-	if err := query.Model(&models.TimesheetEntry{}).Count(&total).Error; err != nil {
-		utils.InternalErrorResponse(c, err)
-		return
-	}
-
-	// Get paginated results
-	if err := query.Order("entry_date DESC, created_at DESC").Offset(offset).Limit(limit).Find(&timesheets).Error; err != nil {
+	timesheets, total, err := h.timesheets.List(repository.TimesheetFilter{
+		UserID: targetUserID,
+		Status: status,
+		From:   startDate,
+		To:     endDate,
+	}, repository.Page{Offset: offset, Limit: limit})
+	if err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
@@ -757,25 +778,9 @@ func (h *TimesheetHandler) DownloadTimesheetEntry(c *gin.Context) {
 		return
 	}
 
-	// Check user role for admin access
-	userRole, roleExists := c.Get("user_role")
-	if !roleExists {
-		utils.UnauthorizedResponse(c)
-		return
-	}
-
-	var timesheet models.TimesheetEntry
-	query := h.db.Preload("Project").Preload("User")
-
-	// Admin/HR/Manager can download any timesheet, others can only download their own
-	if userRole == models.RoleAdmin || userRole == models.RoleHR || userRole == models.RoleManager {
-		query = query.Where("id = ?", timesheetID)
-	} else {
-		query = query.Where("id = ? AND user_id = ?", timesheetID, userIDUUID)
-	}
-
-	if err := query.First(&timesheet).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	timesheet, err := h.timesheets.FindByID(timesheetID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			utils.NotFoundResponse(c, "Timesheet entry")
 			return
 		}
@@ -783,6 +788,11 @@ func (h *TimesheetHandler) DownloadTimesheetEntry(c *gin.Context) {
 		return
 	}
 
+	// Users can download their own entries and those of their team scope
+	if !h.canViewUser(c, userIDUUID, timesheet.UserID) {
+		return
+	}
+
 	// Generate CSV content
 	csvContent := fmt.Sprintf("Employee,Employee ID,Project,Task,Date,Start Time,End Time,Duration,Status\n")
 	csvContent += fmt.Sprintf("%s %s,%s,%s,%s,%s,%s,%s,%.2f,%s\n",
@@ -1035,8 +1045,14 @@ func (h *TimesheetHandler) DownloadTimesheetsBulk(c *gin.Context) {
 	endDate := c.Query("end_date")
 	status := c.Query("status")
 
+	scope, ok := requestTeamScope(c, h.teamScope)
+	if !ok {
+		return
+	}
+
 	// Explicit JOIN to allow ordering by user fields
 	query := h.db.Preload("Project").Preload("User").Joins("JOIN users ON users.id = timesheet_entries.user_id")
+	query = scope.Apply(query, "timesheet_entries.user_id")
 
 	if requestedUserIDStr != "" {
 		userID, err := uuid.Parse(requestedUserIDStr)
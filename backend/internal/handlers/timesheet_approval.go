@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
+	"employee-dashboard-api/internal/utils"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ReviewTimesheetRequest struct {
+	Reason string `json:"reason"`
+}
+
+type ReviewTimesheetWeekRequest struct {
+	UserID    uuid.UUID `json:"user_id" binding:"required"`
+	WeekStart string    `json:"week_start" binding:"required"` // YYYY-MM-DD, first day of the week
+	Reason    string    `json:"reason"`
+}
+
+// @Summary Approve a timesheet entry
+// @Description Approve a single submitted timesheet entry. Allowed for managers with the employee in their team scope and for admin/HR.
+// @Tags Timesheets
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "Timesheet entry ID"
+// @Success 200 {object} models.TimesheetEntry "Timesheet entry approved successfully"
+// @Failure 400 {object} utils.APIResponse "Entry is not in submitted status"
+// @Failure 403 {object} utils.APIResponse "Not allowed to review this employee's timesheets"
+// @Failure 404 {object} utils.APIResponse "Timesheet entry not found"
+// @Router /timesheets/{id}/approve [put]
+func (h *TimesheetHandler) ApproveTimesheet(c *gin.Context) {
+	h.reviewTimesheetEntry(c, models.TimesheetStatusApproved)
+}
+
+// @Summary Reject a timesheet entry
+// @Description Reject a single submitted timesheet entry with a reason.
+// @Tags Timesheets
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Timesheet entry ID"
+// @Param review body ReviewTimesheetRequest true "Rejection reason"
+// @Success 200 {object} models.TimesheetEntry "Timesheet entry rejected successfully"
+// @Router /timesheets/{id}/reject [put]
+func (h *TimesheetHandler) RejectTimesheet(c *gin.Context) {
+	h.reviewTimesheetEntry(c, models.TimesheetStatusRejected)
+}
+
+// @Summary Return a timesheet entry for correction
+// @Description Send a submitted timesheet entry back to the employee so it can be edited and resubmitted.
+// @Tags Timesheets
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Timesheet entry ID"
+// @Param review body ReviewTimesheetRequest true "What needs to be corrected"
+// @Success 200 {object} models.TimesheetEntry "Timesheet entry returned for correction"
+// @Router /timesheets/{id}/return [put]
+func (h *TimesheetHandler) ReturnTimesheet(c *gin.Context) {
+	h.reviewTimesheetEntry(c, models.TimesheetStatusReturned)
+}
+
+// @Summary Approve a week of timesheets
+// @Description Approve every submitted entry of an employee for the 7 days starting at week_start.
+// @Tags Timesheets
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param review body ReviewTimesheetWeekRequest true "Employee and week"
+// @Success 200 {object} object{reviewed_entries=int} "Timesheet week approved successfully"
+// @Router /timesheets/week/approve [post]
+func (h *TimesheetHandler) ApproveTimesheetWeek(c *gin.Context) {
+	h.reviewTimesheetWeek(c, models.TimesheetStatusApproved)
+}
+
+// @Summary Reject a week of timesheets
+// @Tags Timesheets
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param review body ReviewTimesheetWeekRequest true "Employee, week and rejection reason"
+// @Success 200 {object} object{reviewed_entries=int} "Timesheet week rejected successfully"
+// @Router /timesheets/week/reject [post]
+func (h *TimesheetHandler) RejectTimesheetWeek(c *gin.Context) {
+	h.reviewTimesheetWeek(c, models.TimesheetStatusRejected)
+}
+
+// @Summary Return a week of timesheets for correction
+// @Tags Timesheets
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param review body ReviewTimesheetWeekRequest true "Employee, week and correction note"
+// @Success 200 {object} object{reviewed_entries=int} "Timesheet week returned for correction"
+// @Router /timesheets/week/return [post]
+func (h *TimesheetHandler) ReturnTimesheetWeek(c *gin.Context) {
+	h.reviewTimesheetWeek(c, models.TimesheetStatusReturned)
+}
+
+func (h *TimesheetHandler) reviewTimesheetEntry(c *gin.Context, status string) {
+	reviewerID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	timesheetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid timesheet ID", err.Error())
+		return
+	}
+
+	var req ReviewTimesheetRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	if !validReviewReason(c, status, req.Reason) {
+		return
+	}
+
+	timesheet, err := h.timesheets.FindByID(timesheetID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.NotFoundResponse(c, "Timesheet entry")
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	if timesheet.Status != models.TimesheetStatusSubmitted {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Timesheet entry is not in submitted status", "")
+		return
+	}
+
+	allowed, err := h.canReviewTimesheets(c, reviewerID, timesheet.UserID)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	if !allowed {
+		utils.ForbiddenResponse(c)
+		return
+	}
+
+	if err := h.timesheets.Update(timesheet, reviewUpdates(reviewerID, status, req.Reason, h.location)); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	if timesheet, err = h.timesheets.FindByID(timesheet.ID); err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, reviewMessage("Timesheet entry", status), timesheet)
+}
+
+func (h *TimesheetHandler) reviewTimesheetWeek(c *gin.Context, status string) {
+	reviewerID, ok := currentUserUUID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c)
+		return
+	}
+
+	var req ReviewTimesheetWeekRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	if !validReviewReason(c, status, req.Reason) {
+		return
+	}
+
+	weekStart, err := time.ParseInLocation("2006-01-02", req.WeekStart, h.location)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid week start format", err.Error())
+		return
+	}
+	weekEnd := weekStart.AddDate(0, 0, 6)
+
+	allowed, err := h.canReviewTimesheets(c, reviewerID, req.UserID)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+	if !allowed {
+		utils.ForbiddenResponse(c)
+		return
+	}
+
+	reviewed, err := h.timesheets.UpdateInRange(req.UserID, []string{models.TimesheetStatusSubmitted},
+		weekStart, weekEnd, reviewUpdates(reviewerID, status, req.Reason, h.location))
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	if reviewed == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, "No submitted timesheet entries found for this week", "")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, reviewMessage("Timesheet week", status), gin.H{
+		"user_id":          req.UserID,
+		"week_start":       weekStart.Format("2006-01-02"),
+		"week_end":         weekEnd.Format("2006-01-02"),
+		"reviewed_entries": reviewed,
+	})
+}
+
+// canReviewTimesheets reports whether reviewerID may approve, reject or return
+// the timesheets of employeeID: the reviewer needs timesheet:approve and the
+// employee must be in the reviewer's team scope. Nobody may review their own
+// timesheets.
+func (h *TimesheetHandler) canReviewTimesheets(c *gin.Context, reviewerID, employeeID uuid.UUID) (bool, error) {
+	if reviewerID == employeeID {
+		return false, nil
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok || !claims.HasPermission(models.PermTimesheetApprove) {
+		return false, nil
+	}
+
+	scope, err := h.teamScope.ResolveScope(reviewerID, claims.HasPermission(models.PermTeamViewAll))
+	if err != nil {
+		return false, err
+	}
+	return scope.Contains(employeeID), nil
+}
+
+// reviewUpdates builds the column updates for a review decision. The reviewer
+// is recorded in approved_by/approved_at for every outcome, as leaves do.
+func reviewUpdates(reviewerID uuid.UUID, status, reason string, location *time.Location) map[string]interface{} {
+	updates := map[string]interface{}{
+		"status":           status,
+		"approved_by":      reviewerID,
+		"approved_at":      time.Now().In(location),
+		"rejection_reason": nil,
+	}
+	if status != models.TimesheetStatusApproved {
+		updates["rejection_reason"] = strings.TrimSpace(reason)
+	}
+	return updates
+}
+
+func validReviewReason(c *gin.Context, status, reason string) bool {
+	if status != models.TimesheetStatusApproved && strings.TrimSpace(reason) == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Reason is required", "")
+		return false
+	}
+	return true
+}
+
+func reviewMessage(subject, status string) string {
+	switch status {
+	case models.TimesheetStatusApproved:
+		return subject + " approved successfully"
+	case models.TimesheetStatusRejected:
+		return subject + " rejected successfully"
+	default:
+		return subject + " returned for correction"
+	}
+}
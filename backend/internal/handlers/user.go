@@ -3,7 +3,9 @@ package handlers
 import (
 	"employee-dashboard-api/internal/config"
 	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
 	"employee-dashboard-api/internal/utils"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -16,13 +18,15 @@ type UserHandler struct {
 	db     *gorm.DB
 	config *config.Config
 	logger *logrus.Logger
+	users  repository.UserRepository
 }
 
-func NewUserHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger) *UserHandler {
+func NewUserHandler(db *gorm.DB, cfg *config.Config, logger *logrus.Logger, repos *repository.Repositories) *UserHandler {
 	return &UserHandler{
 		db:     db,
 		config: cfg,
 		logger: logger,
+		users:  repos.Users,
 	}
 }
 
@@ -47,9 +51,9 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if err := h.db.Preload("Manager").Where("id = ?", userID).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	user, err := h.users.FindByID(userID.(uuid.UUID))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			utils.NotFoundResponse(c, "User")
 			return
 		}
@@ -76,9 +80,9 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if err := h.db.Where("id = ?", userID).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	user, err := h.users.FindByID(userID.(uuid.UUID))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			utils.NotFoundResponse(c, "User")
 			return
 		}
@@ -105,13 +109,13 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		updates["profile_image_url"] = req.ProfileImageURL
 	}
 
-	if err := h.db.Model(&user).Updates(updates).Error; err != nil {
+	if err := h.users.Update(user, updates); err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
 
 	// Fetch updated user
-	if err := h.db.Preload("Manager").Where("id = ?", userID).First(&user).Error; err != nil {
+	if user, err = h.users.FindByID(user.ID); err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
@@ -135,9 +139,9 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if err := h.db.Where("id = ?", userID).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	user, err := h.users.FindByID(userID.(uuid.UUID))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			utils.NotFoundResponse(c, "User")
 			return
 		}
@@ -146,33 +150,19 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	}
 
 	// Verify current password
-	var currentPasswordMatch bool
-	if h.config.UserPlainPasswords {
-		currentPasswordMatch = (req.CurrentPassword == user.PasswordHash)
-	} else {
-		currentPasswordMatch = utils.CheckPasswordHash(req.CurrentPassword, user.PasswordHash)
-	}
-
-	if !currentPasswordMatch {
+	if !utils.CheckPasswordHash(req.CurrentPassword, user.PasswordHash) {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Current password is incorrect", "")
 		return
 	}
 
-	// Determine password to store
-	var newPasswordToStore string
-	if h.config.UserPlainPasswords {
-		newPasswordToStore = req.NewPassword // Store new plain password
-	} else {
-		hashedPassword, err := utils.HashPassword(req.NewPassword)
-		if err != nil {
-			utils.InternalErrorResponse(c, err)
-			return
-		}
-		newPasswordToStore = hashedPassword
+	newPasswordToStore, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
 	}
 
 	// Update password
-	if err := h.db.Model(&user).Update("password_hash", newPasswordToStore).Error; err != nil {
+	if err := h.users.Update(user, map[string]interface{}{"password_hash": newPasswordToStore}); err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
@@ -223,9 +213,9 @@ type UserListItem struct {
 }
 
 func (h *UserHandler) GetUsers(c *gin.Context) {
-	var users []models.User
 	// Only fetch approved users for timesheet viewing
-	if err := h.db.Where("approval_status = ?", models.StatusApproved).Order("first_name ASC, last_name ASC").Find(&users).Error; err != nil {
+	users, err := h.users.ListApproved()
+	if err != nil {
 		utils.InternalErrorResponse(c, err)
 		return
 	}
@@ -7,11 +7,16 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+// TokenRevocationChecker reports whether an otherwise valid access token has
+// been revoked (logout, log out of all devices, admin session kill).
+type TokenRevocationChecker interface {
+	IsRevoked(claims *utils.Claims) bool
+}
+
+func AuthMiddleware(cfg *config.Config, revocations TokenRevocationChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 
@@ -36,24 +41,28 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		claims := &utils.Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(cfg.JWTSecret), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := utils.ValidateToken(tokenString, cfg.JWTSecret)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
 
+		if revocations.IsRevoked(claims) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			c.Abort()
+			return
+		}
+
 		c.Set("user_id", claims.UserID)
+		c.Set("user_role", claims.Role)
+		c.Set("claims", claims)
 		c.Set("is_anonymous", false)
 		c.Next()
 	}
 }
 
-func OptionalAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+func OptionalAuthMiddleware(cfg *config.Config, revocations TokenRevocationChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 
@@ -72,16 +81,14 @@ func OptionalAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		claims := &utils.Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(cfg.JWTSecret), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := utils.ValidateToken(tokenString, cfg.JWTSecret)
+		if err != nil || revocations.IsRevoked(claims) {
 			c.Set("user_id", uuid.Nil)
 			c.Set("is_anonymous", true)
 		} else {
 			c.Set("user_id", claims.UserID)
+			c.Set("user_role", claims.Role)
+			c.Set("claims", claims)
 			c.Set("is_anonymous", false)
 		}
 
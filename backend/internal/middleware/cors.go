@@ -23,8 +23,8 @@ func ManualCORSMiddleware() gin.HandlerFunc {
 			c.Header("Access-Control-Allow-Origin", origin)
 			c.Header("Access-Control-Allow-Credentials", "true")
 			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
-			c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Accept, Origin, ngrok-skip-browser-warning")
-			c.Header("Access-Control-Expose-Headers", "Content-Length, Content-Type")
+			c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Accept, Origin, ngrok-skip-browser-warning, Idempotency-Key")
+			c.Header("Access-Control-Expose-Headers", "Content-Length, Content-Type, Idempotent-Replayed")
 			c.Header("Access-Control-Max-Age", "86400")
 		}
 		
@@ -46,8 +46,8 @@ func CORSMiddleware() gin.HandlerFunc {
 			return IsAllowedOrigin(origin)
 		},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowHeaders:     []string{"Content-Type", "Authorization", "X-Requested-With", "Accept", "Origin", "ngrok-skip-browser-warning"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type"},
+		AllowHeaders:     []string{"Content-Type", "Authorization", "X-Requested-With", "Accept", "Origin", "ngrok-skip-browser-warning", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Type", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           86400, // 24 hours
 	})
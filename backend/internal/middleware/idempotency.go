@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"employee-dashboard-api/internal/services"
+	"employee-dashboard-api/internal/utils"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// IdempotencyKeyHeader carries a client-chosen key that identifies one
+// logical request across retries.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds the accepted Idempotency-Key value.
+const maxIdempotencyKeyLength = 255
+
+// idempotencyWriter keeps a copy of the response body so it can be stored.
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency makes authenticated mutating requests that carry an
+// Idempotency-Key header safe to retry: a repeat of a completed request gets
+// the stored response (marked with Idempotent-Replayed), a repeat of one still
+// running gets 409, and reusing a key for a different request gets 422.
+// Server errors are not stored, so the request can be retried for real. Must
+// run after AuthMiddleware.
+func Idempotency(service *services.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(IdempotencyKeyHeader))
+		if key == "" || c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid Idempotency-Key header", "key is too long")
+			c.Abort()
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		uid, ok := userID.(uuid.UUID)
+		if !ok || uid == uuid.Nil {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			if body, err = io.ReadAll(c.Request.Body); err != nil {
+				utils.ErrorResponse(c, http.StatusBadRequest, "Failed to read request body", err.Error())
+				c.Abort()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n"))
+		hash.Write(body)
+
+		record, replay, err := service.Begin(uid, key, hex.EncodeToString(hash.Sum(nil)))
+		switch {
+		case errors.Is(err, services.ErrIdempotencyKeyReused):
+			utils.ErrorResponse(c, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request", "")
+			c.Abort()
+			return
+		case errors.Is(err, services.ErrIdempotencyInProgress):
+			utils.ErrorResponse(c, http.StatusConflict, "A request with this Idempotency-Key is still being processed", "")
+			c.Abort()
+			return
+		case err != nil:
+			utils.InternalErrorResponse(c, err)
+			c.Abort()
+			return
+		}
+
+		if replay {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.StatusCode, "application/json; charset=utf-8", []byte(record.ResponseBody))
+			c.Abort()
+			return
+		}
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		defer func() {
+			if r := recover(); r != nil {
+				service.Release(record)
+				panic(r)
+			}
+		}()
+		c.Next()
+
+		if writer.Status() >= http.StatusInternalServerError {
+			service.Release(record)
+			return
+		}
+		service.Complete(record, writer.Status(), writer.body.Bytes())
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bytes"
+	"employee-dashboard-api/internal/services"
+	"employee-dashboard-api/internal/utils"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRateLimitBody bounds how much of a request body is read to find the
+// account a request is for.
+const maxRateLimitBody = 64 << 10
+
+// RateLimitKeyFunc returns the bucket key for a request. An empty key skips
+// the limit for that request.
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// ByIP keys the bucket on the client IP.
+func ByIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// ByJSONField keys the bucket on the first non-empty of the given JSON body
+// fields (e.g. "email", "employee_id"), case-insensitively. The body is
+// restored so the handler can still bind it.
+func ByJSONField(fields ...string) RateLimitKeyFunc {
+	return func(c *gin.Context) string {
+		if c.Request.Body == nil {
+			return ""
+		}
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxRateLimitBody))
+		if err != nil {
+			return ""
+		}
+		c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+
+		var values map[string]interface{}
+		if err := json.Unmarshal(body, &values); err != nil {
+			return ""
+		}
+		for _, field := range fields {
+			if value, ok := values[field].(string); ok && strings.TrimSpace(value) != "" {
+				return field + ":" + strings.ToLower(strings.TrimSpace(value))
+			}
+		}
+		return ""
+	}
+}
+
+// RateLimit rejects requests with 429 and Retry-After once the bucket named
+// name for the request's key is empty.
+func RateLimit(limiter *services.RateLimiter, name string, limit services.RateLimit, key RateLimitKeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k := key(c)
+		if k == "" {
+			c.Next()
+			return
+		}
+
+		allowed, retryAfter := limiter.Allow(c.Request.Context(), "ratelimit:"+name+":"+k, limit)
+		if !allowed {
+			utils.TooManyRequestsResponse(c, retryAfter)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -6,21 +6,15 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-	"gorm.io/gorm"
 )
 
-func RequireRole(db *gorm.DB, allowedRoles ...models.UserRole) gin.HandlerFunc {
+// RequireRole checks the role embedded in the access token. The token's role
+// version has already been verified by AuthMiddleware, so no database lookup
+// is needed here.
+func RequireRole(allowedRoles ...models.UserRole) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userID, exists := c.Get("user_id")
-		if !exists || userID == uuid.Nil {
-			utils.UnauthorizedResponse(c)
-			c.Abort()
-			return
-		}
-
-		var user models.User
-		if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		claims, ok := tokenClaims(c)
+		if !ok {
 			utils.UnauthorizedResponse(c)
 			c.Abort()
 			return
@@ -28,8 +22,7 @@ func RequireRole(db *gorm.DB, allowedRoles ...models.UserRole) gin.HandlerFunc {
 
 		// Check if user's role is in the allowed roles
 		for _, role := range allowedRoles {
-			if user.Role == role {
-				c.Set("user_role", user.Role)
+			if claims.Role == role {
 				c.Next()
 				return
 			}
@@ -40,18 +33,51 @@ func RequireRole(db *gorm.DB, allowedRoles ...models.UserRole) gin.HandlerFunc {
 	}
 }
 
-func RequireAdminRole(db *gorm.DB) gin.HandlerFunc {
-	return RequireRole(db, models.RoleAdmin)
+// RequirePermission lets the request through only if the access token grants
+// every listed permission.
+func RequirePermission(permissions ...models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := tokenClaims(c)
+		if !ok {
+			utils.UnauthorizedResponse(c)
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !claims.HasPermission(permission) {
+				utils.ErrorResponse(c, http.StatusForbidden, "Insufficient permissions", string(permission)+" required")
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
 }
 
-func RequireHRRole(db *gorm.DB) gin.HandlerFunc {
-	return RequireRole(db, models.RoleAdmin, models.RoleHR)
+func RequireAdminRole() gin.HandlerFunc {
+	return RequireRole(models.RoleAdmin)
 }
 
-func RequireManagerRole(db *gorm.DB) gin.HandlerFunc {
-	return RequireRole(db, models.RoleAdmin, models.RoleHR, models.RoleManager)
+func RequireHRRole() gin.HandlerFunc {
+	return RequireRole(models.RoleAdmin, models.RoleHR)
 }
 
-func RequireTeamLeadRole(db *gorm.DB) gin.HandlerFunc {
-	return RequireRole(db, models.RoleAdmin, models.RoleHR, models.RoleManager, models.RoleTeamLead)
+func RequireManagerRole() gin.HandlerFunc {
+	return RequireRole(models.RoleAdmin, models.RoleHR, models.RoleManager)
+}
+
+func RequireTeamLeadRole() gin.HandlerFunc {
+	return RequireRole(models.RoleAdmin, models.RoleHR, models.RoleManager, models.RoleTeamLead)
+}
+
+// tokenClaims returns the claims of an authenticated (non-anonymous) request.
+func tokenClaims(c *gin.Context) (*utils.Claims, bool) {
+	value, exists := c.Get("claims")
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*utils.Claims)
+	return claims, ok && claims != nil
 }
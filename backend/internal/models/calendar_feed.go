@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// What a calendar feed covers
+const (
+	CalendarFeedPersonal = "personal" // the owner's leaves and the holidays of their work location
+	CalendarFeedTeam     = "team"     // the leaves, birthdays and anniversaries of the owner's team
+)
+
+// CalendarFeed is an iCalendar subscription URL. The URL carries a random
+// token instead of a bearer header so calendar apps can poll it; only the
+// token's hash is stored, so the URL is shown once and rotated if lost.
+type CalendarFeed struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	User           *User      `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	Scope          string     `json:"scope" gorm:"not null"`
+	TokenHash      string     `json:"-" gorm:"not null;uniqueIndex"`
+	LastAccessedAt *time.Time `json:"last_accessed_at"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (f *CalendarFeed) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CompOffLeaveTypeName is the leave type approved comp-off claims are
+// credited to.
+const CompOffLeaveTypeName = "Comp Off"
+
+// Why the hours of a day earn comp-off
+const (
+	CompOffWeekend  = "weekend"  // all hours logged on a weekend
+	CompOffHoliday  = "holiday"  // all hours logged on a holiday
+	CompOffOvertime = "overtime" // hours past the daily threshold on a working day
+)
+
+// Comp-off claim statuses
+const (
+	CompOffPending  = "pending"
+	CompOffApproved = "approved"
+	CompOffRejected = "rejected"
+	CompOffExpired  = "expired" // approved, and the window to use the credit has passed
+)
+
+// CompOffClaim asks for comp-off for the extra hours an employee logged on
+// WorkDate. Hours and Days are worked out from the submitted and approved
+// timesheet entries of the day when the claim is made. Once the manager
+// approves, Days are credited to the user's Comp Off balance; whatever is
+// still unused at ExpiresAt lapses, the oldest credits being used first.
+type CompOffClaim struct {
+	ID              uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID          uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	User            *User      `json:"user,omitempty" gorm:"foreignKey:UserID;references:ID"`
+	WorkDate        time.Time  `json:"work_date" gorm:"not null"`
+	Reason          string     `json:"reason" gorm:"not null"`
+	Holiday         *string    `json:"holiday"`
+	Hours           float64    `json:"hours" gorm:"not null"` // eligible hours, not all hours of the day
+	Days            float64    `json:"days" gorm:"not null"`
+	Note            *string    `json:"note"`
+	Status          string     `json:"status" gorm:"not null;default:pending"`
+	ReviewedBy      *uuid.UUID `json:"reviewed_by" gorm:"type:uuid"`
+	ReviewedAt      *time.Time `json:"reviewed_at"`
+	RejectionReason *string    `json:"rejection_reason"`
+	LeaveBalanceID  *uuid.UUID `json:"leave_balance_id" gorm:"type:uuid"` // balance credited on approval
+	ExpiresAt       time.Time  `json:"expires_at" gorm:"not null"`
+	LapsedDays      float64    `json:"lapsed_days" gorm:"not null;default:0"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (c *CompOffClaim) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
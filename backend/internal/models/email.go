@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	EmailStatusPending = "pending"
+	EmailStatusSending = "sending"
+	EmailStatusSent    = "sent"
+	EmailStatusFailed  = "failed" // gave up after MaxAttempts; can be resent by an admin
+)
+
+// EmailOutbox is an outbound email waiting for, or done with, delivery by the
+// outbox worker.
+type EmailOutbox struct {
+	ID            uuid.UUID              `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ToAddress     string                 `json:"to" gorm:"not null;index"`
+	Subject       string                 `json:"subject" gorm:"not null"`
+	TextBody      string                 `json:"text_body" gorm:"type:text"`
+	HTMLBody      string                 `json:"html_body,omitempty" gorm:"type:text"`
+	Sensitive     bool                   `json:"sensitive" gorm:"not null;default:false"` // carries a one-time code: body hidden from admins, cleared once done
+	Status        string                 `json:"status" gorm:"not null;default:pending;index:idx_email_outbox_due"`
+	Attempts      int                    `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts   int                    `json:"max_attempts" gorm:"not null;default:5"`
+	NextAttemptAt time.Time              `json:"next_attempt_at" gorm:"not null;index:idx_email_outbox_due"`
+	LastError     *string                `json:"last_error"`
+	SentAt        *time.Time             `json:"sent_at"`
+	DeliveryLog   []EmailDeliveryAttempt `json:"delivery_attempts,omitempty" gorm:"foreignKey:OutboxID;constraint:OnDelete:CASCADE"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+}
+
+func (EmailOutbox) TableName() string {
+	return "email_outbox"
+}
+
+// EmailDeliveryAttempt records one try at sending an outbox message.
+type EmailDeliveryAttempt struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OutboxID   uuid.UUID `json:"outbox_id" gorm:"type:uuid;not null;index"`
+	Attempt    int       `json:"attempt" gorm:"not null"`
+	Transport  string    `json:"transport" gorm:"not null"`
+	Success    bool      `json:"success"`
+	Error      *string   `json:"error"`
+	DurationMS int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (e *EmailOutbox) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (a *EmailDeliveryAttempt) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
@@ -16,7 +16,11 @@ type Event struct {
 	UserID        *uuid.UUID `json:"user_id"` // for personal events like birthdays
 	User          *User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	IsCompanyWide bool       `json:"is_company_wide" gorm:"default:false"`
+	IsOptional    bool       `json:"is_optional" gorm:"default:false;not null"` // optional holidays are working days unless taken
+	Location      *string    `json:"location,omitempty"`                        // holiday applies only to users at this work location; nil for all
+	HolidayType   *string    `json:"holiday_type,omitempty"`                    // e.g. public, optional, regional; holidays only
 	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
 }
 
 func (e *Event) BeforeCreate(tx *gorm.DB) error {
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IdempotencyKey remembers the outcome of a mutating request sent with an
+// Idempotency-Key header so a retry of it is answered from the stored
+// response instead of being applied again. StatusCode is zero while the
+// original request is still running. Rows can be purged once ExpiresAt has
+// passed.
+type IdempotencyKey struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID       uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	Key          string    `json:"key" gorm:"not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	RequestHash  string    `json:"-" gorm:"not null"`
+	StatusCode   int       `json:"status_code" gorm:"not null;default:0"`
+	ResponseBody string    `json:"-"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (k *IdempotencyKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
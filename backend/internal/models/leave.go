@@ -35,23 +35,137 @@ type LeaveApplication struct {
 	Approver        *User      `json:"approver,omitempty" gorm:"foreignKey:ApprovedBy;references:ID"`
 	ApprovedAt      *time.Time `json:"approved_at"`
 	RejectionReason *string    `json:"rejection_reason"`
+	CancelFrom      *time.Time `json:"cancel_from"` // first day to cancel while a cancellation request is pending
+	CancelReason    *string    `json:"cancel_reason"`
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
 	DateRange       string     `json:"date_range" gorm:"-"`
+	Days            []LeaveDay `json:"days,omitempty" gorm:"-"` // per-day breakdown, filled when the days are computed
+
+	Sessions      []LeaveSession      `json:"sessions,omitempty" gorm:"foreignKey:LeaveApplicationID;references:ID;constraint:OnDelete:CASCADE"`
+	StatusHistory []LeaveStatusChange `json:"status_history,omitempty" gorm:"foreignKey:LeaveApplicationID;references:ID;constraint:OnDelete:CASCADE"`
+	Approvals     []LeaveApproval     `json:"approvals,omitempty" gorm:"foreignKey:LeaveApplicationID;references:ID;constraint:OnDelete:CASCADE"` // approval chain steps, in order
+}
+
+// Sessions a day of leave can be taken in
+const (
+	SessionFull       = "full"
+	SessionFirstHalf  = "first_half"
+	SessionSecondHalf = "second_half"
+)
+
+// LeaveSession takes one day of a leave as a half day. Days of a leave
+// without a session are taken in full.
+type LeaveSession struct {
+	ID                 uuid.UUID `json:"-" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LeaveApplicationID uuid.UUID `json:"-" gorm:"type:uuid;not null;uniqueIndex:idx_leave_sessions_leave_date"`
+	Date               string    `json:"date" gorm:"not null;uniqueIndex:idx_leave_sessions_leave_date"` // YYYY-MM-DD
+	Session            string    `json:"session" gorm:"not null"`
+}
+
+// SessionsByDate maps the days of the leave taken in half to their session.
+func (la *LeaveApplication) SessionsByDate() map[string]string {
+	sessions := make(map[string]string, len(la.Sessions))
+	for _, session := range la.Sessions {
+		sessions[session.Date] = session.Session
+	}
+	return sessions
+}
+
+// SessionsClash reports whether two sessions taken on the same day overlap.
+// An empty session is a full day.
+func SessionsClash(a, b string) bool {
+	if a == "" || b == "" || a == SessionFull || b == SessionFull {
+		return true
+	}
+	return a == b
+}
+
+// Leave application statuses. An approved leave with a pending cancellation
+// request stays in effect until the request is decided.
+const (
+	LeaveStatusPending               = "pending"
+	LeaveStatusApproved              = "approved"
+	LeaveStatusRejected              = "rejected"
+	LeaveStatusCancelled             = "cancelled"
+	LeaveStatusCancellationRequested = "cancellation_requested"
+)
+
+// LeaveTakenStatuses are the statuses of leaves that are in effect.
+var LeaveTakenStatuses = []string{LeaveStatusApproved, LeaveStatusCancellationRequested}
+
+// LeaveStatusChange is one entry in a leave application's status history.
+// FromStatus is empty for the entry recording the application itself.
+type LeaveStatusChange struct {
+	ID                 uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LeaveApplicationID uuid.UUID  `json:"leave_application_id" gorm:"type:uuid;not null;index"`
+	FromStatus         string     `json:"from_status"`
+	ToStatus           string     `json:"to_status" gorm:"not null"`
+	ChangedBy          *uuid.UUID `json:"changed_by" gorm:"type:uuid"`
+	Note               *string    `json:"note"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+func (s *LeaveSession) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
 }
 
+func (c *LeaveStatusChange) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// Kinds of calendar day in a LeaveDay breakdown
+const (
+	DayKindWorking         = "working"
+	DayKindWeekend         = "weekend"
+	DayKindHoliday         = "holiday"
+	DayKindOptionalHoliday = "optional_holiday"
+)
+
+// LeaveDay is one calendar day of a leave and the leave days it is charged.
+type LeaveDay struct {
+	Date    string  `json:"date"` // YYYY-MM-DD
+	Kind    string  `json:"kind"`
+	Holiday string  `json:"holiday,omitempty"`
+	Session string  `json:"session,omitempty"` // session taken, on working days only
+	Days    float64 `json:"days"`              // 1 for a working day, 0.5 for a half day, 0 otherwise
+}
+
+// LeaveBalance is a user's allowance of one leave type for a year. Days
+// carried forward from the previous year are used first; those still unused
+// when CarryExpiresAt passes are moved to ExpiredDays. Every change is
+// recorded in the leave ledger (see LeaveLedgerEntry).
 type LeaveBalance struct {
-	ID            uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	UserID        uuid.UUID `json:"user_id" gorm:"type:uuid;not null"`
-	User          User      `json:"user,omitempty" gorm:"foreignKey:UserID;references:ID"`
-	LeaveTypeID   uuid.UUID `json:"leave_type_id" gorm:"type:uuid;not null"`
-	LeaveType     LeaveType `json:"leave_type,omitempty" gorm:"foreignKey:LeaveTypeID;references:ID"`
-	Year          int       `json:"year" gorm:"not null"`
-	AllocatedDays int       `json:"allocated_days" gorm:"not null"`
-	UsedDays      float64   `json:"used_days" gorm:"default:0"`
-	RemainingDays float64   `json:"remaining_days" gorm:"-"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;not null"`
+	User           User       `json:"user,omitempty" gorm:"foreignKey:UserID;references:ID"`
+	LeaveTypeID    uuid.UUID  `json:"leave_type_id" gorm:"type:uuid;not null"`
+	LeaveType      LeaveType  `json:"leave_type,omitempty" gorm:"foreignKey:LeaveTypeID;references:ID"`
+	Year           int        `json:"year" gorm:"not null"`
+	AllocatedDays  float64    `json:"allocated_days" gorm:"not null"`
+	CarriedDays    float64    `json:"carried_days" gorm:"not null;default:0"` // carried forward from the previous year
+	CarryExpiresAt *time.Time `json:"carry_expires_at"`
+	CarriedOutDays float64    `json:"carried_out_days" gorm:"not null;default:0"` // carried forward to the next year
+	ExpiredDays    float64    `json:"expired_days" gorm:"not null;default:0"`     // lapsed unused, at expiry or the year end
+	EncashedDays   float64    `json:"encashed_days" gorm:"not null;default:0"`    // paid out at the year-end rollover
+	UsedDays       float64    `json:"used_days" gorm:"default:0"`
+	AccruedMonths  int        `json:"-" gorm:"not null;default:0"` // months of Year already accrued by the leave policy
+	ClosedAt       *time.Time `json:"closed_at"`                   // set by the year-end rollover
+	Version        int        `json:"-" gorm:"not null;default:0"` // bumped on every change, for optimistic locking
+	RemainingDays  float64    `json:"remaining_days" gorm:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Available returns the days of the balance that can still be taken.
+func (lb *LeaveBalance) Available() float64 {
+	return lb.AllocatedDays + lb.CarriedDays - lb.CarriedOutDays - lb.ExpiredDays - lb.EncashedDays - lb.UsedDays
 }
 
 func (lb *LeaveBalance) BeforeCreate(tx *gorm.DB) error {
@@ -62,7 +176,7 @@ func (lb *LeaveBalance) BeforeCreate(tx *gorm.DB) error {
 }
 
 func (lb *LeaveBalance) AfterFind(tx *gorm.DB) error {
-	lb.RemainingDays = float64(lb.AllocatedDays) - lb.UsedDays
+	lb.RemainingDays = lb.Available()
 	return nil
 }
 
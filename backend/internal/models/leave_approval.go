@@ -0,0 +1,129 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Who decides a step of a leave approval chain
+const (
+	ApproverManager     = "manager"      // the applicant's manager
+	ApproverSkipManager = "skip_manager" // the manager of the applicant's manager
+	ApproverRole        = "role"         // anyone with the step's role, e.g. hr
+	ApproverUser        = "user"         // one named user
+)
+
+// LeaveApprovalChain routes leave applications through several approvers in
+// turn. It applies to leaves of LeaveTypeID (any type when nil) by applicants
+// in Department (any when nil) of at least MinDays. When several chains
+// apply, the most specific wins: a leave type match before a department
+// match, then the highest MinDays. Leaves no chain applies to are approved in
+// one step by anyone with leave:approve over the applicant.
+type LeaveApprovalChain struct {
+	ID          uuid.UUID           `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name        string              `json:"name" gorm:"not null"`
+	LeaveTypeID *uuid.UUID          `json:"leave_type_id" gorm:"type:uuid"`
+	LeaveType   *LeaveType          `json:"leave_type,omitempty" gorm:"foreignKey:LeaveTypeID;references:ID"`
+	Department  *string             `json:"department"`
+	MinDays     float64             `json:"min_days" gorm:"not null;default:0"`
+	IsActive    bool                `json:"is_active" gorm:"not null;default:true"`
+	Steps       []LeaveApprovalStep `json:"steps" gorm:"foreignKey:ChainID;references:ID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// LeaveApprovalStep is one approver of a chain. A step still open SLAHours
+// after it was reached is escalated to the approver's manager; 0 never
+// escalates.
+type LeaveApprovalStep struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ChainID        uuid.UUID  `json:"-" gorm:"type:uuid;not null;index"`
+	Position       int        `json:"position" gorm:"not null"`
+	Approver       string     `json:"approver" gorm:"not null"`
+	ApproverRole   *UserRole  `json:"approver_role" gorm:"type:varchar(20)"`
+	ApproverUserID *uuid.UUID `json:"approver_user_id" gorm:"type:uuid"`
+	SLAHours       int        `json:"sla_hours" gorm:"not null;default:0"`
+}
+
+// Statuses of a leave approval step
+const (
+	ApprovalWaiting  = "waiting" // an earlier step is still open
+	ApprovalPending  = "pending" // the step being decided
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+	ApprovalSkipped  = "skipped" // never reached: an earlier step rejected the leave
+)
+
+// LeaveApproval is one step of a leave application's approval chain. Steps
+// are copied from the chain when the leave is applied for, so later changes
+// to the chain do not affect it. AssignedTo is the approver resolved for the
+// applicant; role steps leave it nil and go to anyone with ApproverRole.
+type LeaveApproval struct {
+	ID                 uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LeaveApplicationID uuid.UUID  `json:"leave_application_id" gorm:"type:uuid;not null;index"`
+	Position           int        `json:"position" gorm:"not null"`
+	ApproverRole       *UserRole  `json:"approver_role" gorm:"type:varchar(20)"`
+	AssignedTo         *uuid.UUID `json:"assigned_to" gorm:"type:uuid"`
+	Status             string     `json:"status" gorm:"not null"`
+	SLAHours           int        `json:"sla_hours" gorm:"not null;default:0"`
+	DueAt              *time.Time `json:"due_at"`
+	EscalatedAt        *time.Time `json:"escalated_at"`
+	EscalatedTo        *uuid.UUID `json:"escalated_to" gorm:"type:uuid"` // nil when escalated to the admins
+	DecidedBy          *uuid.UUID `json:"decided_by" gorm:"type:uuid"`
+	OnBehalfOf         *uuid.UUID `json:"on_behalf_of" gorm:"type:uuid"` // approver a delegate decided for
+	DecidedAt          *time.Time `json:"decided_at"`
+	Note               *string    `json:"note"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// ApprovalDelegation lets DelegateID decide the leave approvals of
+// DelegatorID from StartDate to EndDate, both inclusive, while the delegator
+// is out of office. Revoked delegations are kept for the record.
+type ApprovalDelegation struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	DelegatorID uuid.UUID  `json:"delegator_id" gorm:"type:uuid;not null;index"`
+	Delegator   *User      `json:"delegator,omitempty" gorm:"foreignKey:DelegatorID;references:ID"`
+	DelegateID  uuid.UUID  `json:"delegate_id" gorm:"type:uuid;not null;index"`
+	Delegate    *User      `json:"delegate,omitempty" gorm:"foreignKey:DelegateID;references:ID"`
+	StartDate   time.Time  `json:"start_date" gorm:"not null"`
+	EndDate     time.Time  `json:"end_date" gorm:"not null"`
+	Reason      *string    `json:"reason"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Covers reports whether the delegation is in effect on the day of t.
+func (d *ApprovalDelegation) Covers(t time.Time) bool {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return d.RevokedAt == nil && !day.Before(d.StartDate) && !day.After(d.EndDate)
+}
+
+func (c *LeaveApprovalChain) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+func (s *LeaveApprovalStep) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+func (a *LeaveApproval) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+func (d *ApprovalDelegation) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Kinds of leave ledger entry
+const (
+	LedgerOpening      = "opening"       // balance as it stood when the ledger was introduced, or as imported
+	LedgerAccrual      = "accrual"       // credited by the leave policy
+	LedgerDeduction    = "deduction"     // paid days of an approved leave
+	LedgerReversal     = "reversal"      // paid days given back by a cancellation
+	LedgerAdjustment   = "adjustment"    // manual correction by an admin
+	LedgerCarryForward = "carry_forward" // unused days moved between years: out of the old, into the new
+	LedgerEncashment   = "encashment"    // unused days paid out at the year-end rollover
+	LedgerLapse        = "lapse"         // unused days forfeited at the year end or on expiry of carried days or comp-off
+	LedgerCompOff      = "comp_off"      // credited for an approved comp-off claim
+)
+
+// LeaveLedgerEntry is one change to a leave balance. Entries are only ever
+// appended, in the same transaction as the change they record, so the days
+// of a balance's entries add up to its available days. Days is positive for
+// credits and negative for debits. An entry links to the leave, admin,
+// policy job run or comp-off claim that caused it.
+type LeaveLedgerEntry struct {
+	ID                 uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LeaveBalanceID     uuid.UUID  `json:"leave_balance_id" gorm:"type:uuid;not null;index"`
+	UserID             uuid.UUID  `json:"user_id" gorm:"type:uuid;not null"`
+	LeaveTypeID        uuid.UUID  `json:"leave_type_id" gorm:"type:uuid;not null"`
+	Year               int        `json:"year" gorm:"not null"`
+	Kind               string     `json:"kind" gorm:"not null"`
+	Days               float64    `json:"days" gorm:"not null"`
+	LeaveApplicationID *uuid.UUID `json:"leave_application_id" gorm:"type:uuid"`
+	ActorID            *uuid.UUID `json:"actor_id" gorm:"type:uuid"` // admin or approver who made the change
+	PolicyRunID        *uuid.UUID `json:"policy_run_id" gorm:"type:uuid"`
+	CompOffClaimID     *uuid.UUID `json:"comp_off_claim_id" gorm:"type:uuid"`
+	Note               *string    `json:"note"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// NewLedgerEntry returns an entry of kind changing balance by days.
+func NewLedgerEntry(balance *LeaveBalance, kind string, days float64) *LeaveLedgerEntry {
+	return &LeaveLedgerEntry{
+		LeaveBalanceID: balance.ID,
+		UserID:         balance.UserID,
+		LeaveTypeID:    balance.LeaveTypeID,
+		Year:           balance.Year,
+		Kind:           kind,
+		Days:           days,
+	}
+}
+
+func (e *LeaveLedgerEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
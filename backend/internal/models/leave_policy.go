@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// How often a leave policy credits its annual days
+const (
+	AccrualYearly    = "yearly"
+	AccrualQuarterly = "quarterly"
+	AccrualMonthly   = "monthly"
+)
+
+// LeavePolicy sets how balances of a leave type are credited and rolled over.
+// AnnualDays are credited in equal parts at the start of each accrual period;
+// a user hired during a period gets the share of the period they are employed
+// for. Accrual stops while the available balance is at MaxBalance, and the
+// days withheld are forfeited. At the year-end rollover up to
+// CarryForwardLimit unused days move to the new year, where they lapse after
+// CarryForwardExpiryMonths (never when zero). Of the days left, up to
+// MaxEncashDays are encashed when EncashmentEnabled; the rest lapse.
+type LeavePolicy struct {
+	ID                       uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LeaveTypeID              uuid.UUID `json:"leave_type_id" gorm:"type:uuid;not null;uniqueIndex"`
+	LeaveType                LeaveType `json:"leave_type,omitempty" gorm:"foreignKey:LeaveTypeID;references:ID"`
+	AnnualDays               float64   `json:"annual_days" gorm:"not null"`
+	AccrualFrequency         string    `json:"accrual_frequency" gorm:"not null;default:monthly"`
+	ProrateByHireDate        bool      `json:"prorate_by_hire_date" gorm:"not null;default:true"`
+	MaxBalance               *float64  `json:"max_balance"` // no cap when nil
+	CarryForwardLimit        float64   `json:"carry_forward_limit" gorm:"not null;default:0"`
+	CarryForwardExpiryMonths int       `json:"carry_forward_expiry_months" gorm:"not null;default:0"`
+	EncashmentEnabled        bool      `json:"encashment_enabled" gorm:"not null;default:false"`
+	MaxEncashDays            float64   `json:"max_encash_days" gorm:"not null;default:0"`
+	IsActive                 bool      `json:"is_active" gorm:"not null;default:true"`
+	CreatedAt                time.Time `json:"created_at"`
+	UpdatedAt                time.Time `json:"updated_at"`
+}
+
+// PeriodMonths returns the length in months of the policy's accrual period.
+func (p *LeavePolicy) PeriodMonths() int {
+	switch p.AccrualFrequency {
+	case AccrualYearly:
+		return 12
+	case AccrualQuarterly:
+		return 3
+	default:
+		return 1
+	}
+}
+
+// Leave policy job run statuses
+const (
+	PolicyRunRunning   = "running"
+	PolicyRunSucceeded = "succeeded"
+	PolicyRunFailed    = "failed"
+)
+
+// LeavePolicyRun records one run of the leave policy job and what it changed,
+// for auditing. TriggeredBy is nil for scheduled runs.
+type LeavePolicyRun struct {
+	ID              uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	AsOf            time.Time  `json:"as_of" gorm:"not null"`
+	TriggeredBy     *uuid.UUID `json:"triggered_by" gorm:"type:uuid"`
+	Status          string     `json:"status" gorm:"not null"`
+	BalancesAccrued int        `json:"balances_accrued" gorm:"not null;default:0"`
+	DaysAccrued     float64    `json:"days_accrued" gorm:"not null;default:0"`
+	BalancesRolled  int        `json:"balances_rolled" gorm:"not null;default:0"`
+	DaysCarried     float64    `json:"days_carried" gorm:"not null;default:0"`
+	DaysEncashed    float64    `json:"days_encashed" gorm:"not null;default:0"`
+	DaysLapsed      float64    `json:"days_lapsed" gorm:"not null;default:0"`
+	BalancesExpired int        `json:"balances_expired" gorm:"not null;default:0"`
+	DaysExpired     float64    `json:"days_expired" gorm:"not null;default:0"`
+	Error           *string    `json:"error"`
+	StartedAt       time.Time  `json:"started_at" gorm:"not null"`
+	FinishedAt      *time.Time `json:"finished_at"`
+}
+
+func (p *LeavePolicy) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+func (r *LeavePolicyRun) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Optional holiday selection statuses
+const (
+	OptionalHolidayPending   = "pending"
+	OptionalHolidayApproved  = "approved"
+	OptionalHolidayRejected  = "rejected"
+	OptionalHolidayCancelled = "cancelled"
+)
+
+// OptionalHolidayQuota is how many optional holidays each employee may pick
+// in Year. Years without one fall back to OPTIONAL_HOLIDAY_QUOTA.
+type OptionalHolidayQuota struct {
+	Year      int        `json:"year" gorm:"primaryKey;autoIncrement:false"`
+	Quota     int        `json:"quota" gorm:"not null"`
+	UpdatedBy *uuid.UUID `json:"updated_by" gorm:"type:uuid"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (OptionalHolidayQuota) TableName() string {
+	return "optional_holiday_quotas"
+}
+
+// OptionalHolidaySelection is an employee's pick of an optional holiday.
+// Pending and approved picks count against the quota of the holiday's year;
+// once the manager approves, the day is a day off for the employee alone.
+type OptionalHolidaySelection struct {
+	ID              uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID          uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	User            *User      `json:"user,omitempty" gorm:"foreignKey:UserID;references:ID"`
+	HolidayID       uuid.UUID  `json:"holiday_id" gorm:"type:uuid;not null;index"`
+	Holiday         *Event     `json:"holiday,omitempty" gorm:"foreignKey:HolidayID;references:ID"`
+	Note            *string    `json:"note"`
+	Status          string     `json:"status" gorm:"not null;default:pending"`
+	ReviewedBy      *uuid.UUID `json:"reviewed_by" gorm:"type:uuid"`
+	ReviewedAt      *time.Time `json:"reviewed_at"`
+	RejectionReason *string    `json:"rejection_reason"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (s *OptionalHolidaySelection) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
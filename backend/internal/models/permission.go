@@ -0,0 +1,86 @@
+package models
+
+// Permission is a single capability checked by route middleware, e.g. "leave:approve".
+type Permission string
+
+const (
+	PermLeaveView        Permission = "leave:view" // team leave views, without approving
+	PermLeaveApprove     Permission = "leave:approve"
+	PermLeaveAllocate    Permission = "leave:allocate"
+	PermTimesheetApprove Permission = "timesheet:approve"
+	PermTimesheetExport  Permission = "timesheet:export"
+	PermUserManage       Permission = "user:manage"
+	PermUserView         Permission = "user:view"
+	PermFeedManage       Permission = "feed:manage"
+	PermEmailManage      Permission = "email:manage"
+	PermTeamViewAll      Permission = "team:view_all" // company-wide visibility instead of own reporting tree
+	PermHolidayManage    Permission = "holiday:manage"
+)
+
+// rolePermissions maps each role to the permissions it grants. Admin is
+// granted everything in AllPermissions. View and approval permissions of
+// managers and team leads only reach their own reporting tree (see
+// services.TeamScope); leave:approve lets them act as approval chain steps.
+var rolePermissions = map[UserRole][]Permission{
+	RoleHR: {
+		PermLeaveView,
+		PermLeaveApprove,
+		PermTimesheetApprove,
+		PermTimesheetExport,
+		PermTeamViewAll,
+		PermHolidayManage,
+	},
+	RoleManager: {
+		PermLeaveView,
+		PermLeaveApprove,
+		PermTimesheetApprove,
+		PermTimesheetExport,
+	},
+	RoleTeamLead: {
+		PermLeaveView,
+		PermLeaveApprove,
+	},
+	RoleEmployee: {},
+}
+
+// AllPermissions lists every known permission.
+var AllPermissions = []Permission{
+	PermLeaveView,
+	PermLeaveApprove,
+	PermLeaveAllocate,
+	PermTimesheetApprove,
+	PermTimesheetExport,
+	PermUserManage,
+	PermUserView,
+	PermFeedManage,
+	PermTeamViewAll,
+	PermEmailManage,
+	PermHolidayManage,
+}
+
+// PermissionsForRole returns the permissions granted to role.
+func PermissionsForRole(role UserRole) []Permission {
+	if role == RoleAdmin {
+		return append([]Permission(nil), AllPermissions...)
+	}
+	return append([]Permission(nil), rolePermissions[role]...)
+}
+
+// HasPermission reports whether role grants permission.
+func (r UserRole) HasPermission(permission Permission) bool {
+	for _, p := range PermissionsForRole(r) {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValid reports whether r is one of the known roles.
+func (r UserRole) IsValid() bool {
+	switch r {
+	case RoleAdmin, RoleHR, RoleManager, RoleTeamLead, RoleEmployee:
+		return true
+	}
+	return false
+}
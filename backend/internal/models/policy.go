@@ -23,15 +23,38 @@ type Policy struct {
 	UpdatedAt     time.Time  `json:"updated_at"`
 }
 
+// Notification types recorded by services.NotificationService
+const (
+	NotificationLeaveRequested             = "leave_requested"
+	NotificationLeaveApprovalRequested     = "leave_approval_requested"
+	NotificationLeaveApprovalEscalated     = "leave_approval_escalated"
+	NotificationLeaveApproved              = "leave_approved"
+	NotificationLeaveRejected              = "leave_rejected"
+	NotificationLeaveCancellationRequested = "leave_cancellation_requested"
+	NotificationLeaveCancellationApproved  = "leave_cancellation_approved"
+	NotificationLeaveCancellationRejected  = "leave_cancellation_rejected"
+	NotificationUserApproved               = "user_approved"
+	NotificationUserRejected               = "user_rejected"
+	NotificationTimesheetSubmitted         = "timesheet_submitted"
+	NotificationCompOffClaimed             = "comp_off_claimed"
+	NotificationCompOffApproved            = "comp_off_approved"
+	NotificationCompOffRejected            = "comp_off_rejected"
+	NotificationOptionalHolidaySelected    = "optional_holiday_selected"
+	NotificationOptionalHolidayApproved    = "optional_holiday_approved"
+	NotificationOptionalHolidayRejected    = "optional_holiday_rejected"
+)
+
 type Notification struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	UserID    uuid.UUID `json:"user_id" gorm:"not null"`
-	User      User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	Title     string    `json:"title" gorm:"not null"`
-	Message   string    `json:"message" gorm:"not null"`
-	Type      *string   `json:"type"` // leave_approved, timesheet_reminder, etc.
-	IsRead    bool      `json:"is_read" gorm:"default:false"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"not null;index:idx_notifications_user_read"`
+	User      User       `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Title     string     `json:"title" gorm:"not null"`
+	Message   string     `json:"message" gorm:"not null"`
+	Type      *string    `json:"type"`      // leave_approved, timesheet_reminder, etc.
+	EntityID  *uuid.UUID `json:"entity_id"` // leave application, user, etc. the notification refers to
+	IsRead    bool       `json:"is_read" gorm:"default:false;index:idx_notifications_user_read"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
 }
 
 func (p *Policy) BeforeCreate(tx *gorm.DB) error {
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserSession is a server-side login session (one per device). It holds the
+// hash of the current refresh token; access tokens reference it via the sid claim.
+type UserSession struct {
+	ID                uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID            uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	User              User       `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	RefreshTokenHash  string     `json:"-" gorm:"not null;uniqueIndex"`
+	PreviousTokenHash *string    `json:"-" gorm:"index"` // last rotated-out token, used for reuse detection
+	UserAgent         *string    `json:"user_agent"`
+	IPAddress         *string    `json:"ip_address"`
+	ExpiresAt         time.Time  `json:"expires_at" gorm:"not null"`
+	LastUsedAt        *time.Time `json:"last_used_at"`
+	RevokedAt         *time.Time `json:"revoked_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// RevokedToken records an access token (by jti) that must no longer be accepted.
+// Rows can be purged once ExpiresAt has passed.
+type RevokedToken struct {
+	JTI       string    `json:"jti" gorm:"primaryKey"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (s *UserSession) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
@@ -7,6 +7,16 @@ import (
 	"gorm.io/gorm"
 )
 
+// Timesheet entry statuses. Draft and returned entries can still be edited by
+// the employee; submitted and approved entries are locked.
+const (
+	TimesheetStatusDraft     = "draft"
+	TimesheetStatusSubmitted = "submitted"
+	TimesheetStatusApproved  = "approved"
+	TimesheetStatusRejected  = "rejected"
+	TimesheetStatusReturned  = "returned"
+)
+
 type Project struct {
 	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	Name        string     `json:"name" gorm:"not null"`
@@ -35,10 +45,17 @@ type TimesheetEntry struct {
 	ApprovedBy       *uuid.UUID `json:"approved_by" gorm:"type:uuid"`
 	Approver         *User      `json:"approver,omitempty" gorm:"foreignKey:ApprovedBy;references:ID"`
 	ApprovedAt       *time.Time `json:"approved_at"`
+	RejectionReason  *string    `json:"rejection_reason"` // reason for rejection or return-for-correction
 	CreatedAt        time.Time  `json:"created_at"`
 	UpdatedAt        time.Time  `json:"updated_at"`
 }
 
+// IsLocked reports whether the entry is under review or already approved and
+// therefore can no longer be changed by the employee.
+func (te *TimesheetEntry) IsLocked() bool {
+	return te.Status == TimesheetStatusSubmitted || te.Status == TimesheetStatusApproved
+}
+
 func (p *Project) BeforeCreate(tx *gorm.DB) error {
 	if p.ID == uuid.Nil {
 		p.ID = uuid.New()
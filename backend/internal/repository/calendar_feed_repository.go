@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"employee-dashboard-api/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CalendarFeedRepository stores iCalendar subscription URLs. Revoked feeds
+// are kept but never returned.
+type CalendarFeedRepository interface {
+	// ListForUser returns userID's feeds, newest first.
+	ListForUser(userID uuid.UUID) ([]models.CalendarFeed, error)
+	// FindForUser loads a feed only if userID owns it.
+	FindForUser(id, userID uuid.UUID) (*models.CalendarFeed, error)
+	FindByTokenHash(hash string) (*models.CalendarFeed, error)
+	Create(feed *models.CalendarFeed) error
+	Update(feed *models.CalendarFeed, updates map[string]interface{}) error
+}
+
+type GormCalendarFeedRepository struct {
+	db *gorm.DB
+}
+
+func NewGormCalendarFeedRepository(db *gorm.DB) *GormCalendarFeedRepository {
+	return &GormCalendarFeedRepository{db: db}
+}
+
+func (r *GormCalendarFeedRepository) ListForUser(userID uuid.UUID) ([]models.CalendarFeed, error) {
+	var feeds []models.CalendarFeed
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at DESC").Find(&feeds).Error
+	return feeds, err
+}
+
+func (r *GormCalendarFeedRepository) FindForUser(id, userID uuid.UUID) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	if err := r.db.Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).First(&feed).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &feed, nil
+}
+
+func (r *GormCalendarFeedRepository) FindByTokenHash(hash string) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	if err := r.db.Where("token_hash = ? AND revoked_at IS NULL", hash).First(&feed).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &feed, nil
+}
+
+func (r *GormCalendarFeedRepository) Create(feed *models.CalendarFeed) error {
+	return r.db.Create(feed).Error
+}
+
+func (r *GormCalendarFeedRepository) Update(feed *models.CalendarFeed, updates map[string]interface{}) error {
+	return r.db.Model(feed).Updates(updates).Error
+}
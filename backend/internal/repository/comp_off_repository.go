@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"employee-dashboard-api/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CompOffFilter narrows a comp-off claim listing. Zero fields do not filter.
+type CompOffFilter struct {
+	UserID uuid.UUID
+	Status string
+	Scope  UserScope // restricts to the claims of these users
+}
+
+// CompOffRepository stores comp-off claims.
+type CompOffRepository interface {
+	// List returns one page of matching claims, newest work date first, with
+	// their claimant, and the total match count.
+	List(filter CompOffFilter, page Page) ([]models.CompOffClaim, int64, error)
+	// FindByID loads a claim with its claimant.
+	FindByID(id uuid.UUID) (*models.CompOffClaim, error)
+	// ListForUserBetween returns userID's claims, rejected ones included, for
+	// work dates from..to inclusive.
+	ListForUserBetween(userID uuid.UUID, from, to time.Time) ([]models.CompOffClaim, error)
+	// FindLive finds userID's claim for workDate unless it was rejected.
+	FindLive(userID uuid.UUID, workDate time.Time) (*models.CompOffClaim, error)
+	// Create inserts a claim, failing with ErrDuplicate if the work date
+	// already has a claim that was not rejected.
+	Create(claim *models.CompOffClaim) error
+	// Transition applies updates only while the claim is still in status
+	// from, failing with ErrConflict otherwise.
+	Transition(claim *models.CompOffClaim, from string, updates map[string]interface{}) error
+	// ListExpiring returns the approved claims that expire by asOf, soonest
+	// first.
+	ListExpiring(asOf time.Time) ([]models.CompOffClaim, error)
+	// ApprovedDays sums the days of the approved claims credited to a
+	// balance, except excludeID.
+	ApprovedDays(balanceID, excludeID uuid.UUID) (float64, error)
+}
+
+type GormCompOffRepository struct {
+	db *gorm.DB
+}
+
+func NewGormCompOffRepository(db *gorm.DB) *GormCompOffRepository {
+	return &GormCompOffRepository{db: db}
+}
+
+func (r *GormCompOffRepository) List(filter CompOffFilter, page Page) ([]models.CompOffClaim, int64, error) {
+	query := r.db.Model(&models.CompOffClaim{})
+	if filter.Scope != nil {
+		query = filter.Scope.Apply(query, "user_id")
+	}
+	if filter.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var claims []models.CompOffClaim
+	err := page.apply(query.Preload("User")).
+		Order("work_date DESC, created_at DESC").
+		Find(&claims).Error
+	return claims, total, err
+}
+
+func (r *GormCompOffRepository) FindByID(id uuid.UUID) (*models.CompOffClaim, error) {
+	var claim models.CompOffClaim
+	if err := r.db.Preload("User").First(&claim, "id = ?", id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &claim, nil
+}
+
+func (r *GormCompOffRepository) ListForUserBetween(userID uuid.UUID, from, to time.Time) ([]models.CompOffClaim, error) {
+	var claims []models.CompOffClaim
+	err := r.db.Where("user_id = ? AND work_date >= ? AND work_date < ?", userID, from, to.AddDate(0, 0, 1)).
+		Order("work_date, created_at").
+		Find(&claims).Error
+	return claims, err
+}
+
+func (r *GormCompOffRepository) FindLive(userID uuid.UUID, workDate time.Time) (*models.CompOffClaim, error) {
+	var claim models.CompOffClaim
+	if err := r.db.Where("user_id = ? AND work_date = ? AND status <> ?", userID, workDate, models.CompOffRejected).
+		First(&claim).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &claim, nil
+}
+
+func (r *GormCompOffRepository) Create(claim *models.CompOffClaim) error {
+	return translateDuplicate(r.db, r.db.Create(claim).Error)
+}
+
+func (r *GormCompOffRepository) Transition(claim *models.CompOffClaim, from string, updates map[string]interface{}) error {
+	result := r.db.Model(claim).Where("status = ?", from).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *GormCompOffRepository) ListExpiring(asOf time.Time) ([]models.CompOffClaim, error) {
+	var claims []models.CompOffClaim
+	err := r.db.Where("status = ? AND expires_at <= ?", models.CompOffApproved, asOf).
+		Order("expires_at, created_at").
+		Find(&claims).Error
+	return claims, err
+}
+
+func (r *GormCompOffRepository) ApprovedDays(balanceID, excludeID uuid.UUID) (float64, error) {
+	var days float64
+	err := r.db.Model(&models.CompOffClaim{}).
+		Select("COALESCE(SUM(days), 0)").
+		Where("leave_balance_id = ? AND status = ? AND id <> ?", balanceID, models.CompOffApproved, excludeID).
+		Scan(&days).Error
+	return days, err
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"employee-dashboard-api/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DocumentFilter narrows a document listing. An empty Category does not filter.
+type DocumentFilter struct {
+	UserID   uuid.UUID
+	Category string
+}
+
+// DocumentRepository stores the metadata of uploaded documents.
+type DocumentRepository interface {
+	// List returns one page of matching documents, latest upload first, and
+	// the total match count.
+	List(filter DocumentFilter, page Page) ([]models.Document, int64, error)
+	// FindForUser loads a document only if userID uploaded it.
+	FindForUser(id, userID uuid.UUID) (*models.Document, error)
+	Create(document *models.Document) error
+	Delete(document *models.Document) error
+	// Categories returns the distinct categories userID has used.
+	Categories(userID uuid.UUID) ([]string, error)
+}
+
+type GormDocumentRepository struct {
+	db *gorm.DB
+}
+
+func NewGormDocumentRepository(db *gorm.DB) *GormDocumentRepository {
+	return &GormDocumentRepository{db: db}
+}
+
+func (r *GormDocumentRepository) List(filter DocumentFilter, page Page) ([]models.Document, int64, error) {
+	query := r.db.Model(&models.Document{}).Where("user_id = ?", filter.UserID)
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var documents []models.Document
+	err := page.apply(query).Order("uploaded_at DESC").Find(&documents).Error
+	return documents, total, err
+}
+
+func (r *GormDocumentRepository) FindForUser(id, userID uuid.UUID) (*models.Document, error) {
+	var document models.Document
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&document).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &document, nil
+}
+
+func (r *GormDocumentRepository) Create(document *models.Document) error {
+	return r.db.Create(document).Error
+}
+
+func (r *GormDocumentRepository) Delete(document *models.Document) error {
+	return r.db.Delete(document).Error
+}
+
+func (r *GormDocumentRepository) Categories(userID uuid.UUID) ([]string, error) {
+	var categories []string
+	err := r.db.Model(&models.Document{}).
+		Where("user_id = ? AND category IS NOT NULL", userID).
+		Distinct("category").
+		Pluck("category", &categories).Error
+	return categories, err
+}
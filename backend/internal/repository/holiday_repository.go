@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"employee-dashboard-api/internal/database"
+	"employee-dashboard-api/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// holidayEventType is the event type holidays are stored under in the
+// events table.
+const holidayEventType = "holiday"
+
+// HolidayRepository stores holidays, the rows of the events table of type
+// holiday.
+type HolidayRepository interface {
+	// ListForYear returns the holidays of year ordered by date.
+	ListForYear(year int) ([]models.Event, error)
+	// ListUpcoming returns up to limit holidays from from on, soonest first.
+	ListUpcoming(from time.Time, limit int) ([]models.Event, error)
+	// ListBetween returns the holidays from from to to inclusive, ordered by
+	// date.
+	ListBetween(from, to time.Time) ([]models.Event, error)
+	FindByID(id uuid.UUID) (*models.Event, error)
+	// Create stores holiday as a holiday event.
+	Create(holiday *models.Event) error
+	Update(holiday *models.Event, updates map[string]interface{}) error
+	Delete(holiday *models.Event) error
+	Count() (int64, error)
+}
+
+type GormHolidayRepository struct {
+	db *gorm.DB
+}
+
+func NewGormHolidayRepository(db *gorm.DB) *GormHolidayRepository {
+	return &GormHolidayRepository{db: db}
+}
+
+func (r *GormHolidayRepository) ListForYear(year int) ([]models.Event, error) {
+	var holidays []models.Event
+	err := r.db.Where("event_type = ? AND "+database.YearOf(r.db, "event_date")+" = ?", holidayEventType, year).
+		Order("event_date ASC").
+		Find(&holidays).Error
+	return holidays, err
+}
+
+func (r *GormHolidayRepository) ListUpcoming(from time.Time, limit int) ([]models.Event, error) {
+	var holidays []models.Event
+	err := r.db.Where("event_type = ? AND event_date >= ?", holidayEventType, from).
+		Order("event_date ASC").
+		Limit(limit).
+		Find(&holidays).Error
+	return holidays, err
+}
+
+func (r *GormHolidayRepository) ListBetween(from, to time.Time) ([]models.Event, error) {
+	var holidays []models.Event
+	err := r.db.Where("event_type = ? AND event_date >= ? AND event_date < ?", holidayEventType, from, to.AddDate(0, 0, 1)).
+		Order("event_date ASC, title ASC").
+		Find(&holidays).Error
+	return holidays, err
+}
+
+func (r *GormHolidayRepository) FindByID(id uuid.UUID) (*models.Event, error) {
+	var holiday models.Event
+	if err := r.db.Where("id = ? AND event_type = ?", id, holidayEventType).First(&holiday).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &holiday, nil
+}
+
+func (r *GormHolidayRepository) Create(holiday *models.Event) error {
+	holiday.EventType = holidayEventType
+	return r.db.Create(holiday).Error
+}
+
+func (r *GormHolidayRepository) Update(holiday *models.Event, updates map[string]interface{}) error {
+	return r.db.Model(holiday).Updates(updates).Error
+}
+
+func (r *GormHolidayRepository) Delete(holiday *models.Event) error {
+	return r.db.Delete(holiday).Error
+}
+
+func (r *GormHolidayRepository) Count() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Event{}).Where("event_type = ?", holidayEventType).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"employee-dashboard-api/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LeaveApprovalRepository stores leave approval chains, the approval steps
+// of leave applications and out-of-office delegations of approvers.
+type LeaveApprovalRepository interface {
+	// ListChains returns every chain with its leave type and steps.
+	ListChains() ([]models.LeaveApprovalChain, error)
+	// ListActiveChains returns the active chains with their steps.
+	ListActiveChains() ([]models.LeaveApprovalChain, error)
+	FindChain(id uuid.UUID) (*models.LeaveApprovalChain, error)
+	// SaveChain creates the chain or updates the one with its ID, replacing
+	// its steps.
+	SaveChain(chain *models.LeaveApprovalChain) error
+	DeleteChain(chain *models.LeaveApprovalChain) error
+
+	// ReplaceApprovals sets the approval steps of an application.
+	ReplaceApprovals(leave *models.LeaveApplication, approvals []models.LeaveApproval) error
+	// Transition applies updates only while the step is still in status
+	// from, failing with ErrConflict otherwise.
+	Transition(approval *models.LeaveApproval, from string, updates map[string]interface{}) error
+	// ListOpen returns the steps being decided of pending applications,
+	// oldest first.
+	ListOpen() ([]models.LeaveApproval, error)
+	// ListOverdue returns the open steps due by asOf that have not been
+	// escalated yet.
+	ListOverdue(asOf time.Time) ([]models.LeaveApproval, error)
+
+	CreateDelegation(delegation *models.ApprovalDelegation) error
+	FindDelegation(id uuid.UUID) (*models.ApprovalDelegation, error)
+	// ListDelegations returns the delegations userID gave or received, with
+	// both users, newest first.
+	ListDelegations(userID uuid.UUID) ([]models.ApprovalDelegation, error)
+	// ListDelegationsTo returns the delegations to delegateID in effect on
+	// the day of on, with their delegator.
+	ListDelegationsTo(delegateID uuid.UUID, on time.Time) ([]models.ApprovalDelegation, error)
+	// ListDelegationsFrom returns the delegations by delegatorID in effect
+	// on the day of on.
+	ListDelegationsFrom(delegatorID uuid.UUID, on time.Time) ([]models.ApprovalDelegation, error)
+	RevokeDelegation(delegation *models.ApprovalDelegation) error
+}
+
+type GormLeaveApprovalRepository struct {
+	db *gorm.DB
+}
+
+func NewGormLeaveApprovalRepository(db *gorm.DB) *GormLeaveApprovalRepository {
+	return &GormLeaveApprovalRepository{db: db}
+}
+
+// byPosition preloads chain and approval steps in order.
+func byPosition(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC")
+}
+
+func (r *GormLeaveApprovalRepository) ListChains() ([]models.LeaveApprovalChain, error) {
+	var chains []models.LeaveApprovalChain
+	err := r.db.Preload("LeaveType").Preload("Steps", byPosition).Order("created_at").Find(&chains).Error
+	return chains, err
+}
+
+func (r *GormLeaveApprovalRepository) ListActiveChains() ([]models.LeaveApprovalChain, error) {
+	var chains []models.LeaveApprovalChain
+	err := r.db.Preload("Steps", byPosition).Where("is_active = true").Order("created_at").Find(&chains).Error
+	return chains, err
+}
+
+func (r *GormLeaveApprovalRepository) FindChain(id uuid.UUID) (*models.LeaveApprovalChain, error) {
+	var chain models.LeaveApprovalChain
+	if err := r.db.Preload("LeaveType").Preload("Steps", byPosition).First(&chain, "id = ?", id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &chain, nil
+}
+
+func (r *GormLeaveApprovalRepository) SaveChain(chain *models.LeaveApprovalChain) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(chain).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("chain_id = ?", chain.ID).Delete(&models.LeaveApprovalStep{}).Error; err != nil {
+			return err
+		}
+		if len(chain.Steps) == 0 {
+			return nil
+		}
+		for i := range chain.Steps {
+			chain.Steps[i].ID = uuid.Nil
+			chain.Steps[i].ChainID = chain.ID
+		}
+		return tx.Create(&chain.Steps).Error
+	})
+}
+
+func (r *GormLeaveApprovalRepository) DeleteChain(chain *models.LeaveApprovalChain) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("chain_id = ?", chain.ID).Delete(&models.LeaveApprovalStep{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(chain).Error
+	})
+}
+
+func (r *GormLeaveApprovalRepository) ReplaceApprovals(leave *models.LeaveApplication, approvals []models.LeaveApproval) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("leave_application_id = ?", leave.ID).Delete(&models.LeaveApproval{}).Error; err != nil {
+			return err
+		}
+		if len(approvals) == 0 {
+			return nil
+		}
+		for i := range approvals {
+			approvals[i].LeaveApplicationID = leave.ID
+		}
+		return tx.Create(&approvals).Error
+	})
+}
+
+func (r *GormLeaveApprovalRepository) Transition(approval *models.LeaveApproval, from string, updates map[string]interface{}) error {
+	result := r.db.Model(approval).Where("status = ?", from).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// openApprovals selects the steps being decided of pending applications.
+func (r *GormLeaveApprovalRepository) openApprovals() *gorm.DB {
+	return r.db.Joins("JOIN leave_applications ON leave_applications.id = leave_approvals.leave_application_id").
+		Where("leave_approvals.status = ? AND leave_applications.status = ?", models.ApprovalPending, models.LeaveStatusPending)
+}
+
+func (r *GormLeaveApprovalRepository) ListOpen() ([]models.LeaveApproval, error) {
+	var approvals []models.LeaveApproval
+	err := r.openApprovals().Order("leave_approvals.created_at").Find(&approvals).Error
+	return approvals, err
+}
+
+func (r *GormLeaveApprovalRepository) ListOverdue(asOf time.Time) ([]models.LeaveApproval, error) {
+	var approvals []models.LeaveApproval
+	err := r.openApprovals().
+		Where("leave_approvals.due_at <= ? AND leave_approvals.escalated_at IS NULL", asOf).
+		Order("leave_approvals.due_at").
+		Find(&approvals).Error
+	return approvals, err
+}
+
+func (r *GormLeaveApprovalRepository) CreateDelegation(delegation *models.ApprovalDelegation) error {
+	return r.db.Create(delegation).Error
+}
+
+func (r *GormLeaveApprovalRepository) FindDelegation(id uuid.UUID) (*models.ApprovalDelegation, error) {
+	var delegation models.ApprovalDelegation
+	if err := r.db.First(&delegation, "id = ?", id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &delegation, nil
+}
+
+func (r *GormLeaveApprovalRepository) ListDelegations(userID uuid.UUID) ([]models.ApprovalDelegation, error) {
+	var delegations []models.ApprovalDelegation
+	err := r.db.Preload("Delegator").Preload("Delegate").
+		Where("delegator_id = ? OR delegate_id = ?", userID, userID).
+		Order("created_at DESC").
+		Find(&delegations).Error
+	return delegations, err
+}
+
+// delegationsOn selects the delegations in effect on the day of on.
+func (r *GormLeaveApprovalRepository) delegationsOn(on time.Time) *gorm.DB {
+	day := time.Date(on.Year(), on.Month(), on.Day(), 0, 0, 0, 0, time.UTC)
+	return r.db.Where("revoked_at IS NULL AND start_date <= ? AND end_date >= ?", day, day)
+}
+
+func (r *GormLeaveApprovalRepository) ListDelegationsTo(delegateID uuid.UUID, on time.Time) ([]models.ApprovalDelegation, error) {
+	var delegations []models.ApprovalDelegation
+	err := r.delegationsOn(on).Preload("Delegator").Where("delegate_id = ?", delegateID).Find(&delegations).Error
+	return delegations, err
+}
+
+func (r *GormLeaveApprovalRepository) ListDelegationsFrom(delegatorID uuid.UUID, on time.Time) ([]models.ApprovalDelegation, error) {
+	var delegations []models.ApprovalDelegation
+	err := r.delegationsOn(on).Where("delegator_id = ?", delegatorID).Find(&delegations).Error
+	return delegations, err
+}
+
+func (r *GormLeaveApprovalRepository) RevokeDelegation(delegation *models.ApprovalDelegation) error {
+	now := time.Now()
+	if err := r.db.Model(delegation).Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+	delegation.RevokedAt = &now
+	return nil
+}
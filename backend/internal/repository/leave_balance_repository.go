@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"employee-dashboard-api/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LeaveBalanceRepository stores the yearly per-type leave allocations.
+type LeaveBalanceRepository interface {
+	Find(userID, leaveTypeID uuid.UUID, year int) (*models.LeaveBalance, error)
+	FindByID(id uuid.UUID) (*models.LeaveBalance, error)
+	// ListForUser returns a user's balances for year with their leave types.
+	ListForUser(userID uuid.UUID, year int) ([]models.LeaveBalance, error)
+	Create(balance *models.LeaveBalance) error
+	// AddUsedDays adds days to the balance's used days, failing with
+	// ErrConflict if the balance changed since it was read.
+	AddUsedDays(balance *models.LeaveBalance, days float64) error
+	// ListForYear returns every balance of year.
+	ListForYear(year int) ([]models.LeaveBalance, error)
+	// ListForType returns the balances of leaveTypeID for year.
+	ListForType(leaveTypeID uuid.UUID, year int) ([]models.LeaveBalance, error)
+	// ListCarryExpired returns the open balances whose carried days expired
+	// by asOf and have not been lapsed yet.
+	ListCarryExpired(asOf time.Time) ([]models.LeaveBalance, error)
+	// Update applies updates to the balance, failing with ErrConflict if the
+	// balance changed since it was read.
+	Update(balance *models.LeaveBalance, updates map[string]interface{}) error
+	Count() (int64, error)
+}
+
+type GormLeaveBalanceRepository struct {
+	db *gorm.DB
+}
+
+func NewGormLeaveBalanceRepository(db *gorm.DB) *GormLeaveBalanceRepository {
+	return &GormLeaveBalanceRepository{db: db}
+}
+
+func (r *GormLeaveBalanceRepository) Find(userID, leaveTypeID uuid.UUID, year int) (*models.LeaveBalance, error) {
+	var balance models.LeaveBalance
+	if err := r.db.Where("user_id = ? AND leave_type_id = ? AND year = ?",
+		userID, leaveTypeID, year).First(&balance).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &balance, nil
+}
+
+func (r *GormLeaveBalanceRepository) FindByID(id uuid.UUID) (*models.LeaveBalance, error) {
+	var balance models.LeaveBalance
+	if err := r.db.First(&balance, "id = ?", id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &balance, nil
+}
+
+func (r *GormLeaveBalanceRepository) ListForUser(userID uuid.UUID, year int) ([]models.LeaveBalance, error) {
+	var balances []models.LeaveBalance
+	err := r.db.Preload("LeaveType").Where("user_id = ? AND year = ?", userID, year).Find(&balances).Error
+	return balances, err
+}
+
+func (r *GormLeaveBalanceRepository) Create(balance *models.LeaveBalance) error {
+	return r.db.Create(balance).Error
+}
+
+func (r *GormLeaveBalanceRepository) AddUsedDays(balance *models.LeaveBalance, days float64) error {
+	result := r.db.Model(&models.LeaveBalance{}).
+		Where("id = ? AND version = ?", balance.ID, balance.Version).
+		Updates(map[string]interface{}{
+			"used_days":  gorm.Expr("used_days + ?", days),
+			"version":    gorm.Expr("version + 1"),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConflict
+	}
+	balance.UsedDays += days
+	balance.Version++
+	return nil
+}
+
+func (r *GormLeaveBalanceRepository) ListForYear(year int) ([]models.LeaveBalance, error) {
+	var balances []models.LeaveBalance
+	err := r.db.Where("year = ?", year).Find(&balances).Error
+	return balances, err
+}
+
+func (r *GormLeaveBalanceRepository) ListForType(leaveTypeID uuid.UUID, year int) ([]models.LeaveBalance, error) {
+	var balances []models.LeaveBalance
+	err := r.db.Where("leave_type_id = ? AND year = ?", leaveTypeID, year).Find(&balances).Error
+	return balances, err
+}
+
+func (r *GormLeaveBalanceRepository) ListCarryExpired(asOf time.Time) ([]models.LeaveBalance, error) {
+	var balances []models.LeaveBalance
+	err := r.db.Where("carry_expires_at <= ? AND carried_days > 0 AND expired_days = 0 AND closed_at IS NULL", asOf).Find(&balances).Error
+	return balances, err
+}
+
+func (r *GormLeaveBalanceRepository) Update(balance *models.LeaveBalance, updates map[string]interface{}) error {
+	values := map[string]interface{}{
+		"version":    gorm.Expr("version + 1"),
+		"updated_at": time.Now(),
+	}
+	for column, value := range updates {
+		values[column] = value
+	}
+	result := r.db.Model(&models.LeaveBalance{}).
+		Where("id = ? AND version = ?", balance.ID, balance.Version).
+		Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConflict
+	}
+	balance.Version++
+	return nil
+}
+
+func (r *GormLeaveBalanceRepository) Count() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.LeaveBalance{}).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"employee-dashboard-api/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LeaveLedgerRepository stores the append-only record of leave balance
+// changes. Entries are never updated or deleted.
+type LeaveLedgerRepository interface {
+	Append(entry *models.LeaveLedgerEntry) error
+	// ListForUser returns a user's entries for year, oldest first.
+	ListForUser(userID uuid.UUID, year int) ([]models.LeaveLedgerEntry, error)
+	// TotalsByBalance returns the sum of the entries of each balance of year.
+	TotalsByBalance(year int) (map[uuid.UUID]float64, error)
+}
+
+type GormLeaveLedgerRepository struct {
+	db *gorm.DB
+}
+
+func NewGormLeaveLedgerRepository(db *gorm.DB) *GormLeaveLedgerRepository {
+	return &GormLeaveLedgerRepository{db: db}
+}
+
+func (r *GormLeaveLedgerRepository) Append(entry *models.LeaveLedgerEntry) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *GormLeaveLedgerRepository) ListForUser(userID uuid.UUID, year int) ([]models.LeaveLedgerEntry, error) {
+	var entries []models.LeaveLedgerEntry
+	err := r.db.Where("user_id = ? AND year = ?", userID, year).Order("created_at ASC").Find(&entries).Error
+	return entries, err
+}
+
+func (r *GormLeaveLedgerRepository) TotalsByBalance(year int) (map[uuid.UUID]float64, error) {
+	var rows []struct {
+		LeaveBalanceID uuid.UUID
+		Total          float64
+	}
+	if err := r.db.Model(&models.LeaveLedgerEntry{}).
+		Select("leave_balance_id, SUM(days) AS total").
+		Where("year = ?", year).
+		Group("leave_balance_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	totals := make(map[uuid.UUID]float64, len(rows))
+	for _, row := range rows {
+		totals[row.LeaveBalanceID] = row.Total
+	}
+	return totals, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"employee-dashboard-api/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LeavePolicyRepository stores the accrual and rollover policies of leave
+// types and the log of the job that applies them.
+type LeavePolicyRepository interface {
+	// List returns every policy with its leave type.
+	List() ([]models.LeavePolicy, error)
+	// ListActive returns the active policies of active leave types.
+	ListActive() ([]models.LeavePolicy, error)
+	FindByLeaveType(leaveTypeID uuid.UUID) (*models.LeavePolicy, error)
+	// Save creates the policy or updates the one with its ID.
+	Save(policy *models.LeavePolicy) error
+
+	CreateRun(run *models.LeavePolicyRun) error
+	SaveRun(run *models.LeavePolicyRun) error
+	// ListRuns returns one page of job runs, newest first, and the total
+	// run count.
+	ListRuns(page Page) ([]models.LeavePolicyRun, int64, error)
+}
+
+type GormLeavePolicyRepository struct {
+	db *gorm.DB
+}
+
+func NewGormLeavePolicyRepository(db *gorm.DB) *GormLeavePolicyRepository {
+	return &GormLeavePolicyRepository{db: db}
+}
+
+func (r *GormLeavePolicyRepository) List() ([]models.LeavePolicy, error) {
+	var policies []models.LeavePolicy
+	err := r.db.Preload("LeaveType").Order("created_at").Find(&policies).Error
+	return policies, err
+}
+
+func (r *GormLeavePolicyRepository) ListActive() ([]models.LeavePolicy, error) {
+	var policies []models.LeavePolicy
+	err := r.db.Preload("LeaveType").
+		Joins("JOIN leave_types ON leave_types.id = leave_policies.leave_type_id").
+		Where("leave_policies.is_active = true AND leave_types.is_active = true").
+		Order("leave_policies.created_at").
+		Find(&policies).Error
+	return policies, err
+}
+
+func (r *GormLeavePolicyRepository) FindByLeaveType(leaveTypeID uuid.UUID) (*models.LeavePolicy, error) {
+	var policy models.LeavePolicy
+	if err := r.db.Preload("LeaveType").Where("leave_type_id = ?", leaveTypeID).First(&policy).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &policy, nil
+}
+
+func (r *GormLeavePolicyRepository) Save(policy *models.LeavePolicy) error {
+	return r.db.Omit(clause.Associations).Save(policy).Error
+}
+
+func (r *GormLeavePolicyRepository) CreateRun(run *models.LeavePolicyRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *GormLeavePolicyRepository) SaveRun(run *models.LeavePolicyRun) error {
+	return r.db.Save(run).Error
+}
+
+func (r *GormLeavePolicyRepository) ListRuns(page Page) ([]models.LeavePolicyRun, int64, error) {
+	query := r.db.Model(&models.LeavePolicyRun{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var runs []models.LeavePolicyRun
+	err := page.apply(query).Order("started_at DESC").Find(&runs).Error
+	return runs, total, err
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"employee-dashboard-api/internal/database"
+	"employee-dashboard-api/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LeaveFilter narrows a leave application listing. Zero fields do not filter.
+type LeaveFilter struct {
+	UserID uuid.UUID
+	Status string
+	Year   int // calendar year of start_date
+	Scope  UserScope
+}
+
+// LeaveRepository stores leave applications and the leave types they
+// reference.
+type LeaveRepository interface {
+	// List returns one page of matching applications, newest first, with
+	// their leave type, applicant, approver, sessions and approval steps, and
+	// the total match count.
+	List(filter LeaveFilter, page Page) ([]models.LeaveApplication, int64, error)
+	// FindByID loads an application with its leave type, applicant, approver,
+	// sessions, status history and approval steps.
+	FindByID(id uuid.UUID) (*models.LeaveApplication, error)
+	// ListByIDs loads the applications among ids with their leave type,
+	// applicant, approver, sessions and approval steps.
+	ListByIDs(ids []uuid.UUID) ([]models.LeaveApplication, error)
+	// FindForUser loads an application with its sessions only if userID
+	// applied for it.
+	FindForUser(id, userID uuid.UUID) (*models.LeaveApplication, error)
+	Create(leave *models.LeaveApplication) error
+	Update(leave *models.LeaveApplication, updates map[string]interface{}) error
+	// Transition applies updates only while the leave is still in status
+	// from, failing with ErrConflict otherwise.
+	Transition(leave *models.LeaveApplication, from string, updates map[string]interface{}) error
+	Delete(leave *models.LeaveApplication) error
+	// ListOverlapping returns userID's pending and approved applications,
+	// other than excludeID, that share a day with start to end, with their
+	// sessions.
+	ListOverlapping(userID uuid.UUID, start, end time.Time, excludeID uuid.UUID) ([]models.LeaveApplication, error)
+	// ListBetween returns matching applications in any of statuses that
+	// share a day with start to end, with their leave type, applicant and
+	// sessions. filter.Status and filter.Year are ignored.
+	ListBetween(filter LeaveFilter, statuses []string, start, end time.Time) ([]models.LeaveApplication, error)
+	// ReplaceSessions sets the half days of an application.
+	ReplaceSessions(leave *models.LeaveApplication, sessions []models.LeaveSession) error
+	// AddStatusChange appends an entry to an application's status history.
+	AddStatusChange(change *models.LeaveStatusChange) error
+	// ListStatusChanges returns an application's status history, oldest first.
+	ListStatusChanges(leaveID uuid.UUID) ([]models.LeaveStatusChange, error)
+	// CountStatusChanges returns how many status history entries each of
+	// leaveIDs has.
+	CountStatusChanges(leaveIDs []uuid.UUID) (map[uuid.UUID]int, error)
+	// WereApproved reports which of leaveIDs were approved at some point,
+	// by their status history.
+	WereApproved(leaveIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+
+	FindActiveType(id uuid.UUID) (*models.LeaveType, error)
+	FindActiveTypeByName(name string) (*models.LeaveType, error)
+	ListActiveTypes() ([]models.LeaveType, error)
+	CountTypes() (int64, error)
+	CreateType(leaveType *models.LeaveType) error
+}
+
+type GormLeaveRepository struct {
+	db *gorm.DB
+}
+
+func NewGormLeaveRepository(db *gorm.DB) *GormLeaveRepository {
+	return &GormLeaveRepository{db: db}
+}
+
+func (r *GormLeaveRepository) List(filter LeaveFilter, page Page) ([]models.LeaveApplication, int64, error) {
+	query := r.db.Model(&models.LeaveApplication{})
+	if filter.Scope != nil {
+		query = filter.Scope.Apply(query, "user_id")
+	}
+	if filter.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Year != 0 {
+		query = query.Where(database.YearOf(r.db, "start_date")+" = ?", filter.Year)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var leaves []models.LeaveApplication
+	err := page.apply(query.Preload("LeaveType").Preload("User").Preload("Approver").Preload("Sessions").Preload("Approvals", byPosition)).
+		Order("created_at DESC").
+		Find(&leaves).Error
+	return leaves, total, err
+}
+
+func (r *GormLeaveRepository) FindByID(id uuid.UUID) (*models.LeaveApplication, error) {
+	var leave models.LeaveApplication
+	if err := r.db.Preload("LeaveType").Preload("User").Preload("Approver").Preload("Sessions").
+		Preload("StatusHistory", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Approvals", byPosition).
+		First(&leave, "id = ?", id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &leave, nil
+}
+
+func (r *GormLeaveRepository) ListByIDs(ids []uuid.UUID) ([]models.LeaveApplication, error) {
+	leaves := []models.LeaveApplication{}
+	if len(ids) == 0 {
+		return leaves, nil
+	}
+	err := r.db.Preload("LeaveType").Preload("User").Preload("Approver").Preload("Sessions").Preload("Approvals", byPosition).
+		Where("id IN ?", ids).
+		Find(&leaves).Error
+	return leaves, err
+}
+
+func (r *GormLeaveRepository) FindForUser(id, userID uuid.UUID) (*models.LeaveApplication, error) {
+	var leave models.LeaveApplication
+	if err := r.db.Preload("Sessions").Where("id = ? AND user_id = ?", id, userID).First(&leave).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &leave, nil
+}
+
+func (r *GormLeaveRepository) Create(leave *models.LeaveApplication) error {
+	return r.db.Create(leave).Error
+}
+
+func (r *GormLeaveRepository) Update(leave *models.LeaveApplication, updates map[string]interface{}) error {
+	return r.db.Model(leave).Omit(clause.Associations).Updates(updates).Error
+}
+
+func (r *GormLeaveRepository) Transition(leave *models.LeaveApplication, from string, updates map[string]interface{}) error {
+	result := r.db.Model(leave).Omit(clause.Associations).Where("status = ?", from).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *GormLeaveRepository) Delete(leave *models.LeaveApplication) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("leave_application_id = ?", leave.ID).Delete(&models.LeaveStatusChange{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("leave_application_id = ?", leave.ID).Delete(&models.LeaveSession{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("leave_application_id = ?", leave.ID).Delete(&models.LeaveApproval{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(leave).Error
+	})
+}
+
+func (r *GormLeaveRepository) ListOverlapping(userID uuid.UUID, start, end time.Time, excludeID uuid.UUID) ([]models.LeaveApplication, error) {
+	var leaves []models.LeaveApplication
+	err := r.db.Preload("Sessions").
+		Where("user_id = ? AND id <> ? AND status IN ? AND start_date < ? AND end_date >= ?",
+			userID, excludeID, []string{models.LeaveStatusPending, models.LeaveStatusApproved, models.LeaveStatusCancellationRequested},
+			end.AddDate(0, 0, 1), start).
+		Order("start_date").
+		Find(&leaves).Error
+	return leaves, err
+}
+
+func (r *GormLeaveRepository) ListBetween(filter LeaveFilter, statuses []string, start, end time.Time) ([]models.LeaveApplication, error) {
+	query := r.db.Preload("LeaveType").Preload("User").Preload("Sessions").
+		Where("status IN ? AND start_date < ? AND end_date >= ?", statuses, end.AddDate(0, 0, 1), start)
+	if filter.Scope != nil {
+		query = filter.Scope.Apply(query, "user_id")
+	}
+	if filter.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+
+	var leaves []models.LeaveApplication
+	err := query.Order("start_date").Find(&leaves).Error
+	return leaves, err
+}
+
+func (r *GormLeaveRepository) ReplaceSessions(leave *models.LeaveApplication, sessions []models.LeaveSession) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("leave_application_id = ?", leave.ID).Delete(&models.LeaveSession{}).Error; err != nil {
+			return err
+		}
+		if len(sessions) == 0 {
+			return nil
+		}
+		for i := range sessions {
+			sessions[i].LeaveApplicationID = leave.ID
+		}
+		return tx.Create(&sessions).Error
+	})
+}
+
+func (r *GormLeaveRepository) AddStatusChange(change *models.LeaveStatusChange) error {
+	return r.db.Create(change).Error
+}
+
+func (r *GormLeaveRepository) ListStatusChanges(leaveID uuid.UUID) ([]models.LeaveStatusChange, error) {
+	var changes []models.LeaveStatusChange
+	err := r.db.Where("leave_application_id = ?", leaveID).Order("created_at ASC").Find(&changes).Error
+	return changes, err
+}
+
+func (r *GormLeaveRepository) CountStatusChanges(leaveIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	counts := make(map[uuid.UUID]int, len(leaveIDs))
+	if len(leaveIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		LeaveApplicationID uuid.UUID
+		Changes            int
+	}
+	if err := r.db.Model(&models.LeaveStatusChange{}).
+		Select("leave_application_id, COUNT(*) AS changes").
+		Where("leave_application_id IN ?", leaveIDs).
+		Group("leave_application_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.LeaveApplicationID] = row.Changes
+	}
+	return counts, nil
+}
+
+func (r *GormLeaveRepository) WereApproved(leaveIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	approved := make(map[uuid.UUID]bool, len(leaveIDs))
+	if len(leaveIDs) == 0 {
+		return approved, nil
+	}
+	var ids []uuid.UUID
+	if err := r.db.Model(&models.LeaveStatusChange{}).
+		Distinct("leave_application_id").
+		Where("leave_application_id IN ? AND to_status = ?", leaveIDs, models.LeaveStatusApproved).
+		Pluck("leave_application_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		approved[id] = true
+	}
+	return approved, nil
+}
+
+func (r *GormLeaveRepository) FindActiveType(id uuid.UUID) (*models.LeaveType, error) {
+	var leaveType models.LeaveType
+	if err := r.db.Where("id = ? AND is_active = true", id).First(&leaveType).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &leaveType, nil
+}
+
+func (r *GormLeaveRepository) FindActiveTypeByName(name string) (*models.LeaveType, error) {
+	var leaveType models.LeaveType
+	if err := r.db.Where("name = ? AND is_active = true", name).First(&leaveType).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &leaveType, nil
+}
+
+func (r *GormLeaveRepository) ListActiveTypes() ([]models.LeaveType, error) {
+	var leaveTypes []models.LeaveType
+	err := r.db.Where("is_active = true").Find(&leaveTypes).Error
+	return leaveTypes, err
+}
+
+func (r *GormLeaveRepository) CountTypes() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.LeaveType{}).Count(&count).Error
+	return count, err
+}
+
+func (r *GormLeaveRepository) CreateType(leaveType *models.LeaveType) error {
+	return r.db.Create(leaveType).Error
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"employee-dashboard-api/internal/database"
+	"employee-dashboard-api/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OptionalHolidayFilter narrows an optional holiday selection listing. Zero
+// fields do not filter.
+type OptionalHolidayFilter struct {
+	UserID uuid.UUID
+	Year   int // of the holiday
+	Status string
+	Scope  UserScope // restricts to the selections of these users
+}
+
+// OptionalHolidayRepository stores the yearly optional holiday quotas and
+// the optional holidays employees select.
+type OptionalHolidayRepository interface {
+	// FindQuota loads the quota set for year.
+	FindQuota(year int) (*models.OptionalHolidayQuota, error)
+	// ListQuotas returns every quota set, latest year first.
+	ListQuotas() ([]models.OptionalHolidayQuota, error)
+	// SaveQuota creates or replaces the quota of quota.Year.
+	SaveQuota(quota *models.OptionalHolidayQuota) error
+
+	// List returns one page of matching selections, in holiday date order,
+	// with their employee and holiday, and the total match count.
+	List(filter OptionalHolidayFilter, page Page) ([]models.OptionalHolidaySelection, int64, error)
+	// ListBetween returns the matching selections in statuses whose holiday
+	// falls from from to to inclusive, with their employee and holiday.
+	ListBetween(filter OptionalHolidayFilter, statuses []string, from, to time.Time) ([]models.OptionalHolidaySelection, error)
+	// FindByID loads a selection with its employee and holiday.
+	FindByID(id uuid.UUID) (*models.OptionalHolidaySelection, error)
+	// CountLive counts userID's pending and approved selections of holidays
+	// in year.
+	CountLive(userID uuid.UUID, year int) (int64, error)
+	// FindLive finds userID's pending or approved selection of holidayID.
+	FindLive(userID, holidayID uuid.UUID) (*models.OptionalHolidaySelection, error)
+	Create(selection *models.OptionalHolidaySelection) error
+	// Transition applies updates only while the selection is still in one
+	// of the statuses from, failing with ErrConflict otherwise.
+	Transition(selection *models.OptionalHolidaySelection, from []string, updates map[string]interface{}) error
+}
+
+type GormOptionalHolidayRepository struct {
+	db *gorm.DB
+}
+
+func NewGormOptionalHolidayRepository(db *gorm.DB) *GormOptionalHolidayRepository {
+	return &GormOptionalHolidayRepository{db: db}
+}
+
+func (r *GormOptionalHolidayRepository) FindQuota(year int) (*models.OptionalHolidayQuota, error) {
+	var quota models.OptionalHolidayQuota
+	if err := r.db.First(&quota, "year = ?", year).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &quota, nil
+}
+
+func (r *GormOptionalHolidayRepository) ListQuotas() ([]models.OptionalHolidayQuota, error) {
+	var quotas []models.OptionalHolidayQuota
+	err := r.db.Order("year DESC").Find(&quotas).Error
+	return quotas, err
+}
+
+func (r *GormOptionalHolidayRepository) SaveQuota(quota *models.OptionalHolidayQuota) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "year"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quota", "updated_by", "updated_at"}),
+	}).Create(quota).Error
+}
+
+// filtered joins the holiday of each selection so filters and ordering can
+// use its date.
+func (r *GormOptionalHolidayRepository) filtered(filter OptionalHolidayFilter) *gorm.DB {
+	query := r.db.Model(&models.OptionalHolidaySelection{}).
+		Joins("JOIN events ON events.id = optional_holiday_selections.holiday_id")
+	if filter.Scope != nil {
+		query = filter.Scope.Apply(query, "optional_holiday_selections.user_id")
+	}
+	if filter.UserID != uuid.Nil {
+		query = query.Where("optional_holiday_selections.user_id = ?", filter.UserID)
+	}
+	if filter.Year != 0 {
+		query = query.Where(database.YearOf(r.db, "events.event_date")+" = ?", filter.Year)
+	}
+	if filter.Status != "" {
+		query = query.Where("optional_holiday_selections.status = ?", filter.Status)
+	}
+	return query
+}
+
+func (r *GormOptionalHolidayRepository) List(filter OptionalHolidayFilter, page Page) ([]models.OptionalHolidaySelection, int64, error) {
+	query := r.filtered(filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var selections []models.OptionalHolidaySelection
+	err := page.apply(query.Preload("User").Preload("Holiday")).
+		Order("events.event_date, optional_holiday_selections.created_at").
+		Find(&selections).Error
+	return selections, total, err
+}
+
+func (r *GormOptionalHolidayRepository) ListBetween(filter OptionalHolidayFilter, statuses []string, from, to time.Time) ([]models.OptionalHolidaySelection, error) {
+	var selections []models.OptionalHolidaySelection
+	err := r.filtered(filter).
+		Where("optional_holiday_selections.status IN ? AND events.event_date >= ? AND events.event_date < ?", statuses, from, to.AddDate(0, 0, 1)).
+		Preload("User").Preload("Holiday").
+		Order("events.event_date, optional_holiday_selections.created_at").
+		Find(&selections).Error
+	return selections, err
+}
+
+func (r *GormOptionalHolidayRepository) FindByID(id uuid.UUID) (*models.OptionalHolidaySelection, error) {
+	var selection models.OptionalHolidaySelection
+	if err := r.db.Preload("User").Preload("Holiday").First(&selection, "id = ?", id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &selection, nil
+}
+
+func (r *GormOptionalHolidayRepository) CountLive(userID uuid.UUID, year int) (int64, error) {
+	var count int64
+	err := r.filtered(OptionalHolidayFilter{UserID: userID, Year: year}).
+		Where("optional_holiday_selections.status IN ?", []string{models.OptionalHolidayPending, models.OptionalHolidayApproved}).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *GormOptionalHolidayRepository) FindLive(userID, holidayID uuid.UUID) (*models.OptionalHolidaySelection, error) {
+	var selection models.OptionalHolidaySelection
+	if err := r.db.Where("user_id = ? AND holiday_id = ? AND status IN ?", userID, holidayID,
+		[]string{models.OptionalHolidayPending, models.OptionalHolidayApproved}).
+		First(&selection).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &selection, nil
+}
+
+func (r *GormOptionalHolidayRepository) Create(selection *models.OptionalHolidaySelection) error {
+	return r.db.Create(selection).Error
+}
+
+func (r *GormOptionalHolidayRepository) Transition(selection *models.OptionalHolidaySelection, from []string, updates map[string]interface{}) error {
+	result := r.db.Model(selection).Where("status IN ?", from).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// Package repository holds the persistence interfaces that handlers and
+// services depend on, one per aggregate, together with their GORM
+// implementations. Reporting queries that join several aggregates stay with
+// the handlers that own them.
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned by lookups that match no row.
+var ErrNotFound = errors.New("record not found")
+
+// ErrConflict is returned by conditional updates whose row changed since it
+// was read.
+var ErrConflict = errors.New("record was modified concurrently")
+
+// ErrDuplicate is returned by inserts that would break a unique index.
+var ErrDuplicate = errors.New("record already exists")
+
+// UserScope restricts a listing to the rows of some set of users.
+// services.TeamScope implements it.
+type UserScope interface {
+	Apply(query *gorm.DB, column string) *gorm.DB
+}
+
+// Page selects a window of a listing. A zero Limit returns every row.
+type Page struct {
+	Offset int
+	Limit  int
+}
+
+func (p Page) apply(query *gorm.DB) *gorm.DB {
+	if p.Limit <= 0 {
+		return query
+	}
+	return query.Offset(p.Offset).Limit(p.Limit)
+}
+
+// Repositories bundles the GORM implementation of every repository so they
+// can be built once and shared.
+type Repositories struct {
+	Leaves           LeaveRepository
+	Balances         LeaveBalanceRepository
+	Ledger           LeaveLedgerRepository
+	Policies         LeavePolicyRepository
+	Approvals        LeaveApprovalRepository
+	CompOffs         CompOffRepository
+	Timesheets       TimesheetRepository
+	Users            UserRepository
+	Documents        DocumentRepository
+	Feeds            CalendarFeedRepository
+	Holidays         HolidayRepository
+	OptionalHolidays OptionalHolidayRepository
+
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repositories {
+	return &Repositories{
+		db:               db,
+		Leaves:           NewGormLeaveRepository(db),
+		Balances:         NewGormLeaveBalanceRepository(db),
+		Ledger:           NewGormLeaveLedgerRepository(db),
+		Policies:         NewGormLeavePolicyRepository(db),
+		Approvals:        NewGormLeaveApprovalRepository(db),
+		CompOffs:         NewGormCompOffRepository(db),
+		Timesheets:       NewGormTimesheetRepository(db),
+		Users:            NewGormUserRepository(db),
+		Documents:        NewGormDocumentRepository(db),
+		Feeds:            NewGormCalendarFeedRepository(db),
+		Holidays:         NewGormHolidayRepository(db),
+		OptionalHolidays: NewGormOptionalHolidayRepository(db),
+	}
+}
+
+// Transaction runs fn with repositories bound to a single database
+// transaction, committing if fn returns nil and rolling back otherwise.
+func (r *Repositories) Transaction(fn func(tx *Repositories) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(New(tx))
+	})
+}
+
+// translate maps GORM's not-found error to ErrNotFound.
+func translate(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// translateDuplicate maps a unique index violation reported by db's driver to
+// ErrDuplicate.
+func translateDuplicate(db *gorm.DB, err error) error {
+	if err == nil {
+		return nil
+	}
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok && errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey) {
+		return ErrDuplicate
+	}
+	return err
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"employee-dashboard-api/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TimesheetFilter narrows a timesheet listing. Zero fields do not filter;
+// From and To are inclusive YYYY-MM-DD dates.
+type TimesheetFilter struct {
+	UserID uuid.UUID
+	Status string
+	From   string
+	To     string
+}
+
+// TimesheetRepository stores timesheet entries and looks up the projects
+// they are booked against.
+type TimesheetRepository interface {
+	// List returns one page of matching entries, latest first, with their
+	// project and user, and the total match count.
+	List(filter TimesheetFilter, page Page) ([]models.TimesheetEntry, int64, error)
+	// FindByID loads an entry with its project, user and approver.
+	FindByID(id uuid.UUID) (*models.TimesheetEntry, error)
+	// FindForUser loads an entry only if it belongs to userID.
+	FindForUser(id, userID uuid.UUID) (*models.TimesheetEntry, error)
+	// ListForDay returns userID's entries on date, except excludeID.
+	ListForDay(userID uuid.UUID, date time.Time, excludeID uuid.UUID) ([]models.TimesheetEntry, error)
+	// ListInRange returns userID's entries in one of statuses dated from..to
+	// inclusive, oldest first.
+	ListInRange(userID uuid.UUID, statuses []string, from, to time.Time) ([]models.TimesheetEntry, error)
+	// HoursOnDay sums the hours userID booked on date.
+	HoursOnDay(userID uuid.UUID, date time.Time) (float64, error)
+	Create(entry *models.TimesheetEntry) error
+	Update(entry *models.TimesheetEntry, updates map[string]interface{}) error
+	// UpdateInRange applies updates to userID's entries in one of statuses
+	// dated from..to inclusive and returns how many changed.
+	UpdateInRange(userID uuid.UUID, statuses []string, from, to time.Time, updates map[string]interface{}) (int64, error)
+	Delete(entry *models.TimesheetEntry) error
+
+	FindActiveProject(id uuid.UUID) (*models.Project, error)
+}
+
+type GormTimesheetRepository struct {
+	db *gorm.DB
+}
+
+func NewGormTimesheetRepository(db *gorm.DB) *GormTimesheetRepository {
+	return &GormTimesheetRepository{db: db}
+}
+
+func (r *GormTimesheetRepository) List(filter TimesheetFilter, page Page) ([]models.TimesheetEntry, int64, error) {
+	query := r.db.Model(&models.TimesheetEntry{})
+	if filter.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.From != "" {
+		query = query.Where("entry_date >= ?", filter.From)
+	}
+	if filter.To != "" {
+		query = query.Where("entry_date <= ?", filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.TimesheetEntry
+	err := page.apply(query.Preload("Project").Preload("User")).
+		Order("entry_date DESC, created_at DESC").
+		Find(&entries).Error
+	return entries, total, err
+}
+
+func (r *GormTimesheetRepository) FindByID(id uuid.UUID) (*models.TimesheetEntry, error) {
+	var entry models.TimesheetEntry
+	if err := r.db.Preload("Project").Preload("User").Preload("Approver").First(&entry, "id = ?", id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &entry, nil
+}
+
+func (r *GormTimesheetRepository) FindForUser(id, userID uuid.UUID) (*models.TimesheetEntry, error) {
+	var entry models.TimesheetEntry
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&entry).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &entry, nil
+}
+
+func (r *GormTimesheetRepository) ListForDay(userID uuid.UUID, date time.Time, excludeID uuid.UUID) ([]models.TimesheetEntry, error) {
+	query := r.db.Where("user_id = ? AND entry_date = ?", userID, date)
+	if excludeID != uuid.Nil {
+		query = query.Where("id <> ?", excludeID)
+	}
+	var entries []models.TimesheetEntry
+	err := query.Find(&entries).Error
+	return entries, err
+}
+
+func (r *GormTimesheetRepository) ListInRange(userID uuid.UUID, statuses []string, from, to time.Time) ([]models.TimesheetEntry, error) {
+	var entries []models.TimesheetEntry
+	err := r.db.Where("user_id = ? AND status IN ? AND entry_date BETWEEN ? AND ?", userID, statuses, from, to).
+		Order("entry_date").
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *GormTimesheetRepository) HoursOnDay(userID uuid.UUID, date time.Time) (float64, error) {
+	var hours float64
+	err := r.db.Model(&models.TimesheetEntry{}).
+		Select("COALESCE(SUM(duration_hours), 0)").
+		Where("user_id = ? AND entry_date = ?", userID, date).
+		Scan(&hours).Error
+	return hours, err
+}
+
+func (r *GormTimesheetRepository) Create(entry *models.TimesheetEntry) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *GormTimesheetRepository) Update(entry *models.TimesheetEntry, updates map[string]interface{}) error {
+	return r.db.Model(entry).Omit(clause.Associations).Updates(updates).Error
+}
+
+func (r *GormTimesheetRepository) UpdateInRange(userID uuid.UUID, statuses []string, from, to time.Time, updates map[string]interface{}) (int64, error) {
+	result := r.db.Model(&models.TimesheetEntry{}).
+		Where("user_id = ? AND status IN ? AND entry_date BETWEEN ? AND ?", userID, statuses, from, to).
+		Updates(updates)
+	return result.RowsAffected, result.Error
+}
+
+func (r *GormTimesheetRepository) Delete(entry *models.TimesheetEntry) error {
+	return r.db.Delete(entry).Error
+}
+
+func (r *GormTimesheetRepository) FindActiveProject(id uuid.UUID) (*models.Project, error) {
+	var project models.Project
+	if err := r.db.Where("id = ? AND status = 'active'", id).First(&project).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &project, nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"employee-dashboard-api/internal/database"
+	"employee-dashboard-api/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserRepository stores user accounts.
+type UserRepository interface {
+	// FindByID loads a user with their manager.
+	FindByID(id uuid.UUID) (*models.User, error)
+	FindByEmployeeID(employeeID string) (*models.User, error)
+	Update(user *models.User, updates map[string]interface{}) error
+	// ListApproved returns approved users ordered by name.
+	ListApproved() ([]models.User, error)
+	// ListDirectReports returns the approved users managerID manages
+	// directly.
+	ListDirectReports(managerID uuid.UUID) ([]models.User, error)
+	// Lock locks the user's row until the end of the transaction, so that
+	// checks of their other rows, such as quotas, run one at a time.
+	Lock(id uuid.UUID) error
+}
+
+type GormUserRepository struct {
+	db *gorm.DB
+}
+
+func NewGormUserRepository(db *gorm.DB) *GormUserRepository {
+	return &GormUserRepository{db: db}
+}
+
+func (r *GormUserRepository) FindByID(id uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := r.db.Preload("Manager").Where("id = ?", id).First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *GormUserRepository) FindByEmployeeID(employeeID string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where("employee_id = ?", employeeID).First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *GormUserRepository) Update(user *models.User, updates map[string]interface{}) error {
+	return r.db.Model(user).Omit(clause.Associations).Updates(updates).Error
+}
+
+func (r *GormUserRepository) ListApproved() ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("approval_status = ?", models.StatusApproved).Order("first_name ASC, last_name ASC").Find(&users).Error
+	return users, err
+}
+
+func (r *GormUserRepository) ListDirectReports(managerID uuid.UUID) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("manager_id = ? AND approval_status = ?", managerID, models.StatusApproved).Find(&users).Error
+	return users, err
+}
+
+func (r *GormUserRepository) Lock(id uuid.UUID) error {
+	var user models.User
+	if err := database.ForUpdate(r.db).Select("id").Where("id = ?", id).First(&user).Error; err != nil {
+		return translate(err)
+	}
+	return nil
+}
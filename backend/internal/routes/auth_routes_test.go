@@ -0,0 +1,189 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// session is the token pair of one login or refresh.
+type session struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// login starts a new session for u, bypassing the cached token of as.
+func (s *testServer) login(u models.User, password string) *testResponse {
+	s.t.Helper()
+	return s.request(http.MethodPost, "/api/v1/auth/login", "", gin.H{"employee_id": u.EmployeeID, "password": password})
+}
+
+// newSession logs u in and returns the new session's token pair.
+func (s *testServer) newSession(u models.User) session {
+	s.t.Helper()
+	var sess session
+	s.login(u, testPassword).expect(http.StatusOK).decode(&sess)
+	return sess
+}
+
+// refresh exchanges refreshToken for a new token pair.
+func (s *testServer) refresh(refreshToken string) *testResponse {
+	s.t.Helper()
+	return s.request(http.MethodPost, "/api/v1/auth/refresh", "", gin.H{"refresh_token": refreshToken})
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	s := newTestServer(t)
+	employee := s.fx.employee
+
+	first := s.newSession(employee)
+	var second session
+	s.refresh(first.RefreshToken).expect(http.StatusOK).decode(&second)
+	if second.RefreshToken == first.RefreshToken || second.Token == "" {
+		t.Fatalf("expected a rotated token pair, got %+v", second)
+	}
+	s.request(http.MethodGet, "/api/v1/auth/me", second.Token, nil).expect(http.StatusOK)
+
+	// Replaying a rotated refresh token revokes the whole session
+	s.refresh(first.RefreshToken).expect(http.StatusUnauthorized)
+	s.refresh(second.RefreshToken).expect(http.StatusUnauthorized)
+	s.request(http.MethodGet, "/api/v1/auth/me", second.Token, nil).expect(http.StatusUnauthorized)
+
+	// Other sessions are not affected
+	other := s.newSession(employee)
+	s.refresh(other.RefreshToken).expect(http.StatusOK)
+}
+
+func TestLogout(t *testing.T) {
+	s := newTestServer(t)
+	employee := s.fx.employee
+
+	phone, laptop, tablet := s.newSession(employee), s.newSession(employee), s.newSession(employee)
+	s.request(http.MethodPost, "/api/v1/auth/logout", phone.Token, nil).expect(http.StatusOK)
+	s.request(http.MethodGet, "/api/v1/auth/me", phone.Token, nil).expect(http.StatusUnauthorized)
+	s.refresh(phone.RefreshToken).expect(http.StatusUnauthorized)
+	s.request(http.MethodGet, "/api/v1/auth/me", laptop.Token, nil).expect(http.StatusOK)
+
+	var result struct {
+		RevokedSessions int64 `json:"revoked_sessions"`
+	}
+	s.request(http.MethodPost, "/api/v1/auth/logout-all", laptop.Token, nil).expect(http.StatusOK).decode(&result)
+	if result.RevokedSessions != 2 {
+		t.Fatalf("expected the 2 open sessions revoked, got %d", result.RevokedSessions)
+	}
+	for _, sess := range []session{laptop, tablet} {
+		s.request(http.MethodGet, "/api/v1/auth/me", sess.Token, nil).expect(http.StatusUnauthorized)
+		s.refresh(sess.RefreshToken).expect(http.StatusUnauthorized)
+	}
+	s.newSession(employee)
+}
+
+func TestRoleChangeInvalidatesTokens(t *testing.T) {
+	s := newTestServer(t)
+	admin, employee := s.fx.admin, s.fx.employee
+
+	sess := s.newSession(employee)
+	s.request(http.MethodGet, "/api/v1/admin/leaves/", sess.Token, nil).expect(http.StatusForbidden)
+
+	s.as(employee, http.MethodPut, "/api/v1/auth/change-role/"+employee.ID.String(), gin.H{"role": "admin"}).expect(http.StatusForbidden)
+	s.as(admin, http.MethodPut, "/api/v1/auth/change-role/"+employee.ID.String(), gin.H{"role": "manager"}).expect(http.StatusOK)
+
+	// Tokens carrying the old role stop working; a refresh picks up the new one
+	s.request(http.MethodGet, "/api/v1/auth/me", sess.Token, nil).expect(http.StatusUnauthorized)
+	var refreshed session
+	s.refresh(sess.RefreshToken).expect(http.StatusOK).decode(&refreshed)
+	claims, err := utils.ValidateToken(refreshed.Token, "test-secret")
+	if err != nil {
+		t.Fatalf("invalid refreshed token: %v", err)
+	}
+	if claims.Role != models.RoleManager || !claims.HasPermission(models.PermLeaveView) {
+		t.Fatalf("expected the refreshed token to carry the manager role, got %+v", claims)
+	}
+	s.request(http.MethodGet, "/api/v1/admin/leaves/", refreshed.Token, nil).expect(http.StatusOK)
+}
+
+func TestAuthRateLimits(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.RateLimitEnabled = true
+	})
+
+	// The per-account bucket allows 10 logins a minute
+	nobody := models.User{EmployeeID: "NOBODY"}
+	for i := 0; i < 10; i++ {
+		s.login(nobody, "guess"+strconv.Itoa(i)).expect(http.StatusUnauthorized)
+	}
+	resp := s.login(nobody, "guess").expect(http.StatusTooManyRequests)
+	if retryAfter, err := strconv.Atoi(resp.recorder.Header().Get("Retry-After")); err != nil || retryAfter < 1 {
+		t.Fatalf("expected a Retry-After header in seconds, got %q", resp.recorder.Header().Get("Retry-After"))
+	}
+
+	// Other accounts have their own bucket
+	s.newSession(s.fx.employee)
+
+	// Password reset and signup OTPs do not share buckets
+	address := gin.H{"email": "new@example.com"}
+	for i := 0; i < 5; i++ {
+		s.request(http.MethodPost, "/api/v1/auth/forgot-password", "", address).expect(http.StatusOK)
+	}
+	s.request(http.MethodPost, "/api/v1/auth/forgot-password", "", address).expect(http.StatusTooManyRequests)
+	s.request(http.MethodPost, "/api/v1/auth/send-signup-otp", "", address).expect(http.StatusOK)
+}
+
+func TestLoginLockout(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.LoginMaxFailures = 3
+		cfg.LoginLockoutMinutes = 15
+	})
+	employee := s.fx.employee
+
+	for i := 0; i < 3; i++ {
+		s.login(employee, "wrong").expect(http.StatusUnauthorized)
+	}
+	var locked models.User
+	s.db.First(&locked, "id = ?", employee.ID)
+	if locked.LockedUntil == nil || !locked.LockedUntil.After(time.Now().Add(14*time.Minute)) {
+		t.Fatalf("expected the account locked for 15 minutes, got %v", locked.LockedUntil)
+	}
+
+	// The right password is refused too, exactly like an unknown account
+	refused := s.login(employee, testPassword).expect(http.StatusUnauthorized).recorder.Body.String()
+	unknown := s.login(models.User{EmployeeID: "NOBODY"}, testPassword).expect(http.StatusUnauthorized).recorder.Body.String()
+	if refused != unknown {
+		t.Fatalf("a locked account must answer like an unknown one, got %s and %s", refused, unknown)
+	}
+
+	// Once the lock expires the account logs in and starts over
+	s.db.Model(&models.User{}).Where("id = ?", employee.ID).Update("locked_until", time.Now().Add(-time.Minute))
+	s.newSession(employee)
+	var unlocked models.User
+	s.db.First(&unlocked, "id = ?", employee.ID)
+	if unlocked.LockedUntil != nil || unlocked.FailedLogins != 0 {
+		t.Fatalf("expected the lockout cleared, got %v after %d failures", unlocked.LockedUntil, unlocked.FailedLogins)
+	}
+}
+
+func TestLoginUpgradesPasswordHash(t *testing.T) {
+	s := newTestServer(t)
+	employee := s.fx.employee
+	s.db.Model(&models.User{}).Where("id = ?", employee.ID).Update("password_hash", testPassword)
+
+	s.login(employee, "wrong").expect(http.StatusUnauthorized)
+	s.newSession(employee)
+
+	var upgraded models.User
+	s.db.First(&upgraded, "id = ?", employee.ID)
+	if utils.PasswordHashScheme(upgraded.PasswordHash) != utils.PasswordSchemeBcrypt {
+		t.Fatalf("expected the plaintext password rehashed on login, got %q", upgraded.PasswordHash)
+	}
+	if ok, _ := utils.VerifyPassword(testPassword, upgraded.PasswordHash); !ok {
+		t.Fatal("the upgraded hash must still match the password")
+	}
+	s.newSession(employee)
+}
@@ -0,0 +1,199 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newCalendarFeedServer boots a test server whose feeds go back ten years,
+// so the fixture dates stay in them.
+func newCalendarFeedServer(t *testing.T) *testServer {
+	return newTestServer(t, func(cfg *config.Config) {
+		cfg.CalendarFeedPastDays = 3650
+	})
+}
+
+// createFeed creates a feed of scope for u and returns it with the path of
+// its URL.
+func (s *testServer) createFeed(u models.User, scope string) (models.CalendarFeed, string) {
+	s.t.Helper()
+	var created struct {
+		Feed models.CalendarFeed `json:"feed"`
+		URL  string              `json:"url"`
+	}
+	s.as(u, http.MethodPost, "/api/v1/calendar-feeds/", gin.H{"scope": scope}).
+		expect(http.StatusCreated).decode(&created)
+	return created.Feed, feedPath(s.t, created.URL)
+}
+
+func feedPath(t *testing.T, url string) string {
+	t.Helper()
+	i := strings.Index(url, "/api/v1/calendar/feeds/")
+	if i < 0 || !strings.HasSuffix(url, ".ics") {
+		t.Fatalf("unexpected feed URL %q", url)
+	}
+	return url[i:]
+}
+
+// fetchFeed fetches a feed without credentials and returns its events by
+// UID, with their properties unfolded and keyed by name.
+func (s *testServer) fetchFeed(path string) map[string]map[string]string {
+	s.t.Helper()
+	resp := s.request(http.MethodGet, path, "", nil).expect(http.StatusOK)
+	if contentType := resp.recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/calendar") {
+		s.t.Fatalf("expected a text/calendar feed, got %q", contentType)
+	}
+
+	body := resp.recorder.Body.String()
+	if !strings.HasPrefix(body, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(body, "END:VCALENDAR\r\n") {
+		s.t.Fatalf("not an iCalendar document: %q", body)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(body, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			s.t.Fatalf("line longer than 75 octets: %q", line)
+		}
+	}
+
+	events := make(map[string]map[string]string)
+	var event map[string]string
+	for _, line := range strings.Split(strings.ReplaceAll(body, "\r\n ", ""), "\r\n") {
+		switch {
+		case line == "BEGIN:VEVENT":
+			event = make(map[string]string)
+		case line == "END:VEVENT":
+			events[event["UID"]] = event
+			event = nil
+		case event != nil:
+			name, value, _ := strings.Cut(line, ":")
+			event[name] = value
+		}
+	}
+	return events
+}
+
+func TestCalendarFeeds(t *testing.T) {
+	s := newCalendarFeedServer(t)
+	manager, employee := s.fx.manager, s.fx.employee
+	description := "Offices closed; the support desk stays open. " + strings.Repeat("Details follow. ", 5)
+	founders := models.Event{Title: "Founders Day, HQ", EventType: "holiday", EventDate: time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC), IsCompanyWide: true, Description: &description}
+	pune := "Pune"
+	s.create(&founders)
+	s.create(&models.Event{Title: "Pune Day", EventType: "holiday", EventDate: time.Date(2025, 3, 6, 0, 0, 0, 0, time.UTC), Location: &pune})
+
+	leave := s.applyForLeave(employee, "2025-03-10", "2025-03-12")
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/approve", nil).expect(http.StatusOK)
+
+	_, path := s.createFeed(employee, models.CalendarFeedPersonal)
+	s.as(employee, http.MethodPost, "/api/v1/calendar-feeds/", gin.H{"scope": "company"}).expect(http.StatusBadRequest)
+
+	events := s.fetchFeed(path)
+	uid := "leave-" + leave.ID.String() + "@employee-dashboard"
+	got := events[uid]
+	if got["DTSTART;VALUE=DATE"] != "20250310" || got["DTEND;VALUE=DATE"] != "20250313" || got["STATUS"] != "CONFIRMED" ||
+		got["SUMMARY"] != "Casual Leave" || got["SEQUENCE"] != "2" {
+		t.Fatalf("unexpected leave event: %+v", got)
+	}
+	holiday := events["holiday-"+founders.ID.String()+"@employee-dashboard"]
+	if holiday["SUMMARY"] != `Founders Day\, HQ` || holiday["DESCRIPTION"] != strings.NewReplacer(";", `\;`).Replace(description) {
+		t.Fatalf("unexpected holiday event: %+v", holiday)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected the leave and the company-wide holiday only, got %d events", len(events))
+	}
+
+	// Cutting the leave short and then cancelling it updates the same event
+	s.as(employee, http.MethodPost, "/api/v1/leaves/"+leave.ID.String()+"/cancel", gin.H{"cancel_from": "2025-03-12"}).
+		expect(http.StatusOK)
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/cancellation/approve", nil).expect(http.StatusOK)
+	got = s.fetchFeed(path)[uid]
+	if got["DTEND;VALUE=DATE"] != "20250312" || got["STATUS"] != "CONFIRMED" || got["SEQUENCE"] != "4" {
+		t.Fatalf("expected the shortened leave, got %+v", got)
+	}
+
+	s.as(employee, http.MethodDelete, "/api/v1/leaves/"+leave.ID.String(), nil).expect(http.StatusOK)
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/cancellation/approve", nil).expect(http.StatusOK)
+	got = s.fetchFeed(path)[uid]
+	if got["STATUS"] != "CANCELLED" || got["SEQUENCE"] != "6" {
+		t.Fatalf("expected the leave cancelled, got %+v", got)
+	}
+
+	// Feeds die with their owner's approval
+	s.db.Model(&models.User{}).Where("id = ?", employee.ID).Update("approval_status", models.StatusRejected)
+	s.request(http.MethodGet, path, "", nil).expect(http.StatusNotFound)
+}
+
+func TestTeamCalendarFeed(t *testing.T) {
+	s := newCalendarFeedServer(t)
+	manager, employee, outsider := s.fx.manager, s.fx.employee, s.fx.outsider
+
+	birthday := models.Event{Title: "EMPLOYEE's birthday", EventType: "birthday", EventDate: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), UserID: &employee.ID}
+	s.create(&birthday)
+	leave := s.applyForLeave(employee, "2025-03-10", "2025-03-10")
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/approve", nil).expect(http.StatusOK)
+	pending := s.applyForLeave(employee, "2025-03-17", "2025-03-17")
+
+	// The manager's team includes their report
+	_, path := s.createFeed(manager, models.CalendarFeedTeam)
+	events := s.fetchFeed(path)
+	if got := events["leave-"+leave.ID.String()+"@employee-dashboard"]; got["SUMMARY"] != "EMPLOYEE Test: Casual Leave" || got["TRANSP"] != "TRANSPARENT" {
+		t.Fatalf("unexpected team leave event: %+v", got)
+	}
+	if _, ok := events["birthday-"+birthday.ID.String()+"@employee-dashboard"]; !ok {
+		t.Fatal("expected the report's birthday in the team feed")
+	}
+	if _, ok := events["leave-"+pending.ID.String()+"@employee-dashboard"]; ok {
+		t.Fatal("pending leave must not be published")
+	}
+	s.as(employee, http.MethodDelete, "/api/v1/leaves/"+pending.ID.String(), nil).expect(http.StatusOK)
+	if _, ok := s.fetchFeed(path)["leave-"+pending.ID.String()+"@employee-dashboard"]; ok {
+		t.Fatal("leave withdrawn before approval must not be published")
+	}
+
+	// The outsider's team is the admin's reports, which the employee is not
+	_, outsiderPath := s.createFeed(outsider, models.CalendarFeedTeam)
+	if events := s.fetchFeed(outsiderPath); len(events) != 0 {
+		t.Fatalf("expected nothing from outside the outsider's team, got %d events", len(events))
+	}
+}
+
+func TestCalendarFeedRotationAndRevocation(t *testing.T) {
+	s := newCalendarFeedServer(t)
+	employee := s.fx.employee
+
+	feed, path := s.createFeed(employee, models.CalendarFeedPersonal)
+	s.fetchFeed(path)
+	s.request(http.MethodGet, "/api/v1/calendar/feeds/not-a-token.ics", "", nil).expect(http.StatusNotFound)
+
+	// Only the owner manages a feed
+	rotate := "/api/v1/calendar-feeds/" + feed.ID.String() + "/rotate"
+	s.as(s.fx.outsider, http.MethodPost, rotate, nil).expect(http.StatusNotFound)
+	s.as(s.fx.outsider, http.MethodDelete, "/api/v1/calendar-feeds/"+feed.ID.String(), nil).expect(http.StatusNotFound)
+
+	var rotated struct {
+		URL string `json:"url"`
+	}
+	s.as(employee, http.MethodPost, rotate, nil).expect(http.StatusOK).decode(&rotated)
+	s.request(http.MethodGet, path, "", nil).expect(http.StatusNotFound)
+	path = feedPath(t, rotated.URL)
+	s.fetchFeed(path)
+
+	var feeds []models.CalendarFeed
+	s.as(employee, http.MethodGet, "/api/v1/calendar-feeds/", nil).expect(http.StatusOK).decode(&feeds)
+	if len(feeds) != 1 || feeds[0].LastAccessedAt == nil {
+		t.Fatalf("expected the feed listed with its last access, got %+v", feeds)
+	}
+
+	s.as(employee, http.MethodDelete, "/api/v1/calendar-feeds/"+feed.ID.String(), nil).expect(http.StatusOK)
+	s.request(http.MethodGet, path, "", nil).expect(http.StatusNotFound)
+	s.as(employee, http.MethodGet, "/api/v1/calendar-feeds/", nil).expect(http.StatusOK).decode(&feeds)
+	if len(feeds) != 0 {
+		t.Fatalf("expected no live feeds after revocation, got %d", len(feeds))
+	}
+}
@@ -0,0 +1,225 @@
+package routes
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// newCompOffServer boots a test server that allows 12 hours a day, so
+// overtime can be logged, and keeps comp-off for ten years, so the fixture
+// dates do not expire. It adds the Comp Off leave type.
+func newCompOffServer(t *testing.T) (*testServer, models.LeaveType) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.TimesheetMaxDailyHours = 12
+		cfg.CompOffOvertimeThresholdHours = 8
+		cfg.CompOffHoursPerDay = 8
+		cfg.CompOffExpiryDays = 3650
+	})
+	compOff := models.LeaveType{Name: models.CompOffLeaveTypeName, IsActive: true}
+	s.create(&compOff)
+	return s, compOff
+}
+
+// claimCompOff claims the comp-off u earned on date.
+func (s *testServer) claimCompOff(u models.User, date string) *testResponse {
+	s.t.Helper()
+	return s.as(u, http.MethodPost, "/api/v1/comp-offs/", gin.H{"work_date": date})
+}
+
+func TestCompOffClaims(t *testing.T) {
+	s, compOff := newCompOffServer(t)
+	manager, employee := s.fx.manager, s.fx.employee
+	s.create(&models.Event{Title: "Founders Day", EventType: "holiday", EventDate: time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC), IsCompanyWide: true})
+
+	// Overtime on Tuesday, the Wednesday holiday, a regular Thursday and Saturday
+	s.logTime(employee, "2025-03-04", "07:00", "19:00", 12).expect(http.StatusCreated)
+	s.logTime(employee, "2025-03-05", "09:00", "13:00", 4).expect(http.StatusCreated)
+	s.logTime(employee, "2025-03-06", "09:00", "17:00", 8).expect(http.StatusCreated)
+	s.logTime(employee, "2025-03-08", "09:00", "17:00", 8).expect(http.StatusCreated)
+
+	// Draft hours do not count yet
+	s.claimCompOff(employee, "2025-03-08").expect(http.StatusBadRequest)
+	s.as(employee, http.MethodPost, "/api/v1/timesheets/submit?start_date=2025-03-03&end_date=2025-03-09", nil).expect(http.StatusOK)
+
+	var eligible []struct {
+		Date   string  `json:"date"`
+		Reason string  `json:"reason"`
+		Hours  float64 `json:"hours"`
+		Days   float64 `json:"days"`
+	}
+	s.as(employee, http.MethodGet, "/api/v1/comp-offs/eligible?from=2025-03-03&to=2025-03-09", nil).
+		expect(http.StatusOK).decode(&eligible)
+	expected := []struct {
+		date, reason string
+		hours, days  float64
+	}{
+		{"2025-03-04", models.CompOffOvertime, 4, 0.5},
+		{"2025-03-05", models.CompOffHoliday, 4, 0.5},
+		{"2025-03-08", models.CompOffWeekend, 8, 1},
+	}
+	if len(eligible) != len(expected) {
+		t.Fatalf("expected %d eligible days, got %+v", len(expected), eligible)
+	}
+	for i, day := range eligible {
+		if day.Date != expected[i].date || day.Reason != expected[i].reason || day.Hours != expected[i].hours || day.Days != expected[i].days {
+			t.Errorf("day %d: expected %+v, got %+v", i, expected[i], day)
+		}
+	}
+
+	var saturday, tuesday models.CompOffClaim
+	s.claimCompOff(employee, "2025-03-08").expect(http.StatusCreated).decode(&saturday)
+	s.claimCompOff(employee, "2025-03-08").expect(http.StatusConflict)
+	s.claimCompOff(employee, "2025-03-06").expect(http.StatusBadRequest)
+	s.claimCompOff(employee, "2025-03-04").expect(http.StatusCreated).decode(&tuesday)
+
+	var notified int64
+	s.db.Model(&models.Notification{}).Where("user_id = ? AND type = ?", manager.ID, models.NotificationCompOffClaimed).Count(&notified)
+	if notified != 2 {
+		t.Fatalf("expected the manager to be notified of 2 claims, got %d", notified)
+	}
+
+	var pending struct {
+		Claims []models.CompOffClaim `json:"claims"`
+	}
+	s.as(manager, http.MethodGet, "/api/v1/admin/comp-offs/?status=pending", nil).expect(http.StatusOK).decode(&pending)
+	if len(pending.Claims) != 2 {
+		t.Fatalf("expected 2 pending claims in the manager's team, got %d", len(pending.Claims))
+	}
+
+	// Only the manager reviews, and rejections need a reason
+	approve := "/api/v1/admin/comp-offs/" + saturday.ID.String() + "/approve"
+	s.as(employee, http.MethodPut, approve, nil).expect(http.StatusForbidden)
+	s.as(s.fx.outsider, http.MethodPut, approve, nil).expect(http.StatusForbidden)
+	s.as(manager, http.MethodPut, "/api/v1/admin/comp-offs/"+tuesday.ID.String()+"/reject", nil).expect(http.StatusBadRequest)
+	s.as(manager, http.MethodPut, "/api/v1/admin/comp-offs/"+tuesday.ID.String()+"/reject", gin.H{"reason": "Not pre-approved"}).
+		expect(http.StatusOK)
+
+	var approved models.CompOffClaim
+	s.as(manager, http.MethodPut, approve, nil).expect(http.StatusOK).decode(&approved)
+	if approved.Status != models.CompOffApproved || approved.LeaveBalanceID == nil {
+		t.Fatalf("expected the claim approved and credited, got %+v", approved)
+	}
+	s.as(manager, http.MethodPut, approve, nil).expect(http.StatusConflict)
+
+	if balance := s.balanceOf(employee, compOff.ID, fixtureYear); balance.AllocatedDays != 1 {
+		t.Fatalf("expected 1 day of comp-off credited, got %.1f", balance.AllocatedDays)
+	}
+	s.expectReconciled(fixtureYear)
+
+	// A rejected day can be claimed again
+	s.claimCompOff(employee, "2025-03-04").expect(http.StatusCreated)
+}
+
+func TestConcurrentCompOffClaims(t *testing.T) {
+	s, _ := newCompOffServer(t)
+	employee := s.fx.employee
+	s.logTime(employee, "2025-03-08", "09:00", "17:00", 8).expect(http.StatusCreated)
+	s.as(employee, http.MethodPost, "/api/v1/timesheets/submit?start_date=2025-03-03&end_date=2025-03-09", nil).expect(http.StatusOK)
+
+	const attempts = 5
+	var wg sync.WaitGroup
+	codes := make(chan int, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- s.claimCompOff(employee, "2025-03-08").recorder.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	claimed := 0
+	for code := range codes {
+		if code == http.StatusCreated {
+			claimed++
+		} else if code != http.StatusConflict {
+			t.Errorf("unexpected status %d from a concurrent claim", code)
+		}
+	}
+	if claimed != 1 {
+		t.Fatalf("expected one claim for the day, got %d", claimed)
+	}
+
+	// The index backs the check up
+	var claim models.CompOffClaim
+	s.db.Where("user_id = ?", employee.ID).First(&claim)
+	duplicate := claim
+	duplicate.ID = uuid.Nil
+	if err := s.db.Create(&duplicate).Error; err == nil {
+		t.Fatal("expected a second live claim for the day to be refused")
+	}
+}
+
+func TestCompOffExpiry(t *testing.T) {
+	s, compOff := newCompOffServer(t)
+	manager, employee := s.fx.manager, s.fx.employee
+
+	// Two Saturdays earn a day each; one day is taken before either expires
+	s.logTime(employee, "2025-03-01", "09:00", "17:00", 8).expect(http.StatusCreated)
+	s.logTime(employee, "2025-03-08", "09:00", "17:00", 8).expect(http.StatusCreated)
+	s.as(employee, http.MethodPost, "/api/v1/timesheets/submit?start_date=2025-03-01&end_date=2025-03-09", nil).expect(http.StatusOK)
+
+	var older, newer models.CompOffClaim
+	s.claimCompOff(employee, "2025-03-01").expect(http.StatusCreated).decode(&older)
+	s.claimCompOff(employee, "2025-03-08").expect(http.StatusCreated).decode(&newer)
+	for _, claim := range []models.CompOffClaim{older, newer} {
+		s.as(manager, http.MethodPut, "/api/v1/admin/comp-offs/"+claim.ID.String()+"/approve", nil).expect(http.StatusOK)
+	}
+
+	var leave models.LeaveApplication
+	s.as(employee, http.MethodPost, "/api/v1/leaves/", gin.H{
+		"leave_type_id": compOff.ID,
+		"start_date":    "2025-03-10",
+		"end_date":      "2025-03-10",
+	}).expect(http.StatusCreated).decode(&leave)
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/approve", nil).expect(http.StatusOK)
+
+	var result struct {
+		Expired int `json:"expired"`
+	}
+	expire := func(asOf time.Time) int {
+		t.Helper()
+		s.as(s.fx.admin, http.MethodPost, "/api/v1/admin/comp-offs/expiry", gin.H{"as_of": asOf.Format(time.RFC3339)}).
+			expect(http.StatusOK).decode(&result)
+		return result.Expired
+	}
+	s.as(manager, http.MethodPost, "/api/v1/admin/comp-offs/expiry", nil).expect(http.StatusForbidden)
+
+	// The day taken used up the older credit, so nothing lapses with it
+	if n := expire(older.ExpiresAt.Add(time.Hour)); n != 1 {
+		t.Fatalf("expected the older credit to expire, got %d", n)
+	}
+	if balance := s.balanceOf(employee, compOff.ID, fixtureYear); balance.Available() != 1 {
+		t.Fatalf("expected the newer credit still available, got %.1f", balance.Available())
+	}
+
+	// The newer one was never used and lapses in full
+	if n := expire(newer.ExpiresAt.Add(time.Hour)); n != 1 {
+		t.Fatalf("expected the newer credit to expire, got %d", n)
+	}
+	if n := expire(newer.ExpiresAt.Add(time.Hour)); n != 0 {
+		t.Fatalf("expected credits to expire once, got %d", n)
+	}
+	balance := s.balanceOf(employee, compOff.ID, fixtureYear)
+	if balance.Available() != 0 || balance.UsedDays != 1 {
+		t.Fatalf("expected the unused credit lapsed and the day taken kept, got %+v", balance)
+	}
+
+	var claims struct {
+		Claims []models.CompOffClaim `json:"claims"`
+	}
+	s.as(employee, http.MethodGet, "/api/v1/comp-offs/?status=expired", nil).expect(http.StatusOK).decode(&claims)
+	if len(claims.Claims) != 2 || claims.Claims[0].LapsedDays != 1 || claims.Claims[1].LapsedDays != 0 {
+		t.Fatalf("expected both claims expired, the newer one lapsing a day, got %+v", claims.Claims)
+	}
+	s.expectReconciled(fixtureYear)
+}
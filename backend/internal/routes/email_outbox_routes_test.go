@@ -0,0 +1,70 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"employee-dashboard-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// waitForEmail waits for the outbox worker to finish with the email queued to
+// address and returns it as stored.
+func (s *testServer) waitForEmail(address string) models.EmailOutbox {
+	s.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var email models.EmailOutbox
+		err := s.db.Where("to_address = ? AND status IN ?", address, []string{models.EmailStatusSent, models.EmailStatusFailed}).
+			First(&email).Error
+		if err == nil {
+			return email
+		}
+		if time.Now().After(deadline) {
+			s.t.Fatalf("no email delivered to %s: %v", address, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSensitiveEmails(t *testing.T) {
+	s := newTestServer(t)
+	admin, employee := s.fx.admin, s.fx.employee
+
+	s.request(http.MethodPost, "/api/v1/auth/forgot-password", "", gin.H{"email": employee.Email}).expect(http.StatusOK)
+	stored := s.waitForEmail(strings.ToLower(employee.Email))
+	if !stored.Sensitive || stored.TextBody != "" || stored.HTMLBody != "" {
+		t.Fatalf("expected the OTP email marked sensitive and its body cleared once sent, got %+v", stored)
+	}
+
+	path := "/api/v1/admin/emails/" + stored.ID.String()
+	var email models.EmailOutbox
+	s.as(admin, http.MethodGet, path, nil).expect(http.StatusOK).decode(&email)
+	if !email.Sensitive || email.TextBody != "" || len(email.DeliveryLog) != 1 {
+		t.Fatalf("expected a redacted OTP email with its delivery attempt, got %+v", email)
+	}
+	s.as(admin, http.MethodPost, path+"/resend", nil).expect(http.StatusConflict)
+
+	// A pending OTP email keeps its body for the worker, hidden from admins
+	pending := models.EmailOutbox{ToAddress: "new@example.com", Subject: "Verify Your teamDa Account", TextBody: "OTP: 123456",
+		Sensitive: true, Status: models.EmailStatusPending, MaxAttempts: 5, NextAttemptAt: time.Now().Add(time.Hour)}
+	s.create(&pending)
+	s.as(admin, http.MethodGet, "/api/v1/admin/emails/"+pending.ID.String(), nil).expect(http.StatusOK).decode(&email)
+	if strings.Contains(email.TextBody, "123456") {
+		t.Fatalf("expected the pending OTP redacted, got %q", email.TextBody)
+	}
+
+	// Other emails can still be read and resent
+	failed := models.EmailOutbox{ToAddress: "team@example.com", Subject: "Leave approved", TextBody: "Enjoy your break",
+		Status: models.EmailStatusFailed, Attempts: 5, MaxAttempts: 5, NextAttemptAt: time.Now()}
+	s.create(&failed)
+	failedPath := "/api/v1/admin/emails/" + failed.ID.String()
+	s.as(admin, http.MethodGet, failedPath, nil).expect(http.StatusOK).decode(&email)
+	if email.TextBody != "Enjoy your break" {
+		t.Fatalf("expected the body of an ordinary email, got %q", email.TextBody)
+	}
+	s.as(admin, http.MethodPost, failedPath+"/resend", nil).expect(http.StatusOK)
+}
@@ -0,0 +1,203 @@
+package routes
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// upload posts content as the multipart file field on behalf of u.
+func (s *testServer) upload(u models.User, path, filename, content string) *testResponse {
+	s.t.Helper()
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		s.t.Fatalf("failed to build upload: %v", err)
+	}
+	part.Write([]byte(content))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, path, &buf)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+s.token(u))
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return &testResponse{t: s.t, method: http.MethodPost, path: path, recorder: w}
+}
+
+// holidaysOf returns the holidays of year as anyone sees them.
+func (s *testServer) holidaysOf(year string) []models.Event {
+	s.t.Helper()
+	var holidays []models.Event
+	s.as(s.fx.employee, http.MethodGet, "/api/v1/holidays/year?year="+year, nil).expect(http.StatusOK).decode(&holidays)
+	return holidays
+}
+
+func TestHolidayAdmin(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.fx.admin
+
+	founders := gin.H{"title": "Founders Day", "date": "2025-03-05", "description": "Company birthday"}
+	s.as(s.fx.manager, http.MethodPost, "/api/v1/admin/holidays/", founders).expect(http.StatusForbidden)
+
+	var holiday models.Event
+	s.as(admin, http.MethodPost, "/api/v1/admin/holidays/", founders).expect(http.StatusCreated).decode(&holiday)
+	if holiday.HolidayType == nil || *holiday.HolidayType != services.HolidayTypePublic || holiday.Location != nil || !holiday.IsCompanyWide {
+		t.Fatalf("expected a company-wide public holiday, got %+v", holiday)
+	}
+	s.as(admin, http.MethodPost, "/api/v1/admin/holidays/", gin.H{"title": "founders day ", "date": "2025-03-05"}).
+		expect(http.StatusConflict)
+	s.as(admin, http.MethodPost, "/api/v1/admin/holidays/", gin.H{"title": "Founders Day", "date": "2025-03-05", "location": "Pune"}).
+		expect(http.StatusCreated)
+
+	var updated models.Event
+	path := "/api/v1/admin/holidays/" + holiday.ID.String()
+	s.as(admin, http.MethodPut, path, gin.H{"is_optional": true, "holiday_type": "Optional"}).expect(http.StatusOK).decode(&updated)
+	if !updated.IsOptional || *updated.HolidayType != services.HolidayTypeOptional || *updated.Description != "Company birthday" {
+		t.Fatalf("expected only the optional flag and type changed, got %+v", updated)
+	}
+	s.as(admin, http.MethodPut, path, gin.H{"location": "pune"}).expect(http.StatusConflict)
+
+	// Leave taken on an optional holiday is charged again
+	var preview struct {
+		Days float64 `json:"total_days"`
+	}
+	s.as(s.fx.employee, http.MethodGet, "/api/v1/leaves/preview?leave_type_id="+s.fx.leaveType.ID.String()+"&start_date=2025-03-05&end_date=2025-03-05", nil).
+		expect(http.StatusOK).decode(&preview)
+	if preview.Days != 1 {
+		t.Fatalf("expected an optional holiday to be charged, got %.1f days", preview.Days)
+	}
+
+	s.as(admin, http.MethodDelete, path, nil).expect(http.StatusOK)
+	s.as(admin, http.MethodDelete, path, nil).expect(http.StatusNotFound)
+	if holidays := s.holidaysOf("2025"); len(holidays) != 1 || holidays[0].Location == nil {
+		t.Fatalf("expected only the Pune holiday left, got %+v", holidays)
+	}
+}
+
+func TestHolidayCSVImport(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.fx.admin
+	importPath := "/api/v1/admin/holidays/import"
+
+	var result services.HolidayImportResult
+	csv := "holiday_name,holiday_date,holiday_type,description,is_optional\n" +
+		"Republic Day,2025-01-26,public,India's Republic Day,false\n" +
+		"Holi,2025-03-14,optional,\"Festival of Colors, spring\",true\n"
+
+	s.upload(s.fx.employee, importPath, "holidays.csv", csv).expect(http.StatusForbidden)
+	s.upload(admin, importPath+"?dry_run=true", "holidays.csv", csv).expect(http.StatusOK).decode(&result)
+	if !result.DryRun || len(result.Created) != 2 || len(s.holidaysOf("2025")) != 0 {
+		t.Fatalf("expected a preview of 2 new holidays and nothing written, got %+v", result)
+	}
+
+	s.upload(admin, importPath, "holidays.csv", csv).expect(http.StatusOK).decode(&result)
+	holidays := s.holidaysOf("2025")
+	if len(result.Created) != 2 || len(holidays) != 2 {
+		t.Fatalf("expected 2 holidays imported, got %+v", result)
+	}
+	if holi := holidays[1]; !holi.IsOptional || *holi.HolidayType != "optional" || *holi.Description != "Festival of Colors, spring" {
+		t.Fatalf("expected the type and optional flag in their own fields, got %+v", holi)
+	}
+
+	// Importing the same file again changes nothing
+	s.upload(admin, importPath, "holidays.csv", csv).expect(http.StatusOK).decode(&result)
+	if len(result.Created) != 0 || len(result.Updated) != 0 || result.Unchanged != 2 {
+		t.Fatalf("expected a re-import to change nothing, got %+v", result)
+	}
+
+	// A revised calendar: Holi moves to public, Republic Day is dropped
+	revised := "holiday_name,holiday_date,holiday_type,description,is_optional\n" +
+		"Holi,2025-03-14,public,\"Festival of Colors, spring\",false\n" +
+		"Diwali,2025-10-20,public,Festival of Lights,false\n"
+	s.upload(admin, importPath+"?dry_run=true&prune=true", "holidays.csv", revised).expect(http.StatusOK).decode(&result)
+	if len(result.Created) != 1 || len(result.Updated) != 1 || len(result.Deleted) != 1 || result.Deleted[0].Title != "Republic Day" {
+		t.Fatalf("unexpected preview of the revised calendar: %+v", result)
+	}
+	if update := result.Updated[0]; !update.Before.IsOptional || update.After.IsOptional {
+		t.Fatalf("expected the preview to show Holi becoming mandatory, got %+v", update)
+	}
+	if len(s.holidaysOf("2025")) != 2 {
+		t.Fatal("a dry run must not change the calendar")
+	}
+
+	s.upload(admin, importPath+"?prune=true", "holidays.csv", revised).expect(http.StatusOK)
+	holidays = s.holidaysOf("2025")
+	if len(holidays) != 2 || holidays[0].Title != "Holi" || holidays[0].IsOptional || holidays[1].Title != "Diwali" {
+		t.Fatalf("expected the revised calendar, got %+v", holidays)
+	}
+
+	// Bad rows reject the whole file
+	bad := "holiday_name,holiday_date\nGood Friday,2025-04-18\nEaster,18/04/2025\n"
+	s.upload(admin, importPath, "holidays.csv", bad).expect(http.StatusBadRequest)
+	s.upload(admin, importPath, "holidays.txt", bad).expect(http.StatusBadRequest)
+	if len(s.holidaysOf("2025")) != 2 {
+		t.Fatal("a rejected file must not change the calendar")
+	}
+}
+
+func TestHolidayICSImport(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.fx.admin
+
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:ganesh@example.com",
+		"DTSTART;VALUE=DATE:20250827",
+		"DTEND;VALUE=DATE:20250829",
+		"SUMMARY:Ganesh Chaturthi\\, Pune",
+		"DESCRIPTION:Two days off for the festival\\; offices closed. The support des",
+		" k stays open.",
+		"CATEGORIES:HOLIDAY,REGIONAL",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:onam@example.com",
+		"DTSTART;VALUE=DATE:20250905",
+		"SUMMARY:Onam (optional)",
+		"CATEGORIES:HOLIDAY",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:dropped@example.com",
+		"DTSTART;VALUE=DATE:20250910",
+		"SUMMARY:Dropped",
+		"STATUS:CANCELLED",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n") + "\r\n"
+
+	var result services.HolidayImportResult
+	s.upload(admin, "/api/v1/admin/holidays/import?location=Pune", "pune.ics", ics).expect(http.StatusOK).decode(&result)
+	if len(result.Created) != 3 {
+		t.Fatalf("expected two days of Ganesh Chaturthi and Onam, got %+v", result.Created)
+	}
+
+	holidays := s.holidaysOf("2025")
+	if len(holidays) != 3 {
+		t.Fatalf("expected 3 holidays, got %d", len(holidays))
+	}
+	ganesh, onam := holidays[0], holidays[2]
+	if ganesh.Title != "Ganesh Chaturthi, Pune" || *ganesh.HolidayType != "regional" || ganesh.Location == nil || *ganesh.Location != "Pune" ||
+		*ganesh.Description != "Two days off for the festival; offices closed. The support desk stays open." {
+		t.Fatalf("unexpected imported holiday: %+v", ganesh)
+	}
+	if onam.Title != "Onam" || !onam.IsOptional || *onam.HolidayType != services.HolidayTypeOptional {
+		t.Fatalf("expected Onam imported as optional, got %+v", onam)
+	}
+
+	s.upload(admin, "/api/v1/admin/holidays/import?location=Pune", "pune.ics", ics).expect(http.StatusOK).decode(&result)
+	if len(result.Created) != 0 || result.Unchanged != 3 {
+		t.Fatalf("expected a re-import to change nothing, got %+v", result)
+	}
+	s.upload(admin, "/api/v1/admin/holidays/import", "pune.ics", "not a calendar").expect(http.StatusBadRequest)
+}
@@ -0,0 +1,210 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"employee-dashboard-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// approvalTree adds a team lead reporting to the fixture manager, a member
+// of the lead's team with a balance of the fixture leave type, and an HR
+// user reporting to the admin.
+func (s *testServer) approvalTree() (lead, member, hr models.User) {
+	s.t.Helper()
+	lead = s.addUser("LEAD", models.RoleTeamLead, &s.fx.manager.ID)
+	member = s.addUser("MEMBER", models.RoleEmployee, &lead.ID)
+	hr = s.addUser("HR", models.RoleHR, &s.fx.admin.ID)
+	s.create(&models.LeaveBalance{UserID: member.ID, LeaveTypeID: s.fx.leaveType.ID, Year: fixtureYear, AllocatedDays: 12})
+	return lead, member, hr
+}
+
+// approvalStatuses returns the statuses of a leave's approval steps in order.
+func approvalStatuses(leave models.LeaveApplication) string {
+	var statuses []string
+	for _, approval := range leave.Approvals {
+		statuses = append(statuses, approval.Status)
+	}
+	return fmt.Sprint(statuses)
+}
+
+func TestLeaveApprovalChain(t *testing.T) {
+	s := newTestServer(t)
+	admin, manager := s.fx.admin, s.fx.manager
+	lead, member, hr := s.approvalTree()
+
+	chain := gin.H{
+		"name":          "Long casual leave",
+		"leave_type_id": s.fx.leaveType.ID,
+		"min_days":      3,
+		"steps": []gin.H{
+			{"approver": "manager"},
+			{"approver": "skip_manager"},
+			{"approver": "role", "approver_role": "hr"},
+		},
+	}
+	s.as(manager, http.MethodPost, "/api/v1/leave-approval-chains/", chain).expect(http.StatusForbidden)
+	s.as(admin, http.MethodPost, "/api/v1/leave-approval-chains/", gin.H{
+		"name": "Invalid", "steps": []gin.H{{"approver": "role", "approver_role": "employee"}},
+	}).expect(http.StatusBadRequest)
+	s.as(admin, http.MethodPost, "/api/v1/leave-approval-chains/", chain).expect(http.StatusCreated)
+
+	// Team lead, then manager, then HR
+	leave := s.applyForLeave(member, "2025-03-03", "2025-03-05")
+	if got := approvalStatuses(leave); got != "[pending waiting waiting]" {
+		t.Fatalf("expected three approval steps, got %s", got)
+	}
+	path := "/api/v1/admin/leaves/" + leave.ID.String() + "/approve"
+	s.as(manager, http.MethodPut, path, nil).expect(http.StatusForbidden)
+
+	var queue []models.LeaveApplication
+	s.as(lead, http.MethodGet, "/api/v1/admin/leaves/approvals", nil).expect(http.StatusOK).decode(&queue)
+	if len(queue) != 1 || queue[0].ID != leave.ID {
+		t.Fatalf("expected the leave in the team lead's queue, got %d leaves", len(queue))
+	}
+
+	var decided models.LeaveApplication
+	s.as(lead, http.MethodPut, path, gin.H{"note": "Covered by the team"}).expect(http.StatusOK).decode(&decided)
+	if decided.Status != models.LeaveStatusPending || approvalStatuses(decided) != "[approved pending waiting]" {
+		t.Fatalf("expected the leave to wait for the manager, got %s %s", decided.Status, approvalStatuses(decided))
+	}
+	s.as(lead, http.MethodPut, path, nil).expect(http.StatusForbidden)
+	s.as(manager, http.MethodPut, path, nil).expect(http.StatusOK)
+
+	s.as(hr, http.MethodPut, path, nil).expect(http.StatusOK).decode(&decided)
+	if decided.Status != models.LeaveStatusApproved || approvalStatuses(decided) != "[approved approved approved]" {
+		t.Fatalf("expected the leave approved by HR, got %s %s", decided.Status, approvalStatuses(decided))
+	}
+	if used := s.balanceOf(member, s.fx.leaveType.ID, fixtureYear).UsedDays; used != 3 {
+		t.Fatalf("expected 3 days charged on the last approval, got %.1f", used)
+	}
+
+	// Shorter leaves are approved in one step as before
+	short := s.applyForLeave(member, "2025-03-10", "2025-03-11")
+	if len(short.Approvals) != 0 {
+		t.Fatalf("expected no approval chain for a 2-day leave, got %d steps", len(short.Approvals))
+	}
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+short.ID.String()+"/approve", nil).
+		expect(http.StatusOK).decode(&decided)
+	if decided.Status != models.LeaveStatusApproved {
+		t.Fatalf("expected the short leave approved, got %s", decided.Status)
+	}
+
+	// A rejection ends the chain
+	rejected := s.applyForLeave(member, "2025-04-07", "2025-04-10")
+	s.as(lead, http.MethodPut, "/api/v1/admin/leaves/"+rejected.ID.String()+"/reject", gin.H{"rejection_reason": "Release week"}).
+		expect(http.StatusOK).decode(&decided)
+	if decided.Status != models.LeaveStatusRejected || approvalStatuses(decided) != "[rejected skipped skipped]" {
+		t.Fatalf("expected the leave rejected by the team lead, got %s %s", decided.Status, approvalStatuses(decided))
+	}
+}
+
+func TestLeaveApprovalRoleStepRespectsTeamScope(t *testing.T) {
+	s := newTestServer(t)
+	admin, manager, employee := s.fx.admin, s.fx.manager, s.fx.employee
+	other := s.addUser("OTHER", models.RoleManager, &admin.ID)
+
+	s.as(admin, http.MethodPost, "/api/v1/leave-approval-chains/", gin.H{
+		"name":          "Managers",
+		"leave_type_id": s.fx.leaveType.ID,
+		"steps":         []gin.H{{"approver": "role", "approver_role": "manager"}},
+	}).expect(http.StatusCreated)
+	leave := s.applyForLeave(employee, "2025-03-03", "2025-03-05")
+
+	// A manager outside the employee's reporting tree neither sees nor
+	// decides the step
+	var queue []models.LeaveApplication
+	s.as(other, http.MethodGet, "/api/v1/admin/leaves/approvals", nil).expect(http.StatusOK).decode(&queue)
+	if len(queue) != 0 {
+		t.Fatalf("expected an empty queue outside the team scope, got %d leaves", len(queue))
+	}
+	s.as(other, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/approve", nil).expect(http.StatusForbidden)
+	s.as(other, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/reject", gin.H{"rejection_reason": "No"}).
+		expect(http.StatusForbidden)
+
+	s.as(manager, http.MethodGet, "/api/v1/admin/leaves/approvals", nil).expect(http.StatusOK).decode(&queue)
+	if len(queue) != 1 || queue[0].ID != leave.ID {
+		t.Fatalf("expected the leave in the manager's queue, got %d leaves", len(queue))
+	}
+	var decided models.LeaveApplication
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/approve", nil).
+		expect(http.StatusOK).decode(&decided)
+	if decided.Status != models.LeaveStatusApproved {
+		t.Fatalf("expected the leave approved by the employee's manager, got %s", decided.Status)
+	}
+}
+
+func TestLeaveApprovalDelegationAndEscalation(t *testing.T) {
+	s := newTestServer(t)
+	admin, manager := s.fx.admin, s.fx.manager
+	lead, member, hr := s.approvalTree()
+
+	s.as(admin, http.MethodPost, "/api/v1/leave-approval-chains/", gin.H{
+		"name":  "Team lead",
+		"steps": []gin.H{{"approver": "manager", "sla_hours": 24}},
+	}).expect(http.StatusCreated)
+
+	// The team lead is out of office and hands their approvals to HR
+	today := time.Now().Format("2006-01-02")
+	nextWeek := time.Now().AddDate(0, 0, 7).Format("2006-01-02")
+	s.as(member, http.MethodPost, "/api/v1/leave-delegations/", gin.H{"delegate_id": hr.ID, "start_date": today, "end_date": nextWeek}).
+		expect(http.StatusForbidden)
+	s.as(lead, http.MethodPost, "/api/v1/leave-delegations/", gin.H{"delegate_id": member.ID, "start_date": today, "end_date": nextWeek}).
+		expect(http.StatusBadRequest)
+	var delegation models.ApprovalDelegation
+	s.as(lead, http.MethodPost, "/api/v1/leave-delegations/", gin.H{"delegate_id": hr.ID, "start_date": today, "end_date": nextWeek, "reason": "Vacation"}).
+		expect(http.StatusCreated).decode(&delegation)
+
+	leave := s.applyForLeave(member, "2025-03-03", "2025-03-03")
+	var decided models.LeaveApplication
+	s.as(hr, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/approve", nil).expect(http.StatusOK).decode(&decided)
+	step := decided.Approvals[0]
+	if decided.Status != models.LeaveStatusApproved || step.OnBehalfOf == nil || *step.OnBehalfOf != lead.ID || *step.DecidedBy != hr.ID {
+		t.Fatalf("expected HR to approve on behalf of the team lead, got %s %+v", decided.Status, step)
+	}
+
+	// Once revoked the delegate has no say
+	s.as(hr, http.MethodDelete, "/api/v1/leave-delegations/"+delegation.ID.String(), nil).expect(http.StatusForbidden)
+	s.as(lead, http.MethodDelete, "/api/v1/leave-delegations/"+delegation.ID.String(), nil).expect(http.StatusOK)
+	leave = s.applyForLeave(member, "2025-03-04", "2025-03-04")
+	path := "/api/v1/admin/leaves/" + leave.ID.String() + "/approve"
+	s.as(hr, http.MethodPut, path, nil).expect(http.StatusForbidden)
+
+	// Left open past the SLA, the step goes to the team lead's manager too
+	var result struct {
+		Escalated int `json:"escalated"`
+	}
+	s.as(admin, http.MethodPost, "/api/v1/leave-approval-chains/escalations", gin.H{"as_of": time.Now().Add(12 * time.Hour).Format(time.RFC3339)}).
+		expect(http.StatusOK).decode(&result)
+	if result.Escalated != 0 {
+		t.Fatalf("expected nothing escalated within the SLA, got %d", result.Escalated)
+	}
+	s.as(manager, http.MethodPut, path, nil).expect(http.StatusForbidden)
+
+	asOf := time.Now().Add(25 * time.Hour).Format(time.RFC3339)
+	s.as(admin, http.MethodPost, "/api/v1/leave-approval-chains/escalations", gin.H{"as_of": asOf}).
+		expect(http.StatusOK).decode(&result)
+	if result.Escalated != 1 {
+		t.Fatalf("expected one step escalated, got %d", result.Escalated)
+	}
+	s.as(admin, http.MethodPost, "/api/v1/leave-approval-chains/escalations", gin.H{"as_of": asOf}).
+		expect(http.StatusOK).decode(&result)
+	if result.Escalated != 0 {
+		t.Fatalf("expected a step to be escalated once, got %d", result.Escalated)
+	}
+
+	var notified int64
+	s.db.Model(&models.Notification{}).Where("user_id = ? AND type = ?", manager.ID, models.NotificationLeaveApprovalEscalated).Count(&notified)
+	if notified != 1 {
+		t.Fatalf("expected the manager to be notified of the escalation, got %d notifications", notified)
+	}
+
+	s.as(manager, http.MethodPut, path, nil).expect(http.StatusOK).decode(&decided)
+	if decided.Status != models.LeaveStatusApproved || decided.Approvals[0].EscalatedTo == nil || *decided.Approvals[0].EscalatedTo != manager.ID {
+		t.Fatalf("expected the manager to approve the escalated leave, got %s %+v", decided.Status, decided.Approvals[0])
+	}
+}
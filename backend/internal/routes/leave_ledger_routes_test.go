@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"employee-dashboard-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type statementEntry struct {
+	Kind           string  `json:"kind"`
+	Days           float64 `json:"days"`
+	RunningBalance float64 `json:"running_balance"`
+	Note           *string `json:"note"`
+}
+
+// statement returns u's ledger entries of the fixture leave type for 2025.
+func (s *testServer) statement(u models.User) []statementEntry {
+	s.t.Helper()
+	var statements []struct {
+		Balance models.LeaveBalance `json:"balance"`
+		Entries []statementEntry    `json:"entries"`
+	}
+	s.as(u, http.MethodGet, "/api/v1/leaves/balance/statement?year=2025", nil).expect(http.StatusOK).decode(&statements)
+	for _, statement := range statements {
+		if statement.Balance.LeaveTypeID == s.fx.leaveType.ID {
+			return statement.Entries
+		}
+	}
+	s.t.Fatalf("no statement for leave type %s", s.fx.leaveType.ID)
+	return nil
+}
+
+// expectReconciled fails the test unless every balance of year matches its ledger.
+func (s *testServer) expectReconciled(year int) {
+	s.t.Helper()
+	var mismatches []map[string]interface{}
+	s.as(s.fx.admin, http.MethodGet, fmt.Sprintf("/api/v1/leave-allocations/reconcile?year=%d", year), nil).
+		expect(http.StatusOK).decode(&mismatches)
+	if len(mismatches) != 0 {
+		s.t.Fatalf("expected the %d balances to match the ledger, got %v", year, mismatches)
+	}
+}
+
+func TestLeaveBalanceStatement(t *testing.T) {
+	s := newTestServer(t)
+	manager, employee := s.fx.manager, s.fx.employee
+
+	leave := s.applyForLeave(employee, "2025-03-03", "2025-03-07")
+	path := "/api/v1/admin/leaves/" + leave.ID.String()
+	s.as(manager, http.MethodPut, path+"/approve", nil).expect(http.StatusOK)
+	s.as(employee, http.MethodPost, "/api/v1/leaves/"+leave.ID.String()+"/cancel", gin.H{"cancel_from": "2025-03-06"}).
+		expect(http.StatusOK)
+	s.as(manager, http.MethodPut, path+"/cancellation/approve", nil).expect(http.StatusOK)
+
+	entries := s.statement(employee)
+	var got []string
+	for _, entry := range entries {
+		got = append(got, fmt.Sprintf("%s %g = %g", entry.Kind, entry.Days, entry.RunningBalance))
+	}
+	want := "[opening 12 = 12 deduction -5 = 7 reversal 2 = 9]"
+	if fmt.Sprint(got) != want {
+		t.Fatalf("expected statement %s, got %v", want, got)
+	}
+	s.expectReconciled(2025)
+}
+
+func TestLeaveBalanceAdjustment(t *testing.T) {
+	s := newTestServer(t)
+	admin, employee := s.fx.admin, s.fx.employee
+
+	adjust := gin.H{
+		"user_id":       employee.ID,
+		"leave_type_id": s.fx.leaveType.ID,
+		"year":          2025,
+		"days":          2.5,
+		"reason":        "Worked the product launch weekend",
+	}
+	s.as(employee, http.MethodPost, "/api/v1/leave-allocations/adjustments", adjust).expect(http.StatusForbidden)
+	s.as(admin, http.MethodPost, "/api/v1/leave-allocations/adjustments", gin.H{
+		"user_id": employee.ID, "leave_type_id": s.fx.leaveType.ID, "year": 2025, "days": 1,
+	}).expect(http.StatusBadRequest)
+
+	var balance models.LeaveBalance
+	s.as(admin, http.MethodPost, "/api/v1/leave-allocations/adjustments", adjust).expect(http.StatusOK).decode(&balance)
+	if balance.AllocatedDays != 14.5 || balance.RemainingDays != 14.5 {
+		t.Fatalf("expected 14.5 days after the adjustment, got %+v", balance)
+	}
+
+	// A deduction may not take the balance below zero
+	adjust["days"] = -15
+	s.as(admin, http.MethodPost, "/api/v1/leave-allocations/adjustments", adjust).expect(http.StatusBadRequest)
+
+	entries := s.statement(employee)
+	last := entries[len(entries)-1]
+	if last.Kind != models.LedgerAdjustment || last.Days != 2.5 || last.Note == nil || *last.Note != "Worked the product launch weekend" {
+		t.Fatalf("expected the adjustment in the ledger, got %+v", last)
+	}
+	s.expectReconciled(2025)
+}
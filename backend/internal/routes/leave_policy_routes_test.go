@@ -0,0 +1,142 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"employee-dashboard-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// balanceOf returns u's balance of leaveTypeID for year, as u sees it.
+func (s *testServer) balanceOf(u models.User, leaveTypeID uuid.UUID, year int) models.LeaveBalance {
+	s.t.Helper()
+	var balances []models.LeaveBalance
+	s.as(u, http.MethodGet, fmt.Sprintf("/api/v1/leaves/balance?year=%d", year), nil).expect(http.StatusOK).decode(&balances)
+	for _, balance := range balances {
+		if balance.LeaveTypeID == leaveTypeID {
+			return balance
+		}
+	}
+	s.t.Fatalf("no %d balance for leave type %s", year, leaveTypeID)
+	return models.LeaveBalance{}
+}
+
+// runPolicies runs the leave policy job as of date as the admin.
+func (s *testServer) runPolicies(date string) models.LeavePolicyRun {
+	s.t.Helper()
+	var run models.LeavePolicyRun
+	s.as(s.fx.admin, http.MethodPost, "/api/v1/leave-policies/runs", gin.H{"as_of": date}).
+		expect(http.StatusOK).decode(&run)
+	if run.Status != models.PolicyRunSucceeded {
+		s.t.Fatalf("expected a successful run, got %q", run.Status)
+	}
+	return run
+}
+
+func TestLeavePolicyAccrual(t *testing.T) {
+	s := newTestServer(t)
+	admin, employee, outsider := s.fx.admin, s.fx.employee, s.fx.outsider
+
+	earned := models.LeaveType{Name: "Earned Leave", IsActive: true}
+	s.create(&earned)
+	path := "/api/v1/leave-policies/" + earned.ID.String()
+	s.as(employee, http.MethodPut, path, gin.H{"annual_days": 18, "accrual_frequency": "monthly"}).expect(http.StatusForbidden)
+	s.as(admin, http.MethodPut, path, gin.H{"annual_days": 18, "accrual_frequency": "fortnightly"}).expect(http.StatusBadRequest)
+	s.as(admin, http.MethodPut, path, gin.H{"annual_days": 18, "accrual_frequency": "monthly", "max_balance": 5}).
+		expect(http.StatusOK)
+
+	// Hired halfway through February: half of February's 1.5 days, then 1.5
+	// days for March and April, rounded to half days
+	hired := time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC)
+	if err := s.db.Model(&employee).Update("hire_date", hired).Error; err != nil {
+		t.Fatalf("failed to set hire date: %v", err)
+	}
+
+	run := s.runPolicies("2025-04-10")
+	if balance := s.balanceOf(employee, earned.ID, 2025); balance.AllocatedDays != 4 {
+		t.Fatalf("expected 4 days accrued for the employee, got %.2f", balance.AllocatedDays)
+	}
+	if balance := s.balanceOf(outsider, earned.ID, 2025); balance.AllocatedDays != 5 {
+		t.Fatalf("expected accrual capped at 5 days for the outsider, got %.2f", balance.AllocatedDays)
+	}
+	if run.BalancesAccrued == 0 || run.TriggeredBy == nil || *run.TriggeredBy != admin.ID {
+		t.Fatalf("unexpected run record %+v", run)
+	}
+
+	// Running again for the same month changes nothing
+	if again := s.runPolicies("2025-04-20"); again.BalancesAccrued != 0 {
+		t.Fatalf("expected nothing accrued on a repeated run, got %d balances", again.BalancesAccrued)
+	}
+
+	// The cap withholds what does not fit; the employee reaches it in May
+	s.runPolicies("2025-06-01")
+	if balance := s.balanceOf(employee, earned.ID, 2025); balance.AllocatedDays != 5 {
+		t.Fatalf("expected accrual capped at 5 days for the employee, got %.2f", balance.AllocatedDays)
+	}
+
+	var runs struct {
+		Runs []models.LeavePolicyRun `json:"runs"`
+	}
+	s.as(admin, http.MethodGet, "/api/v1/leave-policies/runs", nil).expect(http.StatusOK).decode(&runs)
+	if len(runs.Runs) != 3 {
+		t.Fatalf("expected 3 recorded runs, got %d", len(runs.Runs))
+	}
+	s.expectReconciled(2025)
+}
+
+func TestLeavePolicyYearEndRollover(t *testing.T) {
+	s := newTestServer(t)
+	admin, manager, employee := s.fx.admin, s.fx.manager, s.fx.employee
+	leaveType := s.fx.leaveType.ID
+
+	s.as(admin, http.MethodPut, "/api/v1/leave-policies/"+leaveType.String(), gin.H{
+		"annual_days":                 12,
+		"accrual_frequency":           "yearly",
+		"carry_forward_limit":         5,
+		"carry_forward_expiry_months": 3,
+		"encashment_enabled":          true,
+		"max_encash_days":             2,
+	}).expect(http.StatusOK)
+
+	// 3 of 12 days taken in 2025: 5 carried, 2 encashed, 2 lapse
+	leave := s.applyForLeave(employee, "2025-03-03", "2025-03-05")
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/approve", nil).expect(http.StatusOK)
+
+	run := s.runPolicies("2026-01-01")
+	if run.DaysCarried != 10 || run.DaysEncashed != 4 || run.DaysLapsed != 7 {
+		t.Fatalf("expected 10 carried, 4 encashed and 7 lapsed days over both balances, got %+v", run)
+	}
+	closed := s.balanceOf(employee, leaveType, 2025)
+	if closed.ClosedAt == nil || closed.EncashedDays != 2 {
+		t.Fatalf("expected the 2025 balance closed with 2 days encashed, got %+v", closed)
+	}
+	opened := s.balanceOf(employee, leaveType, 2026)
+	if opened.CarriedDays != 5 || opened.AllocatedDays != 12 || opened.RemainingDays != 17 {
+		t.Fatalf("expected 12 days plus 5 carried in 2026, got %+v", opened)
+	}
+	if opened.CarryExpiresAt == nil || !opened.CarryExpiresAt.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected carried days to expire on 2026-04-01, got %v", opened.CarryExpiresAt)
+	}
+
+	// A repeated run does not roll over again
+	if again := s.runPolicies("2026-01-02"); again.BalancesRolled != 0 {
+		t.Fatalf("expected no rollover on a repeated run, got %d", again.BalancesRolled)
+	}
+
+	// 2 days taken from the carried ones; the other 3 expire
+	leave = s.applyForLeave(employee, "2026-02-02", "2026-02-03")
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/approve", nil).expect(http.StatusOK)
+	if expiry := s.runPolicies("2026-04-01"); expiry.DaysExpired != 3+5 {
+		t.Fatalf("expected 8 carried days to expire over both balances, got %.1f", expiry.DaysExpired)
+	}
+	if balance := s.balanceOf(employee, leaveType, 2026); balance.ExpiredDays != 3 || balance.RemainingDays != 12 {
+		t.Fatalf("expected 3 days expired and 12 left, got %+v", balance)
+	}
+	s.expectReconciled(2025)
+	s.expectReconciled(2026)
+}
@@ -0,0 +1,603 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"employee-dashboard-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// applyForLeave files a leave request for u and returns the created application.
+func (s *testServer) applyForLeave(u models.User, start, end string) models.LeaveApplication {
+	s.t.Helper()
+	var leave models.LeaveApplication
+	s.as(u, http.MethodPost, "/api/v1/leaves/", gin.H{
+		"leave_type_id": s.fx.leaveType.ID,
+		"start_date":    start,
+		"end_date":      end,
+		"reason":        "Family visit",
+	}).expect(http.StatusCreated).decode(&leave)
+	return leave
+}
+
+// usedDays returns u's used days of the fixture leave type, as u sees them.
+func (s *testServer) usedDays(u models.User) float64 {
+	s.t.Helper()
+	var balances []models.LeaveBalance
+	s.as(u, http.MethodGet, "/api/v1/leaves/balance?year=2025", nil).expect(http.StatusOK).decode(&balances)
+	for _, balance := range balances {
+		if balance.LeaveTypeID == s.fx.leaveType.ID {
+			return balance.UsedDays
+		}
+	}
+	s.t.Fatalf("no balance for leave type %s", s.fx.leaveType.ID)
+	return 0
+}
+
+func TestLeaveApprovalDeductsBalance(t *testing.T) {
+	s := newTestServer(t)
+	manager, employee := s.fx.manager, s.fx.employee
+
+	leave := s.applyForLeave(employee, "2025-03-03", "2025-03-05")
+	if leave.Status != "pending" || leave.PaidDays != 3 || leave.IsLOP {
+		t.Fatalf("unexpected new leave: status %q, paid %.1f, LOP %t", leave.Status, leave.PaidDays, leave.IsLOP)
+	}
+	if used := s.usedDays(employee); used != 0 {
+		t.Fatalf("pending leave must not use balance, used %.1f", used)
+	}
+
+	var listing struct {
+		Leaves []models.LeaveApplication `json:"leaves"`
+	}
+	s.as(employee, http.MethodGet, "/api/v1/leaves/", nil).expect(http.StatusOK).decode(&listing)
+	if len(listing.Leaves) != 1 || listing.Leaves[0].ID != leave.ID {
+		t.Fatalf("expected the new leave in the employee's list, got %d leaves", len(listing.Leaves))
+	}
+
+	var approved models.LeaveApplication
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/approve", nil).
+		expect(http.StatusOK).decode(&approved)
+	if approved.Status != "approved" || approved.ApprovedBy == nil || *approved.ApprovedBy != manager.ID {
+		t.Fatalf("unexpected approved leave: status %q, approved by %v", approved.Status, approved.ApprovedBy)
+	}
+	if used := s.usedDays(employee); used != 3 {
+		t.Fatalf("expected 3 used days after approval, got %.1f", used)
+	}
+
+	// Decided leaves cannot be decided again
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/approve", nil).expect(http.StatusBadRequest)
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/reject", gin.H{"rejection_reason": "late"}).
+		expect(http.StatusBadRequest)
+	if used := s.usedDays(employee); used != 3 {
+		t.Fatalf("repeated decisions must not change the balance, used %.1f", used)
+	}
+}
+
+func TestLeaveRejectionKeepsBalance(t *testing.T) {
+	s := newTestServer(t)
+	manager, employee := s.fx.manager, s.fx.employee
+
+	leave := s.applyForLeave(employee, "2025-04-07", "2025-04-08")
+
+	var rejected models.LeaveApplication
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/reject", gin.H{"rejection_reason": "Release week"}).
+		expect(http.StatusOK).decode(&rejected)
+	if rejected.Status != "rejected" || rejected.RejectionReason == nil || *rejected.RejectionReason != "Release week" {
+		t.Fatalf("unexpected rejected leave: status %q, reason %v", rejected.Status, rejected.RejectionReason)
+	}
+	if used := s.usedDays(employee); used != 0 {
+		t.Fatalf("rejected leave must not use balance, used %.1f", used)
+	}
+}
+
+func TestLeaveBeyondBalanceIsLOP(t *testing.T) {
+	s := newTestServer(t)
+
+	// 14 working days against an allocation of 12
+	leave := s.applyForLeave(s.fx.employee, "2025-05-01", "2025-05-20")
+	if !leave.IsLOP || leave.PaidDays != 12 || leave.LOPDays != 2 {
+		t.Fatalf("expected 12 paid and 2 LOP days, got paid %.1f, LOP %.1f (LOP %t)", leave.PaidDays, leave.LOPDays, leave.IsLOP)
+	}
+}
+
+func TestLeaveRequestValidation(t *testing.T) {
+	s := newTestServer(t)
+	employee := s.fx.employee
+
+	s.as(employee, http.MethodPost, "/api/v1/leaves/", gin.H{
+		"leave_type_id": s.fx.leaveType.ID,
+		"start_date":    "2025-03-05",
+		"end_date":      "2025-03-03",
+	}).expect(http.StatusBadRequest)
+
+	s.as(employee, http.MethodPost, "/api/v1/leaves/", gin.H{
+		"leave_type_id": s.fx.project.ID, // not a leave type
+		"start_date":    "2025-03-03",
+		"end_date":      "2025-03-03",
+	}).expect(http.StatusBadRequest)
+}
+
+func TestLeaveApprovalRespectsTeamScope(t *testing.T) {
+	s := newTestServer(t)
+	admin, manager, outsider := s.fx.admin, s.fx.manager, s.fx.outsider
+
+	leave := s.applyForLeave(outsider, "2025-06-02", "2025-06-02")
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/approve", nil).expect(http.StatusForbidden)
+
+	var listing struct {
+		Leaves []models.LeaveApplication `json:"leaves"`
+	}
+	s.as(manager, http.MethodGet, "/api/v1/admin/leaves/", nil).expect(http.StatusOK).decode(&listing)
+	if len(listing.Leaves) != 0 {
+		t.Fatalf("leaves outside the team scope must not be listed, got %d", len(listing.Leaves))
+	}
+
+	// Admin sees everybody
+	s.as(admin, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/approve", nil).expect(http.StatusOK)
+	if used := s.usedDays(outsider); used != 1 {
+		t.Fatalf("expected 1 used day after approval, got %.1f", used)
+	}
+}
+
+func TestLeaveSelfApprovalForbidden(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.fx.admin
+	hr := s.addUser("HR", models.RoleHR, &admin.ID)
+	balance := models.LeaveBalance{UserID: admin.ID, LeaveTypeID: s.fx.leaveType.ID, Year: fixtureYear, AllocatedDays: 12}
+	s.create(&balance)
+	s.create(models.NewLedgerEntry(&balance, models.LedgerOpening, 12))
+
+	// Company-wide scope still does not cover one's own leave
+	leave := s.applyForLeave(admin, "2025-06-02", "2025-06-03")
+	path := "/api/v1/admin/leaves/" + leave.ID.String()
+	s.as(admin, http.MethodPut, path+"/approve", nil).expect(http.StatusForbidden)
+	s.as(admin, http.MethodPut, path+"/reject", gin.H{"rejection_reason": "No"}).expect(http.StatusForbidden)
+	s.as(hr, http.MethodPut, path+"/approve", nil).expect(http.StatusOK)
+
+	// Nor does it let one grant their own cancellation
+	s.as(admin, http.MethodDelete, "/api/v1/leaves/"+leave.ID.String(), nil).expect(http.StatusOK)
+	s.as(admin, http.MethodPut, path+"/cancellation/approve", nil).expect(http.StatusForbidden)
+	s.as(admin, http.MethodPut, path+"/cancellation/reject", nil).expect(http.StatusForbidden)
+	s.as(hr, http.MethodPut, path+"/cancellation/approve", nil).expect(http.StatusOK)
+}
+
+func TestLeaveChargesWorkingDaysOnly(t *testing.T) {
+	s := newTestServer(t)
+	manager, employee := s.fx.manager, s.fx.employee
+
+	s.create(&models.Event{Title: "Holi", EventType: "holiday", EventDate: time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC), IsCompanyWide: true})
+	s.create(&models.Event{Title: "Regional Day", EventType: "holiday", EventDate: time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC), IsCompanyWide: true, IsOptional: true})
+
+	// Thursday to Monday: Friday is a holiday, then a weekend, and the
+	// optional holiday on Monday is still a working day
+	leave := s.applyForLeave(employee, "2025-03-13", "2025-03-17")
+	if leave.PaidDays != 2 {
+		t.Fatalf("expected 2 paid days, got %.1f", leave.PaidDays)
+	}
+
+	expected := []models.LeaveDay{
+		{Date: "2025-03-13", Kind: models.DayKindWorking, Session: models.SessionFull, Days: 1},
+		{Date: "2025-03-14", Kind: models.DayKindHoliday, Holiday: "Holi"},
+		{Date: "2025-03-15", Kind: models.DayKindWeekend},
+		{Date: "2025-03-16", Kind: models.DayKindWeekend},
+		{Date: "2025-03-17", Kind: models.DayKindWorking, Holiday: "Regional Day", Session: models.SessionFull, Days: 1},
+	}
+	if len(leave.Days) != len(expected) {
+		t.Fatalf("expected %d days in the breakdown, got %d", len(expected), len(leave.Days))
+	}
+	for i, day := range leave.Days {
+		if day != expected[i] {
+			t.Errorf("day %d: expected %+v, got %+v", i, expected[i], day)
+		}
+	}
+
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/approve", nil).expect(http.StatusOK)
+	if used := s.usedDays(employee); used != 2 {
+		t.Fatalf("expected 2 used days after approval, got %.1f", used)
+	}
+
+	// A leave over non-working days only is refused
+	s.as(employee, http.MethodPost, "/api/v1/leaves/", gin.H{
+		"leave_type_id": s.fx.leaveType.ID,
+		"start_date":    "2025-03-14",
+		"end_date":      "2025-03-16",
+	}).expect(http.StatusBadRequest)
+}
+
+func TestLeavePreview(t *testing.T) {
+	s := newTestServer(t)
+
+	var preview struct {
+		Days      []models.LeaveDay `json:"days"`
+		TotalDays float64           `json:"total_days"`
+		PaidDays  float64           `json:"paid_days"`
+	}
+	s.as(s.fx.employee, http.MethodGet, "/api/v1/leaves/preview?leave_type_id="+s.fx.leaveType.ID.String()+
+		"&start_date=2025-03-07&end_date=2025-03-10", nil).expect(http.StatusOK).decode(&preview)
+	if len(preview.Days) != 4 || preview.TotalDays != 2 || preview.PaidDays != 2 {
+		t.Fatalf("expected 4 days charging 2, got %d days charging %.1f (paid %.1f)", len(preview.Days), preview.TotalDays, preview.PaidDays)
+	}
+
+	// Nothing was applied for
+	var listing struct {
+		Leaves []models.LeaveApplication `json:"leaves"`
+	}
+	s.as(s.fx.employee, http.MethodGet, "/api/v1/leaves/", nil).expect(http.StatusOK).decode(&listing)
+	if len(listing.Leaves) != 0 {
+		t.Fatalf("preview must not create a leave, got %d", len(listing.Leaves))
+	}
+}
+
+func TestLeaveApprovalRecalculatesLOP(t *testing.T) {
+	s := newTestServer(t)
+	manager, employee := s.fx.manager, s.fx.employee
+
+	// 8 and 6 working days: each fits the allocation of 12, together they do not
+	first := s.applyForLeave(employee, "2025-03-03", "2025-03-12")
+	second := s.applyForLeave(employee, "2025-04-07", "2025-04-14")
+	if first.IsLOP || second.IsLOP {
+		t.Fatalf("leaves within balance must not be LOP when applied for")
+	}
+
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+first.ID.String()+"/approve", nil).expect(http.StatusOK)
+
+	var approved models.LeaveApplication
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+second.ID.String()+"/approve", nil).
+		expect(http.StatusOK).decode(&approved)
+	if !approved.IsLOP || approved.PaidDays != 4 || approved.LOPDays != 2 {
+		t.Fatalf("expected 4 paid and 2 LOP days at approval, got paid %.1f, LOP %.1f (LOP %t)", approved.PaidDays, approved.LOPDays, approved.IsLOP)
+	}
+	if used := s.usedDays(employee); used != 12 {
+		t.Fatalf("expected the whole allocation used, got %.1f", used)
+	}
+}
+
+func TestConcurrentLeaveApprovals(t *testing.T) {
+	s := newTestServer(t)
+	manager, employee := s.fx.manager, s.fx.employee
+
+	first := s.applyForLeave(employee, "2025-03-03", "2025-03-05")
+	second := s.applyForLeave(employee, "2025-04-07", "2025-04-08")
+	s.token(manager)
+
+	var wg sync.WaitGroup
+	codes := make(chan int, 10)
+	for i := 0; i < 5; i++ {
+		for _, leave := range []models.LeaveApplication{first, second} {
+			wg.Add(1)
+			go func(path string) {
+				defer wg.Done()
+				codes <- s.as(manager, http.MethodPut, path, nil).recorder.Code
+			}("/api/v1/admin/leaves/" + leave.ID.String() + "/approve")
+		}
+	}
+	wg.Wait()
+	close(codes)
+
+	approvals := 0
+	for code := range codes {
+		if code == http.StatusOK {
+			approvals++
+		} else if code != http.StatusBadRequest {
+			t.Errorf("unexpected status %d from a concurrent approval", code)
+		}
+	}
+	if approvals != 2 {
+		t.Fatalf("expected each leave approved exactly once, got %d approvals", approvals)
+	}
+	if used := s.usedDays(employee); used != 5 {
+		t.Fatalf("expected 5 used days, got %.1f", used)
+	}
+}
+
+func TestLeaveApprovalIsIdempotent(t *testing.T) {
+	s := newTestServer(t)
+	manager, employee := s.fx.manager, s.fx.employee
+
+	leave := s.applyForLeave(employee, "2025-03-03", "2025-03-05")
+	approve := "/api/v1/admin/leaves/" + leave.ID.String() + "/approve"
+	key := map[string]string{"Idempotency-Key": "approve-" + leave.ID.String()}
+
+	s.asWithHeaders(manager, http.MethodPut, approve, key, nil).expect(http.StatusOK)
+	retry := s.asWithHeaders(manager, http.MethodPut, approve, key, nil).expect(http.StatusOK)
+	if retry.recorder.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected the retry to be answered from the stored response")
+	}
+	var replayed models.LeaveApplication
+	retry.decode(&replayed)
+	if replayed.ID != leave.ID || replayed.Status != "approved" {
+		t.Fatalf("unexpected replayed leave: %s %q", replayed.ID, replayed.Status)
+	}
+	if used := s.usedDays(employee); used != 3 {
+		t.Fatalf("a retried approval must not be applied twice, used %.1f", used)
+	}
+
+	// The key belongs to the approval; reusing it for another request is refused
+	s.asWithHeaders(manager, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/reject", key, gin.H{"rejection_reason": "late"}).
+		expect(http.StatusUnprocessableEntity)
+
+	// Keys are per user: the same key from the employee is a new request
+	created := s.asWithHeaders(employee, http.MethodPost, "/api/v1/leaves/", key, gin.H{
+		"leave_type_id": s.fx.leaveType.ID,
+		"start_date":    "2025-06-02",
+		"end_date":      "2025-06-02",
+	}).expect(http.StatusCreated)
+	if created.recorder.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("another user's key must not be replayed")
+	}
+}
+
+// statusTrail returns the to-statuses of a leave's history, oldest first.
+func (s *testServer) statusTrail(u models.User, leaveID string) []string {
+	s.t.Helper()
+	var history []models.LeaveStatusChange
+	s.as(u, http.MethodGet, "/api/v1/leaves/"+leaveID+"/history", nil).expect(http.StatusOK).decode(&history)
+	trail := make([]string, len(history))
+	for i, change := range history {
+		trail[i] = change.ToStatus
+	}
+	return trail
+}
+
+func TestPendingLeaveWithdrawal(t *testing.T) {
+	s := newTestServer(t)
+	employee := s.fx.employee
+
+	// Cut short: Mon-Fri becomes Mon-Wed and stays pending
+	leave := s.applyForLeave(employee, "2025-03-03", "2025-03-07")
+	var shortened models.LeaveApplication
+	s.as(employee, http.MethodPost, "/api/v1/leaves/"+leave.ID.String()+"/cancel", gin.H{"cancel_from": "2025-03-06"}).
+		expect(http.StatusOK).decode(&shortened)
+	if shortened.Status != "pending" || shortened.EndDate.Format("2006-01-02") != "2025-03-05" || shortened.PaidDays != 3 {
+		t.Fatalf("unexpected shortened leave: status %q, ends %s, paid %.1f", shortened.Status, shortened.EndDate.Format("2006-01-02"), shortened.PaidDays)
+	}
+
+	s.as(employee, http.MethodPost, "/api/v1/leaves/"+leave.ID.String()+"/cancel", gin.H{"cancel_from": "2025-03-07"}).
+		expect(http.StatusBadRequest)
+
+	var withdrawn models.LeaveApplication
+	s.as(employee, http.MethodDelete, "/api/v1/leaves/"+leave.ID.String(), nil).expect(http.StatusOK).decode(&withdrawn)
+	if withdrawn.Status != "cancelled" {
+		t.Fatalf("expected the pending leave withdrawn, got %q", withdrawn.Status)
+	}
+	s.as(employee, http.MethodDelete, "/api/v1/leaves/"+leave.ID.String(), nil).expect(http.StatusBadRequest)
+
+	// Nobody else can cancel it
+	s.as(s.fx.outsider, http.MethodDelete, "/api/v1/leaves/"+leave.ID.String(), nil).expect(http.StatusNotFound)
+
+	if trail := s.statusTrail(employee, leave.ID.String()); fmt.Sprint(trail) != "[pending pending cancelled]" {
+		t.Fatalf("unexpected status history %v", trail)
+	}
+}
+
+func TestApprovedLeaveCancellation(t *testing.T) {
+	s := newTestServer(t)
+	manager, employee := s.fx.manager, s.fx.employee
+
+	leave := s.applyForLeave(employee, "2025-03-03", "2025-03-07")
+	path := "/api/v1/admin/leaves/" + leave.ID.String()
+	s.as(manager, http.MethodPut, path+"/approve", nil).expect(http.StatusOK)
+
+	// Cancelling an approved leave only asks for it
+	var requested models.LeaveApplication
+	s.as(employee, http.MethodDelete, "/api/v1/leaves/"+leave.ID.String(), nil).expect(http.StatusOK).decode(&requested)
+	if requested.Status != "cancellation_requested" || requested.CancelFrom == nil {
+		t.Fatalf("expected a cancellation request, got status %q", requested.Status)
+	}
+	if used := s.usedDays(employee); used != 5 {
+		t.Fatalf("a cancellation request must not change the balance, used %.1f", used)
+	}
+	s.as(employee, http.MethodPut, path+"/cancellation/approve", nil).expect(http.StatusForbidden)
+
+	var kept models.LeaveApplication
+	s.as(manager, http.MethodPut, path+"/cancellation/reject", gin.H{"reason": "Release week"}).
+		expect(http.StatusOK).decode(&kept)
+	if kept.Status != "approved" || kept.CancelFrom != nil {
+		t.Fatalf("expected the leave kept, got status %q", kept.Status)
+	}
+	s.as(manager, http.MethodPut, path+"/cancellation/approve", nil).expect(http.StatusBadRequest)
+
+	// Cut short from Thursday: two of five paid days go back
+	s.as(employee, http.MethodPost, "/api/v1/leaves/"+leave.ID.String()+"/cancel", gin.H{"cancel_from": "2025-03-06", "reason": "Back early"}).
+		expect(http.StatusOK)
+	var shortened models.LeaveApplication
+	s.as(manager, http.MethodPut, path+"/cancellation/approve", nil).expect(http.StatusOK).decode(&shortened)
+	if shortened.Status != "approved" || shortened.EndDate.Format("2006-01-02") != "2025-03-05" || shortened.PaidDays != 3 {
+		t.Fatalf("unexpected shortened leave: status %q, ends %s, paid %.1f", shortened.Status, shortened.EndDate.Format("2006-01-02"), shortened.PaidDays)
+	}
+	if used := s.usedDays(employee); used != 3 {
+		t.Fatalf("expected 3 used days after the partial cancellation, got %.1f", used)
+	}
+
+	// And then all of it
+	s.as(employee, http.MethodDelete, "/api/v1/leaves/"+leave.ID.String(), nil).expect(http.StatusOK)
+	var cancelled models.LeaveApplication
+	s.as(manager, http.MethodPut, path+"/cancellation/approve", nil).expect(http.StatusOK).decode(&cancelled)
+	if cancelled.Status != "cancelled" {
+		t.Fatalf("expected the leave cancelled, got %q", cancelled.Status)
+	}
+	if used := s.usedDays(employee); used != 0 {
+		t.Fatalf("expected the whole balance back, used %.1f", used)
+	}
+
+	want := "[pending approved cancellation_requested approved cancellation_requested approved cancellation_requested cancelled]"
+	if trail := s.statusTrail(employee, leave.ID.String()); fmt.Sprint(trail) != want {
+		t.Fatalf("unexpected status history %v", trail)
+	}
+}
+
+func TestLOPLeaveCancellationRestoresProportionally(t *testing.T) {
+	s := newTestServer(t)
+	manager, employee := s.fx.manager, s.fx.employee
+
+	// 14 working days: 12 paid, 2 LOP
+	leave := s.applyForLeave(employee, "2025-05-01", "2025-05-20")
+	path := "/api/v1/admin/leaves/" + leave.ID.String()
+	s.as(manager, http.MethodPut, path+"/approve", nil).expect(http.StatusOK)
+
+	// 3 of 14 days cancelled: 12 * 3/14 = 2.57, rounded to 2.5 paid days back
+	s.as(employee, http.MethodPost, "/api/v1/leaves/"+leave.ID.String()+"/cancel", gin.H{"cancel_from": "2025-05-16"}).
+		expect(http.StatusOK)
+	var shortened models.LeaveApplication
+	s.as(manager, http.MethodPut, path+"/cancellation/approve", nil).expect(http.StatusOK).decode(&shortened)
+	if shortened.PaidDays != 9.5 || shortened.LOPDays != 1.5 || !shortened.IsLOP {
+		t.Fatalf("expected 9.5 paid and 1.5 LOP days, got paid %.1f, LOP %.1f", shortened.PaidDays, shortened.LOPDays)
+	}
+	if used := s.usedDays(employee); used != 9.5 {
+		t.Fatalf("expected 9.5 used days, got %.1f", used)
+	}
+}
+
+func TestLeaveHalfDaySessions(t *testing.T) {
+	s := newTestServer(t)
+	manager, employee := s.fx.manager, s.fx.employee
+
+	// Monday to Wednesday, taking only the afternoon of Monday: 2.5 days
+	var leave models.LeaveApplication
+	s.as(employee, http.MethodPost, "/api/v1/leaves/", gin.H{
+		"leave_type_id": s.fx.leaveType.ID,
+		"start_date":    "2025-03-03",
+		"end_date":      "2025-03-05",
+		"sessions":      []gin.H{{"date": "2025-03-03", "session": "second_half"}},
+	}).expect(http.StatusCreated).decode(&leave)
+	if leave.PaidDays != 2.5 || !leave.IsHalfDay || len(leave.Sessions) != 1 {
+		t.Fatalf("expected 2.5 paid days with one half day, got paid %.1f, %d sessions", leave.PaidDays, len(leave.Sessions))
+	}
+	if leave.Days[0].Session != models.SessionSecondHalf || leave.Days[0].Days != 0.5 || leave.Days[1].Session != models.SessionFull {
+		t.Fatalf("unexpected breakdown %+v", leave.Days)
+	}
+
+	// The morning of Monday is still free; a legacy half-day request takes
+	// the first half of its start date
+	var morning models.LeaveApplication
+	s.as(employee, http.MethodPost, "/api/v1/leaves/", gin.H{
+		"leave_type_id": s.fx.leaveType.ID,
+		"start_date":    "2025-03-03",
+		"end_date":      "2025-03-03",
+		"is_half_day":   true,
+	}).expect(http.StatusCreated).decode(&morning)
+	if morning.PaidDays != 0.5 || len(morning.Sessions) != 1 || morning.Sessions[0].Session != models.SessionFirstHalf {
+		t.Fatalf("expected the first half of the start date, got paid %.1f, sessions %+v", morning.PaidDays, morning.Sessions)
+	}
+
+	// Anything else on those days clashes
+	s.as(employee, http.MethodPost, "/api/v1/leaves/", gin.H{
+		"leave_type_id": s.fx.leaveType.ID,
+		"start_date":    "2025-03-03",
+		"end_date":      "2025-03-03",
+		"sessions":      []gin.H{{"date": "2025-03-03", "session": "second_half"}},
+	}).expect(http.StatusConflict)
+	s.as(employee, http.MethodPost, "/api/v1/leaves/", gin.H{
+		"leave_type_id": s.fx.leaveType.ID,
+		"start_date":    "2025-03-05",
+		"end_date":      "2025-03-06",
+	}).expect(http.StatusConflict)
+
+	// Sessions must fall within the leave and on working days
+	s.as(employee, http.MethodPost, "/api/v1/leaves/", gin.H{
+		"leave_type_id": s.fx.leaveType.ID,
+		"start_date":    "2025-03-10",
+		"end_date":      "2025-03-10",
+		"sessions":      []gin.H{{"date": "2025-03-11", "session": "first_half"}},
+	}).expect(http.StatusBadRequest)
+	s.as(employee, http.MethodPost, "/api/v1/leaves/", gin.H{
+		"leave_type_id": s.fx.leaveType.ID,
+		"start_date":    "2025-03-07",
+		"end_date":      "2025-03-10",
+		"sessions":      []gin.H{{"date": "2025-03-08", "session": "first_half"}},
+	}).expect(http.StatusBadRequest)
+
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/approve", nil).expect(http.StatusOK)
+	if used := s.usedDays(employee); used != 2.5 {
+		t.Fatalf("expected 2.5 used days after approval, got %.1f", used)
+	}
+
+	var preview struct {
+		TotalDays float64 `json:"total_days"`
+	}
+	s.as(employee, http.MethodGet, "/api/v1/leaves/preview?leave_type_id="+s.fx.leaveType.ID.String()+
+		"&start_date=2025-03-10&end_date=2025-03-11&sessions=2025-03-10:second_half,2025-03-11:first_half", nil).
+		expect(http.StatusOK).decode(&preview)
+	if preview.TotalDays != 1 {
+		t.Fatalf("expected a preview of 1 day, got %.1f", preview.TotalDays)
+	}
+}
+
+func TestLeaveTeamConflicts(t *testing.T) {
+	s := newTestServer(t)
+	admin, manager, employee, outsider := s.fx.admin, s.fx.manager, s.fx.employee, s.fx.outsider
+
+	leave := s.applyForLeave(employee, "2025-03-05", "2025-03-07")
+	other := s.applyForLeave(outsider, "2025-03-07", "2025-03-11")
+	s.applyForLeave(outsider, "2025-03-17", "2025-03-18")
+
+	var conflicts []struct {
+		Leave models.LeaveApplication `json:"leave"`
+		Days  []models.LeaveDay       `json:"days"`
+	}
+	s.as(admin, http.MethodGet, "/api/v1/admin/leaves/"+leave.ID.String()+"/conflicts", nil).
+		expect(http.StatusOK).decode(&conflicts)
+	if len(conflicts) != 1 || conflicts[0].Leave.ID != other.ID || conflicts[0].Leave.User.ID != outsider.ID {
+		t.Fatalf("expected the outsider's leave as the only conflict, got %+v", conflicts)
+	}
+	if len(conflicts[0].Days) != 1 || conflicts[0].Days[0].Date != "2025-03-07" {
+		t.Fatalf("expected 2025-03-07 as the only shared day, got %+v", conflicts[0].Days)
+	}
+
+	// Managers only see their own team
+	s.as(manager, http.MethodGet, "/api/v1/admin/leaves/"+leave.ID.String()+"/conflicts", nil).
+		expect(http.StatusOK).decode(&conflicts)
+	if len(conflicts) != 0 {
+		t.Fatalf("leaves outside the team scope must not be listed, got %d", len(conflicts))
+	}
+	s.as(manager, http.MethodGet, "/api/v1/admin/leaves/"+other.ID.String()+"/conflicts", nil).expect(http.StatusForbidden)
+}
+
+func TestConcurrentOverlappingLeaves(t *testing.T) {
+	s := newTestServer(t)
+	employee := s.fx.employee
+	later := s.applyForLeave(employee, "2025-03-17", "2025-03-18")
+
+	// Applications for the same days and an edit moving another leave onto
+	// them race; only one may take the days
+	var wg sync.WaitGroup
+	codes := make(chan int, 5)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- s.as(employee, http.MethodPost, "/api/v1/leaves/", gin.H{
+				"leave_type_id": s.fx.leaveType.ID,
+				"start_date":    "2025-03-03",
+				"end_date":      "2025-03-05",
+			}).recorder.Code
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		codes <- s.as(employee, http.MethodPut, "/api/v1/leaves/"+later.ID.String(), gin.H{
+			"start_date": "2025-03-04",
+			"end_date":   "2025-03-04",
+		}).recorder.Code
+	}()
+	wg.Wait()
+	close(codes)
+
+	taken := 0
+	for code := range codes {
+		if code == http.StatusCreated || code == http.StatusOK {
+			taken++
+		} else if code != http.StatusConflict {
+			t.Errorf("unexpected status %d from a concurrent application", code)
+		}
+	}
+	if taken != 1 {
+		t.Fatalf("expected one leave to take the days, got %d", taken)
+	}
+}
@@ -0,0 +1,254 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// optionalHolidayYear is the year the optional holiday tests pick holidays
+// in; selections must not be in the past.
+var optionalHolidayYear = time.Now().Year() + 1
+
+// weekdayIn returns the first Wednesday of month in optionalHolidayYear
+// plus weeks, so that it is a working day.
+func weekdayIn(month time.Month, weeks int) time.Time {
+	day := time.Date(optionalHolidayYear, month, 1, 0, 0, 0, 0, time.UTC)
+	for day.Weekday() != time.Wednesday {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day.AddDate(0, 0, 7*weeks)
+}
+
+// addHoliday stores a holiday on date, optional or not, observed at location
+// or everywhere if it is empty.
+func (s *testServer) addHoliday(title string, date time.Time, optional bool, location string) models.Event {
+	s.t.Helper()
+	holiday := models.Event{Title: title, EventType: "holiday", EventDate: date, IsCompanyWide: location == "", IsOptional: optional}
+	if location != "" {
+		holiday.Location = &location
+	}
+	s.create(&holiday)
+	return holiday
+}
+
+// allocateOptionalHolidayYear gives users 12 days of the fixture leave type
+// in optionalHolidayYear, so they can apply for leave in it.
+func (s *testServer) allocateOptionalHolidayYear(users ...models.User) {
+	s.t.Helper()
+	for _, u := range users {
+		balance := models.LeaveBalance{UserID: u.ID, LeaveTypeID: s.fx.leaveType.ID, Year: optionalHolidayYear, AllocatedDays: 12}
+		s.create(&balance)
+		s.create(models.NewLedgerEntry(&balance, models.LedgerOpening, 12))
+	}
+}
+
+// selectOptionalHoliday picks holiday for u and returns the response.
+func (s *testServer) selectOptionalHoliday(u models.User, holiday models.Event) *testResponse {
+	s.t.Helper()
+	return s.as(u, http.MethodPost, "/api/v1/optional-holidays/selections", gin.H{"holiday_id": holiday.ID})
+}
+
+// previewDay returns how the working calendar counts date for u.
+func (s *testServer) previewDay(u models.User, date time.Time) models.LeaveDay {
+	s.t.Helper()
+	var preview struct {
+		Days []models.LeaveDay `json:"days"`
+	}
+	day := date.Format("2006-01-02")
+	s.as(u, http.MethodGet, "/api/v1/leaves/preview?leave_type_id="+s.fx.leaveType.ID.String()+"&start_date="+day+"&end_date="+day, nil).
+		expect(http.StatusOK).decode(&preview)
+	if len(preview.Days) != 1 {
+		s.t.Fatalf("expected one day in the preview, got %+v", preview.Days)
+	}
+	return preview.Days[0]
+}
+
+func TestOptionalHolidaySelection(t *testing.T) {
+	s := newTestServer(t)
+	admin, manager, employee := s.fx.admin, s.fx.manager, s.fx.employee
+	year := strconv.Itoa(optionalHolidayYear)
+	s.allocateOptionalHolidayYear(employee, s.fx.outsider)
+
+	holi := s.addHoliday("Holi", weekdayIn(time.March, 0), true, "")
+	onam := s.addHoliday("Onam", weekdayIn(time.September, 0), true, "")
+	diwali := s.addHoliday("Diwali", weekdayIn(time.October, 3), false, "")
+	ganesh := s.addHoliday("Ganesh Chaturthi", weekdayIn(time.August, 3), true, "Pune")
+
+	// Only holiday managers set the quota
+	quotaPath := "/api/v1/admin/optional-holidays/quotas/" + year
+	s.as(manager, http.MethodPut, quotaPath, gin.H{"quota": 1}).expect(http.StatusForbidden)
+	s.as(admin, http.MethodPut, quotaPath, gin.H{"quota": -1}).expect(http.StatusBadRequest)
+	s.as(admin, http.MethodPut, quotaPath, gin.H{"quota": 1}).expect(http.StatusOK)
+
+	var summary services.OptionalHolidaySummary
+	s.as(employee, http.MethodGet, "/api/v1/optional-holidays/?year="+year, nil).expect(http.StatusOK).decode(&summary)
+	if summary.Quota != 1 || summary.Remaining != 1 || len(summary.Holidays) != 2 || summary.Holidays[0].Holiday.ID != holi.ID {
+		t.Fatalf("expected Holi and Onam within a quota of 1, got %+v", summary)
+	}
+
+	// Mandatory holidays and those of other locations cannot be picked
+	s.selectOptionalHoliday(employee, diwali).expect(http.StatusBadRequest)
+	s.selectOptionalHoliday(employee, ganesh).expect(http.StatusBadRequest)
+
+	var selection models.OptionalHolidaySelection
+	s.selectOptionalHoliday(employee, holi).expect(http.StatusCreated).decode(&selection)
+	s.selectOptionalHoliday(employee, holi).expect(http.StatusConflict)
+	s.selectOptionalHoliday(employee, onam).expect(http.StatusConflict)
+
+	// Until the manager approves, the day is still charged
+	if day := s.previewDay(employee, holi.EventDate); day.Days != 1 {
+		t.Fatalf("expected a pending optional holiday charged, got %+v", day)
+	}
+	approvePath := "/api/v1/admin/optional-holidays/selections/" + selection.ID.String() + "/approve"
+	s.as(employee, http.MethodPut, approvePath, nil).expect(http.StatusForbidden)
+	s.as(manager, http.MethodPut, approvePath, nil).expect(http.StatusOK).decode(&selection)
+	if selection.Status != models.OptionalHolidayApproved || selection.ReviewedAt == nil {
+		t.Fatalf("expected the selection approved, got %+v", selection)
+	}
+	s.as(manager, http.MethodPut, approvePath, nil).expect(http.StatusConflict)
+	if day := s.previewDay(employee, holi.EventDate); day.Days != 0 || day.Kind != models.DayKindOptionalHoliday {
+		t.Fatalf("expected the approved optional holiday off, got %+v", day)
+	}
+	if day := s.previewDay(s.fx.outsider, holi.EventDate); day.Days != 1 {
+		t.Fatalf("expected the holiday still a working day for others, got %+v", day)
+	}
+
+	// Cancelling frees the quota for another pick
+	cancelPath := "/api/v1/optional-holidays/selections/" + selection.ID.String()
+	s.as(s.fx.outsider, http.MethodDelete, cancelPath, nil).expect(http.StatusNotFound)
+	s.as(employee, http.MethodDelete, cancelPath, nil).expect(http.StatusOK)
+	s.as(employee, http.MethodDelete, cancelPath, nil).expect(http.StatusConflict)
+	if day := s.previewDay(employee, holi.EventDate); day.Days != 1 {
+		t.Fatalf("expected a cancelled optional holiday charged again, got %+v", day)
+	}
+
+	s.selectOptionalHoliday(employee, onam).expect(http.StatusCreated).decode(&selection)
+	rejectPath := "/api/v1/admin/optional-holidays/selections/" + selection.ID.String() + "/reject"
+	s.as(manager, http.MethodPut, rejectPath, nil).expect(http.StatusBadRequest)
+	s.as(manager, http.MethodPut, rejectPath, gin.H{"reason": "Release week"}).expect(http.StatusOK)
+
+	var notified int64
+	s.db.Model(&models.Notification{}).Where("user_id = ? AND type = ?", employee.ID, models.NotificationOptionalHolidayRejected).Count(&notified)
+	if notified != 1 {
+		t.Fatalf("expected the employee to be told of the rejection, got %d notifications", notified)
+	}
+
+	s.as(employee, http.MethodGet, "/api/v1/optional-holidays/?year="+year, nil).expect(http.StatusOK).decode(&summary)
+	if summary.Used != 0 || summary.Remaining != 1 || summary.Holidays[1].Selection == nil ||
+		summary.Holidays[1].Selection.Status != models.OptionalHolidayRejected {
+		t.Fatalf("expected the quota free and the rejection shown, got %+v", summary)
+	}
+}
+
+func TestOptionalHolidayQuotaAndLeave(t *testing.T) {
+	s := newTestServer(t)
+	employee := s.fx.employee
+	holi := s.addHoliday("Holi", weekdayIn(time.March, 0), true, "")
+	onam := s.addHoliday("Onam", weekdayIn(time.September, 0), true, "")
+	pongal := s.addHoliday("Pongal", weekdayIn(time.January, 1), true, "")
+
+	// Without a quota set, the default of 2 applies
+	var quotas struct {
+		DefaultQuota int                           `json:"default_quota"`
+		Quotas       []models.OptionalHolidayQuota `json:"quotas"`
+	}
+	s.as(s.fx.admin, http.MethodGet, "/api/v1/admin/optional-holidays/quotas", nil).expect(http.StatusOK).decode(&quotas)
+	if quotas.DefaultQuota != 2 || len(quotas.Quotas) != 0 {
+		t.Fatalf("expected only the default quota, got %+v", quotas)
+	}
+	s.selectOptionalHoliday(employee, holi).expect(http.StatusCreated)
+	s.selectOptionalHoliday(employee, pongal).expect(http.StatusCreated)
+	s.selectOptionalHoliday(employee, onam).expect(http.StatusConflict)
+
+	// A day already taken as leave cannot be picked as well
+	s.allocateOptionalHolidayYear(s.fx.outsider)
+	day := onam.EventDate.Format("2006-01-02")
+	s.applyForLeave(s.fx.outsider, day, day)
+	s.selectOptionalHoliday(s.fx.outsider, onam).expect(http.StatusConflict)
+	s.selectOptionalHoliday(s.fx.outsider, holi).expect(http.StatusCreated)
+
+	// Past holidays cannot be picked
+	past := s.addHoliday("Old Holi", time.Date(fixtureYear, time.March, 14, 0, 0, 0, 0, time.UTC), true, "")
+	s.selectOptionalHoliday(s.fx.outsider, past).expect(http.StatusBadRequest)
+}
+
+func TestConcurrentOptionalHolidaySelections(t *testing.T) {
+	s := newTestServer(t)
+	employee := s.fx.employee
+	s.as(s.fx.admin, http.MethodPut, "/api/v1/admin/optional-holidays/quotas/"+strconv.Itoa(optionalHolidayYear), gin.H{"quota": 1}).
+		expect(http.StatusOK)
+	holidays := []models.Event{
+		s.addHoliday("Holi", weekdayIn(time.March, 0), true, ""),
+		s.addHoliday("Pongal", weekdayIn(time.January, 1), true, ""),
+		s.addHoliday("Onam", weekdayIn(time.September, 0), true, ""),
+	}
+	s.token(employee)
+
+	var wg sync.WaitGroup
+	codes := make(chan int, len(holidays))
+	for _, holiday := range holidays {
+		wg.Add(1)
+		go func(holiday models.Event) {
+			defer wg.Done()
+			codes <- s.selectOptionalHoliday(employee, holiday).recorder.Code
+		}(holiday)
+	}
+	wg.Wait()
+	close(codes)
+
+	selected := 0
+	for code := range codes {
+		if code == http.StatusCreated {
+			selected++
+		} else if code != http.StatusConflict {
+			t.Errorf("unexpected status %d from a concurrent selection", code)
+		}
+	}
+	if selected != 1 {
+		t.Fatalf("expected the quota of 1 to admit one selection, got %d", selected)
+	}
+}
+
+func TestOptionalHolidayTeamCalendar(t *testing.T) {
+	s := newCalendarFeedServer(t)
+	manager, employee := s.fx.manager, s.fx.employee
+	holi := s.addHoliday("Holi", weekdayIn(time.March, 0), true, "")
+
+	var selection models.OptionalHolidaySelection
+	s.selectOptionalHoliday(employee, holi).expect(http.StatusCreated).decode(&selection)
+	_, teamPath := s.createFeed(manager, models.CalendarFeedTeam)
+	_, personalPath := s.createFeed(employee, models.CalendarFeedPersonal)
+	uid := "optional-holiday-" + selection.ID.String() + "@employee-dashboard"
+	holidayUID := "holiday-" + holi.ID.String() + "@employee-dashboard"
+
+	if _, ok := s.fetchFeed(teamPath)[uid]; ok {
+		t.Fatal("a pending selection must not be published")
+	}
+	if got := s.fetchFeed(personalPath)[holidayUID]; got["TRANSP"] != "TRANSPARENT" {
+		t.Fatalf("expected an optional holiday not taken to leave the owner free, got %+v", got)
+	}
+
+	s.as(manager, http.MethodPut, "/api/v1/admin/optional-holidays/selections/"+selection.ID.String()+"/approve", nil).
+		expect(http.StatusOK)
+	got := s.fetchFeed(teamPath)[uid]
+	if got["SUMMARY"] != "EMPLOYEE Test: Holi (optional holiday)" || got["STATUS"] != "CONFIRMED" ||
+		got["DTSTART;VALUE=DATE"] != holi.EventDate.Format("20060102") {
+		t.Fatalf("unexpected team optional holiday event: %+v", got)
+	}
+	if got := s.fetchFeed(personalPath)[holidayUID]; got["TRANSP"] != "OPAQUE" {
+		t.Fatalf("expected a taken optional holiday to make the owner busy, got %+v", got)
+	}
+
+	s.as(employee, http.MethodDelete, "/api/v1/optional-holidays/selections/"+selection.ID.String(), nil).expect(http.StatusOK)
+	if got := s.fetchFeed(teamPath)[uid]; got["STATUS"] != "CANCELLED" {
+		t.Fatalf("expected the cancelled optional holiday withdrawn, got %+v", got)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"employee-dashboard-api/internal/config"
 	"employee-dashboard-api/internal/handlers"
 	"employee-dashboard-api/internal/middleware"
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
 	"employee-dashboard-api/internal/services"
 	"net/http"
 	"time"
@@ -43,74 +45,256 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, config *config.Config, logger
 		})
 	})
 
+	// Repositories are shared by every handler and service that persists an
+	// aggregate
+	repos := repository.New(db)
+
+	// Token service is shared between the auth handler and the middleware so
+	// revocations take effect immediately in this process; its job deletes
+	// expired sessions and revocation records
+	tokenService := services.NewTokenService(db, logger, config)
+	tokenService.Start()
+	authMiddleware := middleware.AuthMiddleware(config, tokenService)
+
+	// All outbound email goes through the outbox; its worker sends in the background
+	emailConfig := services.NewEmailConfig(config)
+	emailTransport, err := services.NewEmailTransport(config.EmailTransport, emailConfig, config.EmailFilePath)
+	if err != nil {
+		logger.Errorf("Failed to initialize email transport %q, falling back to SMTP: %v", config.EmailTransport, err)
+		emailTransport = services.NewSMTPTransport(emailConfig)
+	}
+	emailOutbox := services.NewEmailOutboxService(db, logger, emailTransport, emailConfig)
+	emailOutbox.Start()
+	emailService := services.NewEmailService(db, logger, emailConfig, emailOutbox)
+	emailService.Start()
+
+	// Notification service is shared so deliveries registered here apply to
+	// events raised by every handler
+	notificationService := services.NewNotificationService(db, logger)
+	if config.EmailNotificationsEnabled {
+		emailTemplates, err := services.NewEmailTemplates(config.EmailTemplateDir)
+		if err != nil {
+			logger.Errorf("Failed to load email templates, email notifications disabled: %v", err)
+		} else {
+			notificationService.RegisterDelivery(services.NewEmailNotificationDelivery(emailTemplates, emailOutbox, config.SMTPFromName))
+		}
+	}
+
+	// Rate limits for unauthenticated auth endpoints: a per-IP bucket against
+	// floods and a per-account bucket against targeted guessing and inbox spam
+	rateLimiter := services.NewRateLimiter(services.NewMemoryRateLimitStore(), logger)
+	limit := func(name string, perIP, perAccount services.RateLimit, accountFields ...string) []gin.HandlerFunc {
+		if !config.RateLimitEnabled {
+			return nil
+		}
+		limits := []gin.HandlerFunc{middleware.RateLimit(rateLimiter, name+":ip", perIP, middleware.ByIP)}
+		if len(accountFields) > 0 {
+			limits = append(limits, middleware.RateLimit(rateLimiter, name+":account", perAccount, middleware.ByJSONField(accountFields...)))
+		}
+		return limits
+	}
+	loginLimits := limit("login", services.PerMinute(20), services.PerMinute(10), "employee_id")
+	// Each OTP flow has its own buckets, so one cannot use up the other's; the
+	// two signup steps that check the same code share theirs
+	resetSendLimits := limit("password-reset-send", services.PerHour(20), services.PerHour(5), "email")
+	resetVerifyLimits := limit("password-reset-verify", services.PerMinute(20), services.PerMinute(5), "email")
+	signupSendLimits := limit("signup-otp-send", services.PerHour(20), services.PerHour(5), "email")
+	signupVerifyLimits := limit("signup-otp-verify", services.PerMinute(20), services.PerMinute(5), "email")
+	refreshLimits := limit("refresh", services.PerMinute(60), services.RateLimit{})
+	withLimits := func(limits []gin.HandlerFunc, handler gin.HandlerFunc) []gin.HandlerFunc {
+		return append(append([]gin.HandlerFunc(nil), limits...), handler)
+	}
+
 	// Auth routes
-	authHandler := handlers.NewAuthHandler(db, config, logger)
+	authHandler := handlers.NewAuthHandler(db, config, logger, tokenService, emailService, notificationService)
 	authGroup := v1.Group("/auth")
 	{
 		authGroup.POST("/register", authHandler.Register)
-		authGroup.POST("/login", authHandler.Login)
-		authGroup.POST("/logout", middleware.AuthMiddleware(config), authHandler.Logout)
-		authGroup.GET("/me", middleware.AuthMiddleware(config), authHandler.GetCurrentUser)
-		authGroup.POST("/send-signup-otp", authHandler.SendSignupOTP)
-		authGroup.POST("/verify-signup-otp", authHandler.VerifySignupOTP)
-		authGroup.POST("/complete-registration", authHandler.CompleteRegistration)
+		authGroup.POST("/login", withLimits(loginLimits, authHandler.Login)...)
+		authGroup.POST("/refresh", withLimits(refreshLimits, authHandler.RefreshToken)...)
+		authGroup.POST("/forgot-password", withLimits(resetSendLimits, authHandler.ForgotPassword)...)
+		authGroup.POST("/reset-password", withLimits(resetVerifyLimits, authHandler.ResetPassword)...)
+		authGroup.POST("/logout", authMiddleware, authHandler.Logout)
+		authGroup.POST("/logout-all", authMiddleware, authHandler.LogoutAll)
+		authGroup.GET("/me", authMiddleware, authHandler.GetCurrentUser)
+		authGroup.POST("/send-signup-otp", withLimits(signupSendLimits, authHandler.SendSignupOTP)...)
+		authGroup.POST("/verify-signup-otp", withLimits(signupVerifyLimits, authHandler.VerifySignupOTP)...)
+		authGroup.POST("/complete-registration", withLimits(signupVerifyLimits, authHandler.CompleteRegistration)...)
 
 		// Admin routes for user management
-		authGroup.GET("/pending-users", middleware.AuthMiddleware(config), middleware.RequireAdminRole(db), authHandler.GetPendingUsers)
-		authGroup.POST("/approve-user/:id", middleware.AuthMiddleware(config), middleware.RequireAdminRole(db), authHandler.ApproveUser)
-		authGroup.POST("/reject-user/:id", middleware.AuthMiddleware(config), middleware.RequireAdminRole(db), authHandler.RejectUser)
+		authGroup.GET("/pending-users", authMiddleware, middleware.RequirePermission(models.PermUserManage), authHandler.GetPendingUsers)
+		authGroup.POST("/approve-user/:id", authMiddleware, middleware.RequirePermission(models.PermUserManage), authHandler.ApproveUser)
+		authGroup.POST("/reject-user/:id", authMiddleware, middleware.RequirePermission(models.PermUserManage), authHandler.RejectUser)
+		authGroup.PUT("/change-role/:id", authMiddleware, middleware.RequirePermission(models.PermUserManage), authHandler.ChangeUserRole)
+		authGroup.POST("/revoke-sessions/:id", authMiddleware, middleware.RequirePermission(models.PermUserManage), authHandler.RevokeUserSessions)
 	}
 
 	// User routes
-	userHandler := handlers.NewUserHandler(db, config, logger)
+	userHandler := handlers.NewUserHandler(db, config, logger, repos)
 	userGroup := v1.Group("/users")
-	userGroup.Use(middleware.AuthMiddleware(config))
+	userGroup.Use(authMiddleware)
 	{
 		userGroup.GET("/profile", userHandler.GetProfile)
 		userGroup.PUT("/profile", userHandler.UpdateProfile)
 		userGroup.PUT("/password", userHandler.ChangePassword)
-		userGroup.GET("/", middleware.RequireAdminRole(db), userHandler.GetUsers) // Admin only
+		userGroup.GET("/", middleware.RequirePermission(models.PermUserView), userHandler.GetUsers) // Admin only
+	}
+
+	// Email outbox routes (admin only)
+	emailOutboxHandler := handlers.NewEmailOutboxHandler(db, config, logger, emailOutbox)
+	emailOutboxGroup := v1.Group("/admin/emails")
+	emailOutboxGroup.Use(authMiddleware)
+	emailOutboxGroup.Use(middleware.RequirePermission(models.PermEmailManage))
+	{
+		emailOutboxGroup.GET("/", emailOutboxHandler.GetEmails)
+		emailOutboxGroup.GET("/:id", emailOutboxHandler.GetEmail)
+		emailOutboxGroup.POST("/:id/resend", emailOutboxHandler.ResendEmail)
+	}
+
+	// Notification routes
+	notificationHandler := handlers.NewNotificationHandler(db, config, logger, notificationService)
+	notificationGroup := v1.Group("/notifications")
+	notificationGroup.Use(authMiddleware)
+	{
+		notificationGroup.GET("/", notificationHandler.GetNotifications)
+		notificationGroup.GET("/unread-count", notificationHandler.GetUnreadCount)
+		notificationGroup.PUT("/read-all", notificationHandler.MarkAllNotificationsRead)
+		notificationGroup.PUT("/:id/read", notificationHandler.MarkNotificationRead)
+	}
+
+	// Leave approval chains take pending leaves past several approvers in
+	// turn; the scheduled job escalates steps left open past their SLA
+	workingCalendar := services.NewWorkingCalendarService(db, config, logger)
+	leaveService := services.NewLeaveService(repos, workingCalendar, logger)
+	leaveApprovalService := services.NewLeaveApprovalService(repos, leaveService, services.NewTeamScopeService(db, logger), notificationService, logger)
+	if config.LeaveEscalationJobEnabled {
+		leaveApprovalService.Start()
 	}
 
 	// Leave routes
-	leaveHandler := handlers.NewLeaveHandler(db, config, logger)
+	leaveHandler := handlers.NewLeaveHandler(db, config, logger, repos, notificationService, leaveApprovalService)
+	// Idempotency keys are replayable for a day; the service's job deletes
+	// them afterwards
+	idempotencyService := services.NewIdempotencyService(db, logger)
+	idempotencyService.Start()
+	idempotency := middleware.Idempotency(idempotencyService)
 	leaveGroup := v1.Group("/leaves")
-	leaveGroup.Use(middleware.AuthMiddleware(config))
+	leaveGroup.Use(authMiddleware, idempotency)
 	{
 		leaveGroup.GET("/", leaveHandler.GetLeaves)
 		leaveGroup.POST("/", leaveHandler.CreateLeave)
 		leaveGroup.GET("/balance", leaveHandler.GetLeaveBalance)
+		leaveGroup.GET("/balance/statement", leaveHandler.GetBalanceStatement)
+		leaveGroup.GET("/preview", leaveHandler.PreviewLeave)
 		leaveGroup.GET("/types", leaveHandler.GetLeaveTypes)
+		leaveGroup.PUT("/:id", leaveHandler.UpdateLeave)
+		leaveGroup.DELETE("/:id", leaveHandler.DeleteLeave)
+		leaveGroup.POST("/:id/cancel", leaveHandler.CancelLeave)
+		leaveGroup.GET("/:id/history", leaveHandler.GetLeaveHistory)
 	}
 
 	// Admin leave routes
 	adminLeaveGroup := v1.Group("/admin/leaves")
-	adminLeaveGroup.Use(middleware.AuthMiddleware(config))
-	adminLeaveGroup.Use(middleware.RequireAdminRole(db))
+	adminLeaveGroup.Use(authMiddleware)
+	adminLeaveGroup.Use(idempotency)
 	{
-		adminLeaveGroup.GET("/", leaveHandler.GetAllLeaves)
-		adminLeaveGroup.PUT("/:id/approve", leaveHandler.ApproveLeave)
-		adminLeaveGroup.PUT("/:id/reject", leaveHandler.RejectLeave)
-		adminLeaveGroup.GET("/dashboard-stats", leaveHandler.GetDashboardStats)
-		adminLeaveGroup.GET("/team-balances", leaveHandler.GetTeamLeaveBalances)
-		adminLeaveGroup.GET("/employee/:userId", leaveHandler.GetEmployeeLeaves)
+		// Team views (viewer's team scope, checked in the handler)
+		viewLeaves := middleware.RequirePermission(models.PermLeaveView)
+		adminLeaveGroup.GET("/", viewLeaves, leaveHandler.GetAllLeaves)
+		adminLeaveGroup.GET("/:id/conflicts", viewLeaves, leaveHandler.GetLeaveConflicts)
+		adminLeaveGroup.GET("/dashboard-stats", viewLeaves, leaveHandler.GetDashboardStats)
+		adminLeaveGroup.GET("/team-balances", viewLeaves, leaveHandler.GetTeamLeaveBalances)
+		adminLeaveGroup.GET("/employee/:userId", viewLeaves, leaveHandler.GetEmployeeLeaves)
+
+		approveLeaves := middleware.RequirePermission(models.PermLeaveApprove)
+		adminLeaveGroup.GET("/approvals", approveLeaves, leaveHandler.GetApprovalQueue)
+		adminLeaveGroup.PUT("/:id/approve", approveLeaves, leaveHandler.ApproveLeave)
+		adminLeaveGroup.PUT("/:id/reject", approveLeaves, leaveHandler.RejectLeave)
+		adminLeaveGroup.PUT("/:id/cancellation/approve", approveLeaves, leaveHandler.ApproveCancellation)
+		adminLeaveGroup.PUT("/:id/cancellation/reject", approveLeaves, leaveHandler.RejectCancellation)
 	}
 
 	// Leave allocation routes (admin only)
-	leaveAllocationHandler := handlers.NewLeaveAllocationHandler(db, config, logger)
+	leaveAllocationHandler := handlers.NewLeaveAllocationHandler(db, config, logger, repos)
 	leaveAllocationGroup := v1.Group("/leave-allocations")
-	leaveAllocationGroup.Use(middleware.AuthMiddleware(config))
-	leaveAllocationGroup.Use(middleware.RequireAdminRole(db))
+	leaveAllocationGroup.Use(authMiddleware)
+	leaveAllocationGroup.Use(middleware.RequirePermission(models.PermLeaveAllocate))
 	{
 		leaveAllocationGroup.POST("/initialize", leaveAllocationHandler.InitializeLeaveAllocations)
 		leaveAllocationGroup.POST("/load-csv", leaveAllocationHandler.LoadLeaveAllocations)
 		leaveAllocationGroup.GET("/monthly-info", leaveAllocationHandler.GetMonthlyAllocation)
+		leaveAllocationGroup.POST("/adjustments", leaveAllocationHandler.AdjustBalance)
+		leaveAllocationGroup.GET("/reconcile", leaveAllocationHandler.ReconcileBalances)
+	}
+
+	// Comp-off claims for extra hours; the scheduled job lapses credits left
+	// unused past their expiry
+	compOffService := services.NewCompOffService(repos, workingCalendar, config, location, logger)
+	if config.CompOffJobEnabled {
+		compOffService.Start()
+	}
+	compOffHandler := handlers.NewCompOffHandler(db, config, logger, location, repos, compOffService, notificationService)
+	compOffGroup := v1.Group("/comp-offs")
+	compOffGroup.Use(authMiddleware)
+	{
+		compOffGroup.GET("/", compOffHandler.GetClaims)
+		compOffGroup.POST("/", compOffHandler.CreateClaim)
+		compOffGroup.GET("/eligible", compOffHandler.GetEligibleDays)
+	}
+	adminCompOffGroup := v1.Group("/admin/comp-offs")
+	adminCompOffGroup.Use(authMiddleware)
+	adminCompOffGroup.Use(middleware.RequirePermission(models.PermLeaveApprove))
+	{
+		adminCompOffGroup.GET("/", compOffHandler.GetTeamClaims)
+		adminCompOffGroup.PUT("/:id/approve", compOffHandler.ApproveClaim)
+		adminCompOffGroup.PUT("/:id/reject", compOffHandler.RejectClaim)
+		adminCompOffGroup.POST("/expiry", middleware.RequirePermission(models.PermLeaveAllocate), compOffHandler.RunExpiry)
+	}
+
+	// Leave policies (admin only); the scheduled job accrues and rolls over
+	// balances in the background
+	leavePolicyService := services.NewLeavePolicyService(repos, logger)
+	if config.LeavePolicyJobEnabled {
+		leavePolicyService.Start()
+	}
+	leavePolicyHandler := handlers.NewLeavePolicyHandler(db, config, logger, repos, leavePolicyService)
+	leavePolicyGroup := v1.Group("/leave-policies")
+	leavePolicyGroup.Use(authMiddleware)
+	leavePolicyGroup.Use(middleware.RequirePermission(models.PermLeaveAllocate))
+	{
+		leavePolicyGroup.GET("/", leavePolicyHandler.GetPolicies)
+		leavePolicyGroup.PUT("/:leaveTypeId", leavePolicyHandler.SavePolicy)
+		leavePolicyGroup.POST("/runs", leavePolicyHandler.RunPolicies)
+		leavePolicyGroup.GET("/runs", leavePolicyHandler.GetPolicyRuns)
+	}
+
+	// Leave approval chains (admin only)
+	leaveApprovalHandler := handlers.NewLeaveApprovalHandler(db, config, logger, repos, leaveApprovalService)
+	leaveApprovalChainGroup := v1.Group("/leave-approval-chains")
+	leaveApprovalChainGroup.Use(authMiddleware)
+	leaveApprovalChainGroup.Use(middleware.RequirePermission(models.PermLeaveAllocate))
+	{
+		leaveApprovalChainGroup.GET("/", leaveApprovalHandler.GetChains)
+		leaveApprovalChainGroup.POST("/", leaveApprovalHandler.CreateChain)
+		leaveApprovalChainGroup.PUT("/:id", leaveApprovalHandler.UpdateChain)
+		leaveApprovalChainGroup.DELETE("/:id", leaveApprovalHandler.DeleteChain)
+		leaveApprovalChainGroup.POST("/escalations", leaveApprovalHandler.RunEscalation)
+	}
+
+	// Out-of-office delegation of leave approvals
+	leaveDelegationGroup := v1.Group("/leave-delegations")
+	leaveDelegationGroup.Use(authMiddleware)
+	leaveDelegationGroup.Use(middleware.RequirePermission(models.PermLeaveApprove))
+	{
+		leaveDelegationGroup.GET("/", leaveApprovalHandler.GetDelegations)
+		leaveDelegationGroup.POST("/", leaveApprovalHandler.CreateDelegation)
+		leaveDelegationGroup.DELETE("/:id", leaveApprovalHandler.RevokeDelegation)
 	}
 
 	// Timesheet routes
-	timesheetHandler := handlers.NewTimesheetHandler(db, config, logger, location)
+	timesheetHandler := handlers.NewTimesheetHandler(db, config, logger, location, repos, notificationService)
 	timesheetGroup := v1.Group("/timesheets")
-	timesheetGroup.Use(middleware.AuthMiddleware(config))
+	timesheetGroup.Use(authMiddleware)
 	{
 		timesheetGroup.POST("/", timesheetHandler.CreateTimesheet)
 		timesheetGroup.GET("/", timesheetHandler.GetTimesheets)
@@ -119,16 +303,24 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, config *config.Config, logger
 		timesheetGroup.DELETE("/:id", timesheetHandler.DeleteTimesheet)
 		timesheetGroup.POST("/submit", timesheetHandler.SubmitTimesheet)
 
+		// Review endpoints (reviewer's team scope, checked in the handler)
+		timesheetGroup.PUT("/:id/approve", timesheetHandler.ApproveTimesheet)
+		timesheetGroup.PUT("/:id/reject", timesheetHandler.RejectTimesheet)
+		timesheetGroup.PUT("/:id/return", timesheetHandler.ReturnTimesheet)
+		timesheetGroup.POST("/week/approve", timesheetHandler.ApproveTimesheetWeek)
+		timesheetGroup.POST("/week/reject", timesheetHandler.RejectTimesheetWeek)
+		timesheetGroup.POST("/week/return", timesheetHandler.ReturnTimesheetWeek)
+
 		// New download endpoints for admin functionality
 		timesheetGroup.GET("/download/:id", timesheetHandler.DownloadTimesheetEntry)
 		// timesheetGroup.GET("/download-bulk", timesheetHandler.DownloadTimesheetsBulk)
-		timesheetGroup.GET("/download-bulk", middleware.RequireManagerRole(db), timesheetHandler.DownloadTimesheetsBulk)
+		timesheetGroup.GET("/download-bulk", middleware.RequirePermission(models.PermTimesheetExport), timesheetHandler.DownloadTimesheetsBulk)
 	}
 
 	// Event routes
 	eventHandler := handlers.NewEventHandler(db, config, logger, location)
 	eventGroup := v1.Group("/events")
-	eventGroup.Use(middleware.AuthMiddleware(config))
+	eventGroup.Use(authMiddleware)
 	{
 		eventGroup.GET("/", eventHandler.GetEvents)
 		eventGroup.GET("/birthdays", eventHandler.GetBirthdays)
@@ -137,17 +329,67 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, config *config.Config, logger
 	}
 
 	// Holiday routes (separate from events for public access)
-	holidayHandler := handlers.NewHolidayHandler(db, config, logger, location)
+	holidayHandler := handlers.NewHolidayHandler(db, config, logger, location, repos)
 	holidayGroup := v1.Group("/holidays")
 	{
 		holidayGroup.GET("/year", holidayHandler.GetHolidaysByYear)
 		holidayGroup.GET("/upcoming", holidayHandler.GetUpcomingHolidays)
 	}
+	adminHolidayGroup := v1.Group("/admin/holidays")
+	adminHolidayGroup.Use(authMiddleware)
+	adminHolidayGroup.Use(middleware.RequirePermission(models.PermHolidayManage))
+	{
+		adminHolidayGroup.POST("/", holidayHandler.CreateHoliday)
+		adminHolidayGroup.PUT("/:id", holidayHandler.UpdateHoliday)
+		adminHolidayGroup.DELETE("/:id", holidayHandler.DeleteHoliday)
+		adminHolidayGroup.POST("/import", holidayHandler.ImportHolidays)
+		adminHolidayGroup.POST("/load-csv", holidayHandler.LoadHolidayData)
+		adminHolidayGroup.POST("/initialize", holidayHandler.InitializeHolidayData)
+	}
+
+	// Optional holidays employees pick within a yearly quota, subject to
+	// their manager's approval
+	optionalHolidayService := services.NewOptionalHolidayService(repos, config, location, logger)
+	optionalHolidayHandler := handlers.NewOptionalHolidayHandler(db, config, logger, location, repos, optionalHolidayService, notificationService)
+	optionalHolidayGroup := v1.Group("/optional-holidays")
+	optionalHolidayGroup.Use(authMiddleware)
+	{
+		optionalHolidayGroup.GET("/", optionalHolidayHandler.GetOptionalHolidays)
+		optionalHolidayGroup.POST("/selections", optionalHolidayHandler.SelectOptionalHoliday)
+		optionalHolidayGroup.DELETE("/selections/:id", optionalHolidayHandler.CancelSelection)
+	}
+	adminOptionalHolidayGroup := v1.Group("/admin/optional-holidays")
+	adminOptionalHolidayGroup.Use(authMiddleware)
+	adminOptionalHolidayGroup.Use(middleware.RequirePermission(models.PermLeaveApprove))
+	{
+		adminOptionalHolidayGroup.GET("/selections", optionalHolidayHandler.GetTeamSelections)
+		adminOptionalHolidayGroup.PUT("/selections/:id/approve", optionalHolidayHandler.ApproveSelection)
+		adminOptionalHolidayGroup.PUT("/selections/:id/reject", optionalHolidayHandler.RejectSelection)
+		adminOptionalHolidayGroup.GET("/quotas", middleware.RequirePermission(models.PermHolidayManage), optionalHolidayHandler.GetQuotas)
+		adminOptionalHolidayGroup.PUT("/quotas/:year", middleware.RequirePermission(models.PermHolidayManage), optionalHolidayHandler.SetQuota)
+	}
+
+	// Calendar feed routes. Feeds themselves are public: the token in the
+	// URL is the credential, so calendar apps can subscribe.
+	calendarFeedService := services.NewCalendarFeedService(db, repos, workingCalendar, services.NewTeamScopeService(db, logger), config, location, logger)
+	calendarFeedHandler := handlers.NewCalendarFeedHandler(db, config, logger, repos, calendarFeedService)
+	calendarGroup := v1.Group("/calendar")
+	{
+		calendarGroup.GET("/feeds/:token", calendarFeedHandler.ServeFeed)
+	}
+	calendarFeedGroup := v1.Group("/calendar-feeds")
+	calendarFeedGroup.Use(authMiddleware)
+	{
+		calendarFeedGroup.GET("/", calendarFeedHandler.GetFeeds)
+		calendarFeedGroup.POST("/", calendarFeedHandler.CreateFeed)
+		calendarFeedGroup.POST("/:id/rotate", calendarFeedHandler.RotateFeed)
+		calendarFeedGroup.DELETE("/:id", calendarFeedHandler.RevokeFeed)
+	}
 
 	// News routes
 	newsHandler := handlers.NewNewsHandler(db, config, logger)
 	newsGroup := v1.Group("/news")
-	newsGroup.Use(middleware.AuthMiddleware(config))
+	newsGroup.Use(authMiddleware)
 	{
 		newsGroup.GET("/", newsHandler.GetNews)
 		newsGroup.GET("/company", newsHandler.GetCompanyNews)
@@ -156,18 +398,18 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, config *config.Config, logger
 	// RSS routes
 	rssHandler := handlers.NewRSSNewsHandler(db, config, logger)
 	rssGroup := v1.Group("/rss")
-	rssGroup.Use(middleware.AuthMiddleware(config))
+	rssGroup.Use(authMiddleware)
 	{
 		rssGroup.GET("/latest", rssHandler.GetLatestNews)
 		rssGroup.GET("/news", rssHandler.GetNewsByCategory)
 		rssGroup.GET("/categories", rssHandler.GetCategories)
-		rssGroup.POST("/refresh", middleware.RequireAdminRole(db), rssHandler.RefreshFeeds)
+		rssGroup.POST("/refresh", middleware.RequirePermission(models.PermFeedManage), rssHandler.RefreshFeeds)
 	}
 
 	// Document routes
-	documentHandler := handlers.NewDocumentHandler(db, config, logger)
+	documentHandler := handlers.NewDocumentHandler(db, config, logger, repos)
 	documentGroup := v1.Group("/documents")
-	documentGroup.Use(middleware.AuthMiddleware(config))
+	documentGroup.Use(authMiddleware)
 	{
 		documentGroup.GET("/", documentHandler.GetDocuments)
 		documentGroup.POST("/upload", documentHandler.UploadDocument)
@@ -176,7 +418,7 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, config *config.Config, logger
 	// Learning routes
 	learningHandler := handlers.NewLearningHandler(db, config, logger, location)
 	learningGroup := v1.Group("/learning")
-	learningGroup.Use(middleware.AuthMiddleware(config))
+	learningGroup.Use(authMiddleware)
 	{
 		learningGroup.GET("/sessions", learningHandler.GetSessions)
 	}
@@ -184,7 +426,7 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, config *config.Config, logger
 	// Sports routes
 	sportsHandler := handlers.NewSportsHandler(db, config, logger)
 	sportsGroup := v1.Group("/sports")
-	sportsGroup.Use(middleware.AuthMiddleware(config))
+	sportsGroup.Use(authMiddleware)
 	{
 		sportsGroup.GET("/events", sportsHandler.GetSportsEvents)
 		sportsGroup.GET("/facilities", sportsHandler.GetSportsFacilities)
@@ -194,7 +436,7 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, config *config.Config, logger
 	// Gallery routes
 	galleryHandler := handlers.NewGalleryHandler(db, s3Service, config, logger)
 	galleryGroup := v1.Group("/gallery")
-	galleryGroup.Use(middleware.AuthMiddleware(config))
+	galleryGroup.Use(authMiddleware)
 	{
 		galleryGroup.GET("/images", galleryHandler.GetGalleryImages)
 	}
@@ -202,7 +444,7 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, config *config.Config, logger
 	// Project routes
 	projectHandler := handlers.NewProjectHandler(db, config, logger)
 	projectGroup := v1.Group("/projects")
-	projectGroup.Use(middleware.AuthMiddleware(config))
+	projectGroup.Use(authMiddleware)
 	{
 		projectGroup.GET("/", projectHandler.GetProjects)
 	}
@@ -211,7 +453,7 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, config *config.Config, logger
 	policyHandler := handlers.NewPolicyHandler(db, config, logger, s3Service)
 
 	policyGroup := v1.Group("/policies")
-	policyGroup.Use(middleware.AuthMiddleware(config))
+	policyGroup.Use(authMiddleware)
 	{
 		policyGroup.GET("/", policyHandler.GetPolicies)
 		policyGroup.GET("/:id", policyHandler.GetPolicy)
@@ -0,0 +1,265 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/database"
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const testPassword = "secret123"
+
+// fixtureYear is the leave year the seeded balances and requests fall in.
+const fixtureYear = 2025
+
+// fixtures are the rows seeded into every test database. The reporting tree
+// is admin <- manager <- employee; outsider reports to admin and so is outside
+// the manager's team scope.
+type fixtures struct {
+	admin     models.User
+	manager   models.User
+	employee  models.User
+	outsider  models.User
+	leaveType models.LeaveType
+	project   models.Project
+}
+
+type testServer struct {
+	t      *testing.T
+	db     *gorm.DB
+	router *gin.Engine
+	fx     fixtures
+	tokens map[string]string
+
+	passwordHash string // of testPassword
+}
+
+// newTestServer boots the full route tree against a migrated in-memory
+// SQLite database seeded with fixtures. configure may adjust the config
+// before the routes are set up.
+func newTestServer(t *testing.T, configure ...func(cfg *config.Config)) *testServer {
+	t.Helper()
+
+	cfg := config.Load()
+	cfg.DBDriver = database.DriverSQLite
+	cfg.DBPath = ":memory:"
+	cfg.EmailTransport = "memory"
+	cfg.EmailNotificationsEnabled = false
+	cfg.RateLimitEnabled = false
+	cfg.LeavePolicyJobEnabled = false
+	cfg.LeaveEscalationJobEnabled = false
+	cfg.CompOffJobEnabled = false
+	cfg.AllowAnonymousUsers = false
+	cfg.JWTSecret = "test-secret"
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	db, err := database.Initialize(cfg)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	db = db.Session(&gorm.Session{Logger: gormlogger.Discard})
+	migrator, err := database.NewMigrator(db, logger)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	SetupRoutes(router, db, cfg, logger, time.UTC)
+
+	s := &testServer{t: t, db: db, router: router, tokens: make(map[string]string)}
+	s.seed()
+	return s
+}
+
+func (s *testServer) seed() {
+	hash, err := utils.HashPassword(testPassword)
+	if err != nil {
+		s.t.Fatalf("failed to hash password: %v", err)
+	}
+	s.passwordHash = hash
+
+	fx := &s.fx
+	fx.admin = s.addUser("ADMIN", models.RoleAdmin, nil)
+	fx.manager = s.addUser("MANAGER", models.RoleManager, &fx.admin.ID)
+	fx.employee = s.addUser("EMPLOYEE", models.RoleEmployee, &fx.manager.ID)
+	fx.outsider = s.addUser("OUTSIDER", models.RoleEmployee, &fx.admin.ID)
+
+	fx.leaveType = models.LeaveType{Name: "Casual Leave", IsActive: true}
+	s.create(&fx.leaveType)
+	for _, u := range []models.User{fx.employee, fx.outsider} {
+		balance := models.LeaveBalance{UserID: u.ID, LeaveTypeID: fx.leaveType.ID, Year: fixtureYear, AllocatedDays: 12}
+		s.create(&balance)
+		s.create(models.NewLedgerEntry(&balance, models.LedgerOpening, 12))
+	}
+
+	fx.project = models.Project{Name: "Internal", Status: "active"}
+	s.create(&fx.project)
+}
+
+// addUser creates an approved user who logs in with testPassword.
+func (s *testServer) addUser(employeeID string, role models.UserRole, managerID *uuid.UUID) models.User {
+	s.t.Helper()
+	u := models.User{
+		EmployeeID:     employeeID,
+		Email:          employeeID + "@example.com",
+		PasswordHash:   s.passwordHash,
+		FirstName:      employeeID,
+		LastName:       "Test",
+		Role:           role,
+		ApprovalStatus: models.StatusApproved,
+		ManagerID:      managerID,
+	}
+	s.create(&u)
+	return u
+}
+
+func (s *testServer) create(value interface{}) {
+	s.t.Helper()
+	if err := s.db.Create(value).Error; err != nil {
+		s.t.Fatalf("failed to seed %T: %v", value, err)
+	}
+}
+
+// token logs u in once and returns the cached access token.
+func (s *testServer) token(u models.User) string {
+	s.t.Helper()
+	if token, ok := s.tokens[u.EmployeeID]; ok {
+		return token
+	}
+
+	var data struct {
+		Token string `json:"token"`
+	}
+	resp := s.request(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"employee_id": u.EmployeeID,
+		"password":    testPassword,
+	})
+	resp.expect(http.StatusOK).decode(&data)
+	s.tokens[u.EmployeeID] = data.Token
+	return data.Token
+}
+
+// as sends an authenticated request on behalf of u.
+func (s *testServer) as(u models.User, method, path string, body interface{}) *testResponse {
+	s.t.Helper()
+	return s.request(method, path, s.token(u), body)
+}
+
+// asWithHeaders is as with extra request headers.
+func (s *testServer) asWithHeaders(u models.User, method, path string, headers map[string]string, body interface{}) *testResponse {
+	s.t.Helper()
+	return s.requestWithHeaders(method, path, s.token(u), headers, body)
+}
+
+func (s *testServer) request(method, path, token string, body interface{}) *testResponse {
+	s.t.Helper()
+	return s.requestWithHeaders(method, path, token, nil, body)
+}
+
+func (s *testServer) requestWithHeaders(method, path, token string, headers map[string]string, body interface{}) *testResponse {
+	s.t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			s.t.Fatalf("failed to encode request body: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return &testResponse{t: s.t, method: method, path: path, recorder: w}
+}
+
+type testResponse struct {
+	t        *testing.T
+	method   string
+	path     string
+	recorder *httptest.ResponseRecorder
+}
+
+func (r *testResponse) expect(status int) *testResponse {
+	r.t.Helper()
+	if r.recorder.Code != status {
+		r.t.Fatalf("%s %s: expected status %d, got %d: %s", r.method, r.path, status, r.recorder.Code, r.recorder.Body.String())
+	}
+	return r
+}
+
+// decode unmarshals the data field of the response envelope into v.
+func (r *testResponse) decode(v interface{}) {
+	r.t.Helper()
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(r.recorder.Body.Bytes(), &envelope); err != nil {
+		r.t.Fatalf("%s %s: invalid response body: %v", r.method, r.path, err)
+	}
+	if err := json.Unmarshal(envelope.Data, v); err != nil {
+		r.t.Fatalf("%s %s: invalid response data: %v", r.method, r.path, err)
+	}
+}
+
+func TestRequiresAuthentication(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, path := range []string{"/api/v1/leaves/", "/api/v1/timesheets/", "/api/v1/admin/leaves/"} {
+		s.request(http.MethodGet, path, "", nil).expect(http.StatusUnauthorized)
+	}
+}
+
+func TestEmployeeCannotUseAdminEndpoints(t *testing.T) {
+	s := newTestServer(t)
+	employee := s.fx.employee
+
+	s.as(employee, http.MethodGet, "/api/v1/admin/leaves/", nil).expect(http.StatusForbidden)
+	s.as(employee, http.MethodGet, "/api/v1/admin/leaves/team-balances", nil).expect(http.StatusForbidden)
+	s.as(employee, http.MethodGet, "/api/v1/users/", nil).expect(http.StatusForbidden)
+	s.as(employee, http.MethodPost, "/api/v1/leave-allocations/initialize", nil).expect(http.StatusForbidden)
+}
+
+func TestManagerTeamScope(t *testing.T) {
+	s := newTestServer(t)
+	manager, employee, outsider := s.fx.manager, s.fx.employee, s.fx.outsider
+
+	s.as(manager, http.MethodGet, "/api/v1/admin/leaves/employee/"+employee.ID.String(), nil).expect(http.StatusOK)
+	s.as(manager, http.MethodGet, "/api/v1/admin/leaves/employee/"+outsider.ID.String(), nil).expect(http.StatusForbidden)
+
+	s.as(manager, http.MethodGet, "/api/v1/timesheets/?user_id="+employee.ID.String(), nil).expect(http.StatusOK)
+	s.as(manager, http.MethodGet, "/api/v1/timesheets/?user_id="+outsider.ID.String(), nil).expect(http.StatusForbidden)
+
+	// Employees only see their own timesheets
+	s.as(employee, http.MethodGet, "/api/v1/timesheets/?user_id="+outsider.ID.String(), nil).expect(http.StatusForbidden)
+}
@@ -0,0 +1,124 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"employee-dashboard-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *testServer) logTime(u models.User, date, start, end string, hours float64) *testResponse {
+	s.t.Helper()
+	return s.as(u, http.MethodPost, "/api/v1/timesheets/", gin.H{
+		"project_id":       s.fx.project.ID,
+		"task_description": "Development",
+		"entry_date":       date,
+		"start_time":       start,
+		"end_time":         end,
+		"duration_hours":   hours,
+	})
+}
+
+func TestTimesheetOverlapIsRejected(t *testing.T) {
+	s := newTestServer(t)
+	employee := s.fx.employee
+
+	var morning models.TimesheetEntry
+	s.logTime(employee, "2025-03-03", "09:00", "12:00", 3).expect(http.StatusCreated).decode(&morning)
+
+	s.logTime(employee, "2025-03-03", "11:00", "13:00", 2).expect(http.StatusConflict)
+	s.logTime(employee, "2025-03-03", "08:00", "17:00", 4).expect(http.StatusConflict)
+
+	// Touching entries and other days are fine
+	var afternoon models.TimesheetEntry
+	s.logTime(employee, "2025-03-03", "12:00", "14:00", 2).expect(http.StatusCreated).decode(&afternoon)
+	s.logTime(employee, "2025-03-04", "10:00", "11:00", 1).expect(http.StatusCreated)
+
+	// Another user's entries never conflict
+	s.logTime(s.fx.outsider, "2025-03-03", "09:00", "12:00", 3).expect(http.StatusCreated)
+
+	// Moving an entry onto another one conflicts; moving it within its own slot does not
+	s.as(employee, http.MethodPut, "/api/v1/timesheets/"+afternoon.ID.String(), gin.H{
+		"start_time": "11:30",
+		"end_time":   "13:30",
+	}).expect(http.StatusConflict)
+	s.as(employee, http.MethodPut, "/api/v1/timesheets/"+afternoon.ID.String(), gin.H{
+		"start_time": "12:30",
+		"end_time":   "14:00",
+	}).expect(http.StatusOK)
+}
+
+func TestTimesheetDailyLimit(t *testing.T) {
+	s := newTestServer(t)
+	employee := s.fx.employee
+
+	s.logTime(employee, "2025-03-03", "09:00", "14:00", 5).expect(http.StatusCreated)
+	s.logTime(employee, "2025-03-03", "14:00", "18:00", 4).expect(http.StatusBadRequest)
+	s.logTime(employee, "2025-03-03", "14:00", "17:00", 3).expect(http.StatusCreated)
+}
+
+func TestTimesheetInvalidTimes(t *testing.T) {
+	s := newTestServer(t)
+
+	s.logTime(s.fx.employee, "2025-03-03", "12:00", "09:00", 3).expect(http.StatusBadRequest)
+	s.logTime(s.fx.employee, "03/03/2025", "09:00", "12:00", 3).expect(http.StatusBadRequest)
+}
+
+func TestSubmittedTimesheetIsLocked(t *testing.T) {
+	s := newTestServer(t)
+	manager, employee := s.fx.manager, s.fx.employee
+
+	var entry models.TimesheetEntry
+	s.logTime(employee, "2025-03-03", "09:00", "12:00", 3).expect(http.StatusCreated).decode(&entry)
+
+	var submitted struct {
+		SubmittedEntries int64 `json:"submitted_entries"`
+	}
+	s.as(employee, http.MethodPost, "/api/v1/timesheets/submit?start_date=2025-03-03&end_date=2025-03-09", nil).
+		expect(http.StatusOK).decode(&submitted)
+	if submitted.SubmittedEntries != 1 {
+		t.Fatalf("expected 1 submitted entry, got %d", submitted.SubmittedEntries)
+	}
+
+	path := "/api/v1/timesheets/" + entry.ID.String()
+	s.as(employee, http.MethodPut, path, gin.H{"task_description": "Edited"}).expect(http.StatusBadRequest)
+	s.as(employee, http.MethodDelete, path, nil).expect(http.StatusBadRequest)
+
+	// Nobody reviews their own timesheets, and employees review nobody's
+	s.as(employee, http.MethodPut, path+"/approve", nil).expect(http.StatusForbidden)
+	s.as(s.fx.outsider, http.MethodPut, path+"/approve", nil).expect(http.StatusForbidden)
+
+	var approved models.TimesheetEntry
+	s.as(manager, http.MethodPut, path+"/approve", nil).expect(http.StatusOK).decode(&approved)
+	if approved.Status != models.TimesheetStatusApproved {
+		t.Fatalf("expected approved entry, got %q", approved.Status)
+	}
+}
+
+func TestTimesheetOnApprovedLeave(t *testing.T) {
+	s := newTestServer(t)
+	manager, employee := s.fx.manager, s.fx.employee
+
+	// Friday to Monday, with the afternoon of Monday taken as leave
+	var leave models.LeaveApplication
+	s.as(employee, http.MethodPost, "/api/v1/leaves/", gin.H{
+		"leave_type_id": s.fx.leaveType.ID,
+		"start_date":    "2025-03-07",
+		"end_date":      "2025-03-10",
+		"sessions":      []gin.H{{"date": "2025-03-10", "session": "second_half"}},
+	}).expect(http.StatusCreated).decode(&leave)
+
+	// Pending leave does not stop anyone from working
+	s.logTime(employee, "2025-03-07", "09:00", "10:00", 1).expect(http.StatusCreated)
+
+	s.as(manager, http.MethodPut, "/api/v1/admin/leaves/"+leave.ID.String()+"/approve", nil).expect(http.StatusOK)
+
+	s.logTime(employee, "2025-03-07", "10:00", "11:00", 1).expect(http.StatusConflict)
+	// The weekend within the leave is not leave
+	s.logTime(employee, "2025-03-08", "10:00", "11:00", 1).expect(http.StatusCreated)
+	// Half a day on leave leaves half a day to log
+	s.logTime(employee, "2025-03-10", "09:00", "13:00", 4).expect(http.StatusCreated)
+	s.logTime(employee, "2025-03-10", "14:00", "15:00", 1).expect(http.StatusBadRequest)
+}
@@ -0,0 +1,404 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// calendarFeedUIDDomain qualifies the UIDs of feed events. It must never
+// change: calendar apps match updates and cancellations by UID.
+const calendarFeedUIDDomain = "employee-dashboard"
+
+// calendarFeedRefresh is the polling interval suggested to calendar apps.
+const calendarFeedRefresh = "PT1H"
+
+var ErrInvalidFeedScope = errors.New("calendar feed scope must be personal or team")
+
+// feedLeaveStatuses are the leaves published in feeds. Cancelled leaves that
+// had been approved stay in as cancelled events so subscribers drop them.
+var feedLeaveStatuses = []string{models.LeaveStatusApproved, models.LeaveStatusCancellationRequested, models.LeaveStatusCancelled}
+
+// feedOptionalHolidayStatuses are the optional holiday selections published
+// in team feeds, cancelled ones for the same reason.
+var feedOptionalHolidayStatuses = []string{models.OptionalHolidayApproved, models.OptionalHolidayCancelled}
+
+// CalendarFeedService issues tokenised iCalendar subscription URLs and
+// renders their feeds. Feeds go back pastDays and run to the end of next
+// year.
+//
+// A personal feed has the owner's leaves and the holidays of their work
+// location; optional holidays they have not taken leave them free. A team
+// feed has the leaves, taken optional holidays, birthdays and anniversaries
+// of the owner's team: their manager, the manager's other reports and the
+// owner's own reporting tree.
+type CalendarFeedService struct {
+	db        *gorm.DB
+	feeds     repository.CalendarFeedRepository
+	leaves    repository.LeaveRepository
+	users     repository.UserRepository
+	optional  repository.OptionalHolidayRepository
+	calendar  *WorkingCalendarService
+	teamScope *TeamScopeService
+	location  *time.Location
+	logger    *logrus.Logger
+
+	pastDays int
+}
+
+func NewCalendarFeedService(db *gorm.DB, repos *repository.Repositories, calendar *WorkingCalendarService, teamScope *TeamScopeService, cfg *config.Config, location *time.Location, logger *logrus.Logger) *CalendarFeedService {
+	return &CalendarFeedService{
+		db:        db,
+		feeds:     repos.Feeds,
+		leaves:    repos.Leaves,
+		users:     repos.Users,
+		optional:  repos.OptionalHolidays,
+		calendar:  calendar,
+		teamScope: teamScope,
+		location:  location,
+		logger:    logger,
+		pastDays:  cfg.CalendarFeedPastDays,
+	}
+}
+
+// Create issues a feed of scope for userID, returning it with the token of
+// its URL. The token is not stored and cannot be shown again.
+func (s *CalendarFeedService) Create(userID uuid.UUID, scope string) (*models.CalendarFeed, string, error) {
+	if scope != models.CalendarFeedPersonal && scope != models.CalendarFeedTeam {
+		return nil, "", ErrInvalidFeedScope
+	}
+
+	token, hash, err := newFeedToken()
+	if err != nil {
+		return nil, "", err
+	}
+	feed := &models.CalendarFeed{UserID: userID, Scope: scope, TokenHash: hash}
+	if err := s.feeds.Create(feed); err != nil {
+		return nil, "", fmt.Errorf("failed to create calendar feed: %w", err)
+	}
+	return feed, token, nil
+}
+
+// Rotate replaces the token of a feed, so the old URL stops working, and
+// returns the new one.
+func (s *CalendarFeedService) Rotate(feed *models.CalendarFeed) (string, error) {
+	token, hash, err := newFeedToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.feeds.Update(feed, map[string]interface{}{"token_hash": hash}); err != nil {
+		return "", fmt.Errorf("failed to rotate calendar feed: %w", err)
+	}
+	return token, nil
+}
+
+// Revoke disables a feed for good.
+func (s *CalendarFeedService) Revoke(feed *models.CalendarFeed) error {
+	if err := s.feeds.Update(feed, map[string]interface{}{"revoked_at": time.Now()}); err != nil {
+		return fmt.Errorf("failed to revoke calendar feed: %w", err)
+	}
+	return nil
+}
+
+// Resolve returns the live feed a URL token belongs to and its owner. It
+// fails with repository.ErrNotFound for unknown or revoked tokens and for
+// owners who are no longer approved.
+func (s *CalendarFeedService) Resolve(token string) (*models.CalendarFeed, *models.User, error) {
+	feed, err := s.feeds.FindByTokenHash(hashFeedToken(token))
+	if err != nil {
+		return nil, nil, err
+	}
+	owner, err := s.users.FindByID(feed.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if owner.ApprovalStatus != models.StatusApproved {
+		return nil, nil, repository.ErrNotFound
+	}
+
+	if err := s.feeds.Update(feed, map[string]interface{}{"last_accessed_at": time.Now()}); err != nil {
+		s.logger.Errorf("Failed to record access to calendar feed %s: %v", feed.ID, err)
+	}
+	return feed, owner, nil
+}
+
+// Render builds the iCalendar document of a feed as of now.
+func (s *CalendarFeedService) Render(feed *models.CalendarFeed, owner *models.User, now time.Time) ([]byte, error) {
+	// Leave and event dates are stored as UTC midnights
+	today := now.In(s.location)
+	from := time.Date(today.Year(), today.Month(), today.Day()-s.pastDays, 0, 0, 0, 0, time.UTC)
+	to := time.Date(today.Year()+1, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	w := &icalWriter{}
+	w.property("BEGIN", "VCALENDAR")
+	w.property("VERSION", "2.0")
+	w.property("PRODID", "-//Employee Dashboard//Calendar Feed//EN")
+	w.property("CALSCALE", "GREGORIAN")
+	w.property("METHOD", "PUBLISH")
+	w.property("REFRESH-INTERVAL;VALUE=DURATION", calendarFeedRefresh)
+	w.property("X-PUBLISHED-TTL", calendarFeedRefresh)
+
+	var err error
+	if feed.Scope == models.CalendarFeedTeam {
+		w.text("X-WR-CALNAME", "Team calendar")
+		err = s.writeTeam(w, owner, from, to)
+	} else {
+		w.text("X-WR-CALNAME", "My leave and holidays")
+		err = s.writePersonal(w, owner, from, to)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	w.property("END", "VCALENDAR")
+	return w.Bytes(), nil
+}
+
+func (s *CalendarFeedService) writePersonal(w *icalWriter, owner *models.User, from, to time.Time) error {
+	leaves, err := s.leaves.ListBetween(repository.LeaveFilter{UserID: owner.ID}, feedLeaveStatuses, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to load leaves: %w", err)
+	}
+	if err := s.writeLeaves(w, leaves, false); err != nil {
+		return err
+	}
+
+	holidays, err := s.calendar.Holidays(owner.WorkLocation, from, to)
+	if err != nil {
+		return err
+	}
+	selections, err := s.optional.ListBetween(repository.OptionalHolidayFilter{UserID: owner.ID},
+		[]string{models.OptionalHolidayApproved}, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to load optional holidays: %w", err)
+	}
+	taken := make(map[string]bool, len(selections))
+	for _, selection := range selections {
+		taken[selection.Holiday.EventDate.Format(dateLayout)] = true
+	}
+	for _, holiday := range holidays {
+		summary := holiday.Title
+		if holiday.IsOptional {
+			summary += " (optional)"
+		}
+		category := "HOLIDAY"
+		if holiday.HolidayType != nil {
+			category += "," + strings.ToUpper(*holiday.HolidayType)
+		}
+		writeFeedEvent(w, holiday, summary, category, !holiday.IsOptional || taken[holiday.EventDate.Format(dateLayout)])
+	}
+	return nil
+}
+
+func (s *CalendarFeedService) writeTeam(w *icalWriter, owner *models.User, from, to time.Time) error {
+	members, err := s.teamMembers(owner)
+	if err != nil {
+		return err
+	}
+
+	scope := &TeamScope{UserIDs: members}
+	leaves, err := s.leaves.ListBetween(repository.LeaveFilter{Scope: scope}, feedLeaveStatuses, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to load leaves: %w", err)
+	}
+	if err := s.writeLeaves(w, leaves, true); err != nil {
+		return err
+	}
+
+	selections, err := s.optional.ListBetween(repository.OptionalHolidayFilter{Scope: scope}, feedOptionalHolidayStatuses, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to load optional holidays: %w", err)
+	}
+	for _, selection := range selections {
+		// Selections withdrawn before approval were never published
+		if selection.Status == models.OptionalHolidayApproved || selection.ReviewedAt != nil {
+			writeOptionalHoliday(w, selection)
+		}
+	}
+
+	var events []models.Event
+	if err := s.db.Where("event_type IN ? AND user_id IN ? AND event_date >= ? AND event_date < ?",
+		[]string{"birthday", "anniversary"}, members, from, to.AddDate(0, 0, 1)).
+		Order("event_date").
+		Find(&events).Error; err != nil {
+		return fmt.Errorf("failed to load events: %w", err)
+	}
+	for _, event := range events {
+		writeFeedEvent(w, event, event.Title, strings.ToUpper(event.EventType), false)
+	}
+	return nil
+}
+
+// teamMembers returns the owner, their manager, the manager's direct reports
+// and the owner's reporting tree.
+func (s *CalendarFeedService) teamMembers(owner *models.User) ([]uuid.UUID, error) {
+	seen := map[uuid.UUID]bool{owner.ID: true}
+	members := []uuid.UUID{owner.ID}
+	add := func(id uuid.UUID) {
+		if !seen[id] {
+			seen[id] = true
+			members = append(members, id)
+		}
+	}
+
+	if owner.ManagerID != nil {
+		add(*owner.ManagerID)
+		peers, err := s.users.ListDirectReports(*owner.ManagerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load team: %w", err)
+		}
+		for _, peer := range peers {
+			add(peer.ID)
+		}
+	}
+
+	reports, err := s.teamScope.ResolveScope(owner.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range reports.UserIDs {
+		add(id)
+	}
+	return members, nil
+}
+
+// writeLeaves writes one event per leave. Its UID is the leave's, so date
+// changes and cancellations replace the event subscribers already have;
+// SEQUENCE counts the leave's status changes so they know which is newer.
+func (s *CalendarFeedService) writeLeaves(w *icalWriter, leaves []models.LeaveApplication, team bool) error {
+	ids := make([]uuid.UUID, len(leaves))
+	var cancelled []uuid.UUID
+	for i, leave := range leaves {
+		ids[i] = leave.ID
+		if leave.Status == models.LeaveStatusCancelled {
+			cancelled = append(cancelled, leave.ID)
+		}
+	}
+	sequences, err := s.leaves.CountStatusChanges(ids)
+	if err != nil {
+		return fmt.Errorf("failed to load leave history: %w", err)
+	}
+	published, err := s.leaves.WereApproved(cancelled)
+	if err != nil {
+		return fmt.Errorf("failed to load leave history: %w", err)
+	}
+
+	for _, leave := range leaves {
+		// Leaves withdrawn before approval were never published
+		if leave.Status == models.LeaveStatusCancelled && !published[leave.ID] {
+			continue
+		}
+		summary := leave.LeaveType.Name
+		if team {
+			summary = leave.User.FirstName + " " + leave.User.LastName + ": " + summary
+		}
+		if leave.IsHalfDay && leave.StartDate.Equal(leave.EndDate) {
+			summary += " (half day)"
+		}
+
+		w.property("BEGIN", "VEVENT")
+		w.property("UID", "leave-"+leave.ID.String()+"@"+calendarFeedUIDDomain)
+		w.timestamp("DTSTAMP", leave.UpdatedAt)
+		w.timestamp("CREATED", leave.CreatedAt)
+		w.timestamp("LAST-MODIFIED", leave.UpdatedAt)
+		w.property("SEQUENCE", fmt.Sprint(sequences[leave.ID]))
+		w.date("DTSTART", leave.StartDate)
+		w.date("DTEND", leave.EndDate.AddDate(0, 0, 1))
+		w.text("SUMMARY", summary)
+		if len(leave.Sessions) > 0 {
+			halves := make([]string, len(leave.Sessions))
+			for i, session := range leave.Sessions {
+				halves[i] = session.Date + " " + strings.ReplaceAll(session.Session, "_", " ")
+			}
+			w.text("DESCRIPTION", "Half days: "+strings.Join(halves, ", "))
+		}
+		w.property("CATEGORIES", "LEAVE")
+		if leave.Status == models.LeaveStatusCancelled {
+			w.property("STATUS", "CANCELLED")
+		} else {
+			w.property("STATUS", "CONFIRMED")
+		}
+		// Only the owner's own leave makes them busy
+		if team {
+			w.property("TRANSP", "TRANSPARENT")
+		} else {
+			w.property("TRANSP", "OPAQUE")
+		}
+		w.property("END", "VEVENT")
+	}
+	return nil
+}
+
+// writeOptionalHoliday writes the event of an employee taking an optional
+// holiday. Its UID is the selection's, so a cancellation replaces it.
+func writeOptionalHoliday(w *icalWriter, selection models.OptionalHolidaySelection) {
+	w.property("BEGIN", "VEVENT")
+	w.property("UID", "optional-holiday-"+selection.ID.String()+"@"+calendarFeedUIDDomain)
+	w.timestamp("DTSTAMP", selection.UpdatedAt)
+	w.timestamp("CREATED", selection.CreatedAt)
+	w.timestamp("LAST-MODIFIED", selection.UpdatedAt)
+	w.date("DTSTART", selection.Holiday.EventDate)
+	w.date("DTEND", selection.Holiday.EventDate.AddDate(0, 0, 1))
+	w.text("SUMMARY", selection.User.FirstName+" "+selection.User.LastName+": "+selection.Holiday.Title+" (optional holiday)")
+	w.property("CATEGORIES", "HOLIDAY,OPTIONAL")
+	if selection.Status == models.OptionalHolidayCancelled {
+		w.property("STATUS", "CANCELLED")
+	} else {
+		w.property("STATUS", "CONFIRMED")
+	}
+	w.property("TRANSP", "TRANSPARENT")
+	w.property("END", "VEVENT")
+}
+
+// writeFeedEvent writes an all-day event for a row of the events table.
+func writeFeedEvent(w *icalWriter, event models.Event, summary, category string, busy bool) {
+	w.property("BEGIN", "VEVENT")
+	w.property("UID", event.EventType+"-"+event.ID.String()+"@"+calendarFeedUIDDomain)
+	modified := event.UpdatedAt
+	if modified.IsZero() {
+		modified = event.CreatedAt
+	}
+	w.timestamp("DTSTAMP", modified)
+	w.timestamp("LAST-MODIFIED", modified)
+	w.date("DTSTART", event.EventDate)
+	w.date("DTEND", event.EventDate.AddDate(0, 0, 1))
+	w.text("SUMMARY", summary)
+	if event.Description != nil && *event.Description != "" {
+		w.text("DESCRIPTION", *event.Description)
+	}
+	w.property("CATEGORIES", category)
+	if busy {
+		w.property("TRANSP", "OPAQUE")
+	} else {
+		w.property("TRANSP", "TRANSPARENT")
+	}
+	w.property("END", "VEVENT")
+}
+
+// newFeedToken returns a random URL-safe feed token and the hash stored for
+// it.
+func newFeedToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate calendar feed token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashFeedToken(token), nil
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
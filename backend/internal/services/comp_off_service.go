@@ -0,0 +1,414 @@
+package services
+
+import (
+	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// compOffJobInterval is how often expired comp-off credits are looked for.
+const compOffJobInterval = 6 * time.Hour
+
+var (
+	ErrNoCompOffEarned    = errors.New("no comp-off earned on this day")
+	ErrCompOffClaimed     = errors.New("comp-off for this day has already been claimed")
+	ErrCompOffExpired     = errors.New("comp-off for this day has expired")
+	ErrCompOffUnavailable = errors.New("comp off leave type is not available")
+	// ErrCompOffNotPending is returned when reviewing a claim that has
+	// already been decided.
+	ErrCompOffNotPending = errors.New("comp-off claim is not in pending status")
+
+	// errCompOffExpired is returned when expiring a claim that has already
+	// been expired concurrently.
+	errCompOffExpired = errors.New("comp-off claim already expired")
+)
+
+// CompOffService turns extra hours in timesheets into comp-off: it finds the
+// days that earn it, records claims, credits approved claims to the Comp Off
+// balance and lapses credits left unused past their expiry.
+//
+// Hours count once their timesheet entries are submitted. On weekends and
+// holidays every hour counts; on working days only the hours past the
+// overtime threshold. Every half of hoursPerDay earns half a day, up to a
+// whole day per work date.
+type CompOffService struct {
+	repos      *repository.Repositories
+	claims     repository.CompOffRepository
+	timesheets repository.TimesheetRepository
+	leaves     repository.LeaveRepository
+	calendar   *WorkingCalendarService
+	location   *time.Location
+	logger     *logrus.Logger
+
+	overtimeThreshold float64
+	hoursPerDay       float64
+	expiryDays        int
+
+	startOnce sync.Once
+}
+
+func NewCompOffService(repos *repository.Repositories, calendar *WorkingCalendarService, cfg *config.Config, location *time.Location, logger *logrus.Logger) *CompOffService {
+	return &CompOffService{
+		repos:             repos,
+		claims:            repos.CompOffs,
+		timesheets:        repos.Timesheets,
+		leaves:            repos.Leaves,
+		calendar:          calendar,
+		location:          location,
+		logger:            logger,
+		overtimeThreshold: cfg.CompOffOvertimeThresholdHours,
+		hoursPerDay:       cfg.CompOffHoursPerDay,
+		expiryDays:        cfg.CompOffExpiryDays,
+	}
+}
+
+// Start launches the expiry job, running it right away and then every
+// compOffJobInterval. Calling it more than once has no effect.
+func (s *CompOffService) Start() {
+	s.startOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(compOffJobInterval)
+			defer ticker.Stop()
+			for {
+				if _, err := s.Expire(time.Now()); err != nil {
+					s.logger.Errorf("Scheduled comp-off expiry failed: %v", err)
+				}
+				<-ticker.C
+			}
+		}()
+		s.logger.Infof("Comp-off expiry job started (every %s)", compOffJobInterval)
+	})
+}
+
+// CompOffDay is a day on which a user logged hours that earn comp-off.
+type CompOffDay struct {
+	Date        string               `json:"date"` // YYYY-MM-DD
+	Reason      string               `json:"reason"`
+	Holiday     string               `json:"holiday,omitempty"`
+	LoggedHours float64              `json:"logged_hours"`
+	Hours       float64              `json:"hours"` // the logged hours that count
+	Days        float64              `json:"days"`
+	ExpiresAt   time.Time            `json:"expires_at"`
+	Claim       *models.CompOffClaim `json:"claim,omitempty"` // the claim already made for the day, unless rejected
+}
+
+// Eligible returns the days from..to inclusive on which userID earned
+// comp-off, oldest first.
+func (s *CompOffService) Eligible(userID uuid.UUID, from, to time.Time) ([]CompOffDay, error) {
+	from, to = truncateToDate(from.In(s.location)), truncateToDate(to.In(s.location))
+
+	entries, err := s.timesheets.ListInRange(userID,
+		[]string{models.TimesheetStatusSubmitted, models.TimesheetStatusApproved}, from, to)
+	if err != nil {
+		return nil, err
+	}
+	logged := make(map[string]float64)
+	for _, entry := range entries {
+		if entry.DurationHours != nil {
+			logged[entry.EntryDate.In(s.location).Format(dateLayout)] += *entry.DurationHours
+		}
+	}
+	if len(logged) == 0 {
+		return []CompOffDay{}, nil
+	}
+
+	calendarDays, _, err := s.calendar.LeaveDays(userID, from, to, nil)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.claims.ListForUserBetween(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	claimed := make(map[string]*models.CompOffClaim, len(claims))
+	for i := range claims {
+		if claims[i].Status != models.CompOffRejected {
+			claimed[claims[i].WorkDate.In(s.location).Format(dateLayout)] = &claims[i]
+		}
+	}
+
+	days := []CompOffDay{}
+	for _, calendarDay := range calendarDays {
+		hours, ok := logged[calendarDay.Date]
+		if !ok {
+			continue
+		}
+		day := s.earned(calendarDay, hours)
+		if day.Days == 0 {
+			continue
+		}
+		day.Claim = claimed[day.Date]
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// earned works out the comp-off that hours logged on day earn.
+func (s *CompOffService) earned(day models.LeaveDay, hours float64) CompOffDay {
+	earned := CompOffDay{Date: day.Date, Holiday: day.Holiday, LoggedHours: hours, Hours: hours}
+	switch day.Kind {
+	case models.DayKindWeekend:
+		earned.Reason = models.CompOffWeekend
+	case models.DayKindHoliday, models.DayKindOptionalHoliday:
+		earned.Reason = models.CompOffHoliday
+	default:
+		earned.Reason = models.CompOffOvertime
+		earned.Holiday = ""
+		earned.Hours = math.Max(0, hours-s.overtimeThreshold)
+	}
+	if s.hoursPerDay > 0 {
+		halfDays := math.Floor(earned.Hours*2/s.hoursPerDay + 1e-9)
+		earned.Days = math.Min(1, halfDays/2)
+	}
+	if date, err := time.ParseInLocation(dateLayout, day.Date, s.location); err == nil {
+		earned.ExpiresAt = date.AddDate(0, 0, s.expiryDays)
+	}
+	return earned
+}
+
+// Claim records userID's claim for the comp-off earned on workDate, pending
+// their manager's approval.
+func (s *CompOffService) Claim(userID uuid.UUID, workDate time.Time, note string) (*models.CompOffClaim, error) {
+	if _, err := s.leaves.FindActiveTypeByName(models.CompOffLeaveTypeName); errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrCompOffUnavailable
+	} else if err != nil {
+		return nil, err
+	}
+
+	days, err := s.Eligible(userID, workDate, workDate)
+	if err != nil {
+		return nil, err
+	}
+	if len(days) == 0 {
+		return nil, ErrNoCompOffEarned
+	}
+	day := days[0]
+	if day.Claim != nil {
+		return nil, ErrCompOffClaimed
+	}
+	if !time.Now().Before(day.ExpiresAt) {
+		return nil, ErrCompOffExpired
+	}
+
+	claim := &models.CompOffClaim{
+		UserID:    userID,
+		WorkDate:  truncateToDate(workDate.In(s.location)),
+		Reason:    day.Reason,
+		Hours:     day.Hours,
+		Days:      day.Days,
+		Status:    models.CompOffPending,
+		ExpiresAt: day.ExpiresAt,
+	}
+	if day.Holiday != "" {
+		claim.Holiday = &day.Holiday
+	}
+	if note != "" {
+		claim.Note = &note
+	}
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		// Concurrent claims of the same user would each find the day
+		// unclaimed before the other's insert
+		if err := tx.Users.Lock(userID); err != nil {
+			return err
+		}
+		if _, err := tx.CompOffs.FindLive(userID, claim.WorkDate); err == nil {
+			return ErrCompOffClaimed
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		err := tx.CompOffs.Create(claim)
+		if errors.Is(err, repository.ErrDuplicate) {
+			return ErrCompOffClaimed
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Infof("User %s claimed %.1f days of comp-off for %s (%s)", userID, claim.Days, day.Date, claim.Reason)
+	return claim, nil
+}
+
+// Approve approves a pending claim as reviewerID and credits its days to the
+// claimant's Comp Off balance for the year of the work date, or the next
+// year once that one has been rolled over. The balance is created when
+// missing.
+func (s *CompOffService) Approve(claim *models.CompOffClaim, reviewerID uuid.UUID) error {
+	leaveType, err := s.leaves.FindActiveTypeByName(models.CompOffLeaveTypeName)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrCompOffUnavailable
+	} else if err != nil {
+		return err
+	}
+
+	note := fmt.Sprintf("Comp-off for %s (%s)", claim.WorkDate.In(s.location).Format(dateLayout), claim.Reason)
+	for attempt := 1; ; attempt++ {
+		err := s.repos.Transaction(func(tx *repository.Repositories) error {
+			balance, err := compOffBalance(tx, claim.UserID, leaveType.ID, claim.WorkDate.In(s.location).Year())
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			if err := tx.CompOffs.Transition(claim, models.CompOffPending, map[string]interface{}{
+				"status":           models.CompOffApproved,
+				"reviewed_by":      reviewerID,
+				"reviewed_at":      now,
+				"leave_balance_id": balance.ID,
+			}); err != nil {
+				if errors.Is(err, repository.ErrConflict) {
+					return ErrCompOffNotPending
+				}
+				return err
+			}
+
+			if err := tx.Balances.Update(balance, map[string]interface{}{
+				"allocated_days": balance.AllocatedDays + claim.Days,
+			}); err != nil {
+				return err
+			}
+			entry := models.NewLedgerEntry(balance, models.LedgerCompOff, claim.Days)
+			entry.ActorID = &reviewerID
+			entry.CompOffClaimID = &claim.ID
+			entry.Note = &note
+			return tx.Ledger.Append(entry)
+		})
+		if err == nil {
+			s.logger.Infof("Credited %.1f days of comp-off to user %s for claim %s", claim.Days, claim.UserID, claim.ID)
+			return nil
+		}
+		if !errors.Is(err, repository.ErrConflict) || attempt == maxBalanceAttempts {
+			return err
+		}
+		s.logger.Warnf("Leave balance of user %s changed while crediting comp-off, retrying", claim.UserID)
+	}
+}
+
+// compOffBalance returns the balance a comp-off earned in year is credited
+// to, creating it when missing.
+func compOffBalance(tx *repository.Repositories, userID, leaveTypeID uuid.UUID, year int) (*models.LeaveBalance, error) {
+	for {
+		balance, err := tx.Balances.Find(userID, leaveTypeID, year)
+		if errors.Is(err, repository.ErrNotFound) {
+			balance = &models.LeaveBalance{UserID: userID, LeaveTypeID: leaveTypeID, Year: year}
+			if err := tx.Balances.Create(balance); err != nil {
+				return nil, err
+			}
+			return balance, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if balance.ClosedAt == nil {
+			return balance, nil
+		}
+		year++
+	}
+}
+
+// Reject turns down a pending claim as reviewerID with reason.
+func (s *CompOffService) Reject(claim *models.CompOffClaim, reviewerID uuid.UUID, reason string) error {
+	err := s.claims.Transition(claim, models.CompOffPending, map[string]interface{}{
+		"status":           models.CompOffRejected,
+		"reviewed_by":      reviewerID,
+		"reviewed_at":      time.Now(),
+		"rejection_reason": reason,
+	})
+	if errors.Is(err, repository.ErrConflict) {
+		return ErrCompOffNotPending
+	}
+	return err
+}
+
+// Expire lapses the approved credits that expire by asOf and returns how
+// many it expired. Leave taken from the balance is counted against its
+// other days and the oldest credits first, so a credit lapses as far as the
+// available days exceed the credits that expire after it. Credits on a
+// balance already rolled over expire without lapsing anything; the rollover
+// dealt with its unused days.
+func (s *CompOffService) Expire(asOf time.Time) (int, error) {
+	expiring, err := s.claims.ListExpiring(asOf)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range expiring {
+		claim := &expiring[i]
+		lapsed, err := s.expireClaim(claim)
+		if errors.Is(err, errCompOffExpired) {
+			continue
+		}
+		if err != nil {
+			return expired, err
+		}
+		expired++
+		if lapsed > 0 {
+			s.logger.Infof("Lapsed %.1f days of expired comp-off of user %s", lapsed, claim.UserID)
+		}
+	}
+	return expired, nil
+}
+
+// expireClaim expires one approved claim and returns the days that lapsed.
+func (s *CompOffService) expireClaim(claim *models.CompOffClaim) (float64, error) {
+	note := fmt.Sprintf("Comp-off for %s expired", claim.WorkDate.In(s.location).Format(dateLayout))
+	var lapsed float64
+	for attempt := 1; ; attempt++ {
+		err := s.repos.Transaction(func(tx *repository.Repositories) error {
+			lapsed = 0
+			var balance *models.LeaveBalance
+			if claim.LeaveBalanceID != nil {
+				var err error
+				if balance, err = tx.Balances.FindByID(*claim.LeaveBalanceID); err != nil {
+					return err
+				}
+				if balance.ClosedAt == nil {
+					later, err := tx.CompOffs.ApprovedDays(balance.ID, claim.ID)
+					if err != nil {
+						return err
+					}
+					lapsed = math.Min(claim.Days, math.Max(0, balance.Available()-later))
+				}
+			}
+
+			if err := tx.CompOffs.Transition(claim, models.CompOffApproved, map[string]interface{}{
+				"status":      models.CompOffExpired,
+				"lapsed_days": lapsed,
+			}); err != nil {
+				if errors.Is(err, repository.ErrConflict) {
+					return errCompOffExpired
+				}
+				return err
+			}
+			if lapsed == 0 {
+				return nil
+			}
+
+			if err := tx.Balances.Update(balance, map[string]interface{}{
+				"allocated_days": balance.AllocatedDays - lapsed,
+			}); err != nil {
+				return err
+			}
+			entry := models.NewLedgerEntry(balance, models.LedgerLapse, -lapsed)
+			entry.CompOffClaimID = &claim.ID
+			entry.Note = &note
+			return tx.Ledger.Append(entry)
+		})
+		if err == nil {
+			return lapsed, nil
+		}
+		if !errors.Is(err, repository.ErrConflict) || attempt == maxBalanceAttempts {
+			return 0, err
+		}
+		s.logger.Warnf("Leave balance of user %s changed while expiring comp-off, retrying", claim.UserID)
+	}
+}
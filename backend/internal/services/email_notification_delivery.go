@@ -0,0 +1,50 @@
+package services
+
+import (
+	"employee-dashboard-api/internal/models"
+)
+
+// EmailNotificationDelivery emails notification events that have a template
+// named after the event type. Events without a template are skipped.
+type EmailNotificationDelivery struct {
+	templates *EmailTemplates
+	outbox    *EmailOutboxService
+	appName   string
+}
+
+func NewEmailNotificationDelivery(templates *EmailTemplates, outbox *EmailOutboxService, appName string) *EmailNotificationDelivery {
+	return &EmailNotificationDelivery{
+		templates: templates,
+		outbox:    outbox,
+		appName:   appName,
+	}
+}
+
+func (d *EmailNotificationDelivery) Name() string {
+	return "email"
+}
+
+func (d *EmailNotificationDelivery) Deliver(event NotificationEvent, recipient *models.User) error {
+	if !d.templates.Has(event.Type) {
+		return nil
+	}
+
+	rendered, err := d.templates.Render(event.Type, map[string]interface{}{
+		"AppName":   d.appName,
+		"Recipient": recipient,
+		"Title":     event.Title,
+		"Message":   event.Message,
+		"Data":      event.Data,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = d.outbox.Enqueue(EmailMessage{
+		To:      recipient.Email,
+		Subject: rendered.Subject,
+		Text:    rendered.Text,
+		HTML:    rendered.HTML,
+	})
+	return err
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"employee-dashboard-api/internal/models"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	emailOutboxPollInterval = 15 * time.Second
+	emailOutboxBatchSize    = 20
+	emailMaxAttempts        = 5
+	emailRetryBaseDelay     = 30 * time.Second
+	emailRetryMaxDelay      = 6 * time.Hour
+	// emailSendingTimeout makes a message claimed by a worker that died
+	// mid-send due again.
+	emailSendingTimeout = 10 * time.Minute
+)
+
+// ErrSensitiveEmail is returned when resending an email that carried a
+// one-time code; the code has expired and the body is gone.
+var ErrSensitiveEmail = errors.New("emails carrying a one-time code cannot be resent")
+
+// EmailOutboxService stores outbound email in the email_outbox table and
+// delivers it from a background worker, so sending never blocks a request and
+// survives restarts. Failed sends are retried with exponential backoff; every
+// attempt is recorded in email_delivery_attempts. Sensitive messages keep
+// their body only until they are sent or given up on.
+type EmailOutboxService struct {
+	db        *gorm.DB
+	logger    *logrus.Logger
+	transport EmailTransport
+	from      EmailAddress
+
+	wake      chan struct{}
+	startOnce sync.Once
+}
+
+func NewEmailOutboxService(db *gorm.DB, logger *logrus.Logger, transport EmailTransport, config EmailConfig) *EmailOutboxService {
+	return &EmailOutboxService{
+		db:        db,
+		logger:    logger,
+		transport: transport,
+		from:      EmailAddress{Name: config.FromName, Address: config.FromEmail},
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Enqueue stores msg for delivery and wakes the worker.
+func (s *EmailOutboxService) Enqueue(msg EmailMessage) (*models.EmailOutbox, error) {
+	entry := models.EmailOutbox{
+		ToAddress:     msg.To,
+		Subject:       msg.Subject,
+		TextBody:      msg.Text,
+		HTMLBody:      msg.HTML,
+		Sensitive:     msg.Sensitive,
+		Status:        models.EmailStatusPending,
+		MaxAttempts:   emailMaxAttempts,
+		NextAttemptAt: time.Now(),
+	}
+	if err := s.db.Create(&entry).Error; err != nil {
+		return nil, fmt.Errorf("failed to queue email: %w", err)
+	}
+	s.notifyWorker()
+	return &entry, nil
+}
+
+// Start launches the outbox worker. Calling it more than once has no effect.
+func (s *EmailOutboxService) Start() {
+	s.startOnce.Do(func() {
+		go s.run()
+		s.logger.Infof("Email outbox worker started (transport: %s)", s.transport.Name())
+	})
+}
+
+func (s *EmailOutboxService) notifyWorker() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *EmailOutboxService) run() {
+	ticker := time.NewTicker(emailOutboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.ProcessDue()
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// ProcessDue sends every message whose next attempt is due and returns how
+// many were attempted.
+func (s *EmailOutboxService) ProcessDue() int {
+	processed := 0
+	for {
+		var due []models.EmailOutbox
+		if err := s.db.
+			Where("status IN ? AND next_attempt_at <= ?", []string{models.EmailStatusPending, models.EmailStatusSending}, time.Now()).
+			Order("next_attempt_at ASC").
+			Limit(emailOutboxBatchSize).
+			Find(&due).Error; err != nil {
+			s.logger.Errorf("Failed to load due emails: %v", err)
+			return processed
+		}
+		if len(due) == 0 {
+			return processed
+		}
+
+		claimed := 0
+		for i := range due {
+			if !s.claim(&due[i]) {
+				continue
+			}
+			claimed++
+			s.deliver(&due[i])
+		}
+		processed += claimed
+		if claimed == 0 || len(due) < emailOutboxBatchSize {
+			return processed
+		}
+	}
+}
+
+// claim marks entry as being sent. Attempts doubles as a version number so
+// two workers cannot claim the same message.
+func (s *EmailOutboxService) claim(entry *models.EmailOutbox) bool {
+	result := s.db.Model(&models.EmailOutbox{}).
+		Where("id = ? AND attempts = ? AND status IN ?", entry.ID, entry.Attempts,
+			[]string{models.EmailStatusPending, models.EmailStatusSending}).
+		Updates(map[string]interface{}{
+			"status":          models.EmailStatusSending,
+			"attempts":        entry.Attempts + 1,
+			"next_attempt_at": time.Now().Add(emailSendingTimeout),
+		})
+	if result.Error != nil {
+		s.logger.Errorf("Failed to claim email %s: %v", entry.ID, result.Error)
+		return false
+	}
+	if result.RowsAffected == 0 {
+		return false
+	}
+	entry.Attempts++
+	return true
+}
+
+func (s *EmailOutboxService) deliver(entry *models.EmailOutbox) {
+	started := time.Now()
+	sendErr := s.transport.Send(s.from, EmailMessage{
+		To:      entry.ToAddress,
+		Subject: entry.Subject,
+		Text:    entry.TextBody,
+		HTML:    entry.HTMLBody,
+	})
+
+	attempt := models.EmailDeliveryAttempt{
+		OutboxID:   entry.ID,
+		Attempt:    entry.Attempts,
+		Transport:  s.transport.Name(),
+		Success:    sendErr == nil,
+		DurationMS: time.Since(started).Milliseconds(),
+	}
+	updates := map[string]interface{}{}
+	now := time.Now()
+
+	if sendErr == nil {
+		updates["status"] = models.EmailStatusSent
+		updates["sent_at"] = &now
+		updates["last_error"] = nil
+		s.logger.Infof("Email %q sent to %s", entry.Subject, entry.ToAddress)
+	} else {
+		errMsg := sendErr.Error()
+		attempt.Error = &errMsg
+		updates["last_error"] = errMsg
+		if entry.Attempts >= entry.MaxAttempts {
+			updates["status"] = models.EmailStatusFailed
+			s.logger.Errorf("Giving up on email %q to %s after %d attempts: %v", entry.Subject, entry.ToAddress, entry.Attempts, sendErr)
+		} else {
+			delay := emailRetryDelay(entry.Attempts)
+			updates["status"] = models.EmailStatusPending
+			updates["next_attempt_at"] = now.Add(delay)
+			s.logger.Warnf("Email %q to %s failed (attempt %d), retrying in %s: %v", entry.Subject, entry.ToAddress, entry.Attempts, delay, sendErr)
+		}
+	}
+	if entry.Sensitive && updates["status"] != models.EmailStatusPending {
+		updates["text_body"] = ""
+		updates["html_body"] = ""
+	}
+
+	if err := s.db.Create(&attempt).Error; err != nil {
+		s.logger.Errorf("Failed to record delivery attempt for email %s: %v", entry.ID, err)
+	}
+	if err := s.db.Model(&models.EmailOutbox{}).Where("id = ?", entry.ID).Updates(updates).Error; err != nil {
+		s.logger.Errorf("Failed to update email %s after delivery attempt: %v", entry.ID, err)
+	}
+}
+
+// emailRetryDelay is the backoff after the given (1-based) failed attempt.
+func emailRetryDelay(attempt int) time.Duration {
+	delay := emailRetryBaseDelay
+	for i := 1; i < attempt && delay < emailRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > emailRetryMaxDelay {
+		delay = emailRetryMaxDelay
+	}
+	return delay
+}
+
+// ListEmails returns a page of outbox entries, newest first, optionally
+// filtered by status.
+func (s *EmailOutboxService) ListEmails(status string, page, limit int) ([]models.EmailOutbox, int64, error) {
+	query := s.db.Model(&models.EmailOutbox{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.EmailOutbox
+	if err := query.Omit("text_body", "html_body").Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// GetEmail returns an outbox entry with its delivery attempts. The body of a
+// sensitive entry is redacted.
+func (s *EmailOutboxService) GetEmail(id uuid.UUID) (*models.EmailOutbox, error) {
+	var entry models.EmailOutbox
+	if err := s.db.Preload("DeliveryLog", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).First(&entry, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if entry.Sensitive {
+		entry.TextBody = ""
+		entry.HTMLBody = ""
+	}
+	return &entry, nil
+}
+
+// Resend queues a failed or already sent message again with a fresh attempt
+// budget. Its delivery history is kept. Sensitive messages cannot be resent.
+func (s *EmailOutboxService) Resend(id uuid.UUID) (*models.EmailOutbox, error) {
+	var entry models.EmailOutbox
+	if err := s.db.Select("sensitive").First(&entry, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if entry.Sensitive {
+		return nil, ErrSensitiveEmail
+	}
+
+	result := s.db.Model(&models.EmailOutbox{}).
+		Where("id = ? AND status IN ?", id, []string{models.EmailStatusFailed, models.EmailStatusSent}).
+		Updates(map[string]interface{}{
+			"status":          models.EmailStatusPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+			"last_error":      nil,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	s.notifyWorker()
+	return s.GetEmail(id)
+}
@@ -2,9 +2,12 @@ package services
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
+	"employee-dashboard-api/internal/config"
 	"fmt"
 	"math/big"
-	"net/smtp"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -15,6 +18,9 @@ type EmailService struct {
 	db     *gorm.DB
 	logger *logrus.Logger
 	config EmailConfig
+	outbox *EmailOutboxService
+
+	startOnce sync.Once
 }
 
 type EmailConfig struct {
@@ -26,25 +32,74 @@ type EmailConfig struct {
 	FromName     string
 }
 
+// NewEmailConfig builds the SMTP settings from the application config.
+func NewEmailConfig(cfg *config.Config) EmailConfig {
+	return EmailConfig{
+		SMTPHost:     cfg.SMTPHost,
+		SMTPPort:     cfg.SMTPPortStr,
+		SMTPUsername: cfg.SMTPUsername,
+		SMTPPassword: cfg.SMTPPassword,
+		FromEmail:    cfg.SMTPFromEmail,
+		FromName:     cfg.SMTPFromName,
+	}
+}
+
+// EmailMessage is a single outbound email. HTML is optional. Sensitive
+// marks a message carrying a secret such as an OTP.
+type EmailMessage struct {
+	To        string
+	Subject   string
+	Text      string
+	HTML      string
+	Sensitive bool
+}
+
+const (
+	OTPPurposeSignup        = "signup"
+	OTPPurposePasswordReset = "password_reset"
+)
+
+const (
+	passwordResetWindow      = time.Hour
+	passwordResetMaxPerEmail = 3
+	passwordResetMaxPerIP    = 10
+
+	// otpMaxAttempts is how many wrong codes an OTP tolerates before it is
+	// burned and a new one must be requested.
+	otpMaxAttempts = 5
+
+	// otpCleanupInterval is how often old OTP records are deleted.
+	otpCleanupInterval = time.Hour
+)
+
+// RateLimitError is returned when a client has requested too many OTPs.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("too many OTP requests, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
 type OTPRecord struct {
 	ID        uint      `gorm:"primaryKey"`
 	Email     string    `gorm:"not null;index"`
 	OTP       string    `gorm:"not null"`
-	Purpose   string    `gorm:"not null"`  // signup, password_reset, etc.
-	UserData  string    `gorm:"type:text"` // JSON string to store temporary user data
+	Purpose   string    `gorm:"not null"`           // signup, password_reset, etc.
+	UserData  string    `gorm:"type:text"`          // JSON string to store temporary user data
+	RequestIP string    `gorm:"index"`              // client that requested the OTP, for rate limiting
+	Attempts  int       `gorm:"not null;default:0"` // wrong codes entered so far
 	ExpiresAt time.Time `gorm:"not null"`
 	Used      bool      `gorm:"default:false"`
 	CreatedAt time.Time
 }
 
-func NewEmailService(db *gorm.DB, logger *logrus.Logger, config EmailConfig) *EmailService {
-	// Auto-migrate OTP table
-	db.AutoMigrate(&OTPRecord{})
-
+func NewEmailService(db *gorm.DB, logger *logrus.Logger, config EmailConfig, outbox *EmailOutboxService) *EmailService {
 	return &EmailService{
 		db:     db,
 		logger: logger,
 		config: config,
+		outbox: outbox,
 	}
 }
 
@@ -63,6 +118,13 @@ func (s *EmailService) GenerateOTP() string {
 }
 
 func (s *EmailService) SendSignupOTP(email string) error {
+	// Only the latest signup OTP stays valid
+	if err := s.db.Model(&OTPRecord{}).
+		Where("purpose = ? AND email = ? AND used = false", OTPPurposeSignup, email).
+		Update("used", true).Error; err != nil {
+		return fmt.Errorf("failed to invalidate previous OTPs: %w", err)
+	}
+
 	// Generate OTP
 	otp := s.GenerateOTP()
 
@@ -70,7 +132,7 @@ func (s *EmailService) SendSignupOTP(email string) error {
 	otpRecord := OTPRecord{
 		Email:     email,
 		OTP:       otp,
-		Purpose:   "signup",
+		Purpose:   OTPPurposeSignup,
 		ExpiresAt: time.Now().Add(10 * time.Minute), // 10 minutes expiry
 		Used:      false,
 	}
@@ -98,60 +160,164 @@ teamDa Team
 `, otp)
 
 	if err := s.sendEmail(email, subject, body); err != nil {
-		s.logger.Errorf("Failed to send OTP email: %v", err)
-		return fmt.Errorf("failed to send OTP email: %w", err)
+		s.logger.Errorf("Failed to queue OTP email: %v", err)
+		return fmt.Errorf("failed to queue OTP email: %w", err)
+	}
+
+	s.logger.Infof("OTP email queued for %s", email)
+	return nil
+}
+
+// SendPasswordResetOTP stores a password reset OTP for email and, when deliver
+// is true, emails it. The OTP is stored even when deliver is false so that
+// unknown addresses are rate limited exactly like known ones. Requests over the
+// per-email limit are dropped silently; requests over the per-IP limit return
+// a *RateLimitError.
+func (s *EmailService) SendPasswordResetOTP(email, requestIP string, deliver bool) error {
+	since := time.Now().Add(-passwordResetWindow)
+
+	var ipRequests []OTPRecord
+	if err := s.db.Select("created_at").
+		Where("purpose = ? AND request_ip = ? AND created_at > ?", OTPPurposePasswordReset, requestIP, since).
+		Order("created_at ASC").
+		Find(&ipRequests).Error; err != nil {
+		return fmt.Errorf("failed to check OTP rate limit: %w", err)
+	}
+	if len(ipRequests) >= passwordResetMaxPerIP {
+		return &RateLimitError{RetryAfter: time.Until(ipRequests[0].CreatedAt.Add(passwordResetWindow))}
+	}
+
+	var emailRequests int64
+	if err := s.db.Model(&OTPRecord{}).
+		Where("purpose = ? AND email = ? AND created_at > ?", OTPPurposePasswordReset, email, since).
+		Count(&emailRequests).Error; err != nil {
+		return fmt.Errorf("failed to check OTP rate limit: %w", err)
+	}
+	if emailRequests >= passwordResetMaxPerEmail {
+		s.logger.Warnf("Password reset OTP limit reached for %s, request from %s ignored", email, requestIP)
+		return nil
+	}
+
+	// Only the latest reset OTP stays valid
+	if err := s.db.Model(&OTPRecord{}).
+		Where("purpose = ? AND email = ? AND used = false", OTPPurposePasswordReset, email).
+		Update("used", true).Error; err != nil {
+		return fmt.Errorf("failed to invalidate previous OTPs: %w", err)
+	}
+
+	otp := s.GenerateOTP()
+	otpRecord := OTPRecord{
+		Email:     email,
+		OTP:       otp,
+		Purpose:   OTPPurposePasswordReset,
+		RequestIP: requestIP,
+		ExpiresAt: time.Now().Add(10 * time.Minute), // 10 minutes expiry
+		Used:      false,
+	}
+	if err := s.db.Create(&otpRecord).Error; err != nil {
+		s.logger.Errorf("Failed to store OTP: %v", err)
+		return fmt.Errorf("failed to store OTP: %w", err)
+	}
+
+	if !deliver {
+		return nil
+	}
+
+	subject := "Reset Your teamDa Password"
+	body := fmt.Sprintf(`
+Dear User,
+
+We received a request to reset the password of your teamDa account. Please use the following OTP to choose a new password:
+
+OTP: %s
+
+This OTP will expire in 10 minutes. Resetting your password signs you out of all devices.
+
+If you didn't request a password reset, please ignore this email. Your password will not change.
+
+Best regards,
+teamDa Team
+`, otp)
+
+	if err := s.sendEmail(email, subject, body); err != nil {
+		s.logger.Errorf("Failed to queue password reset email: %v", err)
+		return fmt.Errorf("failed to queue password reset email: %w", err)
 	}
 
-	s.logger.Infof("OTP sent successfully to %s", email)
+	s.logger.Infof("Password reset OTP email queued for %s", email)
 	return nil
 }
 
+// VerifyOTP checks otp against the latest unused OTP for email and purpose.
+// Every wrong code counts against the OTP; after otpMaxAttempts it is burned.
 func (s *EmailService) VerifyOTP(email, otp, purpose string) error {
 	var otpRecord OTPRecord
 
-	// Find valid OTP
-	if err := s.db.Where("email = ? AND otp = ? AND purpose = ? AND used = false AND expires_at > ?",
-		email, otp, purpose, time.Now()).First(&otpRecord).Error; err != nil {
+	// Find the current OTP
+	if err := s.db.Where("email = ? AND purpose = ? AND used = false AND expires_at > ?",
+		email, purpose, time.Now()).Order("created_at DESC").First(&otpRecord).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return fmt.Errorf("invalid or expired OTP")
 		}
 		return fmt.Errorf("failed to verify OTP: %w", err)
 	}
 
-	// Mark OTP as used
-	if err := s.db.Model(&otpRecord).Update("used", true).Error; err != nil {
-		s.logger.Errorf("Failed to mark OTP as used: %v", err)
-		return fmt.Errorf("failed to mark OTP as used: %w", err)
+	if subtle.ConstantTimeCompare([]byte(otpRecord.OTP), []byte(strings.TrimSpace(otp))) != 1 {
+		// Counted in SQL so concurrent guesses cannot share an attempt
+		if err := s.db.Model(&OTPRecord{}).Where("id = ?", otpRecord.ID).Updates(map[string]interface{}{
+			"attempts": gorm.Expr("attempts + 1"),
+			"used":     gorm.Expr("attempts + 1 >= ?", otpMaxAttempts),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to record OTP attempt: %w", err)
+		}
+		if otpRecord.Attempts+1 >= otpMaxAttempts {
+			s.logger.Warnf("OTP for %s (%s) burned after %d wrong attempts", email, purpose, otpRecord.Attempts+1)
+		}
+		return fmt.Errorf("invalid or expired OTP")
+	}
+
+	// Mark OTP as used; a concurrent request may have burned or used it already
+	result := s.db.Model(&OTPRecord{}).Where("id = ? AND used = false", otpRecord.ID).Update("used", true)
+	if result.Error != nil {
+		s.logger.Errorf("Failed to mark OTP as used: %v", result.Error)
+		return fmt.Errorf("failed to mark OTP as used: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("invalid or expired OTP")
 	}
 
 	s.logger.Infof("OTP verified successfully for %s", email)
 	return nil
 }
 
+// sendEmail queues a plain-text OTP email in the outbox, marked sensitive.
 func (s *EmailService) sendEmail(to, subject, body string) error {
-	// SMTP configuration
-	auth := smtp.PlainAuth("", s.config.SMTPUsername, s.config.SMTPPassword, s.config.SMTPHost)
-
-	// Email headers and body
-	msg := fmt.Sprintf("From: %s <%s>\r\n", s.config.FromName, s.config.FromEmail)
-	msg += fmt.Sprintf("To: %s\r\n", to)
-	msg += fmt.Sprintf("Subject: %s\r\n", subject)
-	msg += "Content-Type: text/plain; charset=UTF-8\r\n"
-	msg += "\r\n"
-	msg += body
-
-	// Send email
-	addr := fmt.Sprintf("%s:%s", s.config.SMTPHost, s.config.SMTPPort)
-	if err := smtp.SendMail(addr, auth, s.config.FromEmail, []string{to}, []byte(msg)); err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
-	}
+	_, err := s.outbox.Enqueue(EmailMessage{To: to, Subject: subject, Text: body, Sensitive: true})
+	return err
+}
 
-	return nil
+// Start runs CleanupExpiredOTPs now and then every otpCleanupInterval in the
+// background. Calling it more than once has no effect.
+func (s *EmailService) Start() {
+	s.startOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(otpCleanupInterval)
+			defer ticker.Stop()
+			for {
+				s.CleanupExpiredOTPs()
+				<-ticker.C
+			}
+		}()
+		s.logger.Infof("OTP cleanup job started (every %s)", otpCleanupInterval)
+	})
 }
 
+// CleanupExpiredOTPs deletes expired OTPs once they are older than
+// passwordResetWindow. Until then they still count towards the password reset
+// rate limits.
 func (s *EmailService) CleanupExpiredOTPs() {
-	// Clean up expired OTPs (run this periodically)
-	result := s.db.Where("expires_at < ?", time.Now()).Delete(&OTPRecord{})
+	now := time.Now()
+	result := s.db.Where("expires_at < ? AND created_at < ?", now, now.Add(-passwordResetWindow)).Delete(&OTPRecord{})
 	if result.Error != nil {
 		s.logger.Errorf("Failed to cleanup expired OTPs: %v", result.Error)
 	} else if result.RowsAffected > 0 {
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"strings"
+	texttemplate "text/template"
+)
+
+// defaultEmailTemplates are the built-in templates. Each email <name> consists
+// of <name>.subject.tmpl (defines "subject"), <name>.txt.tmpl and
+// <name>.html.tmpl (defines "content", wrapped by layout.html.tmpl).
+//
+//go:embed templates/email/*.tmpl
+var defaultEmailTemplates embed.FS
+
+// RenderedEmail is a template rendered for one recipient.
+type RenderedEmail struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type EmailTemplates struct {
+	files fs.FS
+}
+
+// NewEmailTemplates loads templates from dir, falling back to the built-in
+// templates for any file dir does not contain. An empty dir uses only the
+// built-in templates.
+func NewEmailTemplates(dir string) (*EmailTemplates, error) {
+	builtin, err := fs.Sub(defaultEmailTemplates, "templates/email")
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		return &EmailTemplates{files: builtin}, nil
+	}
+
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("email template directory %s is not readable", dir)
+	}
+	return &EmailTemplates{files: overlayFS{primary: os.DirFS(dir), fallback: builtin}}, nil
+}
+
+// Has reports whether a template called name exists.
+func (t *EmailTemplates) Has(name string) bool {
+	_, err := fs.Stat(t.files, name+".subject.tmpl")
+	return err == nil
+}
+
+// Render executes the subject, plain-text and HTML parts of template name.
+func (t *EmailTemplates) Render(name string, data interface{}) (*RenderedEmail, error) {
+	subjectFile := name + ".subject.tmpl"
+
+	text, err := texttemplate.New(name+".txt.tmpl").ParseFS(t.files, subjectFile, name+".txt.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s text template: %w", name, err)
+	}
+
+	var subject, textBody bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := text.Execute(&textBody, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s text body: %w", name, err)
+	}
+
+	html, err := htmltemplate.ParseFS(t.files, "layout.html.tmpl", subjectFile, name+".html.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s html template: %w", name, err)
+	}
+
+	var htmlBody bytes.Buffer
+	if err := html.ExecuteTemplate(&htmlBody, "layout", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s html body: %w", name, err)
+	}
+
+	return &RenderedEmail{
+		// Subjects must stay on one header line
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    textBody.String(),
+		HTML:    htmlBody.String(),
+	}, nil
+}
+
+// overlayFS serves files from primary and falls back to fallback when a file
+// does not exist there, so a template directory only needs the overrides.
+type overlayFS struct {
+	primary  fs.FS
+	fallback fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.primary.Open(name)
+	if err == nil {
+		return f, nil
+	}
+	return o.fallback.Open(name)
+}
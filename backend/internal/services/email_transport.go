@@ -0,0 +1,182 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailTransport hands a fully composed message to a mail system.
+type EmailTransport interface {
+	Name() string
+	Send(from EmailAddress, msg EmailMessage) error
+}
+
+type EmailAddress struct {
+	Name    string
+	Address string
+}
+
+func (a EmailAddress) String() string {
+	if a.Name == "" {
+		return a.Address
+	}
+	return fmt.Sprintf("%s <%s>", mime.QEncoding.Encode("utf-8", a.Name), a.Address)
+}
+
+// NewEmailTransport returns the transport called name: "smtp" (default),
+// "file" (appends to an mbox file at filePath) or "memory".
+func NewEmailTransport(name string, config EmailConfig, filePath string) (EmailTransport, error) {
+	switch strings.ToLower(name) {
+	case "", "smtp":
+		return NewSMTPTransport(config), nil
+	case "file", "mbox":
+		return NewFileTransport(filePath)
+	case "memory":
+		return NewMemoryTransport(), nil
+	default:
+		return nil, fmt.Errorf("unknown email transport %q", name)
+	}
+}
+
+// composeEmail renders msg as an RFC 5322 message. Messages with an HTML part
+// are sent as multipart/alternative with the plain-text part first.
+func composeEmail(from EmailAddress, msg EmailMessage, date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	if msg.HTML == "" {
+		b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+		b.WriteString("\r\n")
+		b.WriteString(msg.Text)
+		return b.Bytes()
+	}
+
+	boundary := "teamda-" + uuid.NewString()
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n", boundary)
+	b.WriteString("\r\n")
+	fmt.Fprintf(&b, "--%s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n", boundary, msg.Text)
+	fmt.Fprintf(&b, "--%s\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n%s\r\n", boundary, msg.HTML)
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+	return b.Bytes()
+}
+
+// SMTPTransport sends through the configured SMTP server.
+type SMTPTransport struct {
+	config EmailConfig
+}
+
+func NewSMTPTransport(config EmailConfig) *SMTPTransport {
+	return &SMTPTransport{config: config}
+}
+
+func (t *SMTPTransport) Name() string {
+	return "smtp"
+}
+
+func (t *SMTPTransport) Send(from EmailAddress, msg EmailMessage) error {
+	auth := smtp.PlainAuth("", t.config.SMTPUsername, t.config.SMTPPassword, t.config.SMTPHost)
+	addr := fmt.Sprintf("%s:%s", t.config.SMTPHost, t.config.SMTPPort)
+	if err := smtp.SendMail(addr, auth, from.Address, []string{msg.To}, composeEmail(from, msg, time.Now())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// FileTransport appends messages to an mbox file, for local development
+// without a mail server.
+type FileTransport struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileTransport(path string) (*FileTransport, error) {
+	if path == "" {
+		return nil, fmt.Errorf("file email transport requires a path")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	return &FileTransport{path: path}, nil
+}
+
+func (t *FileTransport) Name() string {
+	return "file"
+}
+
+func (t *FileTransport) Send(from EmailAddress, msg EmailMessage) error {
+	now := time.Now()
+	body := strings.ReplaceAll(string(composeEmail(from, msg, now)), "\r\n", "\n")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From %s %s\n", from.Address, now.UTC().Format(time.ANSIC))
+	for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+		// mboxrd quoting so message lines are not mistaken for separators
+		if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+			line = ">" + line
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	f, err := os.OpenFile(t.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open mbox file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(b.String()); err != nil {
+		return fmt.Errorf("failed to write mbox file: %w", err)
+	}
+	return nil
+}
+
+// MemoryTransport keeps sent messages in memory, for tests.
+type MemoryTransport struct {
+	mu       sync.Mutex
+	messages []EmailMessage
+}
+
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{}
+}
+
+func (t *MemoryTransport) Name() string {
+	return "memory"
+}
+
+func (t *MemoryTransport) Send(from EmailAddress, msg EmailMessage) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = append(t.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of every message sent so far.
+func (t *MemoryTransport) Messages() []EmailMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]EmailMessage(nil), t.messages...)
+}
+
+// Reset discards the recorded messages.
+func (t *MemoryTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = nil
+}
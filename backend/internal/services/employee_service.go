@@ -3,6 +3,7 @@ package services
 import (
 	"employee-dashboard-api/internal/config"
 	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/utils"
 	"encoding/csv"
 	"fmt"
 	"os"
@@ -83,26 +84,21 @@ func (s *EmployeeService) LoadEmployeesFromCSV(csvPath string) error {
 			continue
 		}
 
-		var initialPasswordHash string
-		if s.config.UserPlainPasswords {
-			initialPasswordHash = "password" // Default plain password for sample users
-		} else {
-			initialPasswordHash = "$2a$10$dummy.hash.for.demo.purposes.only" // Keep dummy hash
-		}
-
-		// Create user
+		// Create user. Seeded users have no password and set one through
+		// forgot-password on first use.
 		user := models.User{
-			EmployeeID:     employeeID,
-			Email:          email,
-			PasswordHash:   initialPasswordHash,
-			FirstName:      firstName,
-			LastName:       lastName,
-			Phone:          &phone,
-			Department:     &department,
-			Position:       &position,
-			HireDate:       &hireDate,
-			EmploymentType: "full-time",
-			Status:         "active",
+			EmployeeID:        employeeID,
+			Email:             email,
+			PasswordHash:      utils.UnusablePasswordHash,
+			MustResetPassword: true,
+			FirstName:         firstName,
+			LastName:          lastName,
+			Phone:             &phone,
+			Department:        &department,
+			Position:          &position,
+			HireDate:          &hireDate,
+			EmploymentType:    "full-time",
+			Status:            "active",
 		}
 
 		if err := s.db.Create(&user).Error; err != nil {
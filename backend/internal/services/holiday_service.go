@@ -1,119 +1,233 @@
 package services
 
 import (
+	"bufio"
 	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
-	"gorm.io/gorm"
 )
 
-type HolidayService struct {
-	db     *gorm.DB
-	logger *logrus.Logger
+// Common holiday types. Any other short label can be used too.
+const (
+	HolidayTypePublic   = "public"
+	HolidayTypeOptional = "optional"
+)
+
+// maxImportedHolidayDays bounds how many days one multi-day .ics event may
+// expand to.
+const maxImportedHolidayDays = 31
+
+// ErrHolidayExists is returned when a holiday of the same name already falls
+// on the same date at the same location.
+var ErrHolidayExists = errors.New("a holiday with this name already exists on this date and location")
+
+// HolidayImportError lists the rows of an import file that could not be
+// read. Nothing is imported when there are any.
+type HolidayImportError struct {
+	Problems []string
 }
 
-func NewHolidayService(db *gorm.DB, logger *logrus.Logger) *HolidayService {
-	return &HolidayService{
-		db:     db,
-		logger: logger,
-	}
+func (e *HolidayImportError) Error() string {
+	return "invalid holiday import: " + strings.Join(e.Problems, "; ")
 }
 
-func (s *HolidayService) LoadHolidaysFromCSV(csvPath string) error {
-	file, err := os.Open(csvPath)
-	if err != nil {
-		return fmt.Errorf("failed to open CSV file: %w", err)
-	}
-	defer file.Close()
+// HolidayInput describes a holiday to create or update, or one read from an
+// import file.
+type HolidayInput struct {
+	Title       string
+	Date        time.Time
+	HolidayType string // defaults to optional for optional holidays, public otherwise
+	Description string
+	IsOptional  bool
+	Location    string // work location the holiday applies at; empty for everyone
+}
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
-	if err != nil {
-		return fmt.Errorf("failed to read CSV file: %w", err)
+// normalize trims the input and fills in its default type.
+func (in HolidayInput) normalize() HolidayInput {
+	in.Title = strings.TrimSpace(in.Title)
+	in.Date = time.Date(in.Date.Year(), in.Date.Month(), in.Date.Day(), 0, 0, 0, 0, time.UTC)
+	in.HolidayType = strings.ToLower(strings.TrimSpace(in.HolidayType))
+	if in.HolidayType == "" {
+		in.HolidayType = HolidayTypePublic
+		if in.IsOptional {
+			in.HolidayType = HolidayTypeOptional
+		}
 	}
+	in.Description = strings.TrimSpace(in.Description)
+	in.Location = strings.TrimSpace(in.Location)
+	return in
+}
 
-	// Skip header row
-	if len(records) < 2 {
-		return fmt.Errorf("CSV file must contain at least a header and one data row")
-	}
+// key identifies the holiday across imports: its date, location and name,
+// ignoring case.
+func (in HolidayInput) key() string {
+	return holidayKey(in.Date, in.Location, in.Title)
+}
 
-	var createdHolidays int
+func holidayKey(date time.Time, location, title string) string {
+	return date.Format(dateLayout) + "|" + normalizeLocation(location) + "|" + strings.ToLower(strings.TrimSpace(title))
+}
 
-	for i, record := range records[1:] {
-		if len(record) < 5 {
-			s.logger.Warnf("Skipping row %d: insufficient columns", i+2)
-			continue
-		}
+func eventHolidayKey(holiday *models.Event) string {
+	location := ""
+	if holiday.Location != nil {
+		location = *holiday.Location
+	}
+	return holidayKey(holiday.EventDate, location, holiday.Title)
+}
 
-		holidayName := record[0]
-		holidayDateStr := record[1]
-		holidayType := record[2]
-		description := record[3]
-		isOptionalStr := record[4]
+// fields returns the event columns of a normalized input.
+func (in HolidayInput) fields() map[string]interface{} {
+	var description, location *string
+	if in.Description != "" {
+		description = &in.Description
+	}
+	if in.Location != "" {
+		location = &in.Location
+	}
+	holidayType := in.HolidayType
+	return map[string]interface{}{
+		"title":           in.Title,
+		"event_date":      in.Date,
+		"holiday_type":    &holidayType,
+		"description":     description,
+		"is_optional":     in.IsOptional,
+		"location":        location,
+		"is_company_wide": location == nil,
+	}
+}
 
-		// Parse date
-		holidayDate, err := time.Parse("2006-01-02", holidayDateStr)
-		if err != nil {
-			s.logger.Errorf("Invalid holiday date for %s: %v", holidayName, err)
-			continue
+// applyHolidayFields sets fields on holiday as a column update would.
+func applyHolidayFields(holiday *models.Event, fields map[string]interface{}) {
+	for column, value := range fields {
+		switch column {
+		case "title":
+			holiday.Title = value.(string)
+		case "event_date":
+			holiday.EventDate = value.(time.Time)
+		case "holiday_type":
+			holiday.HolidayType = value.(*string)
+		case "description":
+			holiday.Description = value.(*string)
+		case "is_optional":
+			holiday.IsOptional = value.(bool)
+		case "location":
+			holiday.Location = value.(*string)
+		case "is_company_wide":
+			holiday.IsCompanyWide = value.(bool)
 		}
+	}
+}
 
-		// Parse is_optional
-		isOptional, err := strconv.ParseBool(isOptionalStr)
-		if err != nil {
-			s.logger.Errorf("Invalid is_optional value for %s: %v", holidayName, err)
-			continue
+// holidayChanges returns the fields of in that differ from holiday.
+func holidayChanges(holiday *models.Event, in HolidayInput) map[string]interface{} {
+	changes := make(map[string]interface{})
+	for column, value := range in.fields() {
+		var current interface{}
+		switch column {
+		case "title":
+			current = holiday.Title
+		case "event_date":
+			if holiday.EventDate.Format(dateLayout) == in.Date.Format(dateLayout) {
+				continue
+			}
+		case "holiday_type":
+			current = holiday.HolidayType
+		case "description":
+			current = holiday.Description
+		case "is_optional":
+			current = holiday.IsOptional
+		case "location":
+			current = holiday.Location
+		case "is_company_wide":
+			current = holiday.IsCompanyWide
 		}
-
-		// Check if holiday already exists
-		var existingHoliday models.Event
-		if err := s.db.Where("title = ? AND event_type = ? AND event_date = ?",
-			holidayName, "holiday", holidayDate).First(&existingHoliday).Error; err == nil {
-			s.logger.Infof("Holiday %s already exists, skipping", holidayName)
-			continue
+		if !sameHolidayValue(current, value) {
+			changes[column] = value
 		}
+	}
+	return changes
+}
 
-		// Create holiday event
-		holiday := models.Event{
-			Title:         holidayName,
-			Description:   &description,
-			EventType:     "holiday",
-			EventDate:     holidayDate,
-			IsCompanyWide: true,
+func sameHolidayValue(a, b interface{}) bool {
+	if pa, ok := a.(*string); ok {
+		pb := b.(*string)
+		if pa == nil || pb == nil {
+			return pa == nil && pb == nil
 		}
+		return *pa == *pb
+	}
+	return a == b
+}
 
-		// Add holiday type and optional status to description
-		fullDescription := fmt.Sprintf("%s (%s%s)", description, holidayType,
-			func() string {
-				if isOptional {
-					return " - Optional"
-				}
-				return ""
-			}())
-		holiday.Description = &fullDescription
+// HolidayUpdate is a holiday an import changes, before and after.
+type HolidayUpdate struct {
+	Before models.Event `json:"before"`
+	After  models.Event `json:"after"`
+}
 
-		if err := s.db.Create(&holiday).Error; err != nil {
-			s.logger.Errorf("Failed to create holiday %s: %v", holidayName, err)
-			continue
-		}
+// HolidayImportResult is what an import changed, or would change on a dry
+// run.
+type HolidayImportResult struct {
+	DryRun    bool            `json:"dry_run"`
+	Created   []models.Event  `json:"created"`
+	Updated   []HolidayUpdate `json:"updated"`
+	Deleted   []models.Event  `json:"deleted"`
+	Unchanged int             `json:"unchanged"`
+}
+
+// HolidayService manages the holiday calendar: single holidays and bulk
+// imports from CSV or iCalendar files.
+type HolidayService struct {
+	repos    *repository.Repositories
+	holidays repository.HolidayRepository
+	logger   *logrus.Logger
+}
 
-		createdHolidays++
-		s.logger.Infof("Created holiday: %s on %s", holidayName, holidayDate.Format("2006-01-02"))
+func NewHolidayService(repos *repository.Repositories, logger *logrus.Logger) *HolidayService {
+	return &HolidayService{
+		repos:    repos,
+		holidays: repos.Holidays,
+		logger:   logger,
 	}
+}
 
-	s.logger.Infof("Holiday data loading completed: %d holidays created", createdHolidays)
-	return nil
+// LoadHolidaysFromCSV imports the holidays in the CSV file at csvPath. It is
+// safe to run again: holidays already there are updated, not duplicated.
+func (s *HolidayService) LoadHolidaysFromCSV(csvPath string) (*HolidayImportResult, error) {
+	file, err := os.Open(csvPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+	}
+	defer file.Close()
+
+	inputs, err := ParseHolidaysCSV(file)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.Import(inputs, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Infof("Holiday data loading completed: %d created, %d updated, %d unchanged",
+		len(result.Created), len(result.Updated), result.Unchanged)
+	return result, nil
 }
 
 func (s *HolidayService) InitializeHolidayData() error {
 	// Check if we already have holidays in the database
-	var holidayCount int64
-	if err := s.db.Model(&models.Event{}).Where("event_type = ?", "holiday").Count(&holidayCount).Error; err != nil {
+	holidayCount, err := s.holidays.Count()
+	if err != nil {
 		return fmt.Errorf("failed to count existing holidays: %w", err)
 	}
 
@@ -124,7 +238,7 @@ func (s *HolidayService) InitializeHolidayData() error {
 
 	// Load holidays from CSV
 	csvPath := "holidays.csv"
-	if err := s.LoadHolidaysFromCSV(csvPath); err != nil {
+	if _, err := s.LoadHolidaysFromCSV(csvPath); err != nil {
 		return fmt.Errorf("failed to load holidays from CSV: %w", err)
 	}
 
@@ -132,10 +246,8 @@ func (s *HolidayService) InitializeHolidayData() error {
 }
 
 func (s *HolidayService) GetHolidaysByYear(year int) ([]models.Event, error) {
-	var holidays []models.Event
-	if err := s.db.Where("event_type = ? AND EXTRACT(YEAR FROM event_date) = ?", "holiday", year).
-		Order("event_date ASC").
-		Find(&holidays).Error; err != nil {
+	holidays, err := s.holidays.ListForYear(year)
+	if err != nil {
 		return nil, fmt.Errorf("failed to get holidays for year %d: %w", year, err)
 	}
 
@@ -143,13 +255,374 @@ func (s *HolidayService) GetHolidaysByYear(year int) ([]models.Event, error) {
 }
 
 func (s *HolidayService) GetUpcomingHolidays(limit int) ([]models.Event, error) {
-	var holidays []models.Event
-	if err := s.db.Where("event_type = ? AND event_date >= ?", "holiday", time.Now()).
-		Order("event_date ASC").
-		Limit(limit).
-		Find(&holidays).Error; err != nil {
+	holidays, err := s.holidays.ListUpcoming(time.Now(), limit)
+	if err != nil {
 		return nil, fmt.Errorf("failed to get upcoming holidays: %w", err)
 	}
 
 	return holidays, nil
 }
+
+// Create adds a holiday, failing with ErrHolidayExists if one of the same
+// name already falls on its date and location.
+func (s *HolidayService) Create(in HolidayInput) (*models.Event, error) {
+	in = in.normalize()
+	if err := s.ensureUnique(in, nil); err != nil {
+		return nil, err
+	}
+
+	holiday := &models.Event{}
+	applyHolidayFields(holiday, in.fields())
+	if err := s.holidays.Create(holiday); err != nil {
+		return nil, fmt.Errorf("failed to create holiday: %w", err)
+	}
+	return holiday, nil
+}
+
+// Update replaces the fields of holiday with in.
+func (s *HolidayService) Update(holiday *models.Event, in HolidayInput) error {
+	in = in.normalize()
+	if err := s.ensureUnique(in, holiday); err != nil {
+		return err
+	}
+
+	changes := holidayChanges(holiday, in)
+	if len(changes) == 0 {
+		return nil
+	}
+	if err := s.holidays.Update(holiday, changes); err != nil {
+		return fmt.Errorf("failed to update holiday: %w", err)
+	}
+	applyHolidayFields(holiday, changes)
+	return nil
+}
+
+func (s *HolidayService) Delete(holiday *models.Event) error {
+	if err := s.holidays.Delete(holiday); err != nil {
+		return fmt.Errorf("failed to delete holiday: %w", err)
+	}
+	return nil
+}
+
+// ensureUnique fails with ErrHolidayExists if a holiday other than self has
+// the key of in.
+func (s *HolidayService) ensureUnique(in HolidayInput, self *models.Event) error {
+	sameDay, err := s.holidays.ListBetween(in.Date, in.Date)
+	if err != nil {
+		return fmt.Errorf("failed to load holidays: %w", err)
+	}
+	for i := range sameDay {
+		if (self == nil || sameDay[i].ID != self.ID) && eventHolidayKey(&sameDay[i]) == in.key() {
+			return ErrHolidayExists
+		}
+	}
+	return nil
+}
+
+// Import brings the holiday calendar in line with inputs. Holidays are
+// matched on date, location and name, ignoring case: new ones are created
+// and matched ones updated where they differ. With prune, holidays in the
+// years and locations of inputs that are not in inputs are deleted. A dry
+// run only reports what would change.
+func (s *HolidayService) Import(inputs []HolidayInput, prune, dryRun bool) (*HolidayImportResult, error) {
+	result := &HolidayImportResult{
+		DryRun:  dryRun,
+		Created: []models.Event{},
+		Updated: []HolidayUpdate{},
+		Deleted: []models.Event{},
+	}
+	if len(inputs) == 0 {
+		return result, nil
+	}
+
+	normalized := make([]HolidayInput, len(inputs))
+	seen := make(map[string]bool, len(inputs))
+	locations := make(map[string]bool)
+	var problems []string
+	first, last := inputs[0].Date.Year(), inputs[0].Date.Year()
+	for i, in := range inputs {
+		in = in.normalize()
+		if seen[in.key()] {
+			problems = append(problems, fmt.Sprintf("%s on %s is listed more than once", in.Title, in.Date.Format(dateLayout)))
+		}
+		seen[in.key()] = true
+		locations[normalizeLocation(in.Location)] = true
+		if year := in.Date.Year(); year < first {
+			first = year
+		} else if year > last {
+			last = year
+		}
+		normalized[i] = in
+	}
+	if len(problems) > 0 {
+		return nil, &HolidayImportError{Problems: problems}
+	}
+
+	err := s.repos.Transaction(func(tx *repository.Repositories) error {
+		existing, err := tx.Holidays.ListBetween(
+			time.Date(first, time.January, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(last, time.December, 31, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			return fmt.Errorf("failed to load holidays: %w", err)
+		}
+		byKey := make(map[string]*models.Event, len(existing))
+		for i := range existing {
+			byKey[eventHolidayKey(&existing[i])] = &existing[i]
+		}
+
+		for _, in := range normalized {
+			current, ok := byKey[in.key()]
+			if !ok {
+				holiday := models.Event{}
+				applyHolidayFields(&holiday, in.fields())
+				if !dryRun {
+					if err := tx.Holidays.Create(&holiday); err != nil {
+						return fmt.Errorf("failed to create holiday %s: %w", in.Title, err)
+					}
+				}
+				result.Created = append(result.Created, holiday)
+				continue
+			}
+
+			changes := holidayChanges(current, in)
+			if len(changes) == 0 {
+				result.Unchanged++
+				continue
+			}
+			before := *current
+			if !dryRun {
+				if err := tx.Holidays.Update(current, changes); err != nil {
+					return fmt.Errorf("failed to update holiday %s: %w", in.Title, err)
+				}
+			}
+			applyHolidayFields(current, changes)
+			result.Updated = append(result.Updated, HolidayUpdate{Before: before, After: *current})
+		}
+
+		if !prune {
+			return nil
+		}
+		for i := range existing {
+			holiday := &existing[i]
+			location := ""
+			if holiday.Location != nil {
+				location = *holiday.Location
+			}
+			if seen[eventHolidayKey(holiday)] || !locations[normalizeLocation(location)] {
+				continue
+			}
+			if !dryRun {
+				if err := tx.Holidays.Delete(holiday); err != nil {
+					return fmt.Errorf("failed to delete holiday %s: %w", holiday.Title, err)
+				}
+			}
+			result.Deleted = append(result.Deleted, *holiday)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ParseHolidaysCSV reads holidays from a CSV file with a header row. The
+// holiday_name and holiday_date (YYYY-MM-DD) columns are required;
+// holiday_type, description, is_optional and location are optional.
+func ParseHolidaysCSV(r io.Reader) ([]HolidayInput, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, &HolidayImportError{Problems: []string{"failed to read CSV: " + err.Error()}}
+	}
+
+	// Skip header row
+	if len(records) < 2 {
+		return nil, &HolidayImportError{Problems: []string{"CSV file must contain at least a header and one data row"}}
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"holiday_name", "holiday_date"} {
+		if _, ok := columns[required]; !ok {
+			return nil, &HolidayImportError{Problems: []string{"CSV header is missing the " + required + " column"}}
+		}
+	}
+	value := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var inputs []HolidayInput
+	var problems []string
+	for i, record := range records[1:] {
+		line := i + 2
+		in := HolidayInput{
+			Title:       value(record, "holiday_name"),
+			HolidayType: value(record, "holiday_type"),
+			Description: value(record, "description"),
+			Location:    value(record, "location"),
+		}
+		if in.Title == "" {
+			problems = append(problems, fmt.Sprintf("line %d: holiday_name is required", line))
+			continue
+		}
+
+		// Parse date
+		date, err := time.Parse(dateLayout, value(record, "holiday_date"))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: invalid holiday_date %q", line, value(record, "holiday_date")))
+			continue
+		}
+		in.Date = date
+
+		// Parse is_optional
+		if optional := value(record, "is_optional"); optional != "" {
+			if in.IsOptional, err = strconv.ParseBool(optional); err != nil {
+				problems = append(problems, fmt.Sprintf("line %d: invalid is_optional %q", line, optional))
+				continue
+			}
+		}
+		inputs = append(inputs, in)
+	}
+	if len(problems) > 0 {
+		return nil, &HolidayImportError{Problems: problems}
+	}
+	return inputs, nil
+}
+
+// ParseHolidaysICS reads holidays from the all-day events of an iCalendar
+// file. An event's first category other than HOLIDAY is its type; the
+// OPTIONAL category or a "(optional)" suffix on the summary marks it
+// optional, which is how calendar feeds export optional holidays. Events
+// spanning several days give one holiday per day; cancelled events are
+// skipped.
+func ParseHolidaysICS(r io.Reader) ([]HolidayInput, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		// Unfold continuation lines
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, &HolidayImportError{Problems: []string{"failed to read iCalendar file: " + err.Error()}}
+	}
+	if len(lines) == 0 || lines[0] != "BEGIN:VCALENDAR" {
+		return nil, &HolidayImportError{Problems: []string{"not an iCalendar file"}}
+	}
+
+	var inputs []HolidayInput
+	var problems []string
+	var event map[string]string
+	events := 0
+	for _, line := range lines {
+		switch {
+		case line == "BEGIN:VEVENT":
+			event = make(map[string]string)
+		case line == "END:VEVENT" && event != nil:
+			events++
+			parsed, err := icsHolidays(event)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("event %d: %v", events, err))
+			}
+			inputs = append(inputs, parsed...)
+			event = nil
+		case event != nil:
+			nameAndParams, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			name, _, _ := strings.Cut(nameAndParams, ";")
+			event[strings.ToUpper(name)] = value
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &HolidayImportError{Problems: problems}
+	}
+	if len(inputs) == 0 {
+		return nil, &HolidayImportError{Problems: []string{"iCalendar file has no events"}}
+	}
+	return inputs, nil
+}
+
+// icsHolidays turns the properties of one VEVENT into a holiday per day.
+func icsHolidays(event map[string]string) ([]HolidayInput, error) {
+	if strings.EqualFold(event["STATUS"], "CANCELLED") {
+		return nil, nil
+	}
+
+	title := icalUnescape(event["SUMMARY"])
+	if title == "" {
+		return nil, errors.New("SUMMARY is required")
+	}
+	start, err := icsDate(event["DTSTART"])
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid DTSTART %q", title, event["DTSTART"])
+	}
+	days := 1
+	if value, ok := event["DTEND"]; ok && len(value) == len(icalDateLayout) {
+		end, err := icsDate(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid DTEND %q", title, value)
+		}
+		days = int(end.Sub(start).Hours() / 24)
+		if days < 1 {
+			days = 1
+		}
+	}
+	if days > maxImportedHolidayDays {
+		return nil, fmt.Errorf("%s spans more than %d days", title, maxImportedHolidayDays)
+	}
+
+	in := HolidayInput{Title: title, Description: icalUnescape(event["DESCRIPTION"])}
+	if trimmed := strings.TrimSuffix(title, " (optional)"); trimmed != title {
+		in.Title, in.IsOptional = trimmed, true
+	}
+	for _, category := range strings.Split(event["CATEGORIES"], ",") {
+		category = strings.ToLower(strings.TrimSpace(icalUnescape(category)))
+		switch category {
+		case "", "holiday", "holidays":
+		case HolidayTypeOptional:
+			in.IsOptional = true
+		default:
+			if in.HolidayType == "" {
+				in.HolidayType = category
+			}
+		}
+	}
+
+	inputs := make([]HolidayInput, days)
+	for i := range inputs {
+		inputs[i] = in
+		inputs[i].Date = start.AddDate(0, 0, i)
+	}
+	return inputs, nil
+}
+
+// icsDate reads the date of a DATE or DATE-TIME value.
+func icsDate(value string) (time.Time, error) {
+	if len(value) < len(icalDateLayout) {
+		return time.Time{}, errors.New("too short")
+	}
+	return time.Parse(icalDateLayout, value[:len(icalDateLayout)])
+}
+
+var icalUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+// icalUnescape reverses icalEscape.
+func icalUnescape(value string) string {
+	return icalUnescaper.Replace(value)
+}
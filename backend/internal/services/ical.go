@@ -0,0 +1,67 @@
+package services
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// icalMaxLineOctets is the longest content line RFC 5545 allows before it
+// must be folded, excluding the CRLF.
+const icalMaxLineOctets = 75
+
+const (
+	icalDateLayout     = "20060102"
+	icalDateTimeLayout = "20060102T150405Z"
+)
+
+// icalWriter builds an iCalendar (RFC 5545) document line by line.
+type icalWriter struct {
+	b strings.Builder
+}
+
+// property writes one content line, folding it at icalMaxLineOctets without
+// splitting a UTF-8 sequence. value must already be escaped where needed.
+func (w *icalWriter) property(name, value string) {
+	line := name + ":" + value
+	limit := icalMaxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.b.WriteString(line[:cut])
+		w.b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space that counts towards the limit
+		limit = icalMaxLineOctets - 1
+	}
+	w.b.WriteString(line)
+	w.b.WriteString("\r\n")
+}
+
+// text writes a TEXT property, escaping value.
+func (w *icalWriter) text(name, value string) {
+	w.property(name, icalEscape(value))
+}
+
+// date writes an all-day DATE property.
+func (w *icalWriter) date(name string, date time.Time) {
+	w.property(name+";VALUE=DATE", date.Format(icalDateLayout))
+}
+
+// timestamp writes a DATE-TIME property in UTC.
+func (w *icalWriter) timestamp(name string, t time.Time) {
+	w.property(name, t.UTC().Format(icalDateTimeLayout))
+}
+
+func (w *icalWriter) Bytes() []byte {
+	return []byte(w.b.String())
+}
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// icalEscape escapes a TEXT value.
+func icalEscape(value string) string {
+	return icalEscaper.Replace(value)
+}
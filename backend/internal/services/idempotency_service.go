@@ -0,0 +1,121 @@
+package services
+
+import (
+	"employee-dashboard-api/internal/models"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// idempotencyKeyTTL is how long a completed request can be replayed.
+const idempotencyKeyTTL = 24 * time.Hour
+
+// idempotencyCleanupInterval is how often expired keys are deleted.
+const idempotencyCleanupInterval = time.Hour
+
+var (
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// IdempotencyService records the responses of requests sent with an
+// Idempotency-Key so that retries are answered without being applied twice.
+// Keys are scoped per user.
+type IdempotencyService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+
+	startOnce sync.Once
+}
+
+func NewIdempotencyService(db *gorm.DB, logger *logrus.Logger) *IdempotencyService {
+	return &IdempotencyService{db: db, logger: logger}
+}
+
+// Begin claims key for a request identified by requestHash. It returns the
+// new record to Complete or Release once the request has run, or, with replay
+// set, the stored record of an earlier identical request that has completed.
+func (s *IdempotencyService) Begin(userID uuid.UUID, key, requestHash string) (record *models.IdempotencyKey, replay bool, err error) {
+	now := time.Now()
+
+	// An expired key is free to be used again
+	if err := s.db.Where("user_id = ? AND key = ? AND expires_at < ?", userID, key, now).
+		Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to clear expired idempotency key: %w", err)
+	}
+
+	record = &models.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   now.Add(idempotencyKeyTTL),
+	}
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return nil, false, fmt.Errorf("failed to claim idempotency key: %w", result.Error)
+	}
+	if result.RowsAffected == 1 {
+		return record, false, nil
+	}
+
+	var existing models.IdempotencyKey
+	if err := s.db.Where("user_id = ? AND key = ?", userID, key).First(&existing).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to load idempotency key: %w", err)
+	}
+	if existing.RequestHash != requestHash {
+		return nil, false, ErrIdempotencyKeyReused
+	}
+	if existing.StatusCode == 0 {
+		return nil, false, ErrIdempotencyInProgress
+	}
+	return &existing, true, nil
+}
+
+// Complete stores the response of the request that claimed record.
+func (s *IdempotencyService) Complete(record *models.IdempotencyKey, statusCode int, body []byte) {
+	if err := s.db.Model(record).Updates(map[string]interface{}{
+		"status_code":   statusCode,
+		"response_body": string(body),
+	}).Error; err != nil {
+		s.logger.Errorf("Failed to store response for idempotency key %s: %v", record.Key, err)
+	}
+}
+
+// Release gives up the claim on record so the request can be retried, e.g.
+// after a server error.
+func (s *IdempotencyService) Release(record *models.IdempotencyKey) {
+	if err := s.db.Delete(record).Error; err != nil {
+		s.logger.Errorf("Failed to release idempotency key %s: %v", record.Key, err)
+	}
+}
+
+// Start launches the cleanup job, running CleanupExpired right away and then
+// every idempotencyCleanupInterval. Calling it more than once has no effect.
+func (s *IdempotencyService) Start() {
+	s.startOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(idempotencyCleanupInterval)
+			defer ticker.Stop()
+			for {
+				s.CleanupExpired()
+				<-ticker.C
+			}
+		}()
+		s.logger.Infof("Idempotency key cleanup job started (every %s)", idempotencyCleanupInterval)
+	})
+}
+
+// CleanupExpired removes idempotency keys that can no longer be replayed.
+func (s *IdempotencyService) CleanupExpired() {
+	if result := s.db.Where("expires_at < ?", time.Now()).Delete(&models.IdempotencyKey{}); result.Error != nil {
+		s.logger.Errorf("Failed to cleanup idempotency keys: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		s.logger.Infof("Cleaned up %d expired idempotency keys", result.RowsAffected)
+	}
+}
@@ -0,0 +1,517 @@
+package services
+
+import (
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// leaveEscalationJobInterval is how often approval steps past their SLA are
+// looked for.
+const leaveEscalationJobInterval = 15 * time.Minute
+
+// ErrNotApprover is returned when deciding a step of a leave's approval chain
+// as someone who is neither its approver nor their delegate.
+var ErrNotApprover = errors.New("not an approver of the open step of this leave")
+
+// LeaveApprovalService runs leave applications through approval chains:
+// it plans the steps of a new leave, records each approver's decision,
+// honours out-of-office delegations and escalates steps left open past their
+// SLA.
+type LeaveApprovalService struct {
+	repos         *repository.Repositories
+	approvals     repository.LeaveApprovalRepository
+	users         repository.UserRepository
+	leaveService  *LeaveService
+	teamScope     *TeamScopeService
+	notifications *NotificationService
+	logger        *logrus.Logger
+
+	startOnce sync.Once
+}
+
+func NewLeaveApprovalService(repos *repository.Repositories, leaveService *LeaveService, teamScope *TeamScopeService, notifications *NotificationService, logger *logrus.Logger) *LeaveApprovalService {
+	return &LeaveApprovalService{
+		repos:         repos,
+		approvals:     repos.Approvals,
+		users:         repos.Users,
+		leaveService:  leaveService,
+		teamScope:     teamScope,
+		notifications: notifications,
+		logger:        logger,
+	}
+}
+
+// Start launches the escalation job, running it every
+// leaveEscalationJobInterval. Calling it more than once has no effect.
+func (s *LeaveApprovalService) Start() {
+	s.startOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(leaveEscalationJobInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				if _, err := s.Escalate(time.Now()); err != nil {
+					s.logger.Errorf("Scheduled leave approval escalation failed: %v", err)
+				}
+			}
+		}()
+		s.logger.Infof("Leave approval escalation job started (every %s)", leaveEscalationJobInterval)
+	})
+}
+
+// Plan returns the approval steps of the chain that applies to leave, or nil
+// when none does. leave must have its paid and LOP days worked out. Steps
+// without an approver for the applicant, e.g. a skip-level manager, are left
+// out, as are steps that would go to the applicant or to the approver of an
+// earlier step. The first step is opened right away.
+func (s *LeaveApprovalService) Plan(leave *models.LeaveApplication) ([]models.LeaveApproval, error) {
+	applicant, err := s.users.FindByID(leave.UserID)
+	if err != nil {
+		return nil, err
+	}
+	chains, err := s.approvals.ListActiveChains()
+	if err != nil {
+		return nil, err
+	}
+	chain := chainFor(chains, leave.LeaveTypeID, applicant.Department, leave.PaidDays+leave.LOPDays)
+	if chain == nil {
+		return nil, nil
+	}
+
+	var approvals []models.LeaveApproval
+	assigned := map[uuid.UUID]bool{applicant.ID: true}
+	for _, step := range chain.Steps {
+		approval := models.LeaveApproval{Status: models.ApprovalWaiting, SLAHours: step.SLAHours}
+		switch step.Approver {
+		case models.ApproverManager:
+			approval.AssignedTo = applicant.ManagerID
+		case models.ApproverSkipManager:
+			if applicant.Manager != nil {
+				approval.AssignedTo = applicant.Manager.ManagerID
+			}
+		case models.ApproverUser:
+			approval.AssignedTo = step.ApproverUserID
+		case models.ApproverRole:
+			approval.ApproverRole = step.ApproverRole
+		}
+		if approval.ApproverRole == nil {
+			if approval.AssignedTo == nil || assigned[*approval.AssignedTo] {
+				continue
+			}
+			assigned[*approval.AssignedTo] = true
+		}
+		approval.Position = len(approvals) + 1
+		approvals = append(approvals, approval)
+	}
+
+	if len(approvals) > 0 {
+		now := time.Now()
+		approvals[0].Status = models.ApprovalPending
+		approvals[0].DueAt = dueAt(approvals[0].SLAHours, now)
+	}
+	return approvals, nil
+}
+
+// chainFor returns the most specific of chains that applies to a leave of
+// leaveTypeID and days by an applicant in department, or nil.
+func chainFor(chains []models.LeaveApprovalChain, leaveTypeID uuid.UUID, department *string, days float64) *models.LeaveApprovalChain {
+	var best *models.LeaveApprovalChain
+	for i := range chains {
+		chain := &chains[i]
+		if len(chain.Steps) == 0 || days < chain.MinDays {
+			continue
+		}
+		if chain.LeaveTypeID != nil && *chain.LeaveTypeID != leaveTypeID {
+			continue
+		}
+		if chain.Department != nil && (department == nil || !strings.EqualFold(*chain.Department, *department)) {
+			continue
+		}
+		if best == nil || moreSpecific(chain, best) {
+			best = chain
+		}
+	}
+	return best
+}
+
+func moreSpecific(a, b *models.LeaveApprovalChain) bool {
+	if (a.LeaveTypeID != nil) != (b.LeaveTypeID != nil) {
+		return a.LeaveTypeID != nil
+	}
+	if (a.Department != nil) != (b.Department != nil) {
+		return a.Department != nil
+	}
+	return a.MinDays > b.MinDays
+}
+
+// dueAt returns when a step opened at now is escalated, or nil for no SLA.
+func dueAt(slaHours int, now time.Time) *time.Time {
+	if slaHours <= 0 {
+		return nil
+	}
+	due := now.Add(time.Duration(slaHours) * time.Hour)
+	return &due
+}
+
+// openApproval returns the step of leave being decided, or nil.
+func openApproval(leave *models.LeaveApplication) *models.LeaveApproval {
+	for i := range leave.Approvals {
+		if leave.Approvals[i].Status == models.ApprovalPending {
+			return &leave.Approvals[i]
+		}
+	}
+	return nil
+}
+
+// Approve records actorID's approval of the open step of leave's chain. The
+// last approval also approves the leave and charges its balance in the same
+// transaction; any other opens the next step and notifies its approvers. It
+// returns whether the leave is now approved.
+func (s *LeaveApprovalService) Approve(leave *models.LeaveApplication, actorID uuid.UUID, role models.UserRole, note string) (bool, error) {
+	approval := openApproval(leave)
+	if approval == nil {
+		return false, ErrLeaveNotPending
+	}
+	onBehalfOf, err := s.authorize(leave, approval, actorID, role)
+	if err != nil {
+		return false, err
+	}
+	decide := decideApproval(approval, models.ApprovalApproved, actorID, onBehalfOf, note)
+
+	var next *models.LeaveApproval
+	for i := range leave.Approvals {
+		if leave.Approvals[i].Status == models.ApprovalWaiting {
+			next = &leave.Approvals[i]
+			break
+		}
+	}
+	if next == nil {
+		if err := s.leaveService.approveLeave(leave, actorID, decide); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	now := time.Now()
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		// Touching the leave makes sure it is still pending
+		if err := tx.Leaves.Transition(leave, models.LeaveStatusPending, map[string]interface{}{"updated_at": now}); err != nil {
+			if errors.Is(err, repository.ErrConflict) {
+				return ErrLeaveNotPending
+			}
+			return err
+		}
+		if err := decide(tx); err != nil {
+			return err
+		}
+		return tx.Approvals.Transition(next, models.ApprovalWaiting, map[string]interface{}{
+			"status": models.ApprovalPending,
+			"due_at": dueAt(next.SLAHours, now),
+		})
+	})
+	if err != nil {
+		return false, err
+	}
+
+	next.Status = models.ApprovalPending
+	s.notifyApprovers(leave, next, false)
+	return false, nil
+}
+
+// Reject records actorID's rejection of the open step of leave's chain and
+// rejects the leave; the steps not reached are skipped.
+func (s *LeaveApprovalService) Reject(leave *models.LeaveApplication, actorID uuid.UUID, role models.UserRole, reason string) error {
+	approval := openApproval(leave)
+	if approval == nil {
+		return ErrLeaveNotPending
+	}
+	onBehalfOf, err := s.authorize(leave, approval, actorID, role)
+	if err != nil {
+		return err
+	}
+	decide := decideApproval(approval, models.ApprovalRejected, actorID, onBehalfOf, reason)
+
+	return s.leaveService.rejectLeave(leave, actorID, reason, func(tx *repository.Repositories) error {
+		if err := decide(tx); err != nil {
+			return err
+		}
+		for i := range leave.Approvals {
+			if leave.Approvals[i].Status != models.ApprovalWaiting {
+				continue
+			}
+			if err := tx.Approvals.Transition(&leave.Approvals[i], models.ApprovalWaiting, map[string]interface{}{
+				"status": models.ApprovalSkipped,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// decideApproval returns a transaction step closing approval with status as
+// decided by actorID, on behalf of onBehalfOf when set.
+func decideApproval(approval *models.LeaveApproval, status string, actorID uuid.UUID, onBehalfOf *uuid.UUID, note string) func(tx *repository.Repositories) error {
+	return func(tx *repository.Repositories) error {
+		updates := map[string]interface{}{
+			"status":       status,
+			"decided_by":   actorID,
+			"on_behalf_of": onBehalfOf,
+			"decided_at":   time.Now(),
+		}
+		if note = strings.TrimSpace(note); note != "" {
+			updates["note"] = note
+		}
+		if err := tx.Approvals.Transition(approval, models.ApprovalPending, updates); err != nil {
+			if errors.Is(err, repository.ErrConflict) {
+				return ErrLeaveNotPending
+			}
+			return err
+		}
+		return nil
+	}
+}
+
+// authorize works out on whose behalf actorID, holding role, decides
+// approval of leave: nil for themselves, or the approver who delegated their
+// approvals to actorID for today. Admins may decide any step. Nobody decides
+// a step of their own leave.
+func (s *LeaveApprovalService) authorize(leave *models.LeaveApplication, approval *models.LeaveApproval, actorID uuid.UUID, role models.UserRole) (*uuid.UUID, error) {
+	if actorID == leave.UserID {
+		return nil, ErrNotApprover
+	}
+	if role == models.RoleAdmin {
+		return nil, nil
+	}
+
+	delegations, err := s.approvals.ListDelegationsTo(actorID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	onBehalfOf, ok, err := s.mayDecide(leave.UserID, approval, actorID, role, delegations)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return onBehalfOf, nil
+	}
+	return nil, ErrNotApprover
+}
+
+// mayDecide reports whether actorID, holding role and the delegations given
+// to them, is an approver of approval of applicantID's leave, and on whose
+// behalf.
+func (s *LeaveApprovalService) mayDecide(applicantID uuid.UUID, approval *models.LeaveApproval, actorID uuid.UUID, role models.UserRole, delegations []models.ApprovalDelegation) (*uuid.UUID, bool, error) {
+	if actorID == applicantID {
+		return nil, false, nil
+	}
+	if ok, err := s.isApprover(approval, applicantID, actorID, role); err != nil || ok {
+		return nil, ok, err
+	}
+	for _, delegation := range delegations {
+		if delegation.Delegator == nil || delegation.DelegatorID == applicantID {
+			continue
+		}
+		ok, err := s.isApprover(approval, applicantID, delegation.DelegatorID, delegation.Delegator.Role)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			delegatorID := delegation.DelegatorID
+			return &delegatorID, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// isApprover reports whether userID, holding role, is an approver of
+// approval of applicantID's leave in their own right. A step left to a role
+// goes to the holders of the role who have the applicant in their team
+// scope, which for HR and admins is everybody. A step escalated without a
+// manager to take it goes to the admins.
+func (s *LeaveApprovalService) isApprover(approval *models.LeaveApproval, applicantID, userID uuid.UUID, role models.UserRole) (bool, error) {
+	switch {
+	case approval.AssignedTo != nil && *approval.AssignedTo == userID:
+		return true, nil
+	case approval.EscalatedTo != nil && *approval.EscalatedTo == userID:
+		return true, nil
+	case approval.EscalatedAt != nil && approval.EscalatedTo == nil && role == models.RoleAdmin:
+		return true, nil
+	case approval.ApproverRole != nil && *approval.ApproverRole == role:
+		scope, err := s.teamScope.ResolveScope(userID, role.HasPermission(models.PermTeamViewAll))
+		if err != nil {
+			return false, err
+		}
+		return scope.Contains(applicantID), nil
+	}
+	return false, nil
+}
+
+// Queue returns the pending leaves whose open approval step actorID, holding
+// role, may decide themselves or for someone who delegated to them, oldest
+// first.
+func (s *LeaveApprovalService) Queue(actorID uuid.UUID, role models.UserRole) ([]models.LeaveApplication, error) {
+	open, err := s.approvals.ListOpen()
+	if err != nil {
+		return nil, err
+	}
+	delegations, err := s.approvals.ListDelegationsTo(actorID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	leaveIDs := make([]uuid.UUID, len(open))
+	for i := range open {
+		leaveIDs[i] = open[i].LeaveApplicationID
+	}
+	pending, err := s.repos.Leaves.ListByIDs(leaveIDs)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*models.LeaveApplication, len(pending))
+	for i := range pending {
+		byID[pending[i].ID] = &pending[i]
+	}
+
+	leaves := []models.LeaveApplication{}
+	for i := range open {
+		leave, ok := byID[open[i].LeaveApplicationID]
+		if !ok {
+			continue
+		}
+		_, ok, err := s.mayDecide(leave.UserID, &open[i], actorID, role, delegations)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			leaves = append(leaves, *leave)
+		}
+	}
+	return leaves, nil
+}
+
+// NotifyApprovers tells the approvers of the open step of leave's chain that
+// it waits for them. leave must have User and LeaveType loaded.
+func (s *LeaveApprovalService) NotifyApprovers(leave *models.LeaveApplication) {
+	if approval := openApproval(leave); approval != nil {
+		s.notifyApprovers(leave, approval, false)
+	}
+}
+
+// notifyApprovers notifies whoever may decide approval, and their delegates
+// for today. For an escalated step that is who it was escalated to.
+func (s *LeaveApprovalService) notifyApprovers(leave *models.LeaveApplication, approval *models.LeaveApproval, escalated bool) {
+	var recipients []uuid.UUID
+	switch {
+	case escalated && approval.EscalatedTo != nil:
+		recipients = append(recipients, *approval.EscalatedTo)
+	case escalated:
+		recipients = s.usersWithRole(models.RoleAdmin, leave.UserID)
+	case approval.AssignedTo != nil:
+		recipients = append(recipients, *approval.AssignedTo)
+	case approval.ApproverRole != nil:
+		for _, id := range s.usersWithRole(*approval.ApproverRole, leave.UserID) {
+			if ok, err := s.isApprover(approval, leave.UserID, id, *approval.ApproverRole); err != nil {
+				s.logger.Errorf("Failed to check whether %s approves the leave of %s: %v", id, leave.UserID, err)
+			} else if ok {
+				recipients = append(recipients, id)
+			}
+		}
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	var approverIDs []uuid.UUID
+	add := func(id uuid.UUID) {
+		if id != leave.UserID && !seen[id] {
+			seen[id] = true
+			approverIDs = append(approverIDs, id)
+		}
+	}
+	for _, id := range recipients {
+		add(id)
+		delegations, err := s.approvals.ListDelegationsFrom(id, time.Now())
+		if err != nil {
+			s.logger.Errorf("Failed to load the delegates of %s: %v", id, err)
+			continue
+		}
+		for _, delegation := range delegations {
+			add(delegation.DelegateID)
+		}
+	}
+
+	s.notifications.NotifyLeaveApprovalRequested(leave, approverIDs, escalated)
+}
+
+// usersWithRole returns the active users holding role, except exceptID.
+func (s *LeaveApprovalService) usersWithRole(role models.UserRole, exceptID uuid.UUID) []uuid.UUID {
+	users, err := s.users.ListApproved()
+	if err != nil {
+		s.logger.Errorf("Failed to load the users with role %s: %v", role, err)
+		return nil
+	}
+	var ids []uuid.UUID
+	for _, user := range users {
+		if user.Role == role && user.Status == "active" && user.ID != exceptID {
+			ids = append(ids, user.ID)
+		}
+	}
+	return ids
+}
+
+// Escalate escalates the open approval steps that are past their SLA as of
+// asOf: each goes to the manager of its approver as well, or to the admins
+// when the step goes to a role or the approver has no manager. A step is
+// escalated once. It returns how many steps were escalated.
+func (s *LeaveApprovalService) Escalate(asOf time.Time) (int, error) {
+	overdue, err := s.approvals.ListOverdue(asOf)
+	if err != nil {
+		return 0, err
+	}
+
+	escalated := 0
+	for i := range overdue {
+		approval := &overdue[i]
+		leave, err := s.repos.Leaves.FindByID(approval.LeaveApplicationID)
+		if err != nil {
+			return escalated, err
+		}
+
+		var target *uuid.UUID
+		if approval.AssignedTo != nil {
+			approver, err := s.users.FindByID(*approval.AssignedTo)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return escalated, err
+			}
+			if approver != nil && approver.ManagerID != nil && *approver.ManagerID != leave.UserID {
+				target = approver.ManagerID
+			}
+		}
+
+		now := time.Now()
+		if err := s.approvals.Transition(approval, models.ApprovalPending, map[string]interface{}{
+			"escalated_at": now,
+			"escalated_to": target,
+		}); err != nil {
+			if errors.Is(err, repository.ErrConflict) {
+				continue // decided in the meantime
+			}
+			return escalated, err
+		}
+		approval.EscalatedAt = &now
+		approval.EscalatedTo = target
+		escalated++
+
+		s.notifyApprovers(leave, approval, true)
+	}
+
+	if escalated > 0 {
+		s.logger.Infof("Escalated %d leave approval steps past their SLA", escalated)
+	}
+	return escalated, nil
+}
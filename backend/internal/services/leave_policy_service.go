@@ -0,0 +1,379 @@
+package services
+
+import (
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// leavePolicyJobInterval is how often the scheduled leave policy job runs.
+// Every step is idempotent, so running it more often than accrual periods
+// change costs nothing but the run record.
+const leavePolicyJobInterval = 6 * time.Hour
+
+// LeavePolicyService applies leave policies to balances: accrual over the
+// year, the year-end rollover with carry-forward and encashment, and the
+// expiry of carried days. Every run is recorded in leave_policy_runs.
+type LeavePolicyService struct {
+	repos    *repository.Repositories
+	policies repository.LeavePolicyRepository
+	balances repository.LeaveBalanceRepository
+	users    repository.UserRepository
+	logger   *logrus.Logger
+
+	mu        sync.Mutex // one run at a time
+	startOnce sync.Once
+}
+
+func NewLeavePolicyService(repos *repository.Repositories, logger *logrus.Logger) *LeavePolicyService {
+	return &LeavePolicyService{
+		repos:    repos,
+		policies: repos.Policies,
+		balances: repos.Balances,
+		users:    repos.Users,
+		logger:   logger,
+	}
+}
+
+// Start launches the scheduled job, running it right away and then every
+// leavePolicyJobInterval. Calling it more than once has no effect.
+func (s *LeavePolicyService) Start() {
+	s.startOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(leavePolicyJobInterval)
+			defer ticker.Stop()
+			for {
+				if _, err := s.Run(time.Now(), nil); err != nil {
+					s.logger.Errorf("Scheduled leave policy run failed: %v", err)
+				}
+				<-ticker.C
+			}
+		}()
+		s.logger.Infof("Leave policy job started (every %s)", leavePolicyJobInterval)
+	})
+}
+
+// Run applies every active policy as of asOf: balances of the previous year
+// still open are rolled over, carried days past their expiry lapse, and the
+// current year is accrued up to the month of asOf. The run is recorded, also
+// when it fails; triggeredBy is nil for scheduled runs.
+func (s *LeavePolicyService) Run(asOf time.Time, triggeredBy *uuid.UUID) (*models.LeavePolicyRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run := &models.LeavePolicyRun{
+		AsOf:        asOf,
+		TriggeredBy: triggeredBy,
+		Status:      models.PolicyRunRunning,
+		StartedAt:   time.Now(),
+	}
+	if err := s.policies.CreateRun(run); err != nil {
+		return nil, fmt.Errorf("failed to record leave policy run: %w", err)
+	}
+
+	runErr := s.apply(asOf, run)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.Status = models.PolicyRunSucceeded
+	if runErr != nil {
+		message := runErr.Error()
+		run.Status = models.PolicyRunFailed
+		run.Error = &message
+	}
+	if err := s.policies.SaveRun(run); err != nil {
+		s.logger.Errorf("Failed to record the outcome of leave policy run %s: %v", run.ID, err)
+	}
+
+	s.logger.Infof("Leave policy run %s %s: %d balances accrued (%.1f days), %d rolled over (%.1f carried, %.1f encashed, %.1f lapsed), %d expired (%.1f days)",
+		run.ID, run.Status, run.BalancesAccrued, run.DaysAccrued, run.BalancesRolled, run.DaysCarried,
+		run.DaysEncashed, run.DaysLapsed, run.BalancesExpired, run.DaysExpired)
+	return run, runErr
+}
+
+func (s *LeavePolicyService) apply(asOf time.Time, run *models.LeavePolicyRun) error {
+	policies, err := s.policies.ListActive()
+	if err != nil {
+		return fmt.Errorf("failed to load leave policies: %w", err)
+	}
+
+	for i := range policies {
+		if err := s.rollover(&policies[i], asOf.Year()-1, run); err != nil {
+			return fmt.Errorf("rollover of %s failed: %w", policies[i].LeaveType.Name, err)
+		}
+	}
+
+	if err := s.expireCarried(asOf, run); err != nil {
+		return fmt.Errorf("expiry of carried days failed: %w", err)
+	}
+
+	users, err := s.users.ListApproved()
+	if err != nil {
+		return fmt.Errorf("failed to load users: %w", err)
+	}
+	for i := range policies {
+		for j := range users {
+			if users[j].IsAnonymous || users[j].Status != "active" {
+				continue
+			}
+			if err := s.accrue(&policies[i], &users[j], asOf, run); err != nil {
+				return fmt.Errorf("accrual of %s for %s failed: %w", policies[i].LeaveType.Name, users[j].EmployeeID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// accrue credits user's balance of the policy's leave type with the periods
+// started since it was last accrued, up to the month of asOf.
+func (s *LeavePolicyService) accrue(policy *models.LeavePolicy, user *models.User, asOf time.Time, run *models.LeavePolicyRun) error {
+	year, month := asOf.Year(), int(asOf.Month())
+
+	if _, err := s.balances.Find(user.ID, policy.LeaveTypeID, year); errors.Is(err, repository.ErrNotFound) {
+		if accruedDays(policy, user, year, month) == 0 {
+			return nil
+		}
+		balance := models.LeaveBalance{UserID: user.ID, LeaveTypeID: policy.LeaveTypeID, Year: year}
+		if err := s.balances.Create(&balance); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	var credited float64
+	err := s.updateBalance(user.ID, policy.LeaveTypeID, year, func(tx *repository.Repositories, balance *models.LeaveBalance) error {
+		credited = 0
+		if balance.ClosedAt != nil || balance.AccruedMonths >= month {
+			return nil
+		}
+
+		credit := accruedDays(policy, user, year, month) - accruedDays(policy, user, year, balance.AccruedMonths)
+		if policy.MaxBalance != nil {
+			credit = math.Min(credit, math.Max(0, *policy.MaxBalance-balance.Available()))
+		}
+		if err := tx.Balances.Update(balance, map[string]interface{}{
+			"allocated_days": balance.AllocatedDays + credit,
+			"accrued_months": month,
+		}); err != nil {
+			return err
+		}
+		if credit > 0 {
+			if err := tx.Ledger.Append(policyEntry(balance, models.LedgerAccrual, credit, run)); err != nil {
+				return err
+			}
+		}
+		credited = credit
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if credited > 0 {
+		run.BalancesAccrued++
+		run.DaysAccrued += credited
+	}
+	return nil
+}
+
+// rollover closes the open balances of year for the policy's leave type,
+// carrying forward, encashing and lapsing their unused days.
+func (s *LeavePolicyService) rollover(policy *models.LeavePolicy, year int, run *models.LeavePolicyRun) error {
+	balances, err := s.balances.ListForType(policy.LeaveTypeID, year)
+	if err != nil {
+		return err
+	}
+
+	var carryExpiresAt *time.Time
+	if policy.CarryForwardExpiryMonths > 0 {
+		expiry := time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, policy.CarryForwardExpiryMonths, 0)
+		carryExpiresAt = &expiry
+	}
+
+	for _, open := range balances {
+		if open.ClosedAt != nil {
+			continue
+		}
+
+		var carry, encash, lapse float64
+		closed := false
+		err := s.updateBalance(open.UserID, open.LeaveTypeID, year, func(tx *repository.Repositories, balance *models.LeaveBalance) error {
+			closed = false
+			if balance.ClosedAt != nil {
+				return nil
+			}
+
+			unused := math.Max(0, balance.Available())
+			carry = math.Min(unused, policy.CarryForwardLimit)
+			encash = 0
+			if policy.EncashmentEnabled {
+				encash = math.Min(unused-carry, policy.MaxEncashDays)
+			}
+			lapse = unused - carry - encash
+
+			now := time.Now()
+			if err := tx.Balances.Update(balance, map[string]interface{}{
+				"closed_at":        now,
+				"carried_out_days": balance.CarriedOutDays + carry,
+				"encashed_days":    balance.EncashedDays + encash,
+				"expired_days":     balance.ExpiredDays + lapse,
+			}); err != nil {
+				return err
+			}
+			for _, change := range []struct {
+				kind string
+				days float64
+			}{
+				{models.LedgerCarryForward, carry},
+				{models.LedgerEncashment, encash},
+				{models.LedgerLapse, lapse},
+			} {
+				if change.days == 0 {
+					continue
+				}
+				if err := tx.Ledger.Append(policyEntry(balance, change.kind, -change.days, run)); err != nil {
+					return err
+				}
+			}
+
+			next, err := tx.Balances.Find(balance.UserID, balance.LeaveTypeID, year+1)
+			if errors.Is(err, repository.ErrNotFound) {
+				next = &models.LeaveBalance{
+					UserID:         balance.UserID,
+					LeaveTypeID:    balance.LeaveTypeID,
+					Year:           year + 1,
+					CarriedDays:    carry,
+					CarryExpiresAt: carryExpiresAt,
+				}
+				if err := tx.Balances.Create(next); err != nil {
+					return err
+				}
+			} else if err != nil {
+				return err
+			} else if err := tx.Balances.Update(next, map[string]interface{}{
+				"carried_days":     carry,
+				"carry_expires_at": carryExpiresAt,
+			}); err != nil {
+				return err
+			}
+			if carry > 0 {
+				if err := tx.Ledger.Append(policyEntry(next, models.LedgerCarryForward, carry, run)); err != nil {
+					return err
+				}
+			}
+			closed = true
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if closed {
+			run.BalancesRolled++
+			run.DaysCarried += carry
+			run.DaysEncashed += encash
+			run.DaysLapsed += lapse
+		}
+	}
+	return nil
+}
+
+// expireCarried lapses the carried days still unused when they expire.
+// Carried days are taken before the year's own days.
+func (s *LeavePolicyService) expireCarried(asOf time.Time, run *models.LeavePolicyRun) error {
+	balances, err := s.balances.ListCarryExpired(asOf)
+	if err != nil {
+		return err
+	}
+
+	for _, expiring := range balances {
+		var expired float64
+		err := s.updateBalance(expiring.UserID, expiring.LeaveTypeID, expiring.Year, func(tx *repository.Repositories, balance *models.LeaveBalance) error {
+			expired = math.Max(0, balance.CarriedDays-balance.UsedDays)
+			if expired == 0 || balance.ExpiredDays > 0 {
+				expired = 0
+				return nil
+			}
+			if err := tx.Balances.Update(balance, map[string]interface{}{"expired_days": expired}); err != nil {
+				return err
+			}
+			return tx.Ledger.Append(policyEntry(balance, models.LedgerLapse, -expired, run))
+		})
+		if err != nil {
+			return err
+		}
+
+		if expired > 0 {
+			run.BalancesExpired++
+			run.DaysExpired += expired
+		}
+	}
+	return nil
+}
+
+// updateBalance runs fn in a transaction on a freshly read balance, retrying
+// when the balance was changed concurrently, e.g. by a leave approval.
+func (s *LeavePolicyService) updateBalance(userID, leaveTypeID uuid.UUID, year int, fn func(tx *repository.Repositories, balance *models.LeaveBalance) error) error {
+	var err error
+	for attempt := 1; attempt <= maxBalanceAttempts; attempt++ {
+		err = s.repos.Transaction(func(tx *repository.Repositories) error {
+			balance, err := tx.Balances.Find(userID, leaveTypeID, year)
+			if err != nil {
+				return err
+			}
+			return fn(tx, balance)
+		})
+		if !errors.Is(err, repository.ErrConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// policyEntry returns a ledger entry for a change made by run.
+func policyEntry(balance *models.LeaveBalance, kind string, days float64, run *models.LeavePolicyRun) *models.LeaveLedgerEntry {
+	entry := models.NewLedgerEntry(balance, kind, days)
+	entry.PolicyRunID = &run.ID
+	return entry
+}
+
+// accruedDays returns the days of the policy user is entitled to in year
+// through the first months months, rounded to half days.
+func accruedDays(policy *models.LeavePolicy, user *models.User, year, months int) float64 {
+	period := policy.PeriodMonths()
+	perPeriod := policy.AnnualDays * float64(period) / 12
+
+	var days float64
+	for start := 1; start <= months; start += period {
+		days += perPeriod * employedShare(policy, user, year, start, period)
+	}
+	return math.Round(days*2) / 2
+}
+
+// employedShare returns the share of the accrual period of period months
+// starting in month startMonth of year that user is employed for.
+func employedShare(policy *models.LeavePolicy, user *models.User, year, startMonth, period int) float64 {
+	if !policy.ProrateByHireDate || user.HireDate == nil {
+		return 1
+	}
+	start := time.Date(year, time.Month(startMonth), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, period, 0)
+	hired := time.Date(user.HireDate.Year(), user.HireDate.Month(), user.HireDate.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch {
+	case !hired.After(start):
+		return 1
+	case !hired.Before(end):
+		return 0
+	default:
+		return end.Sub(hired).Hours() / end.Sub(start).Hours()
+	}
+}
@@ -2,32 +2,68 @@ package services
 
 import (
 	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
-	"gorm.io/gorm"
+)
+
+// maxBalanceAttempts bounds how often a leave balance update is retried after
+// losing a race with another update.
+const maxBalanceAttempts = 3
+
+var (
+	// ErrLeaveNotPending is returned when deciding a leave that has already
+	// been decided.
+	ErrLeaveNotPending       = errors.New("leave application is not in pending status")
+	ErrLeaveNotCancellable   = errors.New("only pending or approved leave applications can be cancelled")
+	ErrNoCancellationRequest = errors.New("leave application has no pending cancellation request")
+	ErrInvalidCancelFrom     = errors.New("cancellation must start on a day of the leave")
+	ErrNegativeBalance       = errors.New("adjustment would leave the balance negative")
 )
 
 type LeaveService struct {
-	db     *gorm.DB
-	logger *logrus.Logger
+	repos    *repository.Repositories
+	leaves   repository.LeaveRepository
+	balances repository.LeaveBalanceRepository
+	users    repository.UserRepository
+	calendar *WorkingCalendarService
+	logger   *logrus.Logger
 }
 
-func NewLeaveService(db *gorm.DB, logger *logrus.Logger) *LeaveService {
+func NewLeaveService(repos *repository.Repositories, calendar *WorkingCalendarService, logger *logrus.Logger) *LeaveService {
 	return &LeaveService{
-		db:     db,
-		logger: logger,
+		repos:    repos,
+		leaves:   repos.Leaves,
+		balances: repos.Balances,
+		users:    repos.Users,
+		calendar: calendar,
+		logger:   logger,
 	}
 }
 
+// LeaveBreakdown is how a leave request is charged: per day, in total, and
+// split into days paid from the balance and loss-of-pay days.
+type LeaveBreakdown struct {
+	Days      []models.LeaveDay
+	TotalDays float64
+	PaidDays  float64
+	LOPDays   float64
+	IsLOP     bool
+}
+
 func (s *LeaveService) InitializeLeaveTypes() error {
 	// Check if leave types already exist
-	var count int64
-	if err := s.db.Model(&models.LeaveType{}).Count(&count).Error; err != nil {
+	count, err := s.leaves.CountTypes()
+	if err != nil {
 		return fmt.Errorf("failed to count leave types: %w", err)
 	}
 
@@ -39,7 +75,7 @@ func (s *LeaveService) InitializeLeaveTypes() error {
 	// Create default leave types
 	leaveTypes := []models.LeaveType{
 		{
-			Name:           "Comp Off",
+			Name:           models.CompOffLeaveTypeName,
 			Description:    func() *string { desc := "Compensatory off for overtime work - earned by working extra hours"; return &desc }(),
 			MaxDaysPerYear: func() *int { days := 12; return &days }(), // Reasonable limit for comp-off days
 			IsActive:       true,
@@ -71,7 +107,7 @@ func (s *LeaveService) InitializeLeaveTypes() error {
 	}
 
 	for _, leaveType := range leaveTypes {
-		if err := s.db.Create(&leaveType).Error; err != nil {
+		if err := s.leaves.CreateType(&leaveType); err != nil {
 			s.logger.Errorf("Failed to create leave type %s: %v", leaveType.Name, err)
 			return err
 		}
@@ -121,39 +157,35 @@ func (s *LeaveService) LoadLeaveAllocationsFromCSV(csvPath string) error {
 		}
 
 		// Parse allocated days
-		allocatedDays, err := strconv.Atoi(allocatedDaysStr)
+		allocatedDays, err := strconv.ParseFloat(allocatedDaysStr, 64)
 		if err != nil {
 			s.logger.Errorf("Invalid allocated days for %s: %v", employeeID, err)
 			continue
 		}
 
 		// Parse used days
-		// usedDays, err := strconv.Atoi(usedDaysStr)
-		usedDaysInt, err := strconv.Atoi(usedDaysStr)
+		usedDays, err := strconv.ParseFloat(usedDaysStr, 64)
 		if err != nil {
 			s.logger.Errorf("Invalid used days for %s: %v", employeeID, err)
 			continue
 		}
-		usedDays := float64(usedDaysInt) // Convert to float64
 
 		// Find user by employee ID
-		var user models.User
-		if err := s.db.Where("employee_id = ?", employeeID).First(&user).Error; err != nil {
+		user, err := s.users.FindByEmployeeID(employeeID)
+		if err != nil {
 			s.logger.Errorf("User not found for employee ID %s: %v", employeeID, err)
 			continue
 		}
 
 		// Find leave type by name
-		var leaveType models.LeaveType
-		if err := s.db.Where("name = ? AND is_active = true", leaveTypeName).First(&leaveType).Error; err != nil {
+		leaveType, err := s.leaves.FindActiveTypeByName(leaveTypeName)
+		if err != nil {
 			s.logger.Errorf("Leave type not found: %s", leaveTypeName)
 			continue
 		}
 
 		// Check if allocation already exists
-		var existingBalance models.LeaveBalance
-		if err := s.db.Where("user_id = ? AND leave_type_id = ? AND year = ?",
-			user.ID, leaveType.ID, year).First(&existingBalance).Error; err == nil {
+		if _, err := s.balances.Find(user.ID, leaveType.ID, year); err == nil {
 			s.logger.Infof("Leave allocation already exists for %s - %s, skipping", employeeID, leaveTypeName)
 			continue
 		}
@@ -167,13 +199,30 @@ func (s *LeaveService) LoadLeaveAllocationsFromCSV(csvPath string) error {
 			UsedDays:      usedDays,
 		}
 
-		if err := s.db.Create(&leaveBalance).Error; err != nil {
+		// The ledger starts from the imported allocation and usage
+		if err := s.repos.Transaction(func(tx *repository.Repositories) error {
+			if err := tx.Balances.Create(&leaveBalance); err != nil {
+				return err
+			}
+			note := "Imported from " + csvPath
+			opening := models.NewLedgerEntry(&leaveBalance, models.LedgerOpening, allocatedDays)
+			opening.Note = &note
+			if err := tx.Ledger.Append(opening); err != nil {
+				return err
+			}
+			if usedDays == 0 {
+				return nil
+			}
+			used := models.NewLedgerEntry(&leaveBalance, models.LedgerDeduction, -usedDays)
+			used.Note = &note
+			return tx.Ledger.Append(used)
+		}); err != nil {
 			s.logger.Errorf("Failed to create leave balance for %s: %v", employeeID, err)
 			continue
 		}
 
 		createdAllocations++
-		s.logger.Infof("Created leave allocation: %s - %s (%d/%d days)",
+		s.logger.Infof("Created leave allocation: %s - %s (%.1f/%.1f days)",
 			employeeID, leaveTypeName, usedDays, allocatedDays)
 	}
 
@@ -188,8 +237,8 @@ func (s *LeaveService) InitializeLeaveAllocations() error {
 	}
 
 	// Check if leave allocations already exist
-	var count int64
-	if err := s.db.Model(&models.LeaveBalance{}).Count(&count).Error; err != nil {
+	count, err := s.balances.Count()
+	if err != nil {
 		return fmt.Errorf("failed to count leave allocations: %w", err)
 	}
 
@@ -207,55 +256,614 @@ func (s *LeaveService) InitializeLeaveAllocations() error {
 	return nil
 }
 
-// func (s *LeaveService) UpdateLeaveBalance(userID uuid.UUID, leaveTypeID uuid.UUID, year int, daysUsed int) error {
-func (s *LeaveService) UpdateLeaveBalance(userID uuid.UUID, leaveTypeID uuid.UUID, year int, daysUsed float64) error {
-	// Find the leave balance
-	var leaveBalance models.LeaveBalance
-	if err := s.db.Where("user_id = ? AND leave_type_id = ? AND year = ?",
-		userID, leaveTypeID, year).First(&leaveBalance).Error; err != nil {
-		return fmt.Errorf("leave balance not found: %w", err)
+// CreateLeave stores a new leave application together with the first entry
+// of its status history and its approval steps. The charged days of days are
+// checked for overlap again while the applicant is locked, and a
+// *LeaveOverlapError is returned if another leave has taken one since.
+func (s *LeaveService) CreateLeave(leave *models.LeaveApplication, days []models.LeaveDay) error {
+	return s.repos.Transaction(func(tx *repository.Repositories) error {
+		if err := lockAndCheckOverlap(tx, leave.UserID, uuid.Nil, days); err != nil {
+			return err
+		}
+		if err := tx.Leaves.Create(leave); err != nil {
+			return err
+		}
+		return tx.Leaves.AddStatusChange(&models.LeaveStatusChange{
+			LeaveApplicationID: leave.ID,
+			ToStatus:           leave.Status,
+			ChangedBy:          &leave.UserID,
+		})
+	})
+}
+
+// UpdatePendingLeave applies updates to a leave and replaces its half days
+// with sessions and its approval steps with approvals, as long as the leave
+// is still pending. Like CreateLeave, it checks the new days for overlap
+// again while the applicant is locked.
+func (s *LeaveService) UpdatePendingLeave(leave *models.LeaveApplication, updates map[string]interface{}, days []models.LeaveDay, sessions []models.LeaveSession, approvals []models.LeaveApproval) error {
+	return s.repos.Transaction(func(tx *repository.Repositories) error {
+		if err := lockAndCheckOverlap(tx, leave.UserID, leave.ID, days); err != nil {
+			return err
+		}
+		if err := tx.Leaves.Transition(leave, models.LeaveStatusPending, updates); err != nil {
+			if errors.Is(err, repository.ErrConflict) {
+				return ErrLeaveNotPending
+			}
+			return err
+		}
+		if err := tx.Leaves.ReplaceSessions(leave, sessions); err != nil {
+			return err
+		}
+		return tx.Approvals.ReplaceApprovals(leave, approvals)
+	})
+}
+
+// ApproveLeave approves a pending leave as approverID and charges its paid
+// days to the leave balance in one transaction. Paid and LOP days are worked
+// out again against the balance as it is at approval time. The status change
+// only applies while the leave is still pending, and the balance update only
+// while the balance is unchanged since it was read; a balance changed by a
+// concurrent approval is retried.
+func (s *LeaveService) ApproveLeave(leave *models.LeaveApplication, approverID uuid.UUID) error {
+	return s.approveLeave(leave, approverID, nil)
+}
+
+// approveLeave is ApproveLeave running step, when given, first in the same
+// transaction.
+func (s *LeaveService) approveLeave(leave *models.LeaveApplication, approverID uuid.UUID, step func(tx *repository.Repositories) error) error {
+	// The calendar is read outside the transaction: it is not contended and
+	// SQLite serves one connection at a time
+	_, daysUsed, err := s.calendar.LeaveDays(leave.UserID, leave.StartDate, leave.EndDate, leave.SessionsByDate())
+	if err != nil {
+		return err
 	}
 
-	// Update used days
-	newUsedDays := leaveBalance.UsedDays + daysUsed
-	if newUsedDays > float64(leaveBalance.AllocatedDays) {
-		// return fmt.Errorf("insufficient leave balance: trying to use %d days but only %d remaining",
-		return fmt.Errorf("insufficient leave balance: trying to use %.1f days but only %.1f remaining",
-			daysUsed, float64(leaveBalance.AllocatedDays)-leaveBalance.UsedDays)
+	return s.balanceTransaction(leave, func(tx *repository.Repositories) error {
+		if step != nil {
+			if err := step(tx); err != nil {
+				return err
+			}
+		}
+		balance, err := tx.Balances.Find(leave.UserID, leave.LeaveTypeID, leave.StartDate.Year())
+		if err != nil {
+			return fmt.Errorf("leave balance not found: %w", err)
+		}
+		paidDays, lopDays := splitPaidDays(daysUsed, balance.Available())
+
+		now := time.Now()
+		if err := transitionLeave(tx, leave, models.LeaveStatusPending, models.LeaveStatusApproved, map[string]interface{}{
+			"approved_by": approverID,
+			"approved_at": &now,
+			"paid_days":   paidDays,
+			"lop_days":    lopDays,
+			"is_lop":      lopDays > 0,
+		}, approverID, "", ErrLeaveNotPending); err != nil {
+			return err
+		}
+
+		if paidDays > 0 {
+			if err := tx.Balances.AddUsedDays(balance, paidDays); err != nil {
+				return err
+			}
+			entry := models.NewLedgerEntry(balance, models.LedgerDeduction, -paidDays)
+			entry.LeaveApplicationID = &leave.ID
+			entry.ActorID = &approverID
+			if err := tx.Ledger.Append(entry); err != nil {
+				return err
+			}
+		}
+		s.logger.Infof("Approved leave %s for user %s: %.1f paid, %.1f LOP days, %.1f days used, %.1f left",
+			leave.ID, leave.UserID, paidDays, lopDays, balance.UsedDays, balance.Available())
+		return nil
+	})
+}
+
+// RejectLeave rejects a pending leave as approverID.
+func (s *LeaveService) RejectLeave(leave *models.LeaveApplication, approverID uuid.UUID, reason string) error {
+	return s.rejectLeave(leave, approverID, reason, nil)
+}
+
+// rejectLeave is RejectLeave running step, when given, first in the same
+// transaction.
+func (s *LeaveService) rejectLeave(leave *models.LeaveApplication, approverID uuid.UUID, reason string, step func(tx *repository.Repositories) error) error {
+	return s.repos.Transaction(func(tx *repository.Repositories) error {
+		if step != nil {
+			if err := step(tx); err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		return transitionLeave(tx, leave, models.LeaveStatusPending, models.LeaveStatusRejected, map[string]interface{}{
+			"approved_by":      approverID,
+			"approved_at":      &now,
+			"rejection_reason": reason,
+		}, approverID, reason, ErrLeaveNotPending)
+	})
+}
+
+// CancelLeave cancels leave from cancelFrom to its end on behalf of userID;
+// cancelFrom equal to the start date cancels the whole leave. A pending leave
+// is withdrawn or shortened straight away. An approved leave gets a
+// cancellation request that its approver has to decide, and stays in effect
+// until then.
+func (s *LeaveService) CancelLeave(leave *models.LeaveApplication, userID uuid.UUID, cancelFrom time.Time, reason string) error {
+	start, end := truncateToDate(leave.StartDate), truncateToDate(leave.EndDate)
+	cancelFrom = truncateToDate(cancelFrom)
+	if cancelFrom.Before(start) || cancelFrom.After(end) {
+		return ErrInvalidCancelFrom
 	}
+	whole := cancelFrom.Equal(start)
+
+	switch leave.Status {
+	case models.LeaveStatusPending:
+		to := models.LeaveStatusCancelled
+		updates := map[string]interface{}{}
+		var kept []models.LeaveSession
+		if !whole {
+			newEnd := cancelFrom.AddDate(0, 0, -1)
+			kept = sessionsBefore(leave.Sessions, cancelFrom)
+			breakdown, err := s.CalculateLOPBreakdown(leave.UserID, leave.LeaveTypeID, leave.StartDate, newEnd, sessionsByDate(kept))
+			if err != nil {
+				return err
+			}
+			// A leave cut down to no working days is withdrawn altogether
+			if breakdown.TotalDays > 0 {
+				to = models.LeaveStatusPending
+				updates = map[string]interface{}{
+					"end_date":    newEnd,
+					"is_half_day": len(kept) > 0,
+					"paid_days":   breakdown.PaidDays,
+					"lop_days":    breakdown.LOPDays,
+					"is_lop":      breakdown.IsLOP,
+				}
+			}
+		}
+		return s.repos.Transaction(func(tx *repository.Repositories) error {
+			if err := transitionLeave(tx, leave, models.LeaveStatusPending, to, updates, userID, reason, ErrLeaveNotCancellable); err != nil {
+				return err
+			}
+			if to == models.LeaveStatusPending {
+				return tx.Leaves.ReplaceSessions(leave, kept)
+			}
+			return nil
+		})
+
+	case models.LeaveStatusApproved:
+		updates := map[string]interface{}{"cancel_from": cancelFrom, "cancel_reason": nil}
+		if reason != "" {
+			updates["cancel_reason"] = reason
+		}
+		return s.repos.Transaction(func(tx *repository.Repositories) error {
+			return transitionLeave(tx, leave, models.LeaveStatusApproved, models.LeaveStatusCancellationRequested,
+				updates, userID, reason, ErrLeaveNotCancellable)
+		})
+	}
+	return ErrLeaveNotCancellable
+}
 
-	if err := s.db.Model(&leaveBalance).Update("used_days", newUsedDays).Error; err != nil {
-		return fmt.Errorf("failed to update leave balance: %w", err)
+// ApproveCancellation grants a leave's cancellation request as approverID. A
+// whole cancellation cancels the leave and gives back all its paid days; a
+// partial one ends the leave the day before the cancelled days and gives back
+// a share of its paid days in proportion to the days cancelled, rounded to
+// half days. LOP days are reduced by the rest.
+func (s *LeaveService) ApproveCancellation(leave *models.LeaveApplication, approverID uuid.UUID) error {
+	if leave.Status != models.LeaveStatusCancellationRequested || leave.CancelFrom == nil {
+		return ErrNoCancellationRequest
+	}
+	cancelFrom := truncateToDate(*leave.CancelFrom)
+	chargedDays := leave.PaidDays + leave.LOPDays
+
+	updates := map[string]interface{}{"cancel_from": nil, "cancel_reason": nil}
+	to := models.LeaveStatusCancelled
+	restoredDays := leave.PaidDays
+	if cancelFrom.After(truncateToDate(leave.StartDate)) {
+		days, _, err := s.calendar.LeaveDays(leave.UserID, leave.StartDate, leave.EndDate, leave.SessionsByDate())
+		if err != nil {
+			return err
+		}
+		var cancelledDays float64
+		for _, day := range days {
+			if day.Date >= cancelFrom.Format(dateLayout) {
+				cancelledDays += day.Days
+			}
+		}
+		cancelledDays = math.Min(cancelledDays, chargedDays)
+
+		restoredDays = 0
+		if chargedDays > 0 {
+			restoredDays = math.Min(math.Round(leave.PaidDays*cancelledDays/chargedDays*2)/2, leave.PaidDays)
+		}
+		lopDays := math.Max(leave.LOPDays-(cancelledDays-restoredDays), 0)
+
+		to = models.LeaveStatusApproved
+		updates["end_date"] = cancelFrom.AddDate(0, 0, -1)
+		updates["is_half_day"] = len(sessionsBefore(leave.Sessions, cancelFrom)) > 0
+		updates["paid_days"] = leave.PaidDays - restoredDays
+		updates["lop_days"] = lopDays
+		updates["is_lop"] = lopDays > 0
 	}
 
-	// s.logger.Infof("Updated leave balance for user %s: used %d days, remaining %d days",
-	s.logger.Infof("Updated leave balance for user %s: used %.1f days, remaining %.1f days",
-		// userID, newUsedDays, leaveBalance.AllocatedDays-newUsedDays)
-		userID, newUsedDays, float64(leaveBalance.AllocatedDays)-newUsedDays)
+	return s.balanceTransaction(leave, func(tx *repository.Repositories) error {
+		if err := transitionLeave(tx, leave, models.LeaveStatusCancellationRequested, to, updates,
+			approverID, "", ErrNoCancellationRequest); err != nil {
+			return err
+		}
+		if to == models.LeaveStatusApproved {
+			if err := tx.Leaves.ReplaceSessions(leave, sessionsBefore(leave.Sessions, cancelFrom)); err != nil {
+				return err
+			}
+		}
+		if restoredDays <= 0 {
+			return nil
+		}
 
+		balance, err := tx.Balances.Find(leave.UserID, leave.LeaveTypeID, leave.StartDate.Year())
+		if err != nil {
+			return fmt.Errorf("leave balance not found: %w", err)
+		}
+		if err := tx.Balances.AddUsedDays(balance, -restoredDays); err != nil {
+			return err
+		}
+		entry := models.NewLedgerEntry(balance, models.LedgerReversal, restoredDays)
+		entry.LeaveApplicationID = &leave.ID
+		entry.ActorID = &approverID
+		if err := tx.Ledger.Append(entry); err != nil {
+			return err
+		}
+		s.logger.Infof("Cancelled leave %s of user %s from %s: %.1f days given back, %.1f days used, %.1f left",
+			leave.ID, leave.UserID, cancelFrom.Format(dateLayout), restoredDays, balance.UsedDays, balance.Available())
+		return nil
+	})
+}
+
+// RejectCancellation turns down a leave's cancellation request as
+// approverID; the leave stays approved as it was.
+func (s *LeaveService) RejectCancellation(leave *models.LeaveApplication, approverID uuid.UUID, reason string) error {
+	return s.repos.Transaction(func(tx *repository.Repositories) error {
+		return transitionLeave(tx, leave, models.LeaveStatusCancellationRequested, models.LeaveStatusApproved,
+			map[string]interface{}{"cancel_from": nil, "cancel_reason": nil}, approverID, reason, ErrNoCancellationRequest)
+	})
+}
+
+// transitionLeave moves leave from status from to status to, applying
+// updates, and records the change by changedBy in its status history.
+// notInFrom is returned if the leave is no longer in status from.
+func transitionLeave(tx *repository.Repositories, leave *models.LeaveApplication, from, to string, updates map[string]interface{},
+	changedBy uuid.UUID, note string, notInFrom error) error {
+	updates["status"] = to
+	if err := tx.Leaves.Transition(leave, from, updates); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return notInFrom
+		}
+		return err
+	}
+
+	change := &models.LeaveStatusChange{
+		LeaveApplicationID: leave.ID,
+		FromStatus:         from,
+		ToStatus:           to,
+		ChangedBy:          &changedBy,
+	}
+	if note = strings.TrimSpace(note); note != "" {
+		change.Note = &note
+	}
+	return tx.Leaves.AddStatusChange(change)
+}
+
+// balanceTransaction runs fn in a transaction, retrying it when it loses a
+// race on the leave balance of leave.
+func (s *LeaveService) balanceTransaction(leave *models.LeaveApplication, fn func(tx *repository.Repositories) error) error {
+	for attempt := 1; ; attempt++ {
+		err := s.repos.Transaction(fn)
+		if !errors.Is(err, repository.ErrConflict) || attempt == maxBalanceAttempts {
+			return err
+		}
+		s.logger.Warnf("Leave balance for user %s changed while updating leave %s, retrying", leave.UserID, leave.ID)
+	}
+}
+
+// AdjustBalance corrects the allocation of a user's leave balance by days,
+// positive or negative, as actorID and records it in the ledger with reason.
+// The balance is created when the user has none for the year yet. An
+// adjustment that would take the available days below zero is refused with
+// ErrNegativeBalance.
+func (s *LeaveService) AdjustBalance(userID, leaveTypeID uuid.UUID, year int, days float64, actorID uuid.UUID, reason string) (*models.LeaveBalance, error) {
+	if _, err := s.balances.Find(userID, leaveTypeID, year); errors.Is(err, repository.ErrNotFound) {
+		if err := s.balances.Create(&models.LeaveBalance{UserID: userID, LeaveTypeID: leaveTypeID, Year: year}); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := s.repos.Transaction(func(tx *repository.Repositories) error {
+			balance, err := tx.Balances.Find(userID, leaveTypeID, year)
+			if err != nil {
+				return err
+			}
+			if balance.Available()+days < 0 {
+				return ErrNegativeBalance
+			}
+			if err := tx.Balances.Update(balance, map[string]interface{}{
+				"allocated_days": balance.AllocatedDays + days,
+			}); err != nil {
+				return err
+			}
+			entry := models.NewLedgerEntry(balance, models.LedgerAdjustment, days)
+			entry.ActorID = &actorID
+			entry.Note = &reason
+			return tx.Ledger.Append(entry)
+		})
+		if err == nil {
+			s.logger.Infof("Adjusted %d leave balance of user %s for leave type %s by %.1f days: %s",
+				year, userID, leaveTypeID, days, reason)
+			return s.balances.Find(userID, leaveTypeID, year)
+		}
+		if !errors.Is(err, repository.ErrConflict) || attempt == maxBalanceAttempts {
+			return nil, err
+		}
+		s.logger.Warnf("Leave balance of user %s changed while adjusting it, retrying", userID)
+	}
+}
+
+// BalanceStatement is one of a user's leave balances together with the ledger
+// entries that make it up, oldest first.
+type BalanceStatement struct {
+	Balance models.LeaveBalance `json:"balance"`
+	Entries []StatementEntry    `json:"entries"`
+}
+
+// StatementEntry is a ledger entry with the balance it left behind.
+type StatementEntry struct {
+	models.LeaveLedgerEntry
+	RunningBalance float64 `json:"running_balance"`
+}
+
+// BalanceStatement returns the statements of a user's balances for year.
+func (s *LeaveService) BalanceStatement(userID uuid.UUID, year int) ([]BalanceStatement, error) {
+	balances, err := s.balances.ListForUser(userID, year)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.repos.Ledger.ListForUser(userID, year)
+	if err != nil {
+		return nil, err
+	}
+
+	byBalance := make(map[uuid.UUID][]StatementEntry, len(balances))
+	running := make(map[uuid.UUID]float64, len(balances))
+	for _, entry := range entries {
+		running[entry.LeaveBalanceID] += entry.Days
+		byBalance[entry.LeaveBalanceID] = append(byBalance[entry.LeaveBalanceID], StatementEntry{
+			LeaveLedgerEntry: entry,
+			RunningBalance:   running[entry.LeaveBalanceID],
+		})
+	}
+
+	statements := make([]BalanceStatement, 0, len(balances))
+	for _, balance := range balances {
+		statement := BalanceStatement{Balance: balance, Entries: byBalance[balance.ID]}
+		if statement.Entries == nil {
+			statement.Entries = []StatementEntry{}
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+// LedgerMismatch is a balance whose available days differ from the sum of
+// its ledger entries.
+type LedgerMismatch struct {
+	Balance       models.LeaveBalance `json:"balance"`
+	LedgerDays    float64             `json:"ledger_days"`
+	AvailableDays float64             `json:"available_days"`
+}
+
+// Reconcile checks every balance of year against its ledger and returns the
+// ones that disagree.
+func (s *LeaveService) Reconcile(year int) ([]LedgerMismatch, error) {
+	balances, err := s.balances.ListForYear(year)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := s.repos.Ledger.TotalsByBalance(year)
+	if err != nil {
+		return nil, err
+	}
+
+	mismatches := []LedgerMismatch{}
+	for _, balance := range balances {
+		if math.Abs(totals[balance.ID]-balance.Available()) > 1e-9 {
+			mismatches = append(mismatches, LedgerMismatch{
+				Balance:       balance,
+				LedgerDays:    totals[balance.ID],
+				AvailableDays: balance.Available(),
+			})
+		}
+	}
+	return mismatches, nil
+}
+
+// LeaveOverlapError reports a leave that clashes with one the user already
+// has on Date.
+type LeaveOverlapError struct {
+	Leave *models.LeaveApplication
+	Date  string
+}
+
+func (e *LeaveOverlapError) Error() string {
+	return fmt.Sprintf("%s is already taken by a %s leave from %s to %s",
+		e.Date, e.Leave.Status, e.Leave.StartDate.Format(dateLayout), e.Leave.EndDate.Format(dateLayout))
+}
+
+// CheckOverlap returns a *LeaveOverlapError if any charged day of days clashes
+// with a pending or approved leave of userID other than excludeID. A first
+// and a second half of the same day do not clash.
+func (s *LeaveService) CheckOverlap(userID, excludeID uuid.UUID, days []models.LeaveDay) error {
+	return checkOverlap(s.leaves, userID, excludeID, days)
+}
+
+// lockAndCheckOverlap locks userID and then runs CheckOverlap within tx, so
+// that concurrent applications and edits of the same user cannot all pass it.
+func lockAndCheckOverlap(tx *repository.Repositories, userID, excludeID uuid.UUID, days []models.LeaveDay) error {
+	if err := tx.Users.Lock(userID); err != nil {
+		return err
+	}
+	return checkOverlap(tx.Leaves, userID, excludeID, days)
+}
+
+func checkOverlap(leaves repository.LeaveRepository, userID, excludeID uuid.UUID, days []models.LeaveDay) error {
+	var charged []models.LeaveDay
+	for _, day := range days {
+		if day.Days > 0 {
+			charged = append(charged, day)
+		}
+	}
+	if len(charged) == 0 {
+		return nil
+	}
+
+	start, err := time.Parse(dateLayout, charged[0].Date)
+	if err != nil {
+		return err
+	}
+	end, err := time.Parse(dateLayout, charged[len(charged)-1].Date)
+	if err != nil {
+		return err
+	}
+	existing, err := leaves.ListOverlapping(userID, start, end, excludeID)
+	if err != nil {
+		return fmt.Errorf("failed to load overlapping leaves: %w", err)
+	}
+
+	for i := range existing {
+		other := &existing[i]
+		otherSessions := other.SessionsByDate()
+		otherStart, otherEnd := other.StartDate.Format(dateLayout), other.EndDate.Format(dateLayout)
+		for _, day := range charged {
+			if day.Date < otherStart || day.Date > otherEnd {
+				continue
+			}
+			if models.SessionsClash(day.Session, otherSessions[day.Date]) {
+				return &LeaveOverlapError{Leave: other, Date: day.Date}
+			}
+		}
+	}
 	return nil
 }
 
-func (s *LeaveService) GetMonthlyLeaveAllocation() int {
-	// 2 leaves per month as requested
-	return 2
+// TakenLeaveOn returns the approved leave userID is on at date and the
+// session of the day it takes, or nil if userID is working that day. Weekends
+// and holidays within a leave are not leave days.
+func (s *LeaveService) TakenLeaveOn(userID uuid.UUID, date time.Time) (*models.LeaveApplication, string, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	leaves, err := s.leaves.ListBetween(repository.LeaveFilter{UserID: userID}, models.LeaveTakenStatuses, day, day)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load leaves: %w", err)
+	}
+
+	for i := range leaves {
+		days, _, err := s.calendar.LeaveDays(userID, day, day, leaves[i].SessionsByDate())
+		if err != nil {
+			return nil, "", err
+		}
+		if len(days) == 1 && days[0].Days > 0 {
+			return &leaves[i], days[0].Session, nil
+		}
+	}
+	return nil, "", nil
 }
 
-func (s *LeaveService) CalculateAnnualAllocation() int {
-	// 2 leaves per month * 12 months = 24 leaves per year
-	return s.GetMonthlyLeaveAllocation() * 12
+// TeamConflict is another leave of the team that is off on some of the days
+// of a leave under review.
+type TeamConflict struct {
+	Leave models.LeaveApplication `json:"leave"`
+	Days  []models.LeaveDay       `json:"days"`
+}
+
+// TeamConflicts returns the pending and approved leaves of other employees
+// in scope that take any of the working days of leave, with the shared days
+// as the other employee takes them.
+func (s *LeaveService) TeamConflicts(leave *models.LeaveApplication, scope repository.UserScope) ([]TeamConflict, error) {
+	days, _, err := s.calendar.LeaveDays(leave.UserID, leave.StartDate, leave.EndDate, leave.SessionsByDate())
+	if err != nil {
+		return nil, err
+	}
+	charged := make(map[string]bool, len(days))
+	for _, day := range days {
+		if day.Days > 0 {
+			charged[day.Date] = true
+		}
+	}
+
+	statuses := append([]string{models.LeaveStatusPending}, models.LeaveTakenStatuses...)
+	others, err := s.leaves.ListBetween(repository.LeaveFilter{Scope: scope}, statuses, leave.StartDate, leave.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load team leaves: %w", err)
+	}
+
+	conflicts := []TeamConflict{}
+	for _, other := range others {
+		if other.UserID == leave.UserID {
+			continue
+		}
+		otherDays, _, err := s.calendar.LeaveDays(other.UserID, other.StartDate, other.EndDate, other.SessionsByDate())
+		if err != nil {
+			return nil, err
+		}
+		var shared []models.LeaveDay
+		for _, day := range otherDays {
+			if day.Days > 0 && charged[day.Date] {
+				shared = append(shared, day)
+			}
+		}
+		if len(shared) > 0 {
+			conflicts = append(conflicts, TeamConflict{Leave: other, Days: shared})
+		}
+	}
+	return conflicts, nil
+}
+
+// sessionsBefore returns the sessions of days before date.
+func sessionsBefore(sessions []models.LeaveSession, date time.Time) []models.LeaveSession {
+	var kept []models.LeaveSession
+	for _, session := range sessions {
+		if session.Date < date.Format(dateLayout) {
+			kept = append(kept, models.LeaveSession{Date: session.Date, Session: session.Session})
+		}
+	}
+	return kept
+}
+
+func sessionsByDate(sessions []models.LeaveSession) map[string]string {
+	byDate := make(map[string]string, len(sessions))
+	for _, session := range sessions {
+		byDate[session.Date] = session.Session
+	}
+	return byDate
+}
+
+// splitPaidDays splits days into those paid from remaining balance and LOP.
+func splitPaidDays(days, remaining float64) (paid, lop float64) {
+	if remaining < 0 {
+		remaining = 0
+	}
+	if days <= remaining {
+		return days, 0
+	}
+	return remaining, days - remaining
 }
 
 // ValidateLeaveBalance checks if user has sufficient balance (for validation only, no deduction)
 func (s *LeaveService) ValidateLeaveBalance(userID uuid.UUID, leaveTypeID uuid.UUID, year int, daysRequested float64) error {
 	// Find the leave balance
-	var leaveBalance models.LeaveBalance
-	if err := s.db.Where("user_id = ? AND leave_type_id = ? AND year = ?",
-		userID, leaveTypeID, year).First(&leaveBalance).Error; err != nil {
+	leaveBalance, err := s.balances.Find(userID, leaveTypeID, year)
+	if err != nil {
 		return fmt.Errorf("leave balance not found: %w", err)
 	}
 
-	remainingDays := float64(leaveBalance.AllocatedDays) - leaveBalance.UsedDays
+	remainingDays := leaveBalance.Available()
 	if daysRequested > remainingDays {
 		// This is just validation - we'll allow LOP in the handler
 		s.logger.Infof("User %s requesting %.1f days but only %.1f remaining - will be marked as LOP",
@@ -265,24 +873,29 @@ func (s *LeaveService) ValidateLeaveBalance(userID uuid.UUID, leaveTypeID uuid.U
 	return nil // Always return nil - we allow LOP applications
 }
 
-// CalculateLOPBreakdown calculates how many days will be paid vs LOP
-func (s *LeaveService) CalculateLOPBreakdown(userID uuid.UUID, leaveTypeID uuid.UUID, year int, daysRequested float64) (paidDays float64, lopDays float64, isLOP bool, err error) {
-	// Find the leave balance
-	var leaveBalance models.LeaveBalance
-	if err = s.db.Where("user_id = ? AND leave_type_id = ? AND year = ?",
-		userID, leaveTypeID, year).First(&leaveBalance).Error; err != nil {
-		return 0, 0, false, fmt.Errorf("leave balance not found: %w", err)
-	}
+// LeaveDays returns the per-day breakdown and total leave days of a leave,
+// counting working days only. sessions maps half days to their session.
+func (s *LeaveService) LeaveDays(userID uuid.UUID, start, end time.Time, sessions map[string]string) ([]models.LeaveDay, float64, error) {
+	return s.calendar.LeaveDays(userID, start, end, sessions)
+}
 
-	remainingDays := float64(leaveBalance.AllocatedDays) - leaveBalance.UsedDays
+// CalculateLOPBreakdown works out the working days of a leave and how many of
+// them will be paid vs LOP. sessions maps half days to their session.
+func (s *LeaveService) CalculateLOPBreakdown(userID uuid.UUID, leaveTypeID uuid.UUID, start, end time.Time, sessions map[string]string) (*LeaveBreakdown, error) {
+	days, daysRequested, err := s.calendar.LeaveDays(userID, start, end, sessions)
+	if err != nil {
+		return nil, err
+	}
+	breakdown := &LeaveBreakdown{Days: days, TotalDays: daysRequested}
 
-	if daysRequested <= remainingDays {
-		// Sufficient balance - all days are paid
-		return daysRequested, 0, false, nil
-	} else {
-		// Insufficient balance - partial LOP
-		paidDays = remainingDays
-		lopDays = daysRequested - remainingDays
-		return paidDays, lopDays, true, nil
+	// Find the leave balance
+	leaveBalance, err := s.balances.Find(userID, leaveTypeID, start.Year())
+	if err != nil {
+		return nil, fmt.Errorf("leave balance not found: %w", err)
 	}
+
+	remainingDays := leaveBalance.Available()
+	breakdown.PaidDays, breakdown.LOPDays = splitPaidDays(daysRequested, remainingDays)
+	breakdown.IsLOP = breakdown.LOPDays > 0
+	return breakdown, nil
 }
@@ -0,0 +1,463 @@
+package services
+
+import (
+	"employee-dashboard-api/internal/models"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// NotificationEvent is a single thing a user should be told about. It is
+// stored as an in-app notification and handed to every registered delivery.
+// Data carries event-specific fields for delivery templates and is not stored.
+type NotificationEvent struct {
+	UserID   uuid.UUID
+	Type     string
+	Title    string
+	Message  string
+	EntityID *uuid.UUID
+	Data     map[string]interface{}
+}
+
+// NotificationDelivery sends an event over an additional channel (email,
+// push, ...). Deliveries run in the background; errors are logged and never
+// affect the in-app notification or the request that raised the event.
+type NotificationDelivery interface {
+	Name() string
+	Deliver(event NotificationEvent, recipient *models.User) error
+}
+
+type NotificationService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+
+	mu         sync.RWMutex
+	deliveries []NotificationDelivery
+}
+
+func NewNotificationService(db *gorm.DB, logger *logrus.Logger, deliveries ...NotificationDelivery) *NotificationService {
+	return &NotificationService{
+		db:         db,
+		logger:     logger,
+		deliveries: deliveries,
+	}
+}
+
+// RegisterDelivery adds a delivery channel for all subsequent events.
+func (s *NotificationService) RegisterDelivery(delivery NotificationDelivery) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries = append(s.deliveries, delivery)
+}
+
+// Notify records event as an in-app notification and fans it out to the
+// registered deliveries.
+func (s *NotificationService) Notify(event NotificationEvent) error {
+	notificationType := event.Type
+	notification := models.Notification{
+		UserID:   event.UserID,
+		Title:    event.Title,
+		Message:  event.Message,
+		Type:     &notificationType,
+		EntityID: event.EntityID,
+	}
+	if err := s.db.Create(&notification).Error; err != nil {
+		return fmt.Errorf("failed to store notification: %w", err)
+	}
+
+	s.mu.RLock()
+	deliveries := append([]NotificationDelivery(nil), s.deliveries...)
+	s.mu.RUnlock()
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	go s.deliver(event, deliveries)
+	return nil
+}
+
+func (s *NotificationService) deliver(event NotificationEvent, deliveries []NotificationDelivery) {
+	var recipient models.User
+	if err := s.db.First(&recipient, "id = ?", event.UserID).Error; err != nil {
+		s.logger.Errorf("Failed to load recipient %s for %s notification: %v", event.UserID, event.Type, err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		if err := delivery.Deliver(event, &recipient); err != nil {
+			s.logger.Errorf("Failed to deliver %s notification via %s to %s: %v", event.Type, delivery.Name(), event.UserID, err)
+		}
+	}
+}
+
+// notify is Notify for callers that must not fail because of a notification:
+// errors are logged only.
+func (s *NotificationService) notify(event NotificationEvent) {
+	if err := s.Notify(event); err != nil {
+		s.logger.Errorf("Failed to notify user %s of %s: %v", event.UserID, event.Type, err)
+	}
+}
+
+// NotifyLeaveRequested tells the applicant's manager about a new leave
+// application. leave must have User and LeaveType loaded. Nothing is sent when
+// the applicant has no manager.
+func (s *NotificationService) NotifyLeaveRequested(leave *models.LeaveApplication) {
+	if leave.User.ManagerID == nil {
+		return
+	}
+
+	data := leaveEventData(leave)
+	data["ApplicantName"] = fullName(&leave.User)
+
+	s.notify(NotificationEvent{
+		UserID:   *leave.User.ManagerID,
+		Type:     models.NotificationLeaveRequested,
+		Title:    "New leave request",
+		Message:  fmt.Sprintf("%s applied for %s for %s.", data["ApplicantName"], leave.LeaveType.Name, leavePeriod(leave)),
+		EntityID: &leave.ID,
+		Data:     data,
+	})
+}
+
+// NotifyLeaveApprovalRequested tells approverIDs that a step of a leave's
+// approval chain waits for them, or, when escalated is set, that it was
+// escalated to them for being past its deadline. leave must have User and
+// LeaveType loaded.
+func (s *NotificationService) NotifyLeaveApprovalRequested(leave *models.LeaveApplication, approverIDs []uuid.UUID, escalated bool) {
+	data := leaveEventData(leave)
+	data["ApplicantName"] = fullName(&leave.User)
+
+	event := NotificationEvent{
+		Type:     models.NotificationLeaveApprovalRequested,
+		Title:    "Leave awaiting your approval",
+		Message:  fmt.Sprintf("%s's %s for %s is awaiting your approval.", data["ApplicantName"], leave.LeaveType.Name, leavePeriod(leave)),
+		EntityID: &leave.ID,
+		Data:     data,
+	}
+	if escalated {
+		event.Type = models.NotificationLeaveApprovalEscalated
+		event.Title = "Leave approval escalated"
+		event.Message = fmt.Sprintf("%s's %s for %s is past its approval deadline and was escalated to you.", data["ApplicantName"], leave.LeaveType.Name, leavePeriod(leave))
+	}
+	for _, approverID := range approverIDs {
+		event.UserID = approverID
+		s.notify(event)
+	}
+}
+
+// NotifyLeaveReviewed tells the applicant that their leave application was
+// approved or rejected. leave must have LeaveType and Approver loaded.
+func (s *NotificationService) NotifyLeaveReviewed(leave *models.LeaveApplication) {
+	period := leavePeriod(leave)
+
+	data := leaveEventData(leave)
+	data["ReviewerName"] = "your manager"
+	if leave.Approver != nil {
+		data["ReviewerName"] = fullName(leave.Approver)
+	}
+	data["Reason"] = ""
+
+	event := NotificationEvent{
+		UserID:   leave.UserID,
+		EntityID: &leave.ID,
+		Data:     data,
+	}
+	switch leave.Status {
+	case "approved":
+		event.Type = models.NotificationLeaveApproved
+		event.Title = "Leave approved"
+		event.Message = fmt.Sprintf("Your %s for %s has been approved.", leave.LeaveType.Name, period)
+	case "rejected":
+		event.Type = models.NotificationLeaveRejected
+		event.Title = "Leave rejected"
+		event.Message = fmt.Sprintf("Your %s for %s has been rejected.", leave.LeaveType.Name, period)
+		if leave.RejectionReason != nil && strings.TrimSpace(*leave.RejectionReason) != "" {
+			data["Reason"] = strings.TrimSpace(*leave.RejectionReason)
+			event.Message += " Reason: " + strings.TrimSpace(*leave.RejectionReason)
+		}
+	default:
+		return
+	}
+	s.notify(event)
+}
+
+// NotifyLeaveCancellationRequested tells the applicant's manager that an
+// approved leave is asked to be cancelled. leave must have User and LeaveType
+// loaded. Nothing is sent when the applicant has no manager.
+func (s *NotificationService) NotifyLeaveCancellationRequested(leave *models.LeaveApplication) {
+	if leave.User.ManagerID == nil || leave.CancelFrom == nil {
+		return
+	}
+
+	data := leaveEventData(leave)
+	data["ApplicantName"] = fullName(&leave.User)
+	data["CancelFrom"] = leave.CancelFrom.Format("02 Jan 2006")
+
+	message := fmt.Sprintf("%s asked to cancel their %s for %s", data["ApplicantName"], leave.LeaveType.Name, leavePeriod(leave))
+	if !leave.CancelFrom.Equal(leave.StartDate) {
+		message += " from " + data["CancelFrom"].(string)
+	}
+	s.notify(NotificationEvent{
+		UserID:   *leave.User.ManagerID,
+		Type:     models.NotificationLeaveCancellationRequested,
+		Title:    "Leave cancellation request",
+		Message:  message + ".",
+		EntityID: &leave.ID,
+		Data:     data,
+	})
+}
+
+// NotifyLeaveCancellationReviewed tells the applicant whether their
+// cancellation request was granted. leave must have LeaveType loaded and be
+// reloaded after the decision.
+func (s *NotificationService) NotifyLeaveCancellationReviewed(leave *models.LeaveApplication, approved bool, reason string) {
+	data := leaveEventData(leave)
+	data["Reason"] = strings.TrimSpace(reason)
+
+	event := NotificationEvent{
+		UserID:   leave.UserID,
+		EntityID: &leave.ID,
+		Data:     data,
+	}
+	if approved {
+		event.Type = models.NotificationLeaveCancellationApproved
+		event.Title = "Leave cancellation approved"
+		if leave.Status == models.LeaveStatusCancelled {
+			event.Message = fmt.Sprintf("Your %s for %s has been cancelled.", leave.LeaveType.Name, leavePeriod(leave))
+		} else {
+			event.Message = fmt.Sprintf("Your %s has been shortened to %s.", leave.LeaveType.Name, leavePeriod(leave))
+		}
+	} else {
+		event.Type = models.NotificationLeaveCancellationRejected
+		event.Title = "Leave cancellation rejected"
+		event.Message = fmt.Sprintf("Your request to cancel your %s for %s has been rejected.", leave.LeaveType.Name, leavePeriod(leave))
+		if data["Reason"] != "" {
+			event.Message += " Reason: " + data["Reason"].(string)
+		}
+	}
+	s.notify(event)
+}
+
+// NotifyUserReviewed tells a newly registered user whether their account was
+// approved or rejected.
+func (s *NotificationService) NotifyUserReviewed(userID uuid.UUID, approved bool, reason string) {
+	event := NotificationEvent{
+		UserID:   userID,
+		EntityID: &userID,
+		Data:     map[string]interface{}{"Reason": strings.TrimSpace(reason)},
+	}
+	if approved {
+		event.Type = models.NotificationUserApproved
+		event.Title = "Account approved"
+		event.Message = "Your account has been approved. Welcome aboard!"
+	} else {
+		event.Type = models.NotificationUserRejected
+		event.Title = "Account rejected"
+		event.Message = "Your account registration has been rejected."
+		if strings.TrimSpace(reason) != "" {
+			event.Message += " Reason: " + strings.TrimSpace(reason)
+		}
+	}
+	s.notify(event)
+}
+
+// NotifyTimesheetSubmitted tells the employee's manager that timesheets are
+// waiting for review. Nothing is sent when the employee has no manager.
+func (s *NotificationService) NotifyTimesheetSubmitted(userID uuid.UUID, entries int64, startDate, endDate time.Time) {
+	var employee models.User
+	if err := s.db.Select("id", "first_name", "last_name", "manager_id").First(&employee, "id = ?", userID).Error; err != nil {
+		s.logger.Errorf("Failed to load user %s for timesheet notification: %v", userID, err)
+		return
+	}
+	if employee.ManagerID == nil {
+		return
+	}
+
+	s.notify(NotificationEvent{
+		UserID:   *employee.ManagerID,
+		Type:     models.NotificationTimesheetSubmitted,
+		Title:    "Timesheet submitted",
+		Message:  fmt.Sprintf("%s submitted %d timesheet entries for %s - %s.", fullName(&employee), entries, startDate.Format("02 Jan 2006"), endDate.Format("02 Jan 2006")),
+		EntityID: &employee.ID,
+	})
+}
+
+// NotifyCompOffClaimed tells the claimant's manager that a comp-off claim is
+// waiting for review. claim must have User loaded. Nothing is sent when the
+// claimant has no manager.
+func (s *NotificationService) NotifyCompOffClaimed(claim *models.CompOffClaim) {
+	if claim.User == nil || claim.User.ManagerID == nil {
+		return
+	}
+
+	s.notify(NotificationEvent{
+		UserID:   *claim.User.ManagerID,
+		Type:     models.NotificationCompOffClaimed,
+		Title:    "Comp-off claimed",
+		Message:  fmt.Sprintf("%s claimed %g days of comp-off for working on %s.", fullName(claim.User), claim.Days, claim.WorkDate.Format("02 Jan 2006")),
+		EntityID: &claim.ID,
+	})
+}
+
+// NotifyCompOffReviewed tells the claimant whether their comp-off claim was
+// approved or rejected.
+func (s *NotificationService) NotifyCompOffReviewed(claim *models.CompOffClaim) {
+	event := NotificationEvent{
+		UserID:   claim.UserID,
+		EntityID: &claim.ID,
+	}
+	if claim.Status == models.CompOffApproved {
+		event.Type = models.NotificationCompOffApproved
+		event.Title = "Comp-off approved"
+		event.Message = fmt.Sprintf("Your comp-off for %s was approved: %g days were added to your Comp Off balance, to be used by %s.",
+			claim.WorkDate.Format("02 Jan 2006"), claim.Days, claim.ExpiresAt.AddDate(0, 0, -1).Format("02 Jan 2006"))
+	} else {
+		event.Type = models.NotificationCompOffRejected
+		event.Title = "Comp-off rejected"
+		event.Message = fmt.Sprintf("Your comp-off claim for %s was rejected.", claim.WorkDate.Format("02 Jan 2006"))
+		if claim.RejectionReason != nil && *claim.RejectionReason != "" {
+			event.Message += " Reason: " + *claim.RejectionReason
+		}
+	}
+	s.notify(event)
+}
+
+// NotifyOptionalHolidaySelected tells the employee's manager that an
+// optional holiday selection is waiting for review. selection must have User
+// and Holiday loaded. Nothing is sent when the employee has no manager.
+func (s *NotificationService) NotifyOptionalHolidaySelected(selection *models.OptionalHolidaySelection) {
+	if selection.User == nil || selection.User.ManagerID == nil {
+		return
+	}
+
+	s.notify(NotificationEvent{
+		UserID:   *selection.User.ManagerID,
+		Type:     models.NotificationOptionalHolidaySelected,
+		Title:    "Optional holiday selected",
+		Message:  fmt.Sprintf("%s wants to take the optional holiday %s on %s.", fullName(selection.User), selection.Holiday.Title, selection.Holiday.EventDate.Format("02 Jan 2006")),
+		EntityID: &selection.ID,
+	})
+}
+
+// NotifyOptionalHolidayReviewed tells the employee whether their optional
+// holiday selection was approved or rejected. selection must have Holiday
+// loaded.
+func (s *NotificationService) NotifyOptionalHolidayReviewed(selection *models.OptionalHolidaySelection) {
+	event := NotificationEvent{
+		UserID:   selection.UserID,
+		EntityID: &selection.ID,
+	}
+	day := selection.Holiday.Title + " on " + selection.Holiday.EventDate.Format("02 Jan 2006")
+	if selection.Status == models.OptionalHolidayApproved {
+		event.Type = models.NotificationOptionalHolidayApproved
+		event.Title = "Optional holiday approved"
+		event.Message = fmt.Sprintf("Your optional holiday %s was approved. Enjoy the day off!", day)
+	} else {
+		event.Type = models.NotificationOptionalHolidayRejected
+		event.Title = "Optional holiday rejected"
+		event.Message = fmt.Sprintf("Your optional holiday %s was rejected.", day)
+		if selection.RejectionReason != nil && *selection.RejectionReason != "" {
+			event.Message += " Reason: " + *selection.RejectionReason
+		}
+	}
+	s.notify(event)
+}
+
+func leavePeriod(leave *models.LeaveApplication) string {
+	period := leave.StartDate.Format("02 Jan 2006")
+	if !leave.EndDate.Equal(leave.StartDate) {
+		period += " - " + leave.EndDate.Format("02 Jan 2006")
+	}
+	return period
+}
+
+// leaveEventData returns the template fields shared by all leave events.
+func leaveEventData(leave *models.LeaveApplication) map[string]interface{} {
+	days := leave.PaidDays + leave.LOPDays
+	if days <= 0 {
+		days = leave.EndDate.Sub(leave.StartDate).Hours()/24 + 1
+		if leave.IsHalfDay {
+			days = 0.5
+		}
+	}
+	reason := ""
+	if leave.Reason != nil {
+		reason = strings.TrimSpace(*leave.Reason)
+	}
+	return map[string]interface{}{
+		"LeaveType": leave.LeaveType.Name,
+		"StartDate": leave.StartDate.Format("02 Jan 2006"),
+		"EndDate":   leave.EndDate.Format("02 Jan 2006"),
+		"Days":      fmt.Sprintf("%g", days),
+		"Reason":    reason,
+	}
+}
+
+func fullName(user *models.User) string {
+	return strings.TrimSpace(user.FirstName + " " + user.LastName)
+}
+
+// ListNotifications returns a page of userID's notifications, newest first,
+// together with the total count.
+func (s *NotificationService) ListNotifications(userID uuid.UUID, unreadOnly bool, page, limit int) ([]models.Notification, int64, error) {
+	query := s.db.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("is_read = ?", false)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+// MarkRead marks one of userID's notifications as read. It returns
+// gorm.ErrRecordNotFound when the notification does not belong to userID.
+func (s *NotificationService) MarkRead(userID, notificationID uuid.UUID) (*models.Notification, error) {
+	var notification models.Notification
+	if err := s.db.First(&notification, "id = ? AND user_id = ?", notificationID, userID).Error; err != nil {
+		return nil, err
+	}
+	if notification.IsRead {
+		return &notification, nil
+	}
+
+	now := time.Now()
+	if err := s.db.Model(&notification).Updates(map[string]interface{}{
+		"is_read": true,
+		"read_at": &now,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// MarkAllRead marks every unread notification of userID as read and returns
+// how many were updated.
+func (s *NotificationService) MarkAllRead(userID uuid.UUID) (int64, error) {
+	result := s.db.Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Updates(map[string]interface{}{
+			"is_read": true,
+			"read_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// UnreadCount returns the number of unread notifications of userID.
+func (s *NotificationService) UnreadCount(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := s.db.Model(&models.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&count).Error
+	return count, err
+}
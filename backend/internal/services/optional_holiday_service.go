@@ -0,0 +1,289 @@
+package services
+
+import (
+	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/repository"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrNotOptionalHoliday          = errors.New("not an optional holiday at the employee's work location")
+	ErrOptionalHolidaySelected     = errors.New("optional holiday has already been selected")
+	ErrOptionalHolidayQuotaReached = errors.New("optional holiday quota for the year has been used up")
+	ErrOptionalHolidayOnLeave      = errors.New("employee has leave on the optional holiday")
+	ErrOptionalHolidayPassed       = errors.New("optional holiday has already passed")
+	// ErrOptionalHolidaysOff is returned when selecting while every optional
+	// holiday is already a day off for everyone.
+	ErrOptionalHolidaysOff = errors.New("optional holidays are days off for everyone")
+	// ErrOptionalHolidayNotPending is returned when reviewing a selection
+	// that has already been decided or cancelled.
+	ErrOptionalHolidayNotPending = errors.New("optional holiday selection is not in pending status")
+	// ErrOptionalHolidayClosed is returned when cancelling a selection that
+	// has already been rejected or cancelled.
+	ErrOptionalHolidayClosed = errors.New("optional holiday selection has already been rejected or cancelled")
+)
+
+// liveOptionalHolidayStatuses are the statuses of selections that count
+// against the quota.
+var liveOptionalHolidayStatuses = []string{models.OptionalHolidayPending, models.OptionalHolidayApproved}
+
+// OptionalHolidayService lets employees pick optional holidays within a
+// yearly quota. Picks wait for the manager's approval; approved picks are
+// days off for the employee in the working calendar.
+type OptionalHolidayService struct {
+	repos    *repository.Repositories
+	location *time.Location
+	logger   *logrus.Logger
+
+	defaultQuota        int
+	optionalHolidaysOff bool
+}
+
+func NewOptionalHolidayService(repos *repository.Repositories, cfg *config.Config, location *time.Location, logger *logrus.Logger) *OptionalHolidayService {
+	return &OptionalHolidayService{
+		repos:               repos,
+		location:            location,
+		logger:              logger,
+		defaultQuota:        cfg.OptionalHolidayQuota,
+		optionalHolidaysOff: cfg.OptionalHolidaysOff,
+	}
+}
+
+// DefaultQuota is the quota of the years admins have not set one for.
+func (s *OptionalHolidayService) DefaultQuota() int {
+	return s.defaultQuota
+}
+
+// Quota returns how many optional holidays each employee may pick in year.
+func (s *OptionalHolidayService) Quota(year int) (int, error) {
+	return quotaOf(s.repos.OptionalHolidays, year, s.defaultQuota)
+}
+
+func quotaOf(repo repository.OptionalHolidayRepository, year, defaultQuota int) (int, error) {
+	quota, err := repo.FindQuota(year)
+	if errors.Is(err, repository.ErrNotFound) {
+		return defaultQuota, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return quota.Quota, nil
+}
+
+// SetQuota sets the quota of year. Selections already made stay even if a
+// lower quota leaves an employee over it.
+func (s *OptionalHolidayService) SetQuota(year, quota int, actorID uuid.UUID) (*models.OptionalHolidayQuota, error) {
+	record := &models.OptionalHolidayQuota{Year: year, Quota: quota, UpdatedBy: &actorID}
+	if err := s.repos.OptionalHolidays.SaveQuota(record); err != nil {
+		return nil, err
+	}
+	return s.repos.OptionalHolidays.FindQuota(year)
+}
+
+// OptionalHolidayChoice is an optional holiday an employee can pick, with
+// their latest selection of it.
+type OptionalHolidayChoice struct {
+	Holiday   models.Event                     `json:"holiday"`
+	Selection *models.OptionalHolidaySelection `json:"selection,omitempty"`
+}
+
+// OptionalHolidaySummary is an employee's optional holidays of a year and
+// how much of the quota they used.
+type OptionalHolidaySummary struct {
+	Year      int                     `json:"year"`
+	Quota     int                     `json:"quota"`
+	Used      int                     `json:"used"` // pending and approved selections
+	Remaining int                     `json:"remaining"`
+	Holidays  []OptionalHolidayChoice `json:"holidays"`
+}
+
+// Summary returns the optional holidays of year at user's work location with
+// user's selections of them.
+func (s *OptionalHolidayService) Summary(user *models.User, year int) (*OptionalHolidaySummary, error) {
+	quota, err := s.Quota(year)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := s.repos.Holidays.ListForYear(year)
+	if err != nil {
+		return nil, err
+	}
+	selections, _, err := s.repos.OptionalHolidays.List(repository.OptionalHolidayFilter{UserID: user.ID, Year: year}, repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Selections come oldest first, so a holiday ends up with its latest
+	latest := make(map[uuid.UUID]*models.OptionalHolidaySelection, len(selections))
+	used := 0
+	for i := range selections {
+		selection := &selections[i]
+		selection.Holiday = nil
+		latest[selection.HolidayID] = selection
+		if selection.Status == models.OptionalHolidayPending || selection.Status == models.OptionalHolidayApproved {
+			used++
+		}
+	}
+
+	summary := &OptionalHolidaySummary{Year: year, Quota: quota, Used: used, Holidays: []OptionalHolidayChoice{}}
+	if used < quota {
+		summary.Remaining = quota - used
+	}
+	for _, holiday := range holidays {
+		if holiday.IsOptional && holidayAppliesAt(&holiday, user.WorkLocation) {
+			summary.Holidays = append(summary.Holidays, OptionalHolidayChoice{Holiday: holiday, Selection: latest[holiday.ID]})
+		}
+	}
+	return summary, nil
+}
+
+// holidayAppliesAt reports whether holiday is observed at workLocation, nil
+// for users without one.
+func holidayAppliesAt(holiday *models.Event, workLocation *string) bool {
+	if holiday.Location == nil {
+		return true
+	}
+	return workLocation != nil && normalizeLocation(*holiday.Location) == normalizeLocation(*workLocation)
+}
+
+// Select records userID's pick of an optional holiday, pending the manager's
+// approval. It fails if the holiday is not optional at the user's work
+// location, has passed, is already picked, falls on the user's leave or the
+// quota of its year is used up.
+func (s *OptionalHolidayService) Select(userID, holidayID uuid.UUID, note string) (*models.OptionalHolidaySelection, error) {
+	if s.optionalHolidaysOff {
+		return nil, ErrOptionalHolidaysOff
+	}
+
+	user, err := s.repos.Users.FindByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	holiday, err := s.repos.Holidays.FindByID(holidayID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrNotOptionalHoliday
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !holiday.IsOptional || !holidayAppliesAt(holiday, user.WorkLocation) {
+		return nil, ErrNotOptionalHoliday
+	}
+	if s.passed(holiday) {
+		return nil, ErrOptionalHolidayPassed
+	}
+	if err := s.checkNotOnLeave(userID, holiday); err != nil {
+		return nil, err
+	}
+
+	selection := &models.OptionalHolidaySelection{
+		UserID:    userID,
+		HolidayID: holidayID,
+		Status:    models.OptionalHolidayPending,
+	}
+	if note != "" {
+		selection.Note = &note
+	}
+
+	year := holiday.EventDate.Year()
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		// Concurrent picks of the same user would each count the quota
+		// before the other's insert
+		if err := tx.Users.Lock(userID); err != nil {
+			return err
+		}
+		if _, err := tx.OptionalHolidays.FindLive(userID, holidayID); err == nil {
+			return ErrOptionalHolidaySelected
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+
+		quota, err := quotaOf(tx.OptionalHolidays, year, s.defaultQuota)
+		if err != nil {
+			return err
+		}
+		used, err := tx.OptionalHolidays.CountLive(userID, year)
+		if err != nil {
+			return err
+		}
+		if used >= int64(quota) {
+			return ErrOptionalHolidayQuotaReached
+		}
+		return tx.OptionalHolidays.Create(selection)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return selection, nil
+}
+
+// checkNotOnLeave returns ErrOptionalHolidayOnLeave if userID has a pending
+// or approved leave on holiday; the leave already charges the day.
+func (s *OptionalHolidayService) checkNotOnLeave(userID uuid.UUID, holiday *models.Event) error {
+	leaves, err := s.repos.Leaves.ListOverlapping(userID, holiday.EventDate, holiday.EventDate, uuid.Nil)
+	if err != nil {
+		return fmt.Errorf("failed to load overlapping leaves: %w", err)
+	}
+	if len(leaves) > 0 {
+		return ErrOptionalHolidayOnLeave
+	}
+	return nil
+}
+
+// passed reports whether holiday is before today.
+func (s *OptionalHolidayService) passed(holiday *models.Event) bool {
+	return holiday.EventDate.Format(dateLayout) < time.Now().In(s.location).Format(dateLayout)
+}
+
+// Approve approves a pending selection; the holiday becomes a day off for
+// the employee. selection must have Holiday loaded.
+func (s *OptionalHolidayService) Approve(selection *models.OptionalHolidaySelection, reviewerID uuid.UUID) error {
+	if err := s.checkNotOnLeave(selection.UserID, selection.Holiday); err != nil {
+		return err
+	}
+	err := s.repos.OptionalHolidays.Transition(selection, []string{models.OptionalHolidayPending}, map[string]interface{}{
+		"status":      models.OptionalHolidayApproved,
+		"reviewed_by": reviewerID,
+		"reviewed_at": time.Now(),
+	})
+	if errors.Is(err, repository.ErrConflict) {
+		return ErrOptionalHolidayNotPending
+	}
+	return err
+}
+
+// Reject turns down a pending selection, freeing its place in the quota.
+func (s *OptionalHolidayService) Reject(selection *models.OptionalHolidaySelection, reviewerID uuid.UUID, reason string) error {
+	err := s.repos.OptionalHolidays.Transition(selection, []string{models.OptionalHolidayPending}, map[string]interface{}{
+		"status":           models.OptionalHolidayRejected,
+		"reviewed_by":      reviewerID,
+		"reviewed_at":      time.Now(),
+		"rejection_reason": reason,
+	})
+	if errors.Is(err, repository.ErrConflict) {
+		return ErrOptionalHolidayNotPending
+	}
+	return err
+}
+
+// Cancel withdraws a pending or approved selection, freeing its place in the
+// quota. An approved holiday that has passed cannot be given back. selection
+// must have Holiday loaded.
+func (s *OptionalHolidayService) Cancel(selection *models.OptionalHolidaySelection) error {
+	if selection.Status == models.OptionalHolidayApproved && s.passed(selection.Holiday) {
+		return ErrOptionalHolidayPassed
+	}
+	err := s.repos.OptionalHolidays.Transition(selection, liveOptionalHolidayStatuses, map[string]interface{}{
+		"status": models.OptionalHolidayCancelled,
+	})
+	if errors.Is(err, repository.ErrConflict) {
+		return ErrOptionalHolidayClosed
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/utils"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const passwordMigrationBatchSize = 200
+
+// PasswordMigrationReport counts what MigratePasswords found and changed.
+type PasswordMigrationReport struct {
+	Scanned       int `json:"scanned"`
+	Hashed        int `json:"hashed"`         // plaintext values replaced by a hash
+	ResetRequired int `json:"reset_required"` // unusable values (e.g. the old dummy hash) flagged for reset
+	Upgradable    int `json:"upgradable"`     // valid hashes of an older scheme or cost, upgraded at next login
+	Failed        int `json:"failed"`
+}
+
+type PasswordMigrationService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewPasswordMigrationService(db *gorm.DB, logger *logrus.Logger) *PasswordMigrationService {
+	return &PasswordMigrationService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// MigratePasswords hashes every plaintext password left over from
+// USER_PLAIN_PASSWORDS and forces a reset for accounts whose stored value can
+// never match. Hashes that are merely outdated cannot be upgraded without the
+// password and are left to the login path. With dryRun nothing is written.
+func (s *PasswordMigrationService) MigratePasswords(dryRun bool) (*PasswordMigrationReport, error) {
+	report := &PasswordMigrationReport{}
+	var users []models.User
+
+	err := s.db.Select("id", "employee_id", "password_hash", "must_reset_password").
+		FindInBatches(&users, passwordMigrationBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, user := range users {
+				report.Scanned++
+				if err := s.migrateUser(&user, dryRun, report); err != nil {
+					report.Failed++
+					s.logger.Errorf("Failed to migrate password for user %s: %v", user.EmployeeID, err)
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return report, err
+	}
+
+	s.logger.Infof("Password migration (dry run: %t): scanned %d, hashed %d, reset required %d, upgradable at login %d, failed %d",
+		dryRun, report.Scanned, report.Hashed, report.ResetRequired, report.Upgradable, report.Failed)
+	return report, nil
+}
+
+func (s *PasswordMigrationService) migrateUser(user *models.User, dryRun bool, report *PasswordMigrationReport) error {
+	switch utils.PasswordHashScheme(user.PasswordHash) {
+	case utils.PasswordSchemePlaintext:
+		report.Hashed++
+		if dryRun {
+			return nil
+		}
+		hashed, err := utils.HashPassword(user.PasswordHash)
+		if err != nil {
+			return err
+		}
+		// Only replace the value we read, in case the user changed it meanwhile
+		return s.db.Model(&models.User{}).
+			Where("id = ? AND password_hash = ?", user.ID, user.PasswordHash).
+			Update("password_hash", hashed).Error
+
+	case utils.PasswordSchemeUnusable:
+		if user.MustResetPassword && user.PasswordHash == utils.UnusablePasswordHash {
+			return nil
+		}
+		report.ResetRequired++
+		if dryRun {
+			return nil
+		}
+		return s.db.Model(&models.User{}).
+			Where("id = ? AND password_hash = ?", user.ID, user.PasswordHash).
+			Updates(map[string]interface{}{
+				"password_hash":       utils.UnusablePasswordHash,
+				"must_reset_password": true,
+			}).Error
+
+	default:
+		if utils.PasswordHashOutdated(user.PasswordHash) {
+			report.Upgradable++
+		}
+		return nil
+	}
+}
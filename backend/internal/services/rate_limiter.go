@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RateLimit describes a token bucket: up to Burst requests at once, refilled
+// at one token per Every.
+type RateLimit struct {
+	Burst int
+	Every time.Duration
+}
+
+// PerMinute allows n requests per minute with a burst of n.
+func PerMinute(n int) RateLimit {
+	return RateLimit{Burst: n, Every: time.Minute / time.Duration(n)}
+}
+
+// PerHour allows n requests per hour with a burst of n.
+func PerHour(n int) RateLimit {
+	return RateLimit{Burst: n, Every: time.Hour / time.Duration(n)}
+}
+
+// RateLimitStore holds token buckets. Take must be atomic per key across every
+// process sharing the store. The in-memory store covers a single instance; a
+// Redis implementation maps Take onto a small EVAL script over a hash holding
+// (tokens, updated_at) with PEXPIRE set to the time a full refill takes.
+type RateLimitStore interface {
+	// Take removes one token from the bucket at key. When the bucket is empty
+	// it returns false and how long until a token is available.
+	Take(ctx context.Context, key string, limit RateLimit, now time.Time) (bool, time.Duration, error)
+}
+
+// RateLimiter applies named limits on top of a RateLimitStore. Store errors
+// fail open: an unavailable store must not lock everybody out.
+type RateLimiter struct {
+	store  RateLimitStore
+	logger *logrus.Logger
+}
+
+func NewRateLimiter(store RateLimitStore, logger *logrus.Logger) *RateLimiter {
+	return &RateLimiter{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// Allow takes a token for key under limit and reports whether the request may
+// proceed, and if not, when to retry.
+func (l *RateLimiter) Allow(ctx context.Context, key string, limit RateLimit) (bool, time.Duration) {
+	ok, retryAfter, err := l.store.Take(ctx, key, limit, time.Now())
+	if err != nil {
+		l.logger.Errorf("Rate limit store error for %s, allowing request: %v", key, err)
+		return true, 0
+	}
+	return ok, retryAfter
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	expires time.Time
+}
+
+// MemoryRateLimitStore keeps buckets in process memory.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, limit RateLimit, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	fullRefill := limit.Every * time.Duration(limit.Burst)
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = bucket
+	} else {
+		elapsed := now.Sub(bucket.updated)
+		bucket.tokens = math.Min(float64(limit.Burst), bucket.tokens+elapsed.Seconds()/limit.Every.Seconds())
+		bucket.updated = now
+	}
+	bucket.expires = now.Add(fullRefill)
+
+	if bucket.tokens < 1 {
+		missing := 1 - bucket.tokens
+		return false, time.Duration(missing * float64(limit.Every)), nil
+	}
+	bucket.tokens--
+	return true, 0, nil
+}
+
+// sweep drops buckets that have refilled completely, at most once a minute.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	for key, bucket := range s.buckets {
+		if now.After(bucket.expires) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// teamScopeCacheTTL bounds how long a resolved reporting tree is reused before
+// the recursive query runs again.
+const teamScopeCacheTTL = time.Minute
+
+// TeamScope is the set of employees a user may see in manager views: either
+// everybody (admin/HR) or the user's direct and indirect reports.
+type TeamScope struct {
+	Global  bool
+	UserIDs []uuid.UUID
+
+	members map[uuid.UUID]struct{}
+}
+
+// Contains reports whether userID is visible in this scope.
+func (s *TeamScope) Contains(userID uuid.UUID) bool {
+	if s.Global {
+		return true
+	}
+	_, ok := s.members[userID]
+	return ok
+}
+
+// Apply restricts query to rows whose column references a user in scope.
+func (s *TeamScope) Apply(query *gorm.DB, column string) *gorm.DB {
+	if s.Global {
+		return query
+	}
+	if len(s.UserIDs) == 0 {
+		return query.Where("1 = 0")
+	}
+	return query.Where(column+" IN ?", s.UserIDs)
+}
+
+type teamScopeEntry struct {
+	userIDs []uuid.UUID
+	until   time.Time
+}
+
+type TeamScopeService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+
+	mu    sync.RWMutex
+	cache map[uuid.UUID]teamScopeEntry
+}
+
+func NewTeamScopeService(db *gorm.DB, logger *logrus.Logger) *TeamScopeService {
+	return &TeamScopeService{
+		db:     db,
+		logger: logger,
+		cache:  make(map[uuid.UUID]teamScopeEntry),
+	}
+}
+
+// ResolveScope returns the team scope of managerID. When global is true the
+// reporting tree is not resolved and every user is in scope.
+func (s *TeamScopeService) ResolveScope(managerID uuid.UUID, global bool) (*TeamScope, error) {
+	if global {
+		return &TeamScope{Global: true}, nil
+	}
+
+	userIDs, err := s.reportIDs(managerID)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := &TeamScope{
+		UserIDs: userIDs,
+		members: make(map[uuid.UUID]struct{}, len(userIDs)),
+	}
+	for _, id := range userIDs {
+		scope.members[id] = struct{}{}
+	}
+	return scope, nil
+}
+
+// reportIDs returns the direct and indirect reports of managerID following
+// users.manager_id. UNION (not UNION ALL) stops the recursion on cycles.
+func (s *TeamScopeService) reportIDs(managerID uuid.UUID) ([]uuid.UUID, error) {
+	now := time.Now()
+
+	s.mu.RLock()
+	entry, ok := s.cache[managerID]
+	s.mu.RUnlock()
+	if ok && now.Before(entry.until) {
+		return entry.userIDs, nil
+	}
+
+	var userIDs []uuid.UUID
+	if err := s.db.Raw(`
+		WITH RECURSIVE reports AS (
+			SELECT id FROM users WHERE manager_id = ?
+			UNION
+			SELECT u.id FROM users u JOIN reports r ON u.manager_id = r.id
+		)
+		SELECT id FROM reports WHERE id <> ?`, managerID, managerID).
+		Scan(&userIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to resolve team scope: %w", err)
+	}
+
+	s.mu.Lock()
+	s.cache[managerID] = teamScopeEntry{userIDs: userIDs, until: now.Add(teamScopeCacheTTL)}
+	s.mu.Unlock()
+
+	return userIDs, nil
+}
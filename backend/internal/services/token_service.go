@@ -0,0 +1,433 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/models"
+	"employee-dashboard-api/internal/utils"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReuse   = errors.New("refresh token reuse detected, session revoked")
+)
+
+// revocationCacheTTL is how long a "not revoked" answer is trusted before the
+// database is consulted again. Revocations made by this process take effect
+// immediately; revocations made by another replica within this window.
+const revocationCacheTTL = 30 * time.Second
+
+// tokenCleanupInterval is how often expired sessions and revocation records
+// are deleted.
+const tokenCleanupInterval = time.Hour
+
+// TokenPair is what a client receives after login or refresh.
+type TokenPair struct {
+	AccessToken  string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	SessionID    uuid.UUID `json:"session_id"`
+}
+
+// SessionInfo describes the client a session is created for.
+type SessionInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+type revocationEntry struct {
+	revoked bool
+	until   time.Time
+}
+
+type roleVersionEntry struct {
+	version int
+	active  bool // user exists and is approved
+	until   time.Time
+}
+
+type TokenService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+	config *config.Config
+
+	mu              sync.RWMutex
+	cache           map[string]revocationEntry     // keyed by jti
+	revokedSessions map[uuid.UUID]time.Time        // sessions revoked by this process
+	roleVersions    map[uuid.UUID]roleVersionEntry // keyed by user ID
+
+	startOnce sync.Once
+}
+
+func NewTokenService(db *gorm.DB, logger *logrus.Logger, cfg *config.Config) *TokenService {
+	return &TokenService{
+		db:              db,
+		logger:          logger,
+		config:          cfg,
+		cache:           make(map[string]revocationEntry),
+		revokedSessions: make(map[uuid.UUID]time.Time),
+		roleVersions:    make(map[uuid.UUID]roleVersionEntry),
+	}
+}
+
+func (s *TokenService) accessTokenExpiry() time.Duration {
+	return time.Duration(s.config.JWTAccessExpiryMinutes) * time.Minute
+}
+
+func (s *TokenService) refreshTokenExpiry() time.Duration {
+	return time.Duration(s.config.RefreshTokenExpiryDays) * 24 * time.Hour
+}
+
+// CreateSession starts a new session for the user and returns its first token pair.
+func (s *TokenService) CreateSession(user *models.User, info SessionInfo) (*TokenPair, error) {
+	refreshToken, refreshHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := models.UserSession{
+		UserID:           user.ID,
+		RefreshTokenHash: refreshHash,
+		ExpiresAt:        time.Now().Add(s.refreshTokenExpiry()),
+	}
+	if info.UserAgent != "" {
+		session.UserAgent = &info.UserAgent
+	}
+	if info.IPAddress != "" {
+		session.IPAddress = &info.IPAddress
+	}
+
+	if err := s.db.Create(&session).Error; err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return s.issuePair(user, session.ID, refreshToken)
+}
+
+// Refresh rotates a refresh token: the presented token is invalidated and a new
+// pair is returned. Presenting an already-rotated token revokes the session,
+// since it means the token was copied.
+func (s *TokenService) Refresh(refreshToken string) (*TokenPair, error) {
+	hash := hashRefreshToken(refreshToken)
+
+	var session models.UserSession
+	if err := s.db.Where("refresh_token_hash = ? OR previous_token_hash = ?", hash, hash).
+		First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if session.RefreshTokenHash != hash {
+		s.logger.Warnf("Refresh token reuse detected for session %s (user %s)", session.ID, session.UserID)
+		if err := s.RevokeSession(session.ID); err != nil {
+			s.logger.Errorf("Failed to revoke session %s after token reuse: %v", session.ID, err)
+		}
+		return nil, ErrRefreshTokenReuse
+	}
+
+	var user models.User
+	if err := s.db.First(&user, "id = ?", session.UserID).Error; err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if user.ApprovalStatus != models.StatusApproved {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	newToken, newHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Conditional update so two concurrent refreshes of the same token cannot both win
+	now := time.Now()
+	result := s.db.Model(&models.UserSession{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", session.ID, hash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":  newHash,
+			"previous_token_hash": hash,
+			"last_used_at":        now,
+			"expires_at":          now.Add(s.refreshTokenExpiry()),
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return s.issuePair(&user, session.ID, newToken)
+}
+
+// RevokeAccessToken adds the token's jti to the revocation list.
+func (s *TokenService) RevokeAccessToken(claims *utils.Claims) error {
+	if claims == nil || claims.ID == "" {
+		return nil
+	}
+
+	expiresAt := time.Now().Add(s.accessTokenExpiry())
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	revoked := models.RevokedToken{
+		JTI:       claims.ID,
+		UserID:    claims.UserID,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.db.Where("jti = ?", claims.ID).FirstOrCreate(&revoked).Error; err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	s.mu.Lock()
+	s.cache[claims.ID] = revocationEntry{revoked: true, until: expiresAt}
+	s.mu.Unlock()
+	return nil
+}
+
+// RevokeSession ends a single session; its refresh token and every access token
+// issued for it stop working.
+func (s *TokenService) RevokeSession(sessionID uuid.UUID) error {
+	now := time.Now()
+	if err := s.db.Model(&models.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", now).Error; err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	s.mu.Lock()
+	s.revokedSessions[sessionID] = now.Add(s.accessTokenExpiry())
+	s.mu.Unlock()
+	return nil
+}
+
+// RevokeAllSessions ends every active session of the user and returns how many
+// were revoked.
+func (s *TokenService) RevokeAllSessions(userID uuid.UUID) (int64, error) {
+	var sessionIDs []uuid.UUID
+	if err := s.db.Model(&models.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Pluck("id", &sessionIDs).Error; err != nil {
+		return 0, fmt.Errorf("failed to load sessions: %w", err)
+	}
+	if len(sessionIDs) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	result := s.db.Model(&models.UserSession{}).
+		Where("id IN ? AND revoked_at IS NULL", sessionIDs).
+		Update("revoked_at", now)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", result.Error)
+	}
+
+	until := now.Add(s.accessTokenExpiry())
+	s.mu.Lock()
+	for _, id := range sessionIDs {
+		s.revokedSessions[id] = until
+	}
+	s.mu.Unlock()
+
+	s.logger.Infof("Revoked %d sessions for user %s", result.RowsAffected, userID)
+	return result.RowsAffected, nil
+}
+
+// IsRevoked reports whether an access token has been revoked, either directly
+// by jti or through its session, or has gone stale because the user's role
+// changed since it was issued. Answers are cached so that the common case
+// needs no database round trip.
+func (s *TokenService) IsRevoked(claims *utils.Claims) bool {
+	if !s.roleVersionCurrent(claims) {
+		return true
+	}
+
+	now := time.Now()
+
+	s.mu.RLock()
+	if until, ok := s.revokedSessions[claims.SessionID]; ok && now.Before(until) {
+		s.mu.RUnlock()
+		return true
+	}
+	if entry, ok := s.cache[claims.ID]; ok && now.Before(entry.until) {
+		s.mu.RUnlock()
+		return entry.revoked
+	}
+	s.mu.RUnlock()
+
+	revoked, err := s.lookupRevocation(claims)
+	if err != nil {
+		// Fail closed: a token we cannot vouch for is treated as revoked
+		s.logger.Errorf("Failed to check token revocation: %v", err)
+		return true
+	}
+
+	entry := revocationEntry{revoked: revoked, until: now.Add(revocationCacheTTL)}
+	if revoked && claims.ExpiresAt != nil {
+		entry.until = claims.ExpiresAt.Time
+	}
+
+	s.mu.Lock()
+	s.pruneLocked(now)
+	s.cache[claims.ID] = entry
+	s.mu.Unlock()
+
+	return revoked
+}
+
+func (s *TokenService) lookupRevocation(claims *utils.Claims) (bool, error) {
+	var count int64
+	if err := s.db.Model(&models.RevokedToken{}).Where("jti = ?", claims.ID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	var session models.UserSession
+	if err := s.db.Select("id", "revoked_at").First(&session, "id = ?", claims.SessionID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return true, nil
+		}
+		return false, err
+	}
+	return session.RevokedAt != nil, nil
+}
+
+// InvalidateRoleVersion drops the cached role version of a user. Call it after
+// bumping users.role_version so that old tokens are rejected right away.
+func (s *TokenService) InvalidateRoleVersion(userID uuid.UUID) {
+	s.mu.Lock()
+	delete(s.roleVersions, userID)
+	s.mu.Unlock()
+}
+
+// roleVersionCurrent reports whether the token's role version still matches
+// the user's and the user is still approved.
+func (s *TokenService) roleVersionCurrent(claims *utils.Claims) bool {
+	now := time.Now()
+
+	s.mu.RLock()
+	entry, ok := s.roleVersions[claims.UserID]
+	s.mu.RUnlock()
+
+	if !ok || now.After(entry.until) {
+		var user models.User
+		err := s.db.Select("id", "role_version", "approval_status").First(&user, "id = ?", claims.UserID).Error
+		switch {
+		case err == gorm.ErrRecordNotFound:
+			entry = roleVersionEntry{active: false, until: now.Add(revocationCacheTTL)}
+		case err != nil:
+			s.logger.Errorf("Failed to check role version: %v", err)
+			return false
+		default:
+			entry = roleVersionEntry{
+				version: user.RoleVersion,
+				active:  user.ApprovalStatus == models.StatusApproved,
+				until:   now.Add(revocationCacheTTL),
+			}
+		}
+
+		s.mu.Lock()
+		s.roleVersions[claims.UserID] = entry
+		s.mu.Unlock()
+	}
+
+	return entry.active && entry.version == claims.RoleVersion
+}
+
+// pruneLocked drops expired cache entries. Callers must hold s.mu.
+func (s *TokenService) pruneLocked(now time.Time) {
+	for jti, entry := range s.cache {
+		if now.After(entry.until) {
+			delete(s.cache, jti)
+		}
+	}
+	for id, until := range s.revokedSessions {
+		if now.After(until) {
+			delete(s.revokedSessions, id)
+		}
+	}
+	for id, entry := range s.roleVersions {
+		if now.After(entry.until) {
+			delete(s.roleVersions, id)
+		}
+	}
+}
+
+// Start launches the cleanup job, running CleanupExpired right away and then
+// every tokenCleanupInterval. Calling it more than once has no effect.
+func (s *TokenService) Start() {
+	s.startOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(tokenCleanupInterval)
+			defer ticker.Stop()
+			for {
+				s.CleanupExpired()
+				<-ticker.C
+			}
+		}()
+		s.logger.Infof("Token cleanup job started (every %s)", tokenCleanupInterval)
+	})
+}
+
+// CleanupExpired removes revocation records and sessions that can no longer be used.
+func (s *TokenService) CleanupExpired() {
+	now := time.Now()
+	if result := s.db.Where("expires_at < ?", now).Delete(&models.RevokedToken{}); result.Error != nil {
+		s.logger.Errorf("Failed to cleanup revoked tokens: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		s.logger.Infof("Cleaned up %d expired revoked tokens", result.RowsAffected)
+	}
+
+	if result := s.db.Where("expires_at < ?", now).Delete(&models.UserSession{}); result.Error != nil {
+		s.logger.Errorf("Failed to cleanup expired sessions: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		s.logger.Infof("Cleaned up %d expired sessions", result.RowsAffected)
+	}
+}
+
+func (s *TokenService) issuePair(user *models.User, sessionID uuid.UUID, refreshToken string) (*TokenPair, error) {
+	accessToken, claims, err := utils.GenerateToken(user, sessionID, s.config.JWTSecret, s.accessTokenExpiry())
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    claims.ExpiresAt.Time,
+		SessionID:    sessionID,
+	}, nil
+}
+
+// newRefreshToken returns an opaque random token and the hash stored for it.
+func newRefreshToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashRefreshToken(token), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"employee-dashboard-api/internal/config"
+	"employee-dashboard-api/internal/models"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const dateLayout = "2006-01-02"
+
+var weekdayNames = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// WorkingCalendarService decides which days count against leave balances:
+// weekends (per work location) and holidays from the events table are
+// excluded. Optional holidays are working days unless OPTIONAL_HOLIDAYS_OFF is
+// set or the user selected them and had the selection approved.
+type WorkingCalendarService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+
+	weekend             map[time.Weekday]bool
+	weekendByLocation   map[string]map[time.Weekday]bool
+	optionalHolidaysOff bool
+}
+
+func NewWorkingCalendarService(db *gorm.DB, cfg *config.Config, logger *logrus.Logger) *WorkingCalendarService {
+	s := &WorkingCalendarService{
+		db:                  db,
+		logger:              logger,
+		weekendByLocation:   make(map[string]map[time.Weekday]bool),
+		optionalHolidaysOff: cfg.OptionalHolidaysOff,
+	}
+
+	weekend, err := parseWeekdays(cfg.WeekendDays)
+	if err != nil {
+		logger.Errorf("Invalid WEEKEND_DAYS, using saturday,sunday: %v", err)
+		weekend = map[time.Weekday]bool{time.Saturday: true, time.Sunday: true}
+	}
+	s.weekend = weekend
+
+	for _, entry := range strings.Split(cfg.WeekendDaysByLocation, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		location, days, ok := strings.Cut(entry, "=")
+		if !ok {
+			logger.Errorf("Invalid WEEKEND_DAYS_BY_LOCATION entry %q, expected location=days", entry)
+			continue
+		}
+		weekend, err := parseWeekdays(days)
+		if err != nil {
+			logger.Errorf("Invalid WEEKEND_DAYS_BY_LOCATION entry %q: %v", entry, err)
+			continue
+		}
+		s.weekendByLocation[normalizeLocation(location)] = weekend
+	}
+
+	return s
+}
+
+// parseWeekdays parses a comma-separated list of weekday names. An empty list
+// means no weekend at all.
+func parseWeekdays(list string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		day, ok := weekdayNames[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q", name)
+		}
+		days[day] = true
+	}
+	return days, nil
+}
+
+func normalizeLocation(location string) string {
+	return strings.ToLower(strings.TrimSpace(location))
+}
+
+// LeaveDays returns the per-day breakdown of a leave from start to end
+// (inclusive) for userID and the total leave days it charges. sessions maps
+// dates (YYYY-MM-DD) taken as a first or second half to their session; those
+// working days charge 0.5 and all others in full. Sessions on non-working days
+// charge nothing.
+func (s *WorkingCalendarService) LeaveDays(userID uuid.UUID, start, end time.Time, sessions map[string]string) ([]models.LeaveDay, float64, error) {
+	start = truncateToDate(start)
+	end = truncateToDate(end)
+	if end.Before(start) {
+		return nil, 0, fmt.Errorf("end date %s is before start date %s", end.Format(dateLayout), start.Format(dateLayout))
+	}
+
+	var user models.User
+	if err := s.db.Select("id", "work_location").First(&user, "id = ?", userID).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to load user: %w", err)
+	}
+	location := ""
+	if user.WorkLocation != nil {
+		location = normalizeLocation(*user.WorkLocation)
+	}
+
+	holidays, err := s.holidays(location, start, end)
+	if err != nil {
+		return nil, 0, err
+	}
+	taken, err := s.takenOptionalHolidays(userID, location, start, end)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	weekend := s.weekend
+	if override, ok := s.weekendByLocation[location]; ok {
+		weekend = override
+	}
+
+	var days []models.LeaveDay
+	var total float64
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format(dateLayout)
+		leaveDay := models.LeaveDay{Date: date, Kind: models.DayKindWorking}
+
+		holiday, isHoliday := holidays[date]
+		switch {
+		case isHoliday && !holiday.IsOptional:
+			leaveDay.Kind = models.DayKindHoliday
+		case isHoliday && (s.optionalHolidaysOff || taken[date]):
+			leaveDay.Kind = models.DayKindOptionalHoliday
+		case weekend[day.Weekday()]:
+			leaveDay.Kind = models.DayKindWeekend
+		}
+		if isHoliday {
+			// Optional holidays that are not taken stay working days but are named
+			leaveDay.Holiday = holiday.Title
+		}
+
+		if leaveDay.Kind == models.DayKindWorking {
+			leaveDay.Session, leaveDay.Days = models.SessionFull, 1
+			if session := sessions[date]; session == models.SessionFirstHalf || session == models.SessionSecondHalf {
+				leaveDay.Session, leaveDay.Days = session, 0.5
+			}
+		}
+		total += leaveDay.Days
+		days = append(days, leaveDay)
+	}
+
+	return days, total, nil
+}
+
+// takenOptionalHolidays returns the dates from start to end of the optional
+// holidays at location that userID selected and had approved.
+func (s *WorkingCalendarService) takenOptionalHolidays(userID uuid.UUID, location string, start, end time.Time) (map[string]bool, error) {
+	var events []models.Event
+	if err := s.db.Joins("JOIN optional_holiday_selections ON optional_holiday_selections.holiday_id = events.id").
+		Where("optional_holiday_selections.user_id = ? AND optional_holiday_selections.status = ? AND events.event_date >= ? AND events.event_date < ?",
+			userID, models.OptionalHolidayApproved, start, end.AddDate(0, 0, 1)).
+		Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to load optional holidays: %w", err)
+	}
+
+	taken := make(map[string]bool, len(events))
+	for _, event := range events {
+		if event.Location == nil || normalizeLocation(*event.Location) == location {
+			taken[event.EventDate.Format(dateLayout)] = true
+		}
+	}
+	return taken, nil
+}
+
+// Holidays returns the holidays from start to end (inclusive) that apply at
+// workLocation, nil for users without one, ordered by date.
+func (s *WorkingCalendarService) Holidays(workLocation *string, start, end time.Time) ([]models.Event, error) {
+	location := ""
+	if workLocation != nil {
+		location = normalizeLocation(*workLocation)
+	}
+	byDate, err := s.holidays(location, truncateToDate(start), truncateToDate(end))
+	if err != nil {
+		return nil, err
+	}
+
+	holidays := make([]models.Event, 0, len(byDate))
+	for _, holiday := range byDate {
+		holidays = append(holidays, holiday)
+	}
+	sort.Slice(holidays, func(i, j int) bool {
+		return holidays[i].EventDate.Before(holidays[j].EventDate)
+	})
+	return holidays, nil
+}
+
+// holidays returns the holidays between start and end that apply at location,
+// keyed by date. A mandatory holiday wins over an optional one on the same day.
+func (s *WorkingCalendarService) holidays(location string, start, end time.Time) (map[string]models.Event, error) {
+	var events []models.Event
+	if err := s.db.Where("event_type = ? AND event_date >= ? AND event_date < ?", "holiday", start, end.AddDate(0, 0, 1)).
+		Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to load holidays: %w", err)
+	}
+
+	holidays := make(map[string]models.Event, len(events))
+	for _, event := range events {
+		if event.Location != nil && normalizeLocation(*event.Location) != location {
+			continue
+		}
+		date := event.EventDate.Format(dateLayout)
+		if existing, ok := holidays[date]; ok && !existing.IsOptional {
+			continue
+		}
+		holidays[date] = event
+	}
+	return holidays, nil
+}
+
+func truncateToDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
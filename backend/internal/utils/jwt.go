@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"employee-dashboard-api/internal/models"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -8,38 +9,65 @@ import (
 )
 
 type Claims struct {
-	UserID uuid.UUID `json:"user_id"`
-	Email  string    `json:"email"`
+	UserID      uuid.UUID           `json:"user_id"`
+	Email       string              `json:"email"`
+	SessionID   uuid.UUID           `json:"sid"` // server-side session the token was issued for
+	Role        models.UserRole     `json:"role"`
+	Permissions []models.Permission `json:"permissions"`
+	RoleVersion int                 `json:"rv"` // must match users.role_version, see TokenService.IsRevoked
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID uuid.UUID, email string, secret string, expiryHours int) (string, error) {
+// HasPermission reports whether the token grants permission.
+func (c *Claims) HasPermission(permission models.Permission) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateToken issues a signed access token for the given session, embedding
+// the user's role, permissions and role version. Every token gets a unique jti
+// so it can be revoked individually.
+func GenerateToken(user *models.User, sessionID uuid.UUID, secret string, expiry time.Duration) (string, *Claims, error) {
+	now := time.Now()
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:      user.ID,
+		Email:       user.Email,
+		SessionID:   sessionID,
+		Role:        user.Role,
+		Permissions: models.PermissionsForRole(user.Role),
+		RoleVersion: user.RoleVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expiryHours) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	signed, err := token.SignedString([]byte(secret))
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
 }
 
 func ValidateToken(tokenString string, secret string) (*Claims, error) {
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		return []byte(secret), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
 
 	if err != nil {
 		return nil, err
 	}
 
-	if !token.Valid {
+	if !token.Valid || claims.ID == "" || claims.SessionID == uuid.Nil {
 		return nil, jwt.ErrTokenMalformed
 	}
 
 	return claims, nil
-}
\ No newline at end of file
+}
@@ -1,15 +1,182 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Password hash schemes, as reported by PasswordHashScheme
+const (
+	PasswordSchemeBcrypt    = "bcrypt"
+	PasswordSchemeArgon2id  = "argon2id"
+	PasswordSchemeUnusable  = "unusable"  // no password can match; a reset is required
+	PasswordSchemePlaintext = "plaintext" // legacy USER_PLAIN_PASSWORDS value
+)
+
+// UnusablePasswordHash is stored for accounts that have no password yet (e.g.
+// seeded from CSV). It never matches and is not a valid hash of any scheme.
+const UnusablePasswordHash = "!"
+
+// argon2id parameters for new hashes (OWASP baseline)
+const (
+	argon2Memory  = 64 * 1024
+	argon2Time    = 3
+	argon2Threads = 2
+	argon2KeyLen  = 32
+	argon2SaltLen = 16
+)
+
+var bcryptHashPattern = regexp.MustCompile(`^\$2[abxy]?\$\d{2}\$[./A-Za-z0-9]{53}$`)
+
+var (
+	hashingMu     sync.RWMutex
+	hashingScheme = PasswordSchemeBcrypt
+	bcryptCost    = bcrypt.DefaultCost
+)
+
+// ConfigurePasswordHashing sets the scheme ("bcrypt" or "argon2id") and bcrypt
+// cost used for new hashes. Existing hashes of another scheme or cost keep
+// verifying and are upgraded on the next successful login.
+func ConfigurePasswordHashing(scheme string, cost int) error {
+	scheme = strings.ToLower(strings.TrimSpace(scheme))
+	if scheme != PasswordSchemeBcrypt && scheme != PasswordSchemeArgon2id {
+		return fmt.Errorf("unsupported password hash scheme %q", scheme)
+	}
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt cost %d out of range", cost)
+	}
+
+	hashingMu.Lock()
+	defer hashingMu.Unlock()
+	hashingScheme = scheme
+	bcryptCost = cost
+	return nil
+}
+
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashingMu.RLock()
+	scheme, cost := hashingScheme, bcryptCost
+	hashingMu.RUnlock()
+
+	if scheme == PasswordSchemeArgon2id {
+		return hashArgon2id(password)
+	}
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), cost)
 	return string(bytes), err
 }
 
 func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
-}
\ No newline at end of file
+	ok, _ := VerifyPassword(password, hash)
+	return ok
+}
+
+// PasswordHashScheme classifies a stored password value.
+func PasswordHashScheme(stored string) string {
+	switch {
+	case strings.HasPrefix(stored, "$argon2id$"):
+		return PasswordSchemeArgon2id
+	case bcryptHashPattern.MatchString(stored):
+		return PasswordSchemeBcrypt
+	case stored == "" || strings.HasPrefix(stored, UnusablePasswordHash) || strings.HasPrefix(stored, "$"):
+		// "$..." values that are not valid hashes (such as the old dummy
+		// "$2a$10$dummy.hash...") can never match either
+		return PasswordSchemeUnusable
+	default:
+		return PasswordSchemePlaintext
+	}
+}
+
+// VerifyPassword checks password against a stored value of any supported
+// scheme. needsRehash is true when the password matched but the stored value
+// is outdated (see PasswordHashOutdated), so the caller should store a fresh
+// HashPassword result.
+func VerifyPassword(password, stored string) (ok bool, needsRehash bool) {
+	switch PasswordHashScheme(stored) {
+	case PasswordSchemeBcrypt:
+		ok = bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+	case PasswordSchemeArgon2id:
+		if params, valid := parseArgon2id(stored); valid {
+			candidate := argon2.IDKey([]byte(password), params.salt, params.time, params.memory, params.threads, uint32(len(params.key)))
+			ok = subtle.ConstantTimeCompare(candidate, params.key) == 1
+		}
+	case PasswordSchemePlaintext:
+		ok = subtle.ConstantTimeCompare([]byte(password), []byte(stored)) == 1
+	}
+	return ok, ok && PasswordHashOutdated(stored)
+}
+
+// PasswordHashOutdated reports whether a stored value is not in the scheme and
+// parameters HashPassword currently produces. Plaintext is always outdated;
+// unusable values are not, as there is nothing to upgrade.
+func PasswordHashOutdated(stored string) bool {
+	hashingMu.RLock()
+	scheme, cost := hashingScheme, bcryptCost
+	hashingMu.RUnlock()
+
+	switch PasswordHashScheme(stored) {
+	case PasswordSchemeBcrypt:
+		storedCost, err := bcrypt.Cost([]byte(stored))
+		return scheme != PasswordSchemeBcrypt || err != nil || storedCost != cost
+	case PasswordSchemeArgon2id:
+		params, ok := parseArgon2id(stored)
+		return scheme != PasswordSchemeArgon2id || !ok ||
+			params.memory != argon2Memory || params.time != argon2Time ||
+			params.threads != argon2Threads || len(params.key) != argon2KeyLen
+	case PasswordSchemePlaintext:
+		return true
+	default:
+		return false
+	}
+}
+
+func hashArgon2id(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+type argon2idHash struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+// parseArgon2id decodes a PHC-format "$argon2id$v=19$m=..,t=..,p=..$salt$key"
+// string.
+func parseArgon2id(stored string) (argon2idHash, bool) {
+	var h argon2idHash
+	parts := strings.Split(stored, "$")
+	if len(parts) != 6 {
+		return h, false
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return h, false
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.memory, &h.time, &h.threads); err != nil {
+		return h, false
+	}
+	var err error
+	if h.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return h, false
+	}
+	if h.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(h.key) == 0 {
+		return h, false
+	}
+	return h, true
+}
@@ -1,7 +1,10 @@
 package utils
 
 import (
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -52,4 +55,14 @@ func UnauthorizedResponse(c *gin.Context) {
 
 func ForbiddenResponse(c *gin.Context) {
 	ErrorResponse(c, http.StatusForbidden, "Forbidden", "")
+}
+
+// TooManyRequestsResponse writes a 429 with a Retry-After header in whole seconds.
+func TooManyRequestsResponse(c *gin.Context, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	ErrorResponse(c, http.StatusTooManyRequests, "Too many requests, please try again later", "")
 }
\ No newline at end of file
@@ -2,20 +2,27 @@
 package main
 
 import (
+	"context"
 	"employee-dashboard-api/internal/config"
 	"employee-dashboard-api/internal/database"
 	"employee-dashboard-api/internal/middleware"
+	"employee-dashboard-api/internal/repository"
 	"employee-dashboard-api/internal/routes"
 	"employee-dashboard-api/internal/services"
+	"employee-dashboard-api/internal/utils"
 	_ "employee-dashboard-api/docs" // Import generated docs
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/swaggo/files"
 	"github.com/swaggo/gin-swagger"
+	"gorm.io/gorm"
 )
 
 // @title Employee Dashboard API
@@ -66,15 +73,48 @@ func main() {
 		logger.Infof("Using timezone: %s", cfg.AppTimeZone)
 	}
 
+	// Configure password hashing
+	if err := utils.ConfigurePasswordHashing(cfg.PasswordHashScheme, cfg.PasswordBcryptCost); err != nil {
+		log.Fatalf("Invalid password hashing configuration: %v", err)
+	}
+	if cfg.UserPlainPasswords {
+		logger.Warn("USER_PLAIN_PASSWORDS is deprecated and ignored; passwords are always hashed. Run \"migrate-passwords\" to hash stored plaintext values")
+	}
+
 	// Initialize database
 	db, err := database.Initialize(cfg)
 	if err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
 
+	migrator, err := database.NewMigrator(db, logger)
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
+	}
+
+	// One-off maintenance commands, e.g. "go run . migrate up"
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:], db, migrator, logger))
+	}
+
+	// Refuse to serve against an outdated schema
+	if cfg.DBMigrateOnStart {
+		if _, err := migrator.Up(context.Background()); err != nil {
+			log.Fatal("Failed to apply migrations:", err)
+		}
+	}
+	pending, err := migrator.Pending(context.Background())
+	if err != nil {
+		log.Fatal("Failed to check migrations:", err)
+	}
+	if len(pending) > 0 {
+		log.Fatalf("Database schema is behind by %d migration(s), starting with %d_%s; run \"migrate up\" first",
+			len(pending), pending[0].Version, pending[0].Name)
+	}
+
 	// Initialize leave allocations on startup
 	logger.Info("Initializing leave allocations...")
-	leaveService := services.NewLeaveService(db, logger)
+	leaveService := services.NewLeaveService(repository.New(db), services.NewWorkingCalendarService(db, cfg, logger), logger)
 	if err := leaveService.InitializeLeaveAllocations(); err != nil {
 		logger.Errorf("Failed to initialize leave allocations: %v", err)
 		// Don't fail the server startup, just log the error
@@ -117,3 +157,79 @@ func main() {
 		log.Fatal("Failed to start server:", err)
 	}
 }
+
+// runCommand runs a maintenance command instead of the server and returns the
+// process exit code.
+func runCommand(name string, args []string, db *gorm.DB, migrator *database.Migrator, logger *logrus.Logger) int {
+	switch name {
+	case "migrate":
+		return runMigrate(args, migrator)
+	case "migrate-passwords":
+		fs := flag.NewFlagSet(name, flag.ExitOnError)
+		dryRun := fs.Bool("dry-run", false, "report what would change without writing")
+		fs.Parse(args)
+
+		report, err := services.NewPasswordMigrationService(db, logger).MigratePasswords(*dryRun)
+		if err != nil {
+			logger.Errorf("Password migration failed: %v", err)
+			return 1
+		}
+		fmt.Printf("scanned=%d hashed=%d reset_required=%d upgradable=%d failed=%d\n",
+			report.Scanned, report.Hashed, report.ResetRequired, report.Upgradable, report.Failed)
+		if report.Failed > 0 {
+			return 1
+		}
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q (available: migrate, migrate-passwords)\n", name)
+		return 2
+	}
+}
+
+// runMigrate implements "migrate up", "migrate down [-steps N]" and
+// "migrate status".
+func runMigrate(args []string, migrator *database.Migrator) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: migrate up|down|status")
+		return 2
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate up failed: %v\n", err)
+			return 1
+		}
+		fmt.Printf("applied %d migration(s)\n", applied)
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ExitOnError)
+		steps := fs.Int("steps", 1, "number of migrations to roll back")
+		fs.Parse(args[1:])
+
+		rolledBack, err := migrator.Down(ctx, *steps)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate down failed: %v\n", err)
+			return 1
+		}
+		fmt.Printf("rolled back %d migration(s)\n", rolledBack)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate status failed: %v\n", err)
+			return 1
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = "applied " + status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d  %-40s %s\n", status.Version, status.Name, applied)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate command %q (available: up, down, status)\n", args[0])
+		return 2
+	}
+	return 0
+}